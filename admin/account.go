@@ -0,0 +1,162 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/utils"
+)
+
+func openAccount(ctx context.Context, store db.Store, out io.Writer, args []string) error {
+	flags := newFlagSet("open-account", out)
+	owner := flags.String("owner", "", "username of the owner")
+	currency := flags.String("currency", "", "currency of the account")
+	accountType := flags.String("type", utils.Checking, "type of the account: checking or savings")
+
+	err := parseFlags(flags, args, "owner", "currency")
+	if err != nil {
+		return err
+	}
+
+	if !utils.IsSupportedCurrency(*currency) {
+		return fmt.Errorf("unsupported currency %q", *currency)
+	}
+	if !utils.IsSupportedAccountType(*accountType) {
+		return fmt.Errorf("unsupported account type %q", *accountType)
+	}
+
+	account, err := store.CreateAccountTx(ctx, db.CreateAccountParams{
+		Owner:       owner,
+		Currency:    *currency,
+		AccountType: *accountType,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot open account: %w", err)
+	}
+
+	fmt.Fprintf(out, "opened %s account %d in %s for %s\n", account.AccountType, account.ID, account.Currency, *owner)
+	return nil
+}
+
+func freezeAccount(ctx context.Context, store db.Store, out io.Writer, args []string) error {
+	flags := newFlagSet("freeze-account", out)
+	id := flags.Int64("id", 0, "id of the account")
+	reason := flags.String("reason", "", "why the account is frozen")
+
+	err := parseFlags(flags, args, "id", "reason")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(*reason) == "" {
+		return errors.New("reason must not be empty")
+	}
+
+	account, err := store.FreezeAccount(ctx, db.FreezeAccountParams{
+		ID:     *id,
+		Reason: *reason,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("customer account %d not found", *id)
+		}
+		return fmt.Errorf("cannot freeze account: %w", err)
+	}
+
+	fmt.Fprintf(out, "froze account %d: %s\n", account.ID, account.FrozenReason)
+	return nil
+}
+
+func unfreezeAccount(ctx context.Context, store db.Store, out io.Writer, args []string) error {
+	flags := newFlagSet("unfreeze-account", out)
+	id := flags.Int64("id", 0, "id of the account")
+
+	err := parseFlags(flags, args, "id")
+	if err != nil {
+		return err
+	}
+
+	account, err := store.UnfreezeAccount(ctx, *id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("account %d not found", *id)
+		}
+		return fmt.Errorf("cannot unfreeze account: %w", err)
+	}
+
+	fmt.Fprintf(out, "unfroze account %d\n", account.ID)
+	return nil
+}
+
+// Books a manual change of an account's balance against the adjustments
+// system account of its currency. The reason is recorded as the description
+// of the journal and of its entries.
+func adjustBalance(ctx context.Context, store db.Store, out io.Writer, args []string) error {
+	flags := newFlagSet("adjust", out)
+	accountID := flags.Int64("account", 0, "id of the account")
+	amount := flags.Int64("amount", 0, "amount added to the balance, negative to remove money")
+	reason := flags.String("reason", "", "why the adjustment is needed")
+	operator := flags.String("operator", os.Getenv("USER"), "who books the adjustment")
+
+	err := parseFlags(flags, args, "account", "amount", "reason")
+	if err != nil {
+		return err
+	}
+	if *amount == 0 {
+		return errors.New("amount must not be zero")
+	}
+	if strings.TrimSpace(*reason) == "" {
+		return errors.New("reason must not be empty")
+	}
+
+	account, err := store.GetAccount(ctx, *accountID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("account %d not found", *accountID)
+		}
+		return fmt.Errorf("cannot get account: %w", err)
+	}
+	if account.SystemKind != nil {
+		return fmt.Errorf("account %d is a system account", account.ID)
+	}
+
+	adjustments, err := store.GetSystemAccount(ctx, db.GetSystemAccountParams{
+		SystemKind: db.SystemAdjustments,
+		Currency:   account.Currency,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot get %s adjustments account: %w", account.Currency, err)
+	}
+
+	metadata, err := json.Marshal(map[string]string{"operator": *operator})
+	if err != nil {
+		return err
+	}
+
+	result, err := store.PostJournalTx(ctx, db.PostJournalTxParams{
+		Kind:        db.JournalAdjustment,
+		Description: *reason,
+		Lines: []db.JournalLine{
+			{AccountID: account.ID, Amount: *amount, Description: *reason, Metadata: metadata},
+			{AccountID: adjustments.ID, Amount: -*amount, Description: *reason, Metadata: metadata},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot book adjustment: %w", err)
+	}
+
+	for _, posted := range result.Accounts {
+		if posted.ID == account.ID {
+			account = posted
+		}
+	}
+
+	fmt.Fprintf(out, "booked adjustment %d of %d on account %d, balance is %d %s\n",
+		result.Journal.ID, *amount, account.ID, account.Balance, account.Currency)
+	return nil
+}
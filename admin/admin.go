@@ -0,0 +1,111 @@
+// Package admin implements the operations commands of the simplebank binary.
+package admin
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+)
+
+// Returned by Run when the arguments are invalid, after printing the usage.
+var ErrUsage = errors.New("invalid usage")
+
+type command struct {
+	summary string
+	run     func(ctx context.Context, store db.Store, out io.Writer, args []string) error
+}
+
+var commands = map[string]command{
+	"create-user":      {"creates a user, optionally with the banker role", createUser},
+	"delete-user":      {"anonymizes a user and deletes its empty accounts", deleteUser},
+	"reencrypt-users":  {"re-encrypts the personal data of the users with the current key", reencryptUsers},
+	"open-account":     {"opens an account for a user", openAccount},
+	"freeze-account":   {"freezes an account, blocking its transfers", freezeAccount},
+	"unfreeze-account": {"unfreezes an account", unfreezeAccount},
+	"adjust":           {"books a manual adjustment of an account's balance", adjustBalance},
+	"reconcile":        {"checks the balances against the ledger", reconcile},
+	"statement":        {"prints the statement of an account", printStatement},
+}
+
+// Runs the admin command named by args[0] with the rest of args as its flags.
+// The output of the command is written to out.
+func Run(ctx context.Context, store db.Store, out io.Writer, args []string) error {
+	if len(args) == 0 {
+		printUsage(out)
+		return ErrUsage
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+			printUsage(out)
+			return nil
+		}
+		fmt.Fprintf(out, "unknown command %q\n\n", args[0])
+		printUsage(out)
+		return ErrUsage
+	}
+
+	err := cmd.run(ctx, store, out, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return err
+}
+
+func printUsage(out io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(out, "usage: simplebank [serve | migrate <command> | partitions <command> | <command> [flags]]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "commands:")
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-18s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "run simplebank <command> -h for the flags of a command.")
+}
+
+// Creates the flag set of a command, writing its errors and usage to out.
+func newFlagSet(name string, out io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(out)
+	return flags
+}
+
+// Parses the flags of a command and checks that the required ones are set.
+// It returns flag.ErrHelp when the usage of the command was asked for.
+func parseFlags(flags *flag.FlagSet, args []string, required ...string) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return ErrUsage
+	}
+
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	var missing []string
+	for _, name := range required {
+		if !set[name] {
+			missing = append(missing, "-"+name)
+		}
+	}
+	if len(missing) > 0 {
+		fmt.Fprintf(flags.Output(), "missing required flags: %s\n", strings.Join(missing, ", "))
+		flags.Usage()
+		return ErrUsage
+	}
+	return nil
+}
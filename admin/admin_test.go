@@ -0,0 +1,303 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/kvgtl/simplebank/db/mock"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func randomAccount() db.Account {
+	owner := utils.RandomOwner()
+
+	return db.Account{
+		ID:          utils.RandomInt(1, 10000),
+		Owner:       &owner,
+		Balance:     utils.RandomMoneyAmount(),
+		Currency:    utils.USD,
+		AccountType: utils.Checking,
+	}
+}
+
+func runCommand(t *testing.T, buildStubs func(store *mockdb.MockStore), args ...string) (string, error) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	buildStubs(store)
+
+	var out bytes.Buffer
+	err := Run(context.Background(), store, &out, args)
+	return out.String(), err
+}
+
+func noStubs(store *mockdb.MockStore) {}
+
+func TestRunUsage(t *testing.T) {
+	out, err := runCommand(t, noStubs)
+	require.ErrorIs(t, err, ErrUsage)
+	require.Contains(t, out, "reconcile")
+
+	out, err = runCommand(t, noStubs, "print-money")
+	require.ErrorIs(t, err, ErrUsage)
+	require.Contains(t, out, `unknown command "print-money"`)
+
+	_, err = runCommand(t, noStubs, "help")
+	require.NoError(t, err)
+
+	out, err = runCommand(t, noStubs, "freeze-account", "-h")
+	require.NoError(t, err)
+	require.Contains(t, out, "-reason")
+}
+
+func TestCreateUser(t *testing.T) {
+	username := utils.RandomOwner()
+	t.Setenv(passwordEnv, "secret")
+
+	// the role is created with the user, in the same transaction.
+	out, err := runCommand(t, func(store *mockdb.MockStore) {
+		store.EXPECT().
+			CreateUserTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(ctx context.Context, args db.CreateUserParams) (db.User, error) {
+				require.Equal(t, username, args.Username)
+				require.Equal(t, utils.BankerRole, args.Role)
+				require.NoError(t, utils.CheckPassword("secret", args.HashedPassword))
+				return db.User{Username: args.Username, Role: args.Role}, nil
+			})
+		store.EXPECT().UpdateUserRole(gomock.Any(), gomock.Any()).Times(0)
+	}, "create-user", "-username", username, "-full-name", "Jane Doe", "-email", "jane@example.com", "-role", "banker")
+	require.NoError(t, err)
+	require.Equal(t, "created user "+username+" (banker)\n", out)
+
+	out, err = runCommand(t, noStubs, "create-user", "-username", username)
+	require.ErrorIs(t, err, ErrUsage)
+	require.Contains(t, out, "missing required flags: -full-name, -email")
+
+	_, err = runCommand(t, noStubs, "create-user", "-username", username,
+		"-full-name", "Jane Doe", "-email", "jane@example.com", "-role", "owner")
+	require.EqualError(t, err, `unsupported role "owner"`)
+
+	t.Setenv(passwordEnv, "short")
+	_, err = runCommand(t, noStubs, "create-user", "-username", username,
+		"-full-name", "Jane Doe", "-email", "jane@example.com")
+	require.EqualError(t, err, "password must have at least 6 characters")
+}
+
+func TestReadPassword(t *testing.T) {
+	password, err := readPassword(strings.NewReader("secret\r\nignored\n"))
+	require.NoError(t, err)
+	require.Equal(t, "secret", password)
+
+	password, err = readPassword(strings.NewReader("secret"))
+	require.NoError(t, err)
+	require.Equal(t, "secret", password)
+}
+
+func TestDeleteUser(t *testing.T) {
+	username := utils.RandomOwner()
+
+	out, err := runCommand(t, func(store *mockdb.MockStore) {
+		store.EXPECT().
+			DeleteUserTx(gomock.Any(), gomock.Eq(username)).
+			Times(1).
+			Return(db.User{Username: username}, nil)
+	}, "delete-user", "-username", username)
+	require.NoError(t, err)
+	require.Contains(t, out, "deleted user "+username)
+
+	_, err = runCommand(t, func(store *mockdb.MockStore) {
+		store.EXPECT().
+			DeleteUserTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.User{}, db.ErrAccountNotEmpty)
+	}, "delete-user", "-username", username)
+	require.ErrorIs(t, err, db.ErrAccountNotEmpty)
+
+	_, err = runCommand(t, func(store *mockdb.MockStore) {
+		store.EXPECT().
+			DeleteUserTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.User{}, db.ErrRecordNotFound)
+	}, "delete-user", "-username", username)
+	require.EqualError(t, err, "user "+username+" not found")
+}
+
+func TestReencryptUsers(t *testing.T) {
+	out, err := runCommand(t, func(store *mockdb.MockStore) {
+		gomock.InOrder(
+			store.EXPECT().
+				ReencryptUsersTx(gomock.Any(), gomock.Eq(db.ReencryptUsersTxParams{After: "", Limit: 2})).
+				Times(1).
+				Return(db.ReencryptUsersTxResult{Count: 2, Last: "bob"}, nil),
+			store.EXPECT().
+				ReencryptUsersTx(gomock.Any(), gomock.Eq(db.ReencryptUsersTxParams{After: "bob", Limit: 2})).
+				Times(1).
+				Return(db.ReencryptUsersTxResult{Count: 1, Skipped: 1, Last: "carol"}, nil),
+		)
+	}, "reencrypt-users", "-batch", "2")
+	require.NoError(t, err)
+	require.Equal(t, "re-encrypted 2 users, up to bob\nre-encrypted 2 users, 1 already had the current key\n", out)
+
+	_, err = runCommand(t, func(store *mockdb.MockStore) {
+		store.EXPECT().
+			ReencryptUsersTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.ReencryptUsersTxResult{}, db.ErrEncryptionDisabled)
+	}, "reencrypt-users")
+	require.ErrorIs(t, err, db.ErrEncryptionDisabled)
+
+	_, err = runCommand(t, noStubs, "reencrypt-users", "-batch", "0")
+	require.Error(t, err)
+}
+
+func TestFreezeAccount(t *testing.T) {
+	account := randomAccount()
+	frozenAt := time.Now()
+
+	out, err := runCommand(t, func(store *mockdb.MockStore) {
+		frozen := account
+		frozen.FrozenAt = &frozenAt
+		frozen.FrozenReason = "suspected fraud"
+
+		store.EXPECT().
+			FreezeAccount(gomock.Any(), gomock.Eq(db.FreezeAccountParams{ID: account.ID, Reason: "suspected fraud"})).
+			Times(1).
+			Return(frozen, nil)
+	}, "freeze-account", "-id", strconv.FormatInt(account.ID, 10), "-reason", "suspected fraud")
+	require.NoError(t, err)
+	require.Contains(t, out, "suspected fraud")
+
+	_, err = runCommand(t, noStubs, "freeze-account", "-id", "1", "-reason", " ")
+	require.EqualError(t, err, "reason must not be empty")
+
+	_, err = runCommand(t, func(store *mockdb.MockStore) {
+		store.EXPECT().
+			FreezeAccount(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.Account{}, db.ErrRecordNotFound)
+	}, "freeze-account", "-id", "1", "-reason", "closed")
+	require.EqualError(t, err, "customer account 1 not found")
+}
+
+func TestAdjustBalance(t *testing.T) {
+	account := randomAccount()
+
+	systemKind := db.SystemAdjustments
+	adjustments := db.Account{ID: account.ID + 1, Currency: account.Currency, SystemKind: &systemKind}
+
+	out, err := runCommand(t, func(store *mockdb.MockStore) {
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+		store.EXPECT().
+			GetSystemAccount(gomock.Any(), gomock.Eq(db.GetSystemAccountParams{SystemKind: db.SystemAdjustments, Currency: account.Currency})).
+			Times(1).
+			Return(adjustments, nil)
+		store.EXPECT().
+			PostJournalTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(ctx context.Context, args db.PostJournalTxParams) (db.PostJournalTxResult, error) {
+				require.Equal(t, db.JournalAdjustment, args.Kind)
+				require.Equal(t, "duplicate card fee", args.Description)
+				require.Len(t, args.Lines, 2)
+				require.Equal(t, int64(-250), args.Lines[0].Amount)
+				require.Equal(t, adjustments.ID, args.Lines[1].AccountID)
+				require.Equal(t, int64(250), args.Lines[1].Amount)
+
+				var metadata map[string]string
+				require.NoError(t, json.Unmarshal(args.Lines[0].Metadata, &metadata))
+				require.Equal(t, "alice", metadata["operator"])
+
+				updated := account
+				updated.Balance -= 250
+				return db.PostJournalTxResult{
+					Journal:  db.JournalTransaction{ID: 42},
+					Accounts: []db.Account{updated, adjustments},
+				}, nil
+			})
+	}, "adjust", "-account", strconv.FormatInt(account.ID, 10), "-amount", "-250", "-reason", "duplicate card fee", "-operator", "alice")
+	require.NoError(t, err)
+	require.Contains(t, out, "booked adjustment 42 of -250")
+
+	_, err = runCommand(t, noStubs, "adjust", "-account", "1", "-amount", "100")
+	require.ErrorIs(t, err, ErrUsage)
+
+	_, err = runCommand(t, func(store *mockdb.MockStore) {
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(adjustments.ID)).Times(1).Return(adjustments, nil)
+		store.EXPECT().PostJournalTx(gomock.Any(), gomock.Any()).Times(0)
+	}, "adjust", "-account", strconv.FormatInt(adjustments.ID, 10), "-amount", "100", "-reason", "test")
+	require.ErrorContains(t, err, "is a system account")
+}
+
+func TestReconcile(t *testing.T) {
+	trialBalance := []db.GetTrialBalanceRow{
+		{Currency: utils.USD, CustomerAccounts: 2, CustomerBalance: 300, SystemBalance: -300, TotalBalance: 0},
+	}
+
+	out, err := runCommand(t, func(store *mockdb.MockStore) {
+		store.EXPECT().GetTrialBalance(gomock.Any()).Times(1).Return(trialBalance, nil)
+		store.EXPECT().ListAccountBalanceMismatches(gomock.Any()).Times(1).Return([]db.ListAccountBalanceMismatchesRow{}, nil)
+		store.EXPECT().ListUnbalancedJournals(gomock.Any()).Times(1).Return([]db.ListUnbalancedJournalsRow{}, nil)
+	}, "reconcile")
+	require.NoError(t, err)
+	require.Contains(t, out, "USD")
+	require.Contains(t, out, "all account balances match the ledger")
+
+	out, err = runCommand(t, func(store *mockdb.MockStore) {
+		store.EXPECT().GetTrialBalance(gomock.Any()).Times(1).Return(trialBalance, nil)
+		store.EXPECT().
+			ListAccountBalanceMismatches(gomock.Any()).
+			Times(1).
+			Return([]db.ListAccountBalanceMismatchesRow{{AccountID: 7, Currency: utils.USD, Balance: 100, EntriesTotal: 90}}, nil)
+		store.EXPECT().
+			ListUnbalancedJournals(gomock.Any()).
+			Times(1).
+			Return([]db.ListUnbalancedJournalsRow{{JournalID: 3, Currency: utils.EUR, Total: 5}}, nil)
+	}, "reconcile")
+	require.ErrorIs(t, err, ErrReconciliationFailed)
+	require.Contains(t, out, "1 accounts don't match their entries")
+	require.Contains(t, out, "1 journals are unbalanced")
+}
+
+func TestPrintStatement(t *testing.T) {
+	account := randomAccount()
+	account.Balance = 1000
+
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	entries := []db.Entry{
+		{ID: 1, AccountID: account.ID, Amount: 200, Description: "salary", CreatedAt: from.Add(time.Hour)},
+		{ID: 2, AccountID: account.ID, Amount: -50, Description: "rent", Reference: "inv-1", CreatedAt: from.Add(48 * time.Hour)},
+	}
+
+	out, err := runCommand(t, func(store *mockdb.MockStore) {
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+		store.EXPECT().
+			SumAccountEntriesSince(gomock.Any(), gomock.Eq(db.SumAccountEntriesSinceParams{AccountID: account.ID, Since: from})).
+			Times(1).
+			Return(int64(300), nil)
+		store.EXPECT().
+			ListAccountEntriesBetween(gomock.Any(), gomock.Eq(db.ListAccountEntriesBetweenParams{
+				AccountID: account.ID,
+				FromTime:  from,
+				ToTime:    from.AddDate(0, 1, 0),
+			})).
+			Times(1).
+			Return(entries, nil)
+	}, "statement", "-account", strconv.FormatInt(account.ID, 10), "-from", "2024-03-01", "-to", "2024-03-31")
+	require.NoError(t, err)
+	require.Contains(t, out, "from 2024-03-01 to 2024-03-31")
+	require.Regexp(t, `opening balance\s+700\n`, out)
+	require.Regexp(t, `rent\s+inv-1\s+-50\s+850\n`, out)
+	require.Regexp(t, `closing balance\s+850\n`, out)
+
+	_, err = runCommand(t, noStubs, "statement", "-account", "1", "-from", "2024-03-31", "-to", "2024-03-01")
+	require.EqualError(t, err, "-to must not be before -from")
+}
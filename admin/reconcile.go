@@ -0,0 +1,78 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+)
+
+// Returned by the reconcile command when the balances don't match the ledger.
+var ErrReconciliationFailed = errors.New("reconciliation failed")
+
+// Prints the trial balance and checks that every account's balance is the
+// sum of its entries and that every journal is balanced in each currency.
+func reconcile(ctx context.Context, store db.Store, out io.Writer, args []string) error {
+	flags := newFlagSet("reconcile", out)
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	trialBalance, err := store.GetTrialBalance(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot get trial balance: %w", err)
+	}
+
+	mismatches, err := store.ListAccountBalanceMismatches(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot check account balances: %w", err)
+	}
+
+	unbalanced, err := store.ListUnbalancedJournals(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot check journals: %w", err)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "currency\taccounts\tcustomer balance\tsystem balance\ttotal\t")
+	for _, row := range trialBalance {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t\n",
+			row.Currency, row.CustomerAccounts, row.CustomerBalance, row.SystemBalance, row.TotalBalance)
+	}
+	w.Flush()
+	fmt.Fprintln(out)
+
+	if len(mismatches) > 0 {
+		fmt.Fprintf(out, "%d accounts don't match their entries:\n", len(mismatches))
+		w = tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "account\tcurrency\tbalance\tentries\tdifference\t")
+		for _, row := range mismatches {
+			fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%d\t\n",
+				row.AccountID, row.Currency, row.Balance, row.EntriesTotal, row.Balance-row.EntriesTotal)
+		}
+		w.Flush()
+		fmt.Fprintln(out)
+	}
+
+	if len(unbalanced) > 0 {
+		fmt.Fprintf(out, "%d journals are unbalanced:\n", len(unbalanced))
+		w = tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "journal\tcurrency\tsum\t")
+		for _, row := range unbalanced {
+			fmt.Fprintf(w, "%d\t%s\t%d\t\n", row.JournalID, row.Currency, row.Total)
+		}
+		w.Flush()
+		fmt.Fprintln(out)
+	}
+
+	if len(mismatches) > 0 || len(unbalanced) > 0 {
+		return ErrReconciliationFailed
+	}
+
+	fmt.Fprintln(out, "all account balances match the ledger")
+	return nil
+}
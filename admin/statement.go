@@ -0,0 +1,93 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+)
+
+const statementDateLayout = "2006-01-02"
+
+// Prints the entries of an account between two days with its opening and
+// closing balances. The period defaults to the current month.
+func printStatement(ctx context.Context, store db.Store, out io.Writer, args []string) error {
+	now := time.Now().UTC()
+	firstOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	flags := newFlagSet("statement", out)
+	accountID := flags.Int64("account", 0, "id of the account")
+	fromFlag := flags.String("from", firstOfMonth.Format(statementDateLayout), "first day of the statement (UTC)")
+	toFlag := flags.String("to", now.Format(statementDateLayout), "last day of the statement (UTC)")
+
+	err := parseFlags(flags, args, "account")
+	if err != nil {
+		return err
+	}
+
+	from, err := time.Parse(statementDateLayout, *fromFlag)
+	if err != nil {
+		return fmt.Errorf("invalid -from date: %w", err)
+	}
+	to, err := time.Parse(statementDateLayout, *toFlag)
+	if err != nil {
+		return fmt.Errorf("invalid -to date: %w", err)
+	}
+	if to.Before(from) {
+		return fmt.Errorf("-to must not be before -from")
+	}
+	end := to.AddDate(0, 0, 1)
+
+	account, err := store.GetAccount(ctx, *accountID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("account %d not found", *accountID)
+		}
+		return fmt.Errorf("cannot get account: %w", err)
+	}
+
+	// the opening balance is the current one minus everything booked since.
+	since, err := store.SumAccountEntriesSince(ctx, db.SumAccountEntriesSinceParams{
+		AccountID: account.ID,
+		Since:     from,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot get opening balance: %w", err)
+	}
+
+	entries, err := store.ListAccountEntriesBetween(ctx, db.ListAccountEntriesBetweenParams{
+		AccountID: account.ID,
+		FromTime:  from,
+		ToTime:    end,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot list entries: %w", err)
+	}
+
+	owner := "system"
+	if account.Owner != nil {
+		owner = *account.Owner
+	}
+	fmt.Fprintf(out, "statement of %s account %d (%s, %s)\n", account.AccountType, account.ID, owner, account.Currency)
+	fmt.Fprintf(out, "from %s to %s\n", from.Format(statementDateLayout), to.Format(statementDateLayout))
+	if account.FrozenAt != nil {
+		fmt.Fprintf(out, "frozen since %s: %s\n", account.FrozenAt.UTC().Format(time.RFC3339), account.FrozenReason)
+	}
+	fmt.Fprintln(out)
+
+	balance := account.Balance - since
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "date\tentry\tdescription\treference\tamount\tbalance")
+	fmt.Fprintf(w, "%s\t\topening balance\t\t\t%d\n", from.Format(statementDateLayout), balance)
+	for _, entry := range entries {
+		balance += entry.Amount
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%d\t%d\n",
+			entry.CreatedAt.UTC().Format(statementDateLayout), entry.ID, entry.Description, entry.Reference, entry.Amount, balance)
+	}
+	fmt.Fprintf(w, "%s\t\tclosing balance\t\t\t%d\n", to.Format(statementDateLayout), balance)
+	return w.Flush()
+}
@@ -0,0 +1,137 @@
+package admin
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/utils"
+)
+
+// Environment variable of the initial password of create-user, which is read
+// from the first line of stdin when it isn't set. It isn't a flag so that it
+// doesn't show in the process list or the shell history.
+const passwordEnv = "SIMPLEBANK_PASSWORD"
+
+// Creates a user with its role in a single transaction.
+func createUser(ctx context.Context, store db.Store, out io.Writer, args []string) error {
+	flags := newFlagSet("create-user", out)
+	username := flags.String("username", "", "username of the user")
+	fullName := flags.String("full-name", "", "full name of the user")
+	email := flags.String("email", "", "email address of the user")
+	role := flags.String("role", utils.DepositorRole, "role of the user: depositor or banker")
+
+	err := parseFlags(flags, args, "username", "full-name", "email")
+	if err != nil {
+		return err
+	}
+
+	password, ok := os.LookupEnv(passwordEnv)
+	if !ok {
+		password, err = readPassword(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("cannot read password from stdin: %w", err)
+		}
+	}
+	if len(password) < 6 {
+		return fmt.Errorf("password must have at least 6 characters")
+	}
+	if !utils.IsSupportedRole(*role) {
+		return fmt.Errorf("unsupported role %q", *role)
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("cannot hash password: %w", err)
+	}
+
+	user, err := store.CreateUserTx(ctx, db.CreateUserParams{
+		Username:       *username,
+		HashedPassword: hashedPassword,
+		FullName:       *fullName,
+		Email:          *email,
+		Role:           *role,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create user: %w", err)
+	}
+
+	fmt.Fprintf(out, "created user %s (%s)\n", user.Username, user.Role)
+	return nil
+}
+
+// Reads a password from the first line of in.
+func readPassword(in io.Reader) (string, error) {
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Deletes a user on its request: its personal data is anonymized, and its
+// accounts, which must be empty, are soft deleted.
+func deleteUser(ctx context.Context, store db.Store, out io.Writer, args []string) error {
+	flags := newFlagSet("delete-user", out)
+	username := flags.String("username", "", "username of the user")
+
+	err := parseFlags(flags, args, "username")
+	if err != nil {
+		return err
+	}
+
+	user, err := store.DeleteUserTx(ctx, *username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("user %s not found", *username)
+		}
+		return fmt.Errorf("cannot delete user: %w", err)
+	}
+
+	fmt.Fprintf(out, "deleted user %s\n", user.Username)
+	return nil
+}
+
+// Re-encrypts the personal data of every user with the current key, in
+// batches of their own transaction, e.g. after a new key was added to the
+// keyring.
+func reencryptUsers(ctx context.Context, store db.Store, out io.Writer, args []string) error {
+	flags := newFlagSet("reencrypt-users", out)
+	batch := flags.Int("batch", 100, "number of users re-encrypted per transaction")
+	after := flags.String("after", "", "username after which to start, to resume a previous run")
+
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if *batch <= 0 {
+		return fmt.Errorf("batch must be positive")
+	}
+
+	total, skipped := 0, 0
+	for {
+		result, err := store.ReencryptUsersTx(ctx, db.ReencryptUsersTxParams{
+			After: *after,
+			Limit: int32(*batch),
+		})
+		if err != nil {
+			return fmt.Errorf("cannot re-encrypt users after %q: %w", *after, err)
+		}
+
+		total += result.Count - result.Skipped
+		skipped += result.Skipped
+		if result.Count < *batch {
+			break
+		}
+		*after = result.Last
+		fmt.Fprintf(out, "re-encrypted %d users, up to %s\n", total, *after)
+	}
+
+	fmt.Fprintf(out, "re-encrypted %d users, %d already had the current key\n", total, skipped)
+	return nil
+}
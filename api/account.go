@@ -1,43 +1,53 @@
 package api
 
 import (
-	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/kvgtl/simplebank/db/sqlc"
-	"github.com/lib/pq"
+	"github.com/kvgtl/simplebank/utils"
 )
 
 type createAccountRequest struct {
-	Owner    string `json:"owner" binding:"required"`
-	Currency string `json:"currency" binding:"required,currency"`
+	Owner       string `json:"owner" binding:"required"`
+	Currency    string `json:"currency" binding:"required,currency"`
+	AccountType string `json:"account_type" binding:"omitempty,account_type"`
 }
 
 func (server *Server) createAccount(ctx *gin.Context) {
 	var req createAccountRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
 		return
 	}
 
+	if req.AccountType == "" {
+		req.AccountType = utils.Checking
+	}
+
 	args := db.CreateAccountParams{
-		Owner:    req.Owner,
-		Currency: req.Currency,
-		Balance:  0,
+		Owner:       &req.Owner,
+		Currency:    req.Currency,
+		Balance:     0,
+		AccountType: req.AccountType,
 	}
 
-	account, err := server.store.CreateAccount(ctx, args)
+	account, err := server.store.CreateAccountTx(ctx, args)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-			case "foreign_key_violation", "unique_violation":
-				ctx.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			}
+		switch {
+		case errors.Is(err, db.ErrForeignKeyViolation), errors.Is(err, db.ErrUserDeleted):
+			err := fmt.Errorf("user %s doesn't exist", req.Owner)
+			ctx.JSON(http.StatusForbidden, errorResponse(ctx, codeUserNotFound, err))
+			return
+		case errors.Is(err, db.ErrUniqueViolation):
+			err := fmt.Errorf("user %s already has a %s account", req.Owner, req.Currency)
+			ctx.JSON(http.StatusForbidden, errorResponse(ctx, codeAlreadyExists, err))
+			return
 		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
 		return
 	}
 
@@ -51,20 +61,27 @@ type getAccountRequest struct {
 func (server *Server) getAccount(ctx *gin.Context) {
 	var req getAccountRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
 		return
 	}
 
 	account, err := server.store.GetAccount(ctx, req.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(ctx, codeAccountNotFound, accountNotFoundError(req.ID)))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
 		return
 	}
 
+	// deleted accounts are only kept for the ledger history.
+	if account.DeletedAt != nil {
+		ctx.JSON(http.StatusNotFound, errorResponse(ctx, codeAccountNotFound, accountNotFoundError(req.ID)))
+		return
+	}
+
+	ctx.Header("ETag", accountETag(account))
 	ctx.JSON(http.StatusOK, account)
 }
 
@@ -76,7 +93,7 @@ type listAccountsRequest struct {
 func (server *Server) listAccounts(ctx *gin.Context) {
 	var req listAccountsRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
 		return
 	}
 
@@ -87,7 +104,7 @@ func (server *Server) listAccounts(ctx *gin.Context) {
 
 	accounts, err := server.store.ListAccounts(ctx, args)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
 		return
 	}
 
@@ -96,36 +113,102 @@ func (server *Server) listAccounts(ctx *gin.Context) {
 
 type addAccountBalanceRequest struct {
 	Amount int64 `json:"amount" binding:"required"`
-	ID     int64 `uri:"id" binding:"required,min=1"`
 }
 
+// Deposits a positive amount into the account or withdraws a negative one.
+// The money comes from or goes to the cash system account of the currency,
+// so that the posting stays balanced. With an If-Match header, the account
+// must still be at the version of its entity tag.
 func (server *Server) addAccountBalance(ctx *gin.Context) {
-	var req addAccountBalanceRequest
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
+		return
+	}
 
+	var req addAccountBalanceRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
 		return
 	}
 
-	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(ctx, codeAccountNotFound, accountNotFoundError(uri.ID)))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
 		return
 	}
 
-	args := db.AddAccountBalanceParams{
-		ID:     req.ID,
-		Amount: req.Amount,
+	if account.SystemKind != nil {
+		err := fmt.Errorf("account [%d] is a system account", account.ID)
+		ctx.JSON(http.StatusForbidden, errorResponse(ctx, codeSystemAccount, err))
+		return
+	}
+
+	expectedVersion, ok := ifMatchVersion(ctx, account)
+	if !ok {
+		ctx.JSON(http.StatusPreconditionFailed, errorResponse(ctx, codePreconditionFailed, versionMismatchError(account.ID)))
+		return
+	}
+
+	if account.FrozenAt != nil {
+		err := fmt.Errorf("account [%d] is frozen", account.ID)
+		ctx.JSON(http.StatusForbidden, errorResponse(ctx, codeAccountFrozen, err))
+		return
+	}
+
+	if account.DeletedAt != nil {
+		err := fmt.Errorf("account [%d] is deleted", account.ID)
+		ctx.JSON(http.StatusForbidden, errorResponse(ctx, codeAccountDeleted, err))
+		return
+	}
+
+	kind, systemKind := db.JournalDeposit, db.SystemCashIn
+	if req.Amount < 0 {
+		kind, systemKind = db.JournalWithdrawal, db.SystemCashOut
+	}
+
+	cashAccount, err := server.store.GetSystemAccount(ctx, db.GetSystemAccountParams{
+		SystemKind: systemKind,
+		Currency:   account.Currency,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
 	}
 
-	account, err := server.store.AddAccountBalance(ctx, args)
+	result, err := server.store.PostJournalTx(ctx, db.PostJournalTxParams{
+		Kind:        kind,
+		Description: kind,
+		Lines: []db.JournalLine{
+			{AccountID: account.ID, Amount: req.Amount, Description: kind, ExpectedVersion: expectedVersion},
+			{AccountID: cashAccount.ID, Amount: -req.Amount, Description: kind},
+		},
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		if errors.Is(err, db.ErrVersionConflict) {
+			ctx.JSON(http.StatusPreconditionFailed, errorResponse(ctx, codePreconditionFailed, versionMismatchError(account.ID)))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		// the balance is checked under the account's lock, see db.PostJournalTx.
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			err := fmt.Errorf("account [%d] has insufficient funds", account.ID)
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(ctx, codeInsufficientFunds, err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
 		return
 	}
+
+	for _, postedAccount := range result.Accounts {
+		if postedAccount.ID == account.ID {
+			account = postedAccount
+		}
+	}
+	ctx.Header("ETag", accountETag(account))
 	ctx.JSON(http.StatusOK, account)
 }
 
@@ -133,25 +216,96 @@ type deleteAccountRequest struct {
 	ID int64 `uri:"id" binding:"required,min=1"`
 }
 
+// Soft deletes an account, which must be empty. Its ledger history is kept.
+// With an If-Match header, the account must still be at the version of its
+// entity tag.
 func (server *Server) deleteAccount(ctx *gin.Context) {
 	var req deleteAccountRequest
 
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
 		return
 	}
 
-	_, err := server.store.GetAccount(ctx, req.ID)
+	account, err := server.store.GetAccount(ctx, req.ID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(ctx, codeAccountNotFound, accountNotFoundError(req.ID)))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
 		return
 	}
 
-	err = server.store.DeleteAccount(ctx, req.ID)
-	fmt.Println(err)
+	if account.SystemKind != nil {
+		err := fmt.Errorf("account [%d] is a system account", account.ID)
+		ctx.JSON(http.StatusForbidden, errorResponse(ctx, codeSystemAccount, err))
+		return
+	}
+
+	expectedVersion, ok := ifMatchVersion(ctx, account)
+	if !ok {
+		ctx.JSON(http.StatusPreconditionFailed, errorResponse(ctx, codePreconditionFailed, versionMismatchError(account.ID)))
+		return
+	}
+
+	_, err = server.store.DeleteAccountTx(ctx, db.DeleteAccountTxParams{
+		AccountID:       req.ID,
+		ExpectedVersion: expectedVersion,
+	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		switch {
+		case errors.Is(err, db.ErrRecordNotFound):
+			ctx.JSON(http.StatusNotFound, errorResponse(ctx, codeAccountNotFound, accountNotFoundError(req.ID)))
+			return
+		case errors.Is(err, db.ErrVersionConflict):
+			ctx.JSON(http.StatusPreconditionFailed, errorResponse(ctx, codePreconditionFailed, versionMismatchError(req.ID)))
+			return
+		case errors.Is(err, db.ErrAccountNotEmpty):
+			err := fmt.Errorf("account [%d] still has a balance", req.ID)
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(ctx, codeAccountNotEmpty, err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
 		return
 	}
 	ctx.JSON(http.StatusOK, nil)
 }
+
+// Returns the error of an account that doesn't exist.
+func accountNotFoundError(id int64) error {
+	return fmt.Errorf("account [%d] not found", id)
+}
+
+// Returns the error of an account that changed since the version of the
+// If-Match header.
+func versionMismatchError(id int64) error {
+	return fmt.Errorf("account [%d] has changed, get it again", id)
+}
+
+// Returns the entity tag of an account, which changes with its version.
+func accountETag(account db.Account) string {
+	return fmt.Sprintf(`"%d"`, account.Version)
+}
+
+// Matches the If-Match header of the request against the account. It returns
+// the version a change of the account must find, 0 for any when there is no
+// header or it is *, and false if no entity tag matches. Weak tags never
+// match, If-Match compares them strongly.
+func ifMatchVersion(ctx *gin.Context, account db.Account) (int64, bool) {
+	header := ctx.GetHeader("If-Match")
+	if header == "" {
+		return 0, true
+	}
+
+	etag := accountETag(account)
+	for _, tag := range strings.Split(header, ",") {
+		switch strings.TrimSpace(tag) {
+		case "*":
+			return 0, true
+		case etag:
+			return account.Version, true
+		}
+	}
+	return 0, false
+}
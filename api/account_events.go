@@ -0,0 +1,215 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/token"
+)
+
+const (
+	// Interval of the comments sent to keep idle streams open through proxies.
+	heartbeatInterval = 15 * time.Second
+	// Number of entries read at once when resuming a stream.
+	replayPageSize = 100
+	// Number of the last entries sent on a stream that its notifications
+	// are checked against.
+	sentWindowSize = 1024
+)
+
+// Ids of the last entries sent on a stream. The entries commit in any order,
+// an entry can be notified after one with a greater id, so the notifications
+// are skipped by the ids already sent instead of the greatest one.
+type sentIDs struct {
+	ids   map[int64]struct{}
+	order []int64
+	size  int
+}
+
+func newSentIDs(size int) *sentIDs {
+	return &sentIDs{ids: map[int64]struct{}{}, size: size}
+}
+
+// Records an id, forgetting the oldest one once the window is full.
+func (s *sentIDs) add(id int64) {
+	if _, ok := s.ids[id]; ok {
+		return
+	}
+	if len(s.order) == s.size {
+		delete(s.ids, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.ids[id] = struct{}{}
+	s.order = append(s.order, id)
+}
+
+func (s *sentIDs) contains(id int64) bool {
+	_, ok := s.ids[id]
+	return ok
+}
+
+// Data of an "entry" event, its event id is the entry id.
+type entryEvent struct {
+	ID          int64     `json:"id"`
+	AccountID   int64     `json:"account_id"`
+	JournalID   int64     `json:"journal_id"`
+	Amount      int64     `json:"amount"`
+	Description string    `json:"description"`
+	Reference   string    `json:"reference"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Data of a "balance" event.
+type balanceEvent struct {
+	AccountID int64 `json:"account_id"`
+	Balance   int64 `json:"balance"`
+}
+
+// Streams the entries booked on an account and its balance as server-sent
+// events. The stream starts with the current balance; clients resuming with
+// a Last-Event-ID header first get the entries they missed.
+func (server *Server) streamAccountEvents(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
+		return
+	}
+
+	var lastEventID int64
+	if header := ctx.GetHeader("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || id < 0 {
+			err := fmt.Errorf("invalid Last-Event-ID %q", header)
+			ctx.JSON(http.StatusBadRequest, errorResponse(ctx, codeValidationFailed, err))
+			return
+		}
+		lastEventID = id
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(ctx, codeAccountNotFound, accountNotFoundError(uri.ID)))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+	if account.DeletedAt != nil {
+		ctx.JSON(http.StatusNotFound, errorResponse(ctx, codeAccountNotFound, accountNotFoundError(uri.ID)))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner == nil || *account.Owner != authPayload.Username {
+		err := errors.New("account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusForbidden, errorResponse(ctx, codeForbidden, err))
+		return
+	}
+
+	// subscribe before reading the entries and balance, so that nothing booked
+	// in between is missed. The notifications of the replayed entries are
+	// skipped by id.
+	notifications, unsubscribe := server.broker.Subscribe(account.ID)
+	defer unsubscribe()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	sent := newSentIDs(sentWindowSize)
+	lastSent := lastEventID
+	if lastEventID > 0 {
+		for {
+			entries, err := server.store.ListAccountEntriesAfter(ctx, db.ListAccountEntriesAfterParams{
+				AccountID:  account.ID,
+				AfterID:    lastSent,
+				LimitCount: replayPageSize,
+			})
+			if err != nil {
+				writeEvent(ctx, "", "error", internalErrorResponse(ctx, err))
+				return
+			}
+
+			for _, entry := range entries {
+				writeEvent(ctx, strconv.FormatInt(entry.ID, 10), "entry", entryEvent{
+					ID:          entry.ID,
+					AccountID:   entry.AccountID,
+					JournalID:   entry.JournalID.Int64,
+					Amount:      entry.Amount,
+					Description: entry.Description,
+					Reference:   entry.Reference,
+					CreatedAt:   entry.CreatedAt,
+				})
+				sent.add(entry.ID)
+				lastSent = entry.ID
+			}
+
+			if len(entries) < replayPageSize {
+				break
+			}
+		}
+	}
+
+	account, err = server.store.GetAccount(ctx, account.ID)
+	if err != nil {
+		writeEvent(ctx, "", "error", internalErrorResponse(ctx, err))
+		return
+	}
+	writeEvent(ctx, "", "balance", balanceEvent{AccountID: account.ID, Balance: account.Balance})
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+
+		case <-heartbeat.C:
+			fmt.Fprint(ctx.Writer, ": heartbeat\n\n")
+			ctx.Writer.Flush()
+
+		case n, ok := <-notifications:
+			// the broker closes the channel of a client that fell behind,
+			// which reconnects with its Last-Event-ID and replays the entries
+			// it missed.
+			if !ok {
+				return
+			}
+			if sent.contains(n.EntryID) {
+				continue
+			}
+
+			writeEvent(ctx, strconv.FormatInt(n.EntryID, 10), "entry", entryEvent{
+				ID:          n.EntryID,
+				AccountID:   n.AccountID,
+				JournalID:   n.JournalID,
+				Amount:      n.Amount,
+				Description: n.Description,
+				Reference:   n.Reference,
+				CreatedAt:   n.CreatedAt,
+			})
+			writeEvent(ctx, "", "balance", balanceEvent{AccountID: n.AccountID, Balance: n.Balance})
+			sent.add(n.EntryID)
+		}
+	}
+}
+
+// Writes a server-sent event and flushes it to the client.
+func writeEvent(ctx *gin.Context, id string, event string, data any) {
+	ctx.Render(-1, sse.Event{
+		Id:    id,
+		Event: event,
+		Data:  data,
+	})
+	ctx.Writer.Flush()
+}
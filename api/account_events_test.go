@@ -0,0 +1,263 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/kvgtl/simplebank/db/mock"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// Reads the next event of a stream, skipping comments.
+func readEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	var event sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimRight(line, "\n")
+		if line == "" {
+			if event.event != "" {
+				return event
+			}
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			event.id = value
+		case "event":
+			event.event = value
+		case "data":
+			event.data = value
+		}
+	}
+}
+
+func TestStreamAccountEventsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount()
+	account.Owner = &user.Username
+
+	testCases := []struct {
+		name          string
+		accountID     int64
+		username      string
+		lastEventID   string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "NotFound",
+			accountID: account.ID,
+			username:  user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(db.Account{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "OtherUsersAccount",
+			accountID: account.ID,
+			username:  utils.RandomOwner(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:        "InvalidLastEventID",
+			accountID:   account.ID,
+			username:    user.Username,
+			lastEventID: "abc",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidID",
+			accountID: 0,
+			username:  user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			testCase.buildStubs(store)
+			expectActiveUsers(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/events", testCase.accountID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			if testCase.lastEventID != "" {
+				request.Header.Set("Last-Event-ID", testCase.lastEventID)
+			}
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, testCase.username, utils.DepositorRole, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestStreamAccountEventsLive(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount()
+	account.Owner = &user.Username
+
+	missedEntry := db.Entry{
+		ID:        11,
+		AccountID: account.ID,
+		Amount:    -30,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	expectActiveUsers(store)
+	store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+		Times(2).
+		Return(account, nil)
+	store.EXPECT().
+		ListAccountEntriesAfter(gomock.Any(), gomock.Eq(db.ListAccountEntriesAfterParams{
+			AccountID:  account.ID,
+			AfterID:    10,
+			LimitCount: replayPageSize,
+		})).
+		Times(1).
+		Return([]db.Entry{missedEntry}, nil)
+
+	server := newTestServer(t, store)
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/accounts/%d/events", httpServer.URL, account.ID), nil)
+	require.NoError(t, err)
+	request.Header.Set("Last-Event-ID", "10")
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(response.Body)
+
+	// the missed entry is replayed first, then the current balance.
+	event := readEvent(t, reader)
+	require.Equal(t, "entry", event.event)
+	require.Equal(t, "11", event.id)
+
+	var entry entryEvent
+	require.NoError(t, json.Unmarshal([]byte(event.data), &entry))
+	require.Equal(t, missedEntry.ID, entry.ID)
+	require.Equal(t, missedEntry.Amount, entry.Amount)
+
+	event = readEvent(t, reader)
+	require.Equal(t, "balance", event.event)
+
+	var balance balanceEvent
+	require.NoError(t, json.Unmarshal([]byte(event.data), &balance))
+	require.Equal(t, balanceEvent{AccountID: account.ID, Balance: account.Balance}, balance)
+
+	// notifications of entries already sent are skipped.
+	server.broker.Publish(db.AccountEntryNotification{EntryID: 11, AccountID: account.ID, Amount: -30})
+	server.broker.Publish(db.AccountEntryNotification{
+		EntryID:   12,
+		AccountID: account.ID,
+		Amount:    50,
+		Balance:   account.Balance + 50,
+	})
+
+	event = readEvent(t, reader)
+	require.Equal(t, "entry", event.event)
+	require.Equal(t, "12", event.id)
+
+	event = readEvent(t, reader)
+	require.Equal(t, "balance", event.event)
+	require.NoError(t, json.Unmarshal([]byte(event.data), &balance))
+	require.Equal(t, account.Balance+50, balance.Balance)
+
+	// an entry committed after one with a greater id is still sent.
+	server.broker.Publish(db.AccountEntryNotification{EntryID: 14, AccountID: account.ID, Amount: 5})
+	server.broker.Publish(db.AccountEntryNotification{EntryID: 12, AccountID: account.ID, Amount: 50})
+	server.broker.Publish(db.AccountEntryNotification{EntryID: 13, AccountID: account.ID, Amount: 7})
+
+	for _, id := range []string{"14", "13"} {
+		event = readEvent(t, reader)
+		require.Equal(t, "entry", event.event)
+		require.Equal(t, id, event.id)
+		event = readEvent(t, reader)
+		require.Equal(t, "balance", event.event)
+	}
+}
+
+func TestSentIDs(t *testing.T) {
+	sent := newSentIDs(2)
+	sent.add(2)
+	sent.add(1)
+	sent.add(1)
+	require.True(t, sent.contains(1))
+	require.True(t, sent.contains(2))
+	require.False(t, sent.contains(3))
+
+	// the oldest id is forgotten once the window is full.
+	sent.add(3)
+	require.False(t, sent.contains(2))
+	require.True(t, sent.contains(1))
+	require.True(t, sent.contains(3))
+}
@@ -9,7 +9,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	mockdb "github.com/kvgtl/simplebank/db/mock"
 	db "github.com/kvgtl/simplebank/db/sqlc"
 	"github.com/kvgtl/simplebank/utils"
@@ -37,6 +39,7 @@ func TestGetAccountAPI(t *testing.T) {
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, fmt.Sprintf(`"%d"`, account.Version), recorder.Header().Get("ETag"))
 				requireBodyMatchAccount(t, recorder.Body, account)
 			},
 		},
@@ -47,12 +50,29 @@ func TestGetAccountAPI(t *testing.T) {
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
 					Times(1).
-					Return(db.Account{}, sql.ErrNoRows)
+					Return(db.Account{}, db.ErrRecordNotFound)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
 			},
 		},
+		{
+			name:      "Deleted",
+			accountID: account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				deleted := account
+				deletedAt := time.Now()
+				deleted.DeletedAt = &deletedAt
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(deleted, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireErrorCode(t, recorder, codeAccountNotFound)
+			},
+		},
 		{
 			name:      "InternalError",
 			accountID: account.ID,
@@ -94,7 +114,7 @@ func TestGetAccountAPI(t *testing.T) {
 			testCase.buildStubs(store)
 
 			// start test server and send requests.
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprintf("/accounts/%d", testCase.accountID)
@@ -113,12 +133,506 @@ func TestGetAccountAPI(t *testing.T) {
 
 }
 
+func TestCreateAccountAPI(t *testing.T) {
+	account := randomAccount()
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"owner":    *account.Owner,
+				"currency": account.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				args := db.CreateAccountParams{
+					Owner:       account.Owner,
+					Currency:    account.Currency,
+					Balance:     0,
+					AccountType: utils.Checking,
+				}
+				store.EXPECT().
+					CreateAccountTx(gomock.Any(), gomock.Eq(args)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name: "Savings",
+			body: gin.H{
+				"owner":        *account.Owner,
+				"currency":     account.Currency,
+				"account_type": utils.Savings,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				args := db.CreateAccountParams{
+					Owner:       account.Owner,
+					Currency:    account.Currency,
+					Balance:     0,
+					AccountType: utils.Savings,
+				}
+				store.EXPECT().
+					CreateAccountTx(gomock.Any(), gomock.Eq(args)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidAccountType",
+			body: gin.H{
+				"owner":        *account.Owner,
+				"currency":     account.Currency,
+				"account_type": "loan",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAccountTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			testCase.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(testCase.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/accounts", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestAddAccountBalanceAPI(t *testing.T) {
+	account := randomAccount()
+	account.Balance = 1000
+
+	systemKind := db.SystemFees
+	systemAccount := randomAccount()
+	systemAccount.Owner = nil
+	systemAccount.SystemKind = &systemKind
+
+	cashAccount := randomAccount()
+	cashAccount.Owner = nil
+	cashAccount.Currency = account.Currency
+
+	frozenAt := time.Now()
+	frozenAccount := randomAccount()
+	frozenAccount.FrozenAt = &frozenAt
+
+	etag := fmt.Sprintf(`"%d"`, account.Version)
+
+	testCases := []struct {
+		name          string
+		accountID     int64
+		body          gin.H
+		ifMatch       string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "Deposit",
+			accountID: account.ID,
+			body:      gin.H{"amount": 100},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					GetSystemAccount(gomock.Any(), gomock.Eq(db.GetSystemAccountParams{SystemKind: db.SystemCashIn, Currency: account.Currency})).
+					Times(1).
+					Return(cashAccount, nil)
+
+				updatedAccount := account
+				updatedAccount.Balance += 100
+
+				args := db.PostJournalTxParams{
+					Kind:        db.JournalDeposit,
+					Description: db.JournalDeposit,
+					Lines: []db.JournalLine{
+						{AccountID: account.ID, Amount: 100, Description: db.JournalDeposit},
+						{AccountID: cashAccount.ID, Amount: -100, Description: db.JournalDeposit},
+					},
+				}
+				store.EXPECT().
+					PostJournalTx(gomock.Any(), gomock.Eq(args)).
+					Times(1).
+					Return(db.PostJournalTxResult{Accounts: []db.Account{updatedAccount, cashAccount}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				updatedAccount := account
+				updatedAccount.Balance += 100
+				requireBodyMatchAccount(t, recorder.Body, updatedAccount)
+			},
+		},
+		{
+			name:      "IfMatch",
+			accountID: account.ID,
+			body:      gin.H{"amount": 100},
+			ifMatch:   `"0", ` + etag,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetSystemAccount(gomock.Any(), gomock.Any()).Times(1).Return(cashAccount, nil)
+
+				updatedAccount := account
+				updatedAccount.Balance += 100
+				updatedAccount.Version++
+
+				args := db.PostJournalTxParams{
+					Kind:        db.JournalDeposit,
+					Description: db.JournalDeposit,
+					Lines: []db.JournalLine{
+						{AccountID: account.ID, Amount: 100, Description: db.JournalDeposit, ExpectedVersion: account.Version},
+						{AccountID: cashAccount.ID, Amount: -100, Description: db.JournalDeposit},
+					},
+				}
+				store.EXPECT().
+					PostJournalTx(gomock.Any(), gomock.Eq(args)).
+					Times(1).
+					Return(db.PostJournalTxResult{Accounts: []db.Account{updatedAccount, cashAccount}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, fmt.Sprintf(`"%d"`, account.Version+1), recorder.Header().Get("ETag"))
+			},
+		},
+		{
+			name:      "IfMatchMismatch",
+			accountID: account.ID,
+			body:      gin.H{"amount": 100},
+			ifMatch:   "W/" + etag,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().PostJournalTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+				requireErrorCode(t, recorder, codePreconditionFailed)
+			},
+		},
+		{
+			name:      "VersionConflict",
+			accountID: account.ID,
+			body:      gin.H{"amount": 100},
+			ifMatch:   etag,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetSystemAccount(gomock.Any(), gomock.Any()).Times(1).Return(cashAccount, nil)
+				store.EXPECT().
+					PostJournalTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PostJournalTxResult{}, db.ErrVersionConflict)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+				requireErrorCode(t, recorder, codePreconditionFailed)
+			},
+		},
+		{
+			name:      "Withdrawal",
+			accountID: account.ID,
+			body:      gin.H{"amount": -100},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					GetSystemAccount(gomock.Any(), gomock.Eq(db.GetSystemAccountParams{SystemKind: db.SystemCashOut, Currency: account.Currency})).
+					Times(1).
+					Return(cashAccount, nil)
+				store.EXPECT().PostJournalTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "InsufficientFunds",
+			accountID: account.ID,
+			body:      gin.H{"amount": -1001},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					GetSystemAccount(gomock.Any(), gomock.Eq(db.GetSystemAccountParams{SystemKind: db.SystemCashOut, Currency: account.Currency})).
+					Times(1).
+					Return(cashAccount, nil)
+				store.EXPECT().
+					PostJournalTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PostJournalTxResult{}, fmt.Errorf("%w: account %d has 1000, needs 1001", db.ErrInsufficientFunds, account.ID))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				requireErrorCode(t, recorder, codeInsufficientFunds)
+			},
+		},
+		{
+			name:      "NotFound",
+			accountID: account.ID,
+			body:      gin.H{"amount": 100},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, db.ErrRecordNotFound)
+				store.EXPECT().PostJournalTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireErrorCode(t, recorder, codeAccountNotFound)
+			},
+		},
+		{
+			name:      "SystemAccount",
+			accountID: systemAccount.ID,
+			body:      gin.H{"amount": 100},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(systemAccount.ID)).Times(1).Return(systemAccount, nil)
+				store.EXPECT().PostJournalTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "FrozenAccount",
+			accountID: frozenAccount.ID,
+			body:      gin.H{"amount": 100},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(frozenAccount.ID)).Times(1).Return(frozenAccount, nil)
+				store.EXPECT().PostJournalTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireErrorCode(t, recorder, codeAccountFrozen)
+			},
+		},
+		{
+			name:      "MissingAmount",
+			accountID: account.ID,
+			body:      gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			testCase.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(testCase.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d", testCase.accountID)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			if testCase.ifMatch != "" {
+				request.Header.Set("If-Match", testCase.ifMatch)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestDeleteAccountAPI(t *testing.T) {
+	account := randomAccount()
+
+	systemKind := db.SystemFees
+	systemAccount := randomAccount()
+	systemAccount.Owner = nil
+	systemAccount.SystemKind = &systemKind
+
+	testCases := []struct {
+		name          string
+		account       db.Account
+		ifMatch       string
+		buildStubs    func(store *mockdb.MockStore, account db.Account)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "OK",
+			account: account,
+			buildStubs: func(store *mockdb.MockStore, account db.Account) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().DeleteAccountTx(gomock.Any(), gomock.Eq(db.DeleteAccountTxParams{AccountID: account.ID})).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:    "IfMatch",
+			account: account,
+			ifMatch: fmt.Sprintf(`"%d"`, account.Version),
+			buildStubs: func(store *mockdb.MockStore, account db.Account) {
+				args := db.DeleteAccountTxParams{AccountID: account.ID, ExpectedVersion: account.Version}
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().DeleteAccountTx(gomock.Any(), gomock.Eq(args)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:    "IfMatchAny",
+			account: account,
+			ifMatch: "*",
+			buildStubs: func(store *mockdb.MockStore, account db.Account) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().DeleteAccountTx(gomock.Any(), gomock.Eq(db.DeleteAccountTxParams{AccountID: account.ID})).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:    "IfMatchMismatch",
+			account: account,
+			ifMatch: fmt.Sprintf(`"%d"`, account.Version+1),
+			buildStubs: func(store *mockdb.MockStore, account db.Account) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().DeleteAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+				requireErrorCode(t, recorder, codePreconditionFailed)
+			},
+		},
+		{
+			name:    "VersionConflict",
+			account: account,
+			ifMatch: fmt.Sprintf(`"%d"`, account.Version),
+			buildStubs: func(store *mockdb.MockStore, account db.Account) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().DeleteAccountTx(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, db.ErrVersionConflict)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+				requireErrorCode(t, recorder, codePreconditionFailed)
+			},
+		},
+		{
+			name:    "NotEmpty",
+			account: account,
+			buildStubs: func(store *mockdb.MockStore, account db.Account) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().DeleteAccountTx(gomock.Any(), gomock.Eq(db.DeleteAccountTxParams{AccountID: account.ID})).Times(1).Return(db.Account{}, db.ErrAccountNotEmpty)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				requireErrorCode(t, recorder, codeAccountNotEmpty)
+			},
+		},
+		{
+			name:    "AlreadyDeleted",
+			account: account,
+			buildStubs: func(store *mockdb.MockStore, account db.Account) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().DeleteAccountTx(gomock.Any(), gomock.Eq(db.DeleteAccountTxParams{AccountID: account.ID})).Times(1).Return(db.Account{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:    "SystemAccount",
+			account: systemAccount,
+			buildStubs: func(store *mockdb.MockStore, account db.Account) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().DeleteAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireErrorCode(t, recorder, codeSystemAccount)
+			},
+		},
+		{
+			name:    "InternalError",
+			account: account,
+			buildStubs: func(store *mockdb.MockStore, account db.Account) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().DeleteAccountTx(gomock.Any(), gomock.Eq(db.DeleteAccountTxParams{AccountID: account.ID})).Times(1).Return(db.Account{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			testCase.buildStubs(store, testCase.account)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d", testCase.account.ID)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+			if testCase.ifMatch != "" {
+				request.Header.Set("If-Match", testCase.ifMatch)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(t, recorder)
+		})
+	}
+}
+
 func randomAccount() db.Account {
+	owner := utils.RandomOwner()
+
 	return db.Account{
-		ID:       utils.RandomInt(1, 10000),
-		Owner:    utils.RandomOwner(),
-		Balance:  utils.RandomMoneyAmount(),
-		Currency: utils.RandomCurrency(),
+		ID:          utils.RandomInt(1, 10000),
+		Owner:       &owner,
+		Balance:     utils.RandomMoneyAmount(),
+		Currency:    utils.RandomCurrency(),
+		AccountType: utils.Checking,
+		Version:     utils.RandomInt(1, 100),
 	}
 }
 
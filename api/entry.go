@@ -0,0 +1,67 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/token"
+	"github.com/kvgtl/simplebank/utils"
+)
+
+// The pages follow the (after_created_at, after_id) of the last entry of the
+// previous page, none for the first page.
+type listAccountEntriesRequest struct {
+	PageSize       int32     `form:"page_size" binding:"required,min=1,max=10"`
+	AfterCreatedAt time.Time `form:"after_created_at"`
+	AfterID        int64     `form:"after_id" binding:"min=0"`
+}
+
+func (server *Server) listAccountEntries(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
+		return
+	}
+
+	var req listAccountEntriesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(ctx, codeAccountNotFound, accountNotFoundError(uri.ID)))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+
+	// bankers can read the entries of any account.
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role != utils.BankerRole && (account.Owner == nil || *account.Owner != authPayload.Username) {
+		err := errors.New("account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusForbidden, errorResponse(ctx, codeForbidden, err))
+		return
+	}
+
+	args := db.ListAccountEntriesParams{
+		AccountID:      uri.ID,
+		AfterCreatedAt: req.AfterCreatedAt,
+		AfterID:        req.AfterID,
+		LimitCount:     req.PageSize,
+	}
+
+	entries, err := server.store.ListAccountEntries(ctx, args)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+)
+
+// Stable codes of the error responses, for clients to switch on. Messages
+// may change, codes don't.
+const (
+	codeValidationFailed      = "VALIDATION_FAILED"
+	codeUnauthenticated       = "UNAUTHENTICATED"
+	codeInvalidCredentials    = "INVALID_CREDENTIALS"
+	codeForbidden             = "FORBIDDEN"
+	codeAccountNotFound       = "ACCOUNT_NOT_FOUND"
+	codeUserNotFound          = "USER_NOT_FOUND"
+	codeSessionNotFound       = "SESSION_NOT_FOUND"
+	codeWebhookNotFound       = "WEBHOOK_NOT_FOUND"
+	codeAlreadyExists         = "ALREADY_EXISTS"
+	codeSystemAccount         = "SYSTEM_ACCOUNT"
+	codeAccountFrozen         = "ACCOUNT_FROZEN"
+	codeAccountDeleted        = "ACCOUNT_DELETED"
+	codeAccountNotEmpty       = "ACCOUNT_NOT_EMPTY"
+	codePreconditionFailed    = "PRECONDITION_FAILED"
+	codeCurrencyMismatch      = "CURRENCY_MISMATCH"
+	codeInsufficientFunds     = "INSUFFICIENT_FUNDS"
+	codeTransferLimitExceeded = "TRANSFER_LIMIT_EXCEEDED"
+	codeInternal              = "INTERNAL"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	requestIDKey    = "request_id"
+)
+
+// Body of every error response.
+type errorBody struct {
+	Error apiError `json:"error"`
+}
+
+// Error returned by the API.
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Why each invalid field of the request was rejected.
+	Details []fieldError `json:"details,omitempty"`
+	// Period and amount of the exceeded transfer limit, and how much is left
+	// in it.
+	Period    string `json:"period,omitempty"`
+	Limit     *int64 `json:"limit,omitempty"`
+	Remaining *int64 `json:"remaining,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Invalid field of a request.
+type fieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Returns an error response with the code and the message of the error.
+func errorResponse(ctx *gin.Context, code string, err error) errorBody {
+	return errorBody{Error: apiError{
+		Code:      code,
+		Message:   err.Error(),
+		RequestID: ctx.GetString(requestIDKey),
+	}}
+}
+
+// Returns the response of an unexpected error. The error is logged with the
+// request ID rather than sent to the client, as it may come from the database.
+func internalErrorResponse(ctx *gin.Context, err error) errorBody {
+	requestID := ctx.GetString(requestIDKey)
+	log.Printf("request %s: %s %s: %v", requestID, ctx.Request.Method, ctx.FullPath(), err)
+
+	return errorBody{Error: apiError{
+		Code:      codeInternal,
+		Message:   "internal server error",
+		RequestID: requestID,
+	}}
+}
+
+// Returns the response of a request that couldn't be bound, with a detail
+// for each field that failed validation.
+func validationErrorResponse(ctx *gin.Context, err error) errorBody {
+	response := errorBody{Error: apiError{
+		Code:      codeValidationFailed,
+		Message:   "invalid request",
+		RequestID: ctx.GetString(requestIDKey),
+	}}
+
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &validationErrs):
+		for _, fieldErr := range validationErrs {
+			response.Error.Details = append(response.Error.Details, fieldError{
+				Field:   fieldErr.Field(),
+				Rule:    fieldErr.Tag(),
+				Message: validationMessage(fieldErr),
+			})
+		}
+	case errors.As(err, &typeErr):
+		response.Error.Details = []fieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: "must be " + jsonTypeName(typeErr.Type),
+		}}
+	case errors.As(err, &syntaxErr):
+		response.Error.Message = "request body is not valid JSON"
+	default:
+		response.Error.Message = err.Error()
+	}
+	return response
+}
+
+// Returns the response of a transfer rejected by the sender's limits,
+// including how much the sender can still move.
+func transferLimitResponse(ctx *gin.Context, err *db.TransferLimitError) errorBody {
+	response := errorResponse(ctx, codeTransferLimitExceeded, err)
+	response.Error.Period = err.Period
+	response.Error.Limit = &err.Limit
+	response.Error.Remaining = &err.Remaining
+	return response
+}
+
+// Describes why a field failed a validation rule.
+func validationMessage(fieldErr validator.FieldError) string {
+	isString := fieldErr.Kind() == reflect.String
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if isString {
+			return fmt.Sprintf("must have at least %s characters", fieldErr.Param())
+		}
+		return "must be at least " + fieldErr.Param()
+	case "max":
+		if isString {
+			return fmt.Sprintf("must have at most %s characters", fieldErr.Param())
+		}
+		return "must be at most " + fieldErr.Param()
+	case "gt":
+		return "must be greater than " + fieldErr.Param()
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fieldErr.Param(), " ", ", ")
+	case "email":
+		return "must be a valid email address"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "webhook_url":
+		return "must be an https URL of a public host"
+	case "alphanum":
+		return "must only contain letters and digits"
+	case "printascii":
+		return "must only contain printable ASCII characters"
+	case "currency":
+		return "must be a supported currency"
+	case "account_type":
+		return "must be checking or savings"
+	}
+	return fmt.Sprintf("must satisfy %s", fieldErr.Tag())
+}
+
+// Returns the JSON type a Go type is decoded from, with its article.
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
+
+// Returns the name of a request field as the client sends it, so that
+// validation details don't refer to Go struct fields.
+func requestFieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form", "uri"} {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// Creates a gin middleware that gives each request an ID, echoed in the
+// X-Request-ID header and in error responses. The ID of the client is kept
+// if it sends a reasonable one.
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetHeader(requestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.NewString()
+		}
+
+		ctx.Set(requestIDKey, requestID)
+		ctx.Header(requestIDHeader, requestID)
+		ctx.Next()
+	}
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/kvgtl/simplebank/db/mock"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// Requires an error response with the code and returns its error.
+func requireErrorCode(t *testing.T, recorder *httptest.ResponseRecorder, code string) apiError {
+	var body errorBody
+	err := json.Unmarshal(recorder.Body.Bytes(), &body)
+	require.NoError(t, err)
+
+	require.Equal(t, code, body.Error.Code)
+	require.NotEmpty(t, body.Error.Message)
+	require.Equal(t, recorder.Header().Get(requestIDHeader), body.Error.RequestID)
+	return body.Error
+}
+
+func TestValidationErrorResponse(t *testing.T) {
+	testCases := []struct {
+		name          string
+		body          string
+		checkResponse func(t *testing.T, apiErr apiError)
+	}{
+		{
+			name: "InvalidFields",
+			body: `{"from_account_id": 1, "to_account_id": 2, "currency": "XYZ", "description": "` + string(bytes.Repeat([]byte("a"), 256)) + `"}`,
+			checkResponse: func(t *testing.T, apiErr apiError) {
+				require.Equal(t, []fieldError{
+					{Field: "amount", Rule: "required", Message: "is required"},
+					{Field: "currency", Rule: "currency", Message: "must be a supported currency"},
+					{Field: "description", Rule: "max", Message: "must have at most 255 characters"},
+				}, apiErr.Details)
+			},
+		},
+		{
+			name: "WrongType",
+			body: `{"from_account_id": "one"}`,
+			checkResponse: func(t *testing.T, apiErr apiError) {
+				require.Equal(t, []fieldError{
+					{Field: "from_account_id", Rule: "type", Message: "must be an integer"},
+				}, apiErr.Details)
+			},
+		},
+		{
+			name: "InvalidJSON",
+			body: `{"from_account_id": `,
+			checkResponse: func(t *testing.T, apiErr apiError) {
+				require.Empty(t, apiErr.Details)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			expectActiveUsers(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader([]byte(testCase.body)))
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, utils.RandomOwner(), utils.DepositorRole, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusBadRequest, recorder.Code)
+			testCase.checkResponse(t, requireErrorCode(t, recorder, codeValidationFailed))
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+
+	// the ID of the client is kept.
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/accounts/0", nil)
+	require.NoError(t, err)
+	request.Header.Set(requestIDHeader, "client-request-1")
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, "client-request-1", recorder.Header().Get(requestIDHeader))
+	requireErrorCode(t, recorder, codeValidationFailed)
+
+	// otherwise one is generated.
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/webhooks", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.NotEmpty(t, recorder.Header().Get(requestIDHeader))
+	requireErrorCode(t, recorder, codeUnauthenticated)
+}
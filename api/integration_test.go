@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kvgtl/simplebank/db/dbtest"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// Sends a JSON request to the server, authorized with the access token unless
+// it's empty, and decodes its response into result.
+func sendJSON(t *testing.T, server *Server, method, url, accessToken string, body any, result any) {
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+
+	request, err := http.NewRequest(method, url, bytes.NewReader(data))
+	require.NoError(t, err)
+	if accessToken != "" {
+		request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+	}
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	if result != nil {
+		err = json.Unmarshal(recorder.Body.Bytes(), result)
+		require.NoError(t, err)
+	}
+}
+
+func TestTransferIntegration(t *testing.T) {
+	config := utils.Config{DBSource: dbtest.Source(t), DBTxMaxRetries: 3}
+	connPool, err := db.NewConnPool(context.Background(), config)
+	require.NoError(t, err)
+	defer connPool.Close()
+
+	server := newTestServer(t, db.NewStore(connPool, config))
+
+	var accounts [2]db.Account
+	var accessTokens [2]string
+	for i := range accounts {
+		password := utils.RandomString(6)
+		var user createUserResponse
+		sendJSON(t, server, http.MethodPost, "/users", "", gin.H{
+			"username":  utils.RandomOwner(),
+			"password":  password,
+			"full_name": utils.RandomOwner(),
+			"email":     utils.RandomEmailAddress(),
+		}, &user)
+
+		var login loginUserResponse
+		sendJSON(t, server, http.MethodPost, "/users/login", "", gin.H{
+			"username": user.Username,
+			"password": password,
+		}, &login)
+		require.NotEmpty(t, login.AccessToken)
+		accessTokens[i] = login.AccessToken
+
+		sendJSON(t, server, http.MethodPost, "/accounts", "", gin.H{
+			"owner":    user.Username,
+			"currency": utils.USD,
+		}, &accounts[i])
+	}
+
+	sendJSON(t, server, http.MethodPatch, fmt.Sprintf("/accounts/%d", accounts[0].ID), "", gin.H{
+		"amount": 100,
+	}, nil)
+
+	var result db.TransferTxResult
+	sendJSON(t, server, http.MethodPost, "/transfers", accessTokens[0], gin.H{
+		"from_account_id": accounts[0].ID,
+		"to_account_id":   accounts[1].ID,
+		"amount":          30,
+		"currency":        utils.USD,
+	}, &result)
+	require.Equal(t, int64(30), result.Transfer.Amount)
+
+	for i, balance := range []int64{70, 30} {
+		var account db.Account
+		sendJSON(t, server, http.MethodGet, fmt.Sprintf("/accounts/%d", accounts[i].ID), "", nil, &account)
+		require.Equal(t, balance, account.Balance)
+	}
+}
@@ -3,11 +3,33 @@ package api
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kvgtl/simplebank/db/dbtest"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/notify"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
 )
 
+func newTestServer(t *testing.T, store db.Store) *Server {
+	config := utils.Config{
+		TokenSymmetricKey:    utils.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+	}
+
+	server, err := NewServer(config, store, notify.NewBroker())
+	require.NoError(t, err)
+
+	return server
+}
+
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
-	os.Exit(m.Run())
+
+	code := m.Run()
+	dbtest.Stop()
+	os.Exit(code)
 }
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/token"
+)
+
+const (
+	authorizationHeaderKey  = "authorization"
+	authorizationTypeBearer = "bearer"
+	authorizationPayloadKey = "authorization_payload"
+)
+
+// Creates a gin middleware that requires a valid bearer access token of a
+// user that isn't deleted and stores its payload in the context.
+func authMiddleware(tokenMaker token.Maker, store db.Store) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
+		if len(authorizationHeader) == 0 {
+			err := errors.New("authorization header is not provided")
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(ctx, codeUnauthenticated, err))
+			return
+		}
+
+		fields := strings.Fields(authorizationHeader)
+		if len(fields) < 2 {
+			err := errors.New("invalid authorization header format")
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(ctx, codeUnauthenticated, err))
+			return
+		}
+
+		authorizationType := strings.ToLower(fields[0])
+		if authorizationType != authorizationTypeBearer {
+			err := fmt.Errorf("unsupported authorization type %s", authorizationType)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(ctx, codeUnauthenticated, err))
+			return
+		}
+
+		payload, err := tokenMaker.VerifyToken(fields[1], token.TokenTypeAccessToken)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(ctx, codeUnauthenticated, err))
+			return
+		}
+
+		// the tokens of a user stay valid until they expire, the user is
+		// checked on every request.
+		deletedAt, err := store.GetUserDeletedAt(ctx, payload.Username)
+		if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+			return
+		}
+		if err != nil || deletedAt.Valid {
+			err := fmt.Errorf("user %s doesn't exist anymore", payload.Username)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(ctx, codeUnauthenticated, err))
+			return
+		}
+
+		ctx.Set(authorizationPayloadKey, payload)
+		ctx.Next()
+	}
+}
+
+// Creates a gin middleware that only lets through users with one of the roles.
+// It must run after authMiddleware.
+func roleMiddleware(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+		for _, role := range roles {
+			if payload.Role == role {
+				ctx.Next()
+				return
+			}
+		}
+
+		err := fmt.Errorf("role %s is not allowed to access this resource", payload.Role)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(ctx, codeForbidden, err))
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPI 3 document of the HTTP API, kept in sync with the routes and
+// their request and response types by TestOpenAPISpec.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// Renders the document with Swagger UI loaded from a CDN.
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Simple Bank API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+func (server *Server) getOpenAPI(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", openAPISpec)
+}
+
+func (server *Server) getDocs(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
@@ -0,0 +1,318 @@
+package api
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+// Go types of the request and response of an operation of the spec, nil when
+// the operation has none or when its response isn't json.
+type openAPIOperation struct {
+	body     any
+	query    any
+	uri      any
+	response any
+}
+
+var openAPIOperations = map[string]openAPIOperation{
+	"POST /accounts":             {body: createAccountRequest{}, response: db.Account{}},
+	"GET /accounts/{id}":         {uri: getAccountRequest{}, response: db.Account{}},
+	"GET /accounts":              {query: listAccountsRequest{}, response: []db.Account{}},
+	"PATCH /accounts/{id}":       {uri: getAccountRequest{}, body: addAccountBalanceRequest{}, response: db.Account{}},
+	"DELETE /accounts/{id}":      {uri: deleteAccountRequest{}},
+	"GET /accounts/{id}/entries": {uri: getAccountRequest{}, query: listAccountEntriesRequest{}, response: []db.Entry{}},
+	"GET /accounts/{id}/events":  {uri: getAccountRequest{}},
+	"POST /transfers":            {body: transferRequest{}, response: db.TransferTxResult{}},
+	"GET /transfers":             {query: listTransfersRequest{}, response: []db.Transfer{}},
+	"POST /users":                {body: createUserRequest{}, response: createUserResponse{}},
+	"POST /users/login":          {body: loginUserRequest{}, response: loginUserResponse{}},
+	"DELETE /users/{username}":   {uri: deleteUserRequest{}, response: createUserResponse{}},
+	"POST /tokens/renew_access":  {body: renewAccessTokenRequest{}, response: renewAccessTokenResponse{}},
+	"GET /openapi.json":          {},
+	"GET /docs":                  {},
+	"POST /webhooks":             {body: createWebhookRequest{}, response: createWebhookResponse{}},
+	"GET /webhooks":              {response: []webhookResponse{}},
+	"DELETE /webhooks/{id}":      {uri: webhookRequest{}, response: webhookResponse{}},
+	"GET /webhooks/{id}/deliveries": {
+		uri:      webhookRequest{},
+		query:    listWebhookDeliveriesRequest{},
+		response: []db.WebhookDelivery{},
+	},
+	"GET /reports/trial-balance":   {query: reportFormatRequest{}, response: []db.GetTrialBalanceRow{}},
+	"GET /reports/transfer-volume": {query: reportRangeRequest{}, response: []dailyTransferVolumeResponse{}},
+	"GET /reports/top-accounts":    {query: topAccountsRequest{}, response: []db.Account{}},
+	"GET /reports/new-users":       {query: reportRangeRequest{}, response: []dailyNewUsersResponse{}},
+}
+
+// Schemas that aren't the body of an operation.
+var openAPISchemas = map[string]any{
+	"EntryEvent":   entryEvent{},
+	"BalanceEvent": balanceEvent{},
+	"Error":        errorBody{},
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	pathParamRegex    = regexp.MustCompile(`:(\w+)`)
+)
+
+type openAPIDocument struct {
+	Paths      map[string]map[string]map[string]any `json:"paths"`
+	Components struct {
+		Schemas map[string]map[string]any `json:"schemas"`
+	} `json:"components"`
+}
+
+func loadOpenAPIDocument(t *testing.T) openAPIDocument {
+	var doc openAPIDocument
+	require.NoError(t, json.Unmarshal(openAPISpec, &doc))
+	return doc
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	doc := loadOpenAPIDocument(t)
+	server := newTestServer(t, nil)
+
+	routes := make(map[string]bool)
+	for _, route := range server.router.Routes() {
+		key := route.Method + " " + pathParamRegex.ReplaceAllString(route.Path, "{$1}")
+		routes[key] = true
+
+		path, ok := doc.Paths[strings.Split(key, " ")[1]]
+		require.True(t, ok, "route %s is missing from the spec", key)
+		_, ok = path[strings.ToLower(route.Method)]
+		require.True(t, ok, "route %s is missing from the spec", key)
+		_, ok = openAPIOperations[key]
+		require.True(t, ok, "route %s is missing from openAPIOperations", key)
+	}
+
+	var operations []string
+	for path, methods := range doc.Paths {
+		for method := range methods {
+			operations = append(operations, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(operations)
+	require.Len(t, operations, len(routes))
+
+	for _, key := range operations {
+		t.Run(key, func(t *testing.T) {
+			require.True(t, routes[key], "operation %s has no route", key)
+
+			method, path, _ := strings.Cut(key, " ")
+			operation := doc.Paths[path][strings.ToLower(method)]
+			types := openAPIOperations[key]
+
+			checkOpenAPIParameters(t, doc, operation, "path", types.uri, "uri")
+			checkOpenAPIParameters(t, doc, operation, "query", types.query, "form")
+
+			body, ok := operation["requestBody"].(map[string]any)
+			require.Equal(t, types.body != nil, ok, "request body")
+			if ok {
+				schema := jsonPath(t, body, "content", "application/json", "schema")
+				checkOpenAPISchema(t, doc, "body", schema, reflect.TypeOf(types.body), true)
+			}
+
+			response := jsonPath(t, operation, "responses", "200")
+			content, _ := response["content"].(map[string]any)
+			schema, ok := content["application/json"].(map[string]any)
+			if types.response == nil {
+				require.True(t, !ok || key == "GET /openapi.json", "unexpected json response")
+				return
+			}
+			require.True(t, ok, "missing json response")
+			checkOpenAPISchema(t, doc, "response", jsonPath(t, schema, "schema"), reflect.TypeOf(types.response), false)
+		})
+	}
+
+	for name, value := range openAPISchemas {
+		schema, ok := doc.Components.Schemas[name]
+		require.True(t, ok, "schema %s is missing from the spec", name)
+		checkOpenAPISchema(t, doc, name, schema, reflect.TypeOf(value), false)
+	}
+}
+
+func TestGetOpenAPI(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	for _, url := range []string{"/openapi.json", "/docs"} {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	}
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/openapi.json", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.JSONEq(t, string(openAPISpec), recorder.Body.String())
+}
+
+// Returns the value of a chain of keys in a json object.
+func jsonPath(t *testing.T, value map[string]any, keys ...string) map[string]any {
+	for _, key := range keys {
+		next, ok := value[key].(map[string]any)
+		require.True(t, ok, "missing %q", key)
+		value = next
+	}
+	return value
+}
+
+// Follows the $ref of a schema to the component it points to.
+func resolveOpenAPISchema(t *testing.T, doc openAPIDocument, schema map[string]any) map[string]any {
+	ref, ok := schema["$ref"].(string)
+	if !ok {
+		return schema
+	}
+	name := strings.TrimPrefix(ref, "#/components/schemas/")
+	resolved, ok := doc.Components.Schemas[name]
+	require.True(t, ok, "unknown schema %s", ref)
+	return resolved
+}
+
+// Checks that the parameters of an operation located in "in" match the
+// fields of value, bound through the given struct tag.
+func checkOpenAPIParameters(t *testing.T, doc openAPIDocument, operation map[string]any, in string, value any, tag string) {
+	specParams := make(map[string]map[string]any)
+	params, _ := operation["parameters"].([]any)
+	for _, param := range params {
+		param := param.(map[string]any)
+		if param["in"] == in {
+			specParams[param["name"].(string)] = param
+		}
+	}
+
+	fields := make(map[string]reflect.StructField)
+	if value != nil {
+		typ := reflect.TypeOf(value)
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if name := field.Tag.Get(tag); name != "" {
+				fields[name] = field
+			}
+		}
+	}
+	require.Len(t, specParams, len(fields), "%s parameters", in)
+
+	for name, field := range fields {
+		param, ok := specParams[name]
+		require.True(t, ok, "%s parameter %s is missing from the spec", in, name)
+		require.Equal(t, isRequiredField(field), param["required"], "%s parameter %s required", in, name)
+		checkOpenAPISchema(t, doc, in+"."+name, jsonPath(t, param, "schema"), field.Type, false)
+	}
+}
+
+func isRequiredField(field reflect.StructField) bool {
+	for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the fields of a struct by json name, including the ones of its
+// embedded structs.
+func jsonFields(typ reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.Anonymous && name == "" {
+			for embeddedName, embedded := range jsonFields(field.Type) {
+				fields[embeddedName] = embedded
+			}
+			continue
+		}
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// Checks that a schema describes the json encoding of typ. The required
+// fields of request bodies must match their binding rules.
+func checkOpenAPISchema(t *testing.T, doc openAPIDocument, where string, schema map[string]any, typ reflect.Type, request bool) {
+	schema = resolveOpenAPISchema(t, doc, schema)
+
+	if typ.Kind() == reflect.Pointer {
+		require.Equal(t, true, schema["nullable"], "%s must be nullable", where)
+		typ = typ.Elem()
+	}
+
+	switch {
+	case typ == timeType:
+		require.Equal(t, "string", schema["type"], where)
+		require.Contains(t, []any{"date-time", "date"}, schema["format"], where)
+	case typ.Implements(textMarshalerType):
+		require.Equal(t, "string", schema["type"], where)
+	case typ == rawMessageType:
+		require.Equal(t, "object", schema["type"], where)
+	case typ.Kind() == reflect.Struct:
+		require.Equal(t, "object", schema["type"], where)
+
+		properties, _ := schema["properties"].(map[string]any)
+		fields := jsonFields(typ)
+
+		var names, specNames []string
+		for name := range fields {
+			names = append(names, name)
+		}
+		for name := range properties {
+			specNames = append(specNames, name)
+		}
+		require.ElementsMatch(t, names, specNames, "%s properties", where)
+
+		var required []any
+		for name, field := range fields {
+			checkOpenAPISchema(t, doc, where+"."+name, properties[name].(map[string]any), field.Type, request)
+			if isRequiredField(field) {
+				required = append(required, name)
+			}
+		}
+		if request {
+			specRequired, _ := schema["required"].([]any)
+			require.ElementsMatch(t, required, specRequired, "%s required", where)
+		}
+	case typ.Kind() == reflect.Slice:
+		require.Equal(t, "array", schema["type"], where)
+		checkOpenAPISchema(t, doc, where+"[]", jsonPath(t, schema, "items"), typ.Elem(), request)
+	case typ.Kind() == reflect.Map:
+		require.Equal(t, "object", schema["type"], where)
+		checkOpenAPISchema(t, doc, where+"{}", jsonPath(t, schema, "additionalProperties"), typ.Elem(), request)
+	case typ.Kind() == reflect.String:
+		require.Equal(t, "string", schema["type"], where)
+	case typ.Kind() == reflect.Bool:
+		require.Equal(t, "boolean", schema["type"], where)
+	case typ.Kind() == reflect.Int64:
+		require.Equal(t, "integer", schema["type"], where)
+		require.Equal(t, "int64", schema["format"], where)
+	case typ.Kind() == reflect.Int32:
+		require.Equal(t, "integer", schema["type"], where)
+		require.Equal(t, "int32", schema["format"], where)
+	default:
+		t.Fatalf("%s: unsupported type %s", where, typ)
+	}
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+)
+
+const (
+	reportDateLayout = "2006-01-02"
+	maxReportDays    = 366
+)
+
+type reportFormatRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=json csv"`
+}
+
+type reportRangeRequest struct {
+	From   time.Time `form:"from" binding:"required" time_format:"2006-01-02" time_utc:"1"`
+	To     time.Time `form:"to" binding:"required" time_format:"2006-01-02" time_utc:"1"`
+	Format string    `form:"format" binding:"omitempty,oneof=json csv"`
+}
+
+// Returns the half-open time range [from, to+1 day) covered by the request,
+// so that the to date is inclusive.
+func (req reportRangeRequest) bounds() (time.Time, time.Time, error) {
+	if req.To.Before(req.From) {
+		return time.Time{}, time.Time{}, errors.New("to must not be before from")
+	}
+	end := req.To.AddDate(0, 0, 1)
+	if end.Sub(req.From) > maxReportDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("date range must not exceed %d days", maxReportDays)
+	}
+	return req.From, end, nil
+}
+
+func (server *Server) getTrialBalance(ctx *gin.Context) {
+	var req reportFormatRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
+		return
+	}
+
+	rows, err := server.store.GetTrialBalance(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+
+	records := make([][]string, len(rows))
+	for i, row := range rows {
+		records[i] = []string{
+			row.Currency,
+			strconv.FormatInt(row.CustomerAccounts, 10),
+			strconv.FormatInt(row.CustomerBalance, 10),
+			strconv.FormatInt(row.SystemBalance, 10),
+			strconv.FormatInt(row.TotalBalance, 10),
+		}
+	}
+
+	header := []string{"currency", "customer_accounts", "customer_balance", "system_balance", "total_balance"}
+	renderReport(ctx, req.Format, "trial-balance", header, records, rows)
+}
+
+type dailyTransferVolumeResponse struct {
+	Day       string `json:"day"`
+	Currency  string `json:"currency"`
+	Transfers int64  `json:"transfers"`
+	Volume    int64  `json:"volume"`
+}
+
+func (server *Server) getTransferVolume(ctx *gin.Context) {
+	var req reportRangeRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
+		return
+	}
+
+	from, to, err := req.bounds()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(ctx, codeValidationFailed, err))
+		return
+	}
+
+	rows, err := server.store.GetDailyTransferVolume(ctx, db.GetDailyTransferVolumeParams{
+		FromTime: from,
+		ToTime:   to,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+
+	response := make([]dailyTransferVolumeResponse, len(rows))
+	records := make([][]string, len(rows))
+	for i, row := range rows {
+		response[i] = dailyTransferVolumeResponse{
+			Day:       row.Day.Format(reportDateLayout),
+			Currency:  row.Currency,
+			Transfers: row.Transfers,
+			Volume:    row.Volume,
+		}
+		records[i] = []string{
+			response[i].Day,
+			row.Currency,
+			strconv.FormatInt(row.Transfers, 10),
+			strconv.FormatInt(row.Volume, 10),
+		}
+	}
+
+	header := []string{"day", "currency", "transfers", "volume"}
+	renderReport(ctx, req.Format, "transfer-volume", header, records, response)
+}
+
+type topAccountsRequest struct {
+	Currency string `form:"currency" binding:"required,currency"`
+	Limit    int32  `form:"limit" binding:"omitempty,min=1,max=100"`
+	Format   string `form:"format" binding:"omitempty,oneof=json csv"`
+}
+
+func (server *Server) getTopAccounts(ctx *gin.Context) {
+	var req topAccountsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 10
+	}
+
+	accounts, err := server.store.ListTopAccountsByBalance(ctx, db.ListTopAccountsByBalanceParams{
+		Currency: req.Currency,
+		Limit:    req.Limit,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+
+	records := make([][]string, len(accounts))
+	for i, account := range accounts {
+		var owner string
+		if account.Owner != nil {
+			owner = *account.Owner
+		}
+		records[i] = []string{
+			strconv.FormatInt(account.ID, 10),
+			owner,
+			account.AccountType,
+			account.Currency,
+			strconv.FormatInt(account.Balance, 10),
+		}
+	}
+
+	header := []string{"id", "owner", "account_type", "currency", "balance"}
+	renderReport(ctx, req.Format, "top-accounts", header, records, accounts)
+}
+
+type dailyNewUsersResponse struct {
+	Day   string `json:"day"`
+	Users int64  `json:"users"`
+}
+
+func (server *Server) getNewUsers(ctx *gin.Context) {
+	var req reportRangeRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
+		return
+	}
+
+	from, to, err := req.bounds()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(ctx, codeValidationFailed, err))
+		return
+	}
+
+	rows, err := server.store.GetDailyNewUsers(ctx, db.GetDailyNewUsersParams{
+		FromTime: from,
+		ToTime:   to,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+
+	response := make([]dailyNewUsersResponse, len(rows))
+	records := make([][]string, len(rows))
+	for i, row := range rows {
+		response[i] = dailyNewUsersResponse{
+			Day:   row.Day.Format(reportDateLayout),
+			Users: row.Users,
+		}
+		records[i] = []string{response[i].Day, strconv.FormatInt(row.Users, 10)}
+	}
+
+	header := []string{"day", "users"}
+	renderReport(ctx, req.Format, "new-users", header, records, response)
+}
+
+// Writes a report either as a CSV attachment with a header row or as json.
+func renderReport(ctx *gin.Context, format string, name string, header []string, records [][]string, data any) {
+	if format != "csv" {
+		ctx.JSON(http.StatusOK, data)
+		return
+	}
+
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".csv"))
+	ctx.Status(http.StatusOK)
+
+	w := csv.NewWriter(ctx.Writer)
+	w.Write(header)
+	w.WriteAll(records)
+}
@@ -0,0 +1,375 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/kvgtl/simplebank/db/mock"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/token"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetTrialBalanceAPI(t *testing.T) {
+	rows := []db.GetTrialBalanceRow{
+		{
+			Currency:         utils.USD,
+			CustomerAccounts: 3,
+			CustomerBalance:  300,
+			SystemBalance:    -300,
+			TotalBalance:     0,
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, utils.RandomOwner(), utils.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTrialBalance(gomock.Any()).
+					Times(1).
+					Return(rows, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotRows []db.GetTrialBalanceRow
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotRows)
+				require.NoError(t, err)
+				require.Equal(t, rows, gotRows)
+			},
+		},
+		{
+			name:  "CSV",
+			query: "?format=csv",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, utils.RandomOwner(), utils.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTrialBalance(gomock.Any()).
+					Times(1).
+					Return(rows, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Header().Get("Content-Type"), "text/csv")
+
+				lines := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n")
+				require.Len(t, lines, 2)
+				require.Equal(t, "currency,customer_accounts,customer_balance,system_balance,total_balance", lines[0])
+				require.Equal(t, "USD,3,300,-300,0", lines[1])
+			},
+		},
+		{
+			name:  "InvalidFormat",
+			query: "?format=xml",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, utils.RandomOwner(), utils.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTrialBalance(gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "NoAuthorization",
+			query: "",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTrialBalance(gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:  "DepositorForbidden",
+			query: "",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, utils.RandomOwner(), utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTrialBalance(gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: "",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, utils.RandomOwner(), utils.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTrialBalance(gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			testCase.buildStubs(store)
+			expectActiveUsers(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/reports/trial-balance"+testCase.query, nil)
+			require.NoError(t, err)
+
+			testCase.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetTransferVolumeAPI(t *testing.T) {
+	from := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	rows := []db.GetDailyTransferVolumeRow{
+		{
+			Day:       from,
+			Currency:  utils.USD,
+			Transfers: 2,
+			Volume:    150,
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "?from=2024-03-01&to=2024-03-31",
+			buildStubs: func(store *mockdb.MockStore) {
+				args := db.GetDailyTransferVolumeParams{
+					FromTime: from,
+					ToTime:   time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC),
+				}
+				store.EXPECT().
+					GetDailyTransferVolume(gomock.Any(), gomock.Eq(args)).
+					Times(1).
+					Return(rows, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotRows []dailyTransferVolumeResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotRows)
+				require.NoError(t, err)
+				require.Equal(t, []dailyTransferVolumeResponse{
+					{Day: "2024-03-01", Currency: utils.USD, Transfers: 2, Volume: 150},
+				}, gotRows)
+			},
+		},
+		{
+			name:  "CSV",
+			query: "?from=2024-03-01&to=2024-03-01&format=csv",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDailyTransferVolume(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(rows, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "day,currency,transfers,volume\n2024-03-01,USD,2,150\n", recorder.Body.String())
+			},
+		},
+		{
+			name:  "MissingRange",
+			query: "?from=2024-03-01",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDailyTransferVolume(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidDate",
+			query: "?from=03/01/2024&to=2024-03-31",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDailyTransferVolume(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "ReversedRange",
+			query: "?from=2024-03-31&to=2024-03-01",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDailyTransferVolume(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "RangeTooLong",
+			query: "?from=2022-01-01&to=2024-01-01",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDailyTransferVolume(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			testCase.buildStubs(store)
+			expectActiveUsers(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/reports/transfer-volume"+testCase.query, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, utils.RandomOwner(), utils.BankerRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetTopAccountsAPI(t *testing.T) {
+	account := randomAccount()
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "?currency=" + account.Currency,
+			buildStubs: func(store *mockdb.MockStore) {
+				args := db.ListTopAccountsByBalanceParams{
+					Currency: account.Currency,
+					Limit:    10,
+				}
+				store.EXPECT().
+					ListTopAccountsByBalance(gomock.Any(), gomock.Eq(args)).
+					Times(1).
+					Return([]db.Account{account}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotAccounts []db.Account
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotAccounts)
+				require.NoError(t, err)
+				require.Equal(t, []db.Account{account}, gotAccounts)
+			},
+		},
+		{
+			name:  "InvalidCurrency",
+			query: "?currency=XYZ",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTopAccountsByBalance(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidLimit",
+			query: "?currency=" + account.Currency + "&limit=1000",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTopAccountsByBalance(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			testCase.buildStubs(store)
+			expectActiveUsers(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/reports/top-accounts"+testCase.query, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, utils.RandomOwner(), utils.BankerRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(t, recorder)
+		})
+	}
+}
@@ -1,25 +1,49 @@
 package api
 
 import (
+	"fmt"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/notify"
+	"github.com/kvgtl/simplebank/token"
+	"github.com/kvgtl/simplebank/utils"
 )
 
 // Serves HTTP request for the banking system.
 type Server struct {
-	store  db.Store
-	router *gin.Engine
+	config     utils.Config
+	store      db.Store
+	tokenMaker token.Maker
+	broker     *notify.Broker
+	router     *gin.Engine
 }
 
 // Creates a new HTTP server and setup routing.
-func NewServer(store db.Store) *Server {
-	server := &Server{store: store}
+// The broker feeds the streams of account events.
+func NewServer(config utils.Config, store db.Store, broker *notify.Broker) (*Server, error) {
+	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %w", err)
+	}
+
+	server := &Server{
+		config:     config,
+		store:      store,
+		tokenMaker: tokenMaker,
+		broker:     broker,
+	}
 	router := gin.Default()
+	router.Use(requestIDMiddleware())
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", validCurrency)
+		v.RegisterValidation("account_type", validAccountType)
+		v.RegisterValidation("webhook_url", validWebhookURL)
+		v.RegisterTagNameFunc(requestFieldName)
 	}
 
 	// routes.
@@ -29,12 +53,35 @@ func NewServer(store db.Store) *Server {
 	router.PATCH("/accounts/:id", server.addAccountBalance)
 	router.DELETE("/accounts/:id", server.deleteAccount)
 
-	router.POST("/transfers", server.createTransfer)
-
 	router.POST("/users", server.createUser)
+	router.POST("/users/login", server.loginUser)
+	router.POST("/tokens/renew_access", server.renewAccessToken)
+
+	router.GET("/openapi.json", server.getOpenAPI)
+	router.GET("/docs", server.getDocs)
+
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.store))
+	authRoutes.GET("/accounts/:id/entries", server.listAccountEntries)
+	authRoutes.GET("/accounts/:id/events", server.streamAccountEvents)
+	authRoutes.DELETE("/users/:username", server.deleteUser)
+
+	authRoutes.POST("/transfers", server.createTransfer)
+	authRoutes.GET("/transfers", server.listTransfers)
+
+	authRoutes.POST("/webhooks", server.createWebhook)
+	authRoutes.GET("/webhooks", server.listWebhooks)
+	authRoutes.DELETE("/webhooks/:id", server.deleteWebhook)
+	authRoutes.GET("/webhooks/:id/deliveries", server.listWebhookDeliveries)
+
+	// reports are only available to bankers.
+	reportRoutes := router.Group("/reports").Use(authMiddleware(server.tokenMaker, server.store), roleMiddleware(utils.BankerRole))
+	reportRoutes.GET("/trial-balance", server.getTrialBalance)
+	reportRoutes.GET("/transfer-volume", server.getTransferVolume)
+	reportRoutes.GET("/top-accounts", server.getTopAccounts)
+	reportRoutes.GET("/new-users", server.getNewUsers)
 
 	server.router = router
-	return server
+	return server, nil
 }
 
 // Runs the HTTP server on a specific address.
@@ -42,7 +89,7 @@ func (server *Server) Start(address string) error {
 	return server.router.Run(address)
 }
 
-// Returns and error response as json.
-func errorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
+// Returns the handler of the routes, to serve them from another http.Server.
+func (server *Server) Handler() http.Handler {
+	return server.router
 }
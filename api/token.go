@@ -0,0 +1,97 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/token"
+)
+
+type renewAccessTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type renewAccessTokenResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+}
+
+// Issues a new access token for the session of a refresh token, as long as
+// the session is neither blocked nor expired.
+func (server *Server) renewAccessToken(ctx *gin.Context) {
+	var req renewAccessTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
+		return
+	}
+
+	refreshPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken, token.TokenTypeRefreshToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(ctx, codeUnauthenticated, err))
+		return
+	}
+
+	session, err := server.store.GetSession(ctx, refreshPayload.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := errors.New("session not found")
+			ctx.JSON(http.StatusNotFound, errorResponse(ctx, codeSessionNotFound, err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+
+	if session.IsBlocked {
+		err := errors.New("blocked session")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(ctx, codeUnauthenticated, err))
+		return
+	}
+
+	if session.Username != refreshPayload.Username {
+		err := errors.New("incorrect session user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(ctx, codeUnauthenticated, err))
+		return
+	}
+
+	if session.RefreshToken != req.RefreshToken {
+		err := errors.New("mismatched session token")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(ctx, codeUnauthenticated, err))
+		return
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		err := errors.New("expired session")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(ctx, codeUnauthenticated, err))
+		return
+	}
+
+	// the role may have changed since the login, the access token gets the
+	// current one.
+	user, err := server.store.GetUserRole(ctx, refreshPayload.Username)
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+	if err != nil || user.DeletedAt.Valid {
+		err := fmt.Errorf("user %s doesn't exist anymore", refreshPayload.Username)
+		ctx.JSON(http.StatusUnauthorized, errorResponse(ctx, codeUnauthenticated, err))
+		return
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(refreshPayload.Username, user.Role, server.config.AccessTokenDuration, token.TokenTypeAccessToken)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+
+	response := renewAccessTokenResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+	}
+	ctx.JSON(http.StatusOK, response)
+}
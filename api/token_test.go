@@ -0,0 +1,218 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	mockdb "github.com/kvgtl/simplebank/db/mock"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/token"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRenewAccessTokenAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		duration      time.Duration
+		tokenType     token.TokenType
+		buildSession  func(payload *token.Payload, refreshToken string) db.Session
+		buildStubs    func(store *mockdb.MockStore, payload *token.Payload, session db.Session)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker)
+	}{
+		{
+			name:      "OK",
+			duration:  time.Hour,
+			tokenType: token.TokenTypeRefreshToken,
+			buildSession: func(payload *token.Payload, refreshToken string) db.Session {
+				return db.Session{
+					ID:           payload.ID,
+					Username:     user.Username,
+					RefreshToken: refreshToken,
+					ExpiresAt:    payload.ExpiredAt,
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore, payload *token.Payload, session db.Session) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(session, nil)
+				// the user became a banker since the login.
+				store.EXPECT().
+					GetUserRole(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.GetUserRoleRow{Role: utils.BankerRole}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response renewAccessTokenResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+				require.NotEmpty(t, response.AccessToken)
+				require.WithinDuration(t, time.Now().Add(time.Minute), response.AccessTokenExpiresAt, time.Second)
+
+				payload, err := tokenMaker.VerifyToken(response.AccessToken, token.TokenTypeAccessToken)
+				require.NoError(t, err)
+				require.Equal(t, utils.BankerRole, payload.Role)
+			},
+		},
+		{
+			name:      "DeletedUser",
+			duration:  time.Hour,
+			tokenType: token.TokenTypeRefreshToken,
+			buildSession: func(payload *token.Payload, refreshToken string) db.Session {
+				return db.Session{
+					ID:           payload.ID,
+					Username:     user.Username,
+					RefreshToken: refreshToken,
+					ExpiresAt:    payload.ExpiredAt,
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore, payload *token.Payload, session db.Session) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(session, nil)
+				store.EXPECT().
+					GetUserRole(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.GetUserRoleRow{Role: user.Role, DeletedAt: sql.NullTime{Time: time.Now(), Valid: true}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "AccessToken",
+			duration:  time.Hour,
+			tokenType: token.TokenTypeAccessToken,
+			buildSession: func(payload *token.Payload, refreshToken string) db.Session {
+				return db.Session{}
+			},
+			buildStubs: func(store *mockdb.MockStore, payload *token.Payload, session db.Session) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "SessionNotFound",
+			duration:  time.Hour,
+			tokenType: token.TokenTypeRefreshToken,
+			buildSession: func(payload *token.Payload, refreshToken string) db.Session {
+				return db.Session{}
+			},
+			buildStubs: func(store *mockdb.MockStore, payload *token.Payload, session db.Session) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(db.Session{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "BlockedSession",
+			duration:  time.Hour,
+			tokenType: token.TokenTypeRefreshToken,
+			buildSession: func(payload *token.Payload, refreshToken string) db.Session {
+				return db.Session{
+					ID:           payload.ID,
+					Username:     user.Username,
+					RefreshToken: refreshToken,
+					IsBlocked:    true,
+					ExpiresAt:    payload.ExpiredAt,
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore, payload *token.Payload, session db.Session) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(session, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "MismatchedToken",
+			duration:  time.Hour,
+			tokenType: token.TokenTypeRefreshToken,
+			buildSession: func(payload *token.Payload, refreshToken string) db.Session {
+				return db.Session{
+					ID:           payload.ID,
+					Username:     user.Username,
+					RefreshToken: "other",
+					ExpiresAt:    payload.ExpiredAt,
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore, payload *token.Payload, session db.Session) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(session, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "ExpiredToken",
+			duration:  -time.Minute,
+			tokenType: token.TokenTypeRefreshToken,
+			buildSession: func(payload *token.Payload, refreshToken string) db.Session {
+				return db.Session{}
+			},
+			buildStubs: func(store *mockdb.MockStore, payload *token.Payload, session db.Session) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			refreshToken, payload, err := server.tokenMaker.CreateToken(user.Username, user.Role, testCase.duration, testCase.tokenType)
+			require.NoError(t, err)
+
+			session := testCase.buildSession(payload, refreshToken)
+			testCase.buildStubs(store, payload, session)
+
+			data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, "/tokens/renew_access", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(t, recorder, server.tokenMaker)
+		})
+	}
+}
@@ -1,33 +1,48 @@
 package api
 
 import (
-	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/token"
+	"github.com/kvgtl/simplebank/utils"
 )
 
 type transferRequest struct {
-	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount        int64  `json:"amount" binding:"required,gt=0"`
-	Currency      string `json:"currency" binding:"required,currency"`
+	FromAccountID int64             `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64             `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64             `json:"amount" binding:"required,gt=0"`
+	Currency      string            `json:"currency" binding:"required,currency"`
+	Description   string            `json:"description" binding:"max=255"`
+	Reference     string            `json:"reference" binding:"max=64,printascii"`
+	Metadata      map[string]string `json:"metadata" binding:"max=20,dive,keys,min=1,max=40,endkeys,max=500"`
 }
 
 func (server *Server) createTransfer(ctx *gin.Context) {
 	var req transferRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
 		return
 	}
 
-	if !server.validAccount(ctx, req.FromAccountID, req.Currency) {
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
 		return
 	}
 
-	if !server.validAccount(ctx, req.ToAccountID, req.Currency) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner == nil || *fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusForbidden, errorResponse(ctx, codeForbidden, err))
+		return
+	}
+
+	if _, valid := server.validAccount(ctx, req.ToAccountID, req.Currency); !valid {
 		return
 	}
 
@@ -35,32 +50,129 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		FromAccountID: req.FromAccountID,
 		ToAccountID:   req.ToAccountID,
 		Amount:        req.Amount,
+		Description:   req.Description,
+		Reference:     req.Reference,
+	}
+
+	if len(req.Metadata) > 0 {
+		metadata, err := json.Marshal(req.Metadata)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+			return
+		}
+		args.Metadata = metadata
 	}
 
 	result, err := server.store.TransferTx(ctx, args)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		var limitErr *db.TransferLimitError
+		if errors.As(err, &limitErr) {
+			ctx.JSON(http.StatusUnprocessableEntity, transferLimitResponse(ctx, limitErr))
+			return
+		}
+		if errors.Is(err, db.ErrAccountFrozen) {
+			ctx.JSON(http.StatusForbidden, errorResponse(ctx, codeAccountFrozen, err))
+			return
+		}
+		if errors.Is(err, db.ErrAccountDeleted) {
+			ctx.JSON(http.StatusForbidden, errorResponse(ctx, codeAccountDeleted, err))
+			return
+		}
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			err := fmt.Errorf("account [%d] has insufficient funds", req.FromAccountID)
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(ctx, codeInsufficientFunds, err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
 		return
 	}
 
 	ctx.JSON(http.StatusOK, result)
 }
 
-func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) bool {
+// The pages follow the (after_created_at, after_id) of the last transfer of
+// the previous page, none for the first page.
+type listTransfersRequest struct {
+	Reference      string    `form:"reference" binding:"max=64"`
+	PageSize       int32     `form:"page_size" binding:"required,min=1,max=10"`
+	AfterCreatedAt time.Time `form:"after_created_at"`
+	AfterID        int64     `form:"after_id" binding:"min=0"`
+}
+
+func (server *Server) listTransfers(ctx *gin.Context) {
+	var req listTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
+		return
+	}
+
+	var transfers []db.Transfer
+	var err error
+
+	// bankers list all the transfers, the others the transfers of their
+	// accounts.
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	switch {
+	case authPayload.Role == utils.BankerRole && req.Reference != "":
+		transfers, err = server.store.ListTransfersByReference(ctx, db.ListTransfersByReferenceParams{
+			Reference:      req.Reference,
+			AfterCreatedAt: req.AfterCreatedAt,
+			AfterID:        req.AfterID,
+			LimitCount:     req.PageSize,
+		})
+	case authPayload.Role == utils.BankerRole:
+		transfers, err = server.store.ListTransfers(ctx, db.ListTransfersParams{
+			AfterCreatedAt: req.AfterCreatedAt,
+			AfterID:        req.AfterID,
+			LimitCount:     req.PageSize,
+		})
+	case req.Reference != "":
+		transfers, err = server.store.ListOwnerTransfersByReference(ctx, db.ListOwnerTransfersByReferenceParams{
+			Reference:      req.Reference,
+			Owner:          &authPayload.Username,
+			AfterCreatedAt: req.AfterCreatedAt,
+			AfterID:        req.AfterID,
+			LimitCount:     req.PageSize,
+		})
+	default:
+		transfers, err = server.store.ListOwnerTransfers(ctx, db.ListOwnerTransfersParams{
+			Owner:          &authPayload.Username,
+			AfterCreatedAt: req.AfterCreatedAt,
+			AfterID:        req.AfterID,
+			LimitCount:     req.PageSize,
+		})
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transfers)
+}
+
+func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
 	account, err := server.store.GetAccount(ctx, accountID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return false
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(ctx, codeAccountNotFound, accountNotFoundError(accountID)))
+			return account, false
 		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return false
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return account, false
+	}
+
+	// system accounts are only moved by the ledger itself, transferring from
+	// them would skip the overdraft check.
+	if account.SystemKind != nil {
+		err := fmt.Errorf("account [%d] is a system account", account.ID)
+		ctx.JSON(http.StatusForbidden, errorResponse(ctx, codeSystemAccount, err))
+		return account, false
 	}
 
 	if account.Currency != currency {
 		err := fmt.Errorf("account [%d] currency missmatch: %s vs %s", accountID, account.Currency, currency)
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
-		return false
+		ctx.JSON(http.StatusBadRequest, errorResponse(ctx, codeCurrencyMismatch, err))
+		return account, false
 	}
-	return true
+	return account, true
 }
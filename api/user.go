@@ -1,13 +1,16 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/token"
 	"github.com/kvgtl/simplebank/utils"
-	"github.com/lib/pq"
 )
 
 type createUserRequest struct {
@@ -21,20 +24,33 @@ type createUserResponse struct {
 	Username          string    `json:"username"`
 	FullName          string    `json:"full_name"`
 	Email             string    `json:"email"`
+	Role              string    `json:"role"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 }
 
+// Returns the public fields of a user, without its hashed password.
+func newCreateUserResponse(user db.User) createUserResponse {
+	return createUserResponse{
+		Username:          user.Username,
+		FullName:          user.FullName,
+		Email:             user.Email,
+		Role:              user.Role,
+		PasswordChangedAt: user.PasswordChangedAt,
+		CreatedAt:         user.CreatedAt,
+	}
+}
+
 func (server *Server) createUser(ctx *gin.Context) {
 	var req createUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
 		return
 	}
 
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
 		return
 	}
 
@@ -45,26 +61,139 @@ func (server *Server) createUser(ctx *gin.Context) {
 		Email:          req.Email,
 	}
 
-	user, err := server.store.CreateUser(ctx, args)
+	user, err := server.store.CreateUserTx(ctx, args)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-			case "unique_violation":
-				ctx.JSON(http.StatusForbidden, errorResponse(err))
-				return
+		if errors.Is(err, db.ErrUniqueViolation) {
+			taken := fmt.Errorf("username %s is already taken", req.Username)
+			if db.ErrorConstraint(err) == "email_key" {
+				taken = fmt.Errorf("email address %s is already taken", req.Email)
 			}
+			ctx.JSON(http.StatusForbidden, errorResponse(ctx, codeAlreadyExists, taken))
+			return
 		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
 		return
 	}
 
-	response := createUserResponse{
-		Username:          user.Username,
-		FullName:          user.FullName,
-		Email:             user.Email,
-		PasswordChangedAt: user.PasswordChangedAt,
-		CreatedAt:         user.CreatedAt,
+	ctx.JSON(http.StatusOK, newCreateUserResponse(user))
+}
+
+type loginUserRequest struct {
+	Username string `json:"username" binding:"required,alphanum"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+type loginUserResponse struct {
+	SessionID             uuid.UUID          `json:"session_id"`
+	AccessToken           string             `json:"access_token"`
+	AccessTokenExpiresAt  time.Time          `json:"access_token_expires_at"`
+	RefreshToken          string             `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time          `json:"refresh_token_expires_at"`
+	User                  createUserResponse `json:"user"`
+}
+
+func (server *Server) loginUser(ctx *gin.Context) {
+	var req loginUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
+		return
 	}
 
+	user, err := server.store.GetUser(ctx, req.Username)
+	if err == nil && user.DeletedAt.Valid {
+		// deleted users can't log in anymore.
+		err = db.ErrRecordNotFound
+	}
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := fmt.Errorf("user %s not found", req.Username)
+			ctx.JSON(http.StatusNotFound, errorResponse(ctx, codeUserNotFound, err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+
+	err = utils.CheckPassword(req.Password, user.HashedPassword)
+	if err != nil {
+		err := errors.New("incorrect password")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(ctx, codeInvalidCredentials, err))
+		return
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.AccessTokenDuration, token.TokenTypeAccessToken)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.RefreshTokenDuration, token.TokenTypeRefreshToken)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+
+	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		UserAgent:    ctx.Request.UserAgent(),
+		ClientIp:     ctx.ClientIP(),
+		IsBlocked:    false,
+		ExpiresAt:    refreshPayload.ExpiredAt,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+
+	response := loginUserResponse{
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
+		User:                  newCreateUserResponse(user),
+	}
 	ctx.JSON(http.StatusOK, response)
 }
+
+type deleteUserRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// Deletes a user: its personal data is anonymized and its accounts, which
+// must be empty, are soft deleted. Users can delete themselves, bankers can
+// delete anyone.
+func (server *Server) deleteUser(ctx *gin.Context) {
+	var req deleteUserRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if req.Username != authPayload.Username && authPayload.Role != utils.BankerRole {
+		err := errors.New("only bankers can delete other users")
+		ctx.JSON(http.StatusForbidden, errorResponse(ctx, codeForbidden, err))
+		return
+	}
+
+	user, err := server.store.DeleteUserTx(ctx, req.Username)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrRecordNotFound):
+			err := fmt.Errorf("user %s not found", req.Username)
+			ctx.JSON(http.StatusNotFound, errorResponse(ctx, codeUserNotFound, err))
+			return
+		case errors.Is(err, db.ErrAccountNotEmpty):
+			err := fmt.Errorf("user %s still has money in an account", req.Username)
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(ctx, codeAccountNotEmpty, err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newCreateUserResponse(user))
+}
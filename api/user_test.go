@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -10,12 +11,14 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	memdb "github.com/kvgtl/simplebank/db/memory"
 	mockdb "github.com/kvgtl/simplebank/db/mock"
 	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/token"
 	"github.com/kvgtl/simplebank/utils"
-	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
@@ -75,7 +78,7 @@ func TestCreateUserAPI(t *testing.T) {
 				}
 
 				store.EXPECT().
-					CreateUser(gomock.Any(), eqCreateUserParams(args, password)).
+					CreateUserTx(gomock.Any(), eqCreateUserParams(args, password)).
 					Times(1).
 					Return(user, nil)
 			},
@@ -102,7 +105,7 @@ func TestCreateUserAPI(t *testing.T) {
 				// with db, and the "Return()" part is what that function
 				// returns, not the api response.
 				store.EXPECT().
-					CreateUser(gomock.Any(), eqCreateUserParams(args, password)).
+					CreateUserTx(gomock.Any(), eqCreateUserParams(args, password)).
 					Times(1).
 					Return(db.User{}, sql.ErrConnDone)
 			},
@@ -129,9 +132,9 @@ func TestCreateUserAPI(t *testing.T) {
 				// with db, and the "Return()" part is what that function
 				// returns, not the api response.
 				store.EXPECT().
-					CreateUser(gomock.Any(), eqCreateUserParams(args, password)).
+					CreateUserTx(gomock.Any(), eqCreateUserParams(args, password)).
 					Times(1).
-					Return(db.User{}, &pq.Error{Code: "23505"})
+					Return(db.User{}, db.ErrUniqueViolation)
 			},
 			// this is what checks the api endpoint response.
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -156,7 +159,7 @@ func TestCreateUserAPI(t *testing.T) {
 				// with db, and the "Return()" part is what that function
 				// returns, not the api response.
 				store.EXPECT().
-					CreateUser(gomock.Any(), eqCreateUserParams(args, password)).
+					CreateUserTx(gomock.Any(), eqCreateUserParams(args, password)).
 					Times(0)
 			},
 			// this is what checks the api endpoint response.
@@ -182,7 +185,7 @@ func TestCreateUserAPI(t *testing.T) {
 				// with db, and the "Return()" part is what that function
 				// returns, not the api response.
 				store.EXPECT().
-					CreateUser(gomock.Any(), eqCreateUserParams(args, password)).
+					CreateUserTx(gomock.Any(), eqCreateUserParams(args, password)).
 					Times(0)
 			},
 			// this is what checks the api endpoint response.
@@ -208,7 +211,7 @@ func TestCreateUserAPI(t *testing.T) {
 				// with db, and the "Return()" part is what that function
 				// returns, not the api response.
 				store.EXPECT().
-					CreateUser(gomock.Any(), eqCreateUserParams(args, password)).
+					CreateUserTx(gomock.Any(), eqCreateUserParams(args, password)).
 					Times(0)
 			},
 			// this is what checks the api endpoint response.
@@ -232,7 +235,7 @@ func TestCreateUserAPI(t *testing.T) {
 			testCase.buildStubs(store)
 
 			// start test server and send requests.
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			// marshall body data to json.
@@ -254,6 +257,318 @@ func TestCreateUserAPI(t *testing.T) {
 
 }
 
+func TestLoginUserAPI(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, args db.CreateSessionParams) (db.Session, error) {
+						require.Equal(t, user.Username, args.Username)
+						require.NotEmpty(t, args.RefreshToken)
+						require.False(t, args.IsBlocked)
+						return db.Session{ID: args.ID, Username: args.Username, RefreshToken: args.RefreshToken}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response loginUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+				require.NotEmpty(t, response.AccessToken)
+				require.NotEmpty(t, response.RefreshToken)
+				require.NotZero(t, response.SessionID)
+				require.True(t, response.RefreshTokenExpiresAt.After(response.AccessTokenExpiresAt))
+				require.Equal(t, user.Username, response.User.Username)
+				require.Equal(t, user.Role, response.User.Role)
+			},
+		},
+		{
+			name: "UserNotFound",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "DeletedUser",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				deletedUser := user
+				deletedUser.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(deletedUser, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "IncorrectPassword",
+			body: gin.H{
+				"username": user.Username,
+				"password": "incorrect",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidUsername",
+			body: gin.H{
+				"username": "invalid-user#1",
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			testCase.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(testCase.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestDeleteUserAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	deletedUser := user
+	deletedUser.FullName = ""
+	deletedUser.Email = "deleted-" + user.Username + "@invalid"
+	deletedUser.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			role:     utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteUserTx(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(deletedUser, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchUser(t, recorder.Body, deletedUser)
+			},
+		},
+		{
+			name:     "Banker",
+			username: utils.RandomOwner(),
+			role:     utils.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteUserTx(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(deletedUser, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "OtherUser",
+			username: utils.RandomOwner(),
+			role:     utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user.Username,
+			role:     utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteUserTx(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.User{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "AccountNotEmpty",
+			username: user.Username,
+			role:     utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteUserTx(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.User{}, db.ErrAccountNotEmpty)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				requireErrorCode(t, recorder, codeAccountNotEmpty)
+			},
+		},
+		{
+			name:     "InternalError",
+			username: user.Username,
+			role:     utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			testCase.buildStubs(store)
+			expectActiveUsers(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodDelete, "/users/"+user.Username, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, testCase.username, testCase.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestDeleteUserRevokesTokens(t *testing.T) {
+	store := memdb.NewStore(utils.Config{}, nil)
+	server := newTestServer(t, store)
+
+	user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       utils.RandomOwner(),
+		HashedPassword: utils.RandomString(32),
+		FullName:       utils.RandomOwner(),
+		Email:          utils.RandomEmailAddress(),
+	})
+	require.NoError(t, err)
+	accessToken, _, err := server.tokenMaker.CreateToken(user.Username, user.Role, time.Minute, token.TokenTypeAccessToken)
+	require.NoError(t, err)
+
+	send := func(method, url string) int {
+		request, err := http.NewRequest(method, url, nil)
+		require.NoError(t, err)
+		request.Header.Set(authorizationHeaderKey, authorizationTypeBearer+" "+accessToken)
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	require.Equal(t, http.StatusOK, send(http.MethodGet, "/webhooks"))
+	require.Equal(t, http.StatusOK, send(http.MethodDelete, "/users/"+user.Username))
+
+	// the token hasn't expired, but its user is gone.
+	require.Equal(t, http.StatusUnauthorized, send(http.MethodGet, "/webhooks"))
+}
+
 func randomUser(t *testing.T) (db.User, string) {
 	password := utils.RandomString(6)
 	hashedPassword, err := utils.HashPassword(password)
@@ -264,6 +579,7 @@ func randomUser(t *testing.T) (db.User, string) {
 		HashedPassword: hashedPassword,
 		FullName:       utils.RandomString(10),
 		Email:          utils.RandomEmailAddress(),
+		Role:           utils.DepositorRole,
 	}, password
 }
 
@@ -3,6 +3,7 @@ package api
 import (
 	"github.com/go-playground/validator/v10"
 	"github.com/kvgtl/simplebank/utils"
+	"github.com/kvgtl/simplebank/webhook"
 )
 
 var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
@@ -12,3 +13,17 @@ var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
 	}
 	return false
 }
+
+var validAccountType validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if accountType, ok := fieldLevel.Field().Interface().(string); ok {
+		return utils.IsSupportedAccountType(accountType)
+	}
+	return false
+}
+
+var validWebhookURL validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if url, ok := fieldLevel.Field().Interface().(string); ok {
+		return webhook.CheckEndpoint(url) == nil
+	}
+	return false
+}
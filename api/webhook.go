@@ -0,0 +1,185 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/token"
+)
+
+type createWebhookRequest struct {
+	Url        string   `json:"url" binding:"required,webhook_url,max=2048"`
+	EventTypes []string `json:"event_types" binding:"max=10,dive,oneof=TransferCompleted AccountCreated UserRegistered"`
+}
+
+type webhookResponse struct {
+	ID         int64     `json:"id"`
+	Owner      string    `json:"owner"`
+	Url        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// The secret is only returned when the webhook is created.
+type createWebhookResponse struct {
+	webhookResponse
+	Secret string `json:"secret"`
+}
+
+// Returns the public fields of a webhook, without its secret.
+func newWebhookResponse(webhook db.Webhook) webhookResponse {
+	return webhookResponse{
+		ID:         webhook.ID,
+		Owner:      webhook.Owner,
+		Url:        webhook.Url,
+		EventTypes: webhook.EventTypes,
+		CreatedAt:  webhook.CreatedAt,
+	}
+}
+
+// Returns a random secret to sign the deliveries of a webhook.
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func (server *Server) createWebhook(ctx *gin.Context) {
+	var req createWebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
+		return
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+
+	eventTypes := req.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	webhook, err := server.store.CreateWebhook(ctx, db.CreateWebhookParams{
+		Owner:      authPayload.Username,
+		Url:        req.Url,
+		Secret:     secret,
+		EventTypes: eventTypes,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, createWebhookResponse{
+		webhookResponse: newWebhookResponse(webhook),
+		Secret:          webhook.Secret,
+	})
+}
+
+func (server *Server) listWebhooks(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	webhooks, err := server.store.ListWebhooks(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+
+	response := make([]webhookResponse, len(webhooks))
+	for i, webhook := range webhooks {
+		response[i] = newWebhookResponse(webhook)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+type webhookRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// Returns the webhook of the request's uri if it belongs to the authenticated
+// user, otherwise it writes the error response and returns false.
+func (server *Server) getOwnWebhook(ctx *gin.Context) (db.Webhook, bool) {
+	var uri webhookRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
+		return db.Webhook{}, false
+	}
+
+	webhook, err := server.store.GetWebhook(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := fmt.Errorf("webhook [%d] not found", uri.ID)
+			ctx.JSON(http.StatusNotFound, errorResponse(ctx, codeWebhookNotFound, err))
+			return db.Webhook{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return db.Webhook{}, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if webhook.Owner != authPayload.Username {
+		err := errors.New("webhook doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusForbidden, errorResponse(ctx, codeForbidden, err))
+		return db.Webhook{}, false
+	}
+
+	return webhook, true
+}
+
+func (server *Server) deleteWebhook(ctx *gin.Context) {
+	webhook, ok := server.getOwnWebhook(ctx)
+	if !ok {
+		return
+	}
+
+	err := server.store.DeleteWebhook(ctx, webhook.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newWebhookResponse(webhook))
+}
+
+type listWebhookDeliveriesRequest struct {
+	Page     int32 `form:"page" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=1,max=50"`
+}
+
+// Lists the deliveries of a webhook, most recent first.
+func (server *Server) listWebhookDeliveries(ctx *gin.Context) {
+	var req listWebhookDeliveriesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(ctx, err))
+		return
+	}
+
+	webhook, ok := server.getOwnWebhook(ctx)
+	if !ok {
+		return
+	}
+
+	deliveries, err := server.store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{
+		WebhookID: webhook.ID,
+		Limit:     req.PageSize,
+		Offset:    (req.Page - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, internalErrorResponse(ctx, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, deliveries)
+}
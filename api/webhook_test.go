@@ -0,0 +1,336 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	mockdb "github.com/kvgtl/simplebank/db/mock"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/token"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func randomWebhook(owner string) db.Webhook {
+	return db.Webhook{
+		ID:         utils.RandomInt(1, 1000),
+		Owner:      owner,
+		Url:        "https://example.com/hooks/" + utils.RandomString(6),
+		Secret:     "whsec_" + utils.RandomString(32),
+		EventTypes: []string{db.EventTransferCompleted},
+	}
+}
+
+func TestCreateWebhookAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	webhook := randomWebhook(user.Username)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"url":         webhook.Url,
+				"event_types": webhook.EventTypes,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhook(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, args db.CreateWebhookParams) (db.Webhook, error) {
+						require.Equal(t, user.Username, args.Owner)
+						require.Equal(t, webhook.Url, args.Url)
+						require.Equal(t, webhook.EventTypes, args.EventTypes)
+						require.True(t, strings.HasPrefix(args.Secret, "whsec_"))
+						return webhook, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response createWebhookResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+				require.Equal(t, webhook.ID, response.ID)
+				require.Equal(t, webhook.Secret, response.Secret)
+			},
+		},
+		{
+			name: "AllEvents",
+			body: gin.H{
+				"url": webhook.Url,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhook(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, args db.CreateWebhookParams) (db.Webhook, error) {
+						require.NotNil(t, args.EventTypes)
+						require.Empty(t, args.EventTypes)
+						return webhook, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			body: gin.H{
+				"url": webhook.Url,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhook(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidURL",
+			body: gin.H{
+				"url": "ftp://example.com",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhook(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "PlainHTTPURL",
+			body: gin.H{
+				"url": "http://example.com/hooks",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhook(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalURL",
+			body: gin.H{
+				"url": "https://169.254.169.254/latest/meta-data",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhook(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "must be an https URL of a public host")
+			},
+		},
+		{
+			name: "InvalidEventType",
+			body: gin.H{
+				"url":         webhook.Url,
+				"event_types": []string{"MoneyPrinted"},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhook(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			testCase.buildStubs(store)
+			expectActiveUsers(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(testCase.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			testCase.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListWebhookDeliveriesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	webhook := randomWebhook(user.Username)
+
+	deliveries := []db.WebhookDelivery{
+		{
+			ID:        2,
+			WebhookID: webhook.ID,
+			EventID:   12,
+			EventType: db.EventTransferCompleted,
+			Payload:   json.RawMessage(`{"transfer_id":1}`),
+			Status:    db.WebhookDeliveryDead,
+			Attempts:  8,
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		webhookID     int64
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			webhookID: webhook.ID,
+			username:  user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhook(gomock.Any(), gomock.Eq(webhook.ID)).
+					Times(1).
+					Return(webhook, nil)
+				store.EXPECT().
+					ListWebhookDeliveries(gomock.Any(), gomock.Eq(db.ListWebhookDeliveriesParams{
+						WebhookID: webhook.ID,
+						Limit:     5,
+						Offset:    0,
+					})).
+					Times(1).
+					Return(deliveries, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []db.WebhookDelivery
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Len(t, got, 1)
+				require.Equal(t, db.WebhookDeliveryDead, got[0].Status)
+				require.Equal(t, int32(8), got[0].Attempts)
+			},
+		},
+		{
+			name:      "NotFound",
+			webhookID: webhook.ID,
+			username:  user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhook(gomock.Any(), gomock.Eq(webhook.ID)).
+					Times(1).
+					Return(db.Webhook{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					ListWebhookDeliveries(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "OtherUsersWebhook",
+			webhookID: webhook.ID,
+			username:  utils.RandomOwner(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhook(gomock.Any(), gomock.Eq(webhook.ID)).
+					Times(1).
+					Return(webhook, nil)
+				store.EXPECT().
+					ListWebhookDeliveries(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidID",
+			webhookID: 0,
+			username:  user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhook(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			testCase.buildStubs(store)
+			expectActiveUsers(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/webhooks/%d/deliveries?page=1&page_size=5", testCase.webhookID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, testCase.username, utils.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,302 @@
+// Package client is a typed Go client of the simplebank HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultRefreshMargin = 30 * time.Second
+
+// Client of the simplebank HTTP API. It sends the access token of its
+// session with every request, renewing it shortly before it expires.
+// A Client is safe for concurrent use.
+type Client struct {
+	baseURL       *url.URL
+	httpClient    *http.Client
+	refreshMargin time.Duration
+
+	mu      sync.Mutex
+	session *Session
+}
+
+// Configures a Client.
+type Option func(*Client)
+
+// Sends the requests with a specific http.Client instead of http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// Resumes a session saved from a previous client.
+func WithSession(session Session) Option {
+	return func(c *Client) {
+		c.session = &session
+	}
+}
+
+// Renews the access token when it expires within margin, 30 seconds by default.
+func WithRefreshMargin(margin time.Duration) Option {
+	return func(c *Client) {
+		c.refreshMargin = margin
+	}
+}
+
+// Creates a new client of the API served at baseURL.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base url %q: scheme must be http or https", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	c := &Client{
+		baseURL:       u,
+		httpClient:    http.DefaultClient,
+		refreshMargin: defaultRefreshMargin,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Returns the current session of the client, or false if it hasn't logged in.
+func (c *Client) Session() (Session, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session == nil {
+		return Session{}, false
+	}
+	return *c.session, true
+}
+
+func (c *Client) CreateUser(ctx context.Context, req CreateUserRequest) (User, error) {
+	var user User
+	err := c.do(ctx, http.MethodPost, "/users", nil, req, &user)
+	return user, err
+}
+
+// Logs the user in, the following requests are sent with its access token.
+func (c *Client) Login(ctx context.Context, username, password string) (LoginResponse, error) {
+	req := map[string]string{
+		"username": username,
+		"password": password,
+	}
+
+	var response LoginResponse
+	err := c.send(ctx, http.MethodPost, "/users/login", nil, req, &response, "")
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	c.mu.Lock()
+	session := response.Session
+	c.session = &session
+	c.mu.Unlock()
+
+	return response, nil
+}
+
+// Renews the access token of the session with its refresh token.
+func (c *Client) RefreshAccessToken(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.refreshLocked(ctx)
+}
+
+// Renews the access token, c.mu must be held.
+func (c *Client) refreshLocked(ctx context.Context) error {
+	if c.session == nil || !time.Now().Before(c.session.RefreshTokenExpiresAt) {
+		return ErrNoSession
+	}
+
+	req := map[string]string{"refresh_token": c.session.RefreshToken}
+
+	var response struct {
+		AccessToken          string    `json:"access_token"`
+		AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	}
+	err := c.send(ctx, http.MethodPost, "/tokens/renew_access", nil, req, &response, "")
+	if err != nil {
+		return err
+	}
+
+	c.session.AccessToken = response.AccessToken
+	c.session.AccessTokenExpiresAt = response.AccessTokenExpiresAt
+	return nil
+}
+
+// Returns the access token to send, renewing it first if it is about to
+// expire. It is empty when the client hasn't logged in.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session == nil {
+		return "", nil
+	}
+	if time.Until(c.session.AccessTokenExpiresAt) < c.refreshMargin {
+		if err := c.refreshLocked(ctx); err != nil {
+			return "", fmt.Errorf("cannot refresh access token: %w", err)
+		}
+	}
+	return c.session.AccessToken, nil
+}
+
+func (c *Client) CreateAccount(ctx context.Context, req CreateAccountRequest) (Account, error) {
+	var account Account
+	err := c.do(ctx, http.MethodPost, "/accounts", nil, req, &account)
+	return account, err
+}
+
+func (c *Client) GetAccount(ctx context.Context, id int64) (Account, error) {
+	var account Account
+	err := c.do(ctx, http.MethodGet, "/accounts/"+strconv.FormatInt(id, 10), nil, nil, &account)
+	return account, err
+}
+
+func (c *Client) ListAccounts(ctx context.Context, page, pageSize int32) ([]Account, error) {
+	var accounts []Account
+	err := c.do(ctx, http.MethodGet, "/accounts", pageQuery(page, pageSize), nil, &accounts)
+	return accounts, err
+}
+
+// Deposits a positive amount into the account or withdraws a negative one.
+func (c *Client) AddAccountBalance(ctx context.Context, id int64, amount int64) (Account, error) {
+	req := map[string]int64{"amount": amount}
+
+	var account Account
+	err := c.do(ctx, http.MethodPatch, "/accounts/"+strconv.FormatInt(id, 10), nil, req, &account)
+	return account, err
+}
+
+func (c *Client) DeleteAccount(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, "/accounts/"+strconv.FormatInt(id, 10), nil, nil, nil)
+}
+
+func (c *Client) ListAccountEntries(ctx context.Context, accountID int64, req ListEntriesRequest) ([]Entry, error) {
+	var entries []Entry
+	path := "/accounts/" + strconv.FormatInt(accountID, 10) + "/entries"
+	err := c.do(ctx, http.MethodGet, path, cursorQuery(req.PageSize, req.AfterCreatedAt, req.AfterID), nil, &entries)
+	return entries, err
+}
+
+// Transfers money between two accounts, it returns a *TransferLimitError
+// when the transfer exceeds one of the sender's limits.
+func (c *Client) CreateTransfer(ctx context.Context, req TransferRequest) (TransferResult, error) {
+	var result TransferResult
+	err := c.do(ctx, http.MethodPost, "/transfers", nil, req, &result)
+	return result, err
+}
+
+func (c *Client) ListTransfers(ctx context.Context, req ListTransfersRequest) ([]Transfer, error) {
+	query := cursorQuery(req.PageSize, req.AfterCreatedAt, req.AfterID)
+	if req.Reference != "" {
+		query.Set("reference", req.Reference)
+	}
+
+	var transfers []Transfer
+	err := c.do(ctx, http.MethodGet, "/transfers", query, nil, &transfers)
+	return transfers, err
+}
+
+func pageQuery(page, pageSize int32) url.Values {
+	return url.Values{
+		"page":      {strconv.FormatInt(int64(page), 10)},
+		"page_size": {strconv.FormatInt(int64(pageSize), 10)},
+	}
+}
+
+// The cursor is only sent after the first page.
+func cursorQuery(pageSize int32, afterCreatedAt time.Time, afterID int64) url.Values {
+	query := url.Values{
+		"page_size": {strconv.FormatInt(int64(pageSize), 10)},
+	}
+	if !afterCreatedAt.IsZero() || afterID != 0 {
+		query.Set("after_created_at", afterCreatedAt.Format(time.RFC3339Nano))
+		query.Set("after_id", strconv.FormatInt(afterID, 10))
+	}
+	return query
+}
+
+// Sends a request with the access token of the session, if any.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	accessToken, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	return c.send(ctx, method, path, query, body, out, accessToken)
+}
+
+// Sends a request with a json body and decodes the json response into out.
+// Error responses are returned as *Error or *TransferLimitError.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body, out any, accessToken string) error {
+	u := *c.baseURL
+	u.Path += path
+	u.RawQuery = query.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("cannot encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if accessToken != "" {
+		request.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("cannot read response: %w", err)
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		var errBody errorBody
+		if json.Unmarshal(data, &errBody) != nil || errBody.Error.Message == "" {
+			errBody.Error.Message = strings.TrimSpace(string(data))
+		}
+		return newError(response.StatusCode, errBody)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("cannot decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,390 @@
+package client
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kvgtl/simplebank/api"
+	mockdb "github.com/kvgtl/simplebank/db/mock"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/notify"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// Runs the API with a mock store and records the authorization header of
+// the last request.
+type testServer struct {
+	*httptest.Server
+	store *mockdb.MockStore
+
+	mu            sync.Mutex
+	authorization string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	config := utils.Config{
+		TokenSymmetricKey:    utils.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+	}
+	server, err := api.NewServer(config, store, notify.NewBroker())
+	require.NoError(t, err)
+
+	ts := &testServer{store: store}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ts.mu.Lock()
+		ts.authorization = r.Header.Get("Authorization")
+		ts.mu.Unlock()
+		server.Handler().ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func (ts *testServer) lastAuthorization() string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.authorization
+}
+
+func randomUser(t *testing.T) (db.User, string) {
+	password := utils.RandomString(6)
+	hashedPassword, err := utils.HashPassword(password)
+	require.NoError(t, err)
+
+	return db.User{
+		Username:       utils.RandomOwner(),
+		HashedPassword: hashedPassword,
+		FullName:       utils.RandomString(10),
+		Email:          utils.RandomEmailAddress(),
+		Role:           utils.DepositorRole,
+	}, password
+}
+
+func randomAccount(owner string) db.Account {
+	return db.Account{
+		ID:          utils.RandomInt(1, 1000),
+		Owner:       &owner,
+		Balance:     utils.RandomMoneyAmount(),
+		Currency:    utils.USD,
+		AccountType: utils.Checking,
+	}
+}
+
+// Expects a login of the user and records its session.
+func expectLogin(ts *testServer, user db.User) *db.Session {
+	session := &db.Session{}
+	ts.store.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(user, nil)
+	ts.store.EXPECT().
+		CreateSession(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, args db.CreateSessionParams) (db.Session, error) {
+			*session = db.Session{
+				ID:           args.ID,
+				Username:     args.Username,
+				RefreshToken: args.RefreshToken,
+				ExpiresAt:    args.ExpiresAt,
+			}
+			return *session, nil
+		})
+	return session
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("localhost:8080")
+	require.Error(t, err)
+
+	c, err := New("http://localhost:8080/")
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:8080", c.baseURL.String())
+
+	_, ok := c.Session()
+	require.False(t, ok)
+}
+
+func TestLogin(t *testing.T) {
+	ts := newTestServer(t)
+	user, password := randomUser(t)
+	account := randomAccount(user.Username)
+
+	expectLogin(ts, user)
+	ts.store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+		Times(1).
+		Return(account, nil)
+
+	c, err := New(ts.URL)
+	require.NoError(t, err)
+
+	response, err := c.Login(context.Background(), user.Username, password)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, response.User.Username)
+	require.NotEmpty(t, response.AccessToken)
+	require.NotEmpty(t, response.RefreshToken)
+
+	session, ok := c.Session()
+	require.True(t, ok)
+	require.Equal(t, response.Session, session)
+
+	got, err := c.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.ID, got.ID)
+	require.Equal(t, user.Username, *got.Owner)
+	require.Equal(t, "Bearer "+session.AccessToken, ts.lastAuthorization())
+}
+
+func TestLoginIncorrectPassword(t *testing.T) {
+	ts := newTestServer(t)
+	user, _ := randomUser(t)
+
+	ts.store.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(user, nil)
+
+	c, err := New(ts.URL)
+	require.NoError(t, err)
+
+	_, err = c.Login(context.Background(), user.Username, "incorrect")
+	require.ErrorIs(t, err, ErrUnauthorized)
+
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	require.Equal(t, CodeInvalidCredentials, apiErr.Code)
+	require.NotEmpty(t, apiErr.Message)
+	require.NotEmpty(t, apiErr.RequestID)
+
+	_, ok := c.Session()
+	require.False(t, ok)
+}
+
+func TestRefreshAccessToken(t *testing.T) {
+	ts := newTestServer(t)
+	user, password := randomUser(t)
+	account := randomAccount(user.Username)
+
+	// the access tokens of the server last a minute, so the client renews
+	// them before every request.
+	c, err := New(ts.URL, WithRefreshMargin(2*time.Minute))
+	require.NoError(t, err)
+
+	session := expectLogin(ts, user)
+	_, err = c.Login(context.Background(), user.Username, password)
+	require.NoError(t, err)
+
+	loginSession, _ := c.Session()
+
+	ts.store.EXPECT().
+		GetSession(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, id uuid.UUID) (db.Session, error) {
+			return *session, nil
+		})
+	ts.store.EXPECT().
+		GetUserRole(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(db.GetUserRoleRow{Role: user.Role}, nil)
+	ts.store.EXPECT().
+		ListAccounts(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.Account{account}, nil)
+
+	accounts, err := c.ListAccounts(context.Background(), 1, 5)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+
+	refreshed, _ := c.Session()
+	require.NotEqual(t, loginSession.AccessToken, refreshed.AccessToken)
+	require.Equal(t, loginSession.RefreshToken, refreshed.RefreshToken)
+	require.Equal(t, "Bearer "+refreshed.AccessToken, ts.lastAuthorization())
+}
+
+func TestRefreshAccessTokenWithoutSession(t *testing.T) {
+	c, err := New("http://localhost:8080")
+	require.NoError(t, err)
+
+	err = c.RefreshAccessToken(context.Background())
+	require.ErrorIs(t, err, ErrNoSession)
+
+	c, err = New("http://localhost:8080", WithSession(Session{
+		AccessToken:           "access",
+		AccessTokenExpiresAt:  time.Now().Add(-time.Hour),
+		RefreshToken:          "refresh",
+		RefreshTokenExpiresAt: time.Now().Add(-time.Minute),
+	}))
+	require.NoError(t, err)
+
+	_, err = c.GetAccount(context.Background(), 1)
+	require.ErrorIs(t, err, ErrNoSession)
+}
+
+func TestAccounts(t *testing.T) {
+	ts := newTestServer(t)
+	user, password := randomUser(t)
+	account := randomAccount(user.Username)
+
+	c, err := New(ts.URL)
+	require.NoError(t, err)
+
+	ts.store.EXPECT().
+		CreateAccountTx(gomock.Any(), gomock.Eq(db.CreateAccountParams{
+			Owner:       &user.Username,
+			Currency:    account.Currency,
+			AccountType: account.AccountType,
+			Balance:     0,
+		})).
+		Times(1).
+		Return(account, nil)
+
+	created, err := c.CreateAccount(context.Background(), CreateAccountRequest{
+		Owner:    user.Username,
+		Currency: account.Currency,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.ID, created.ID)
+	require.Empty(t, ts.lastAuthorization())
+
+	ts.store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+		Times(1).
+		Return(db.Account{}, db.ErrRecordNotFound)
+
+	_, err = c.GetAccount(context.Background(), account.ID)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = c.ListAccounts(context.Background(), 0, 5)
+	require.ErrorIs(t, err, ErrBadRequest)
+
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, CodeValidationFailed, apiErr.Code)
+	require.Equal(t, []FieldError{{Field: "page", Rule: "required", Message: "is required"}}, apiErr.Details)
+
+	// the entries are only listed to the owner of the account.
+	expectLogin(ts, user)
+	_, err = c.Login(context.Background(), user.Username, password)
+	require.NoError(t, err)
+
+	ts.store.EXPECT().
+		GetUserDeletedAt(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(sql.NullTime{}, nil)
+	ts.store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+		Times(1).
+		Return(account, nil)
+
+	entries := []db.Entry{
+		{ID: 1, AccountID: account.ID, Amount: 10, JournalID: sql.NullInt64{Int64: 4, Valid: true}},
+	}
+	after := time.Date(2024, 8, 1, 10, 30, 0, 123456000, time.UTC)
+	ts.store.EXPECT().
+		ListAccountEntries(gomock.Any(), gomock.Eq(db.ListAccountEntriesParams{
+			AccountID:      account.ID,
+			AfterCreatedAt: after,
+			AfterID:        9,
+			LimitCount:     5,
+		})).
+		Times(1).
+		Return(entries, nil)
+
+	gotEntries, err := c.ListAccountEntries(context.Background(), account.ID, ListEntriesRequest{
+		PageSize:       5,
+		AfterCreatedAt: after,
+		AfterID:        9,
+	})
+	require.NoError(t, err)
+	require.Len(t, gotEntries, 1)
+	require.Equal(t, NullInt64{Int64: 4, Valid: true}, gotEntries[0].JournalID)
+}
+
+func TestCreateTransfer(t *testing.T) {
+	ts := newTestServer(t)
+	user, password := randomUser(t)
+	account1 := randomAccount(user.Username)
+	account2 := randomAccount(utils.RandomOwner())
+	account2.ID = account1.ID + 1
+
+	// transfers require the owner of the from account to be logged in.
+	expectLogin(ts, user)
+	ts.store.EXPECT().
+		GetUserDeletedAt(gomock.Any(), gomock.Eq(user.Username)).
+		AnyTimes().
+		Return(sql.NullTime{}, nil)
+
+	c, err := New(ts.URL)
+	require.NoError(t, err)
+
+	_, err = c.Login(context.Background(), user.Username, password)
+	require.NoError(t, err)
+
+	req := TransferRequest{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Currency:      utils.USD,
+		Reference:     "invoice-1",
+	}
+
+	ts.store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(2).Return(account1, nil)
+	ts.store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(2).Return(account2, nil)
+	gomock.InOrder(
+		ts.store.EXPECT().
+			TransferTx(gomock.Any(), gomock.Any()).
+			Return(db.TransferTxResult{
+				Transfer: db.Transfer{ID: 7, FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10, Reference: "invoice-1"},
+			}, nil),
+		ts.store.EXPECT().
+			TransferTx(gomock.Any(), gomock.Any()).
+			Return(db.TransferTxResult{}, &db.TransferLimitError{Period: db.LimitDaily, Limit: 100, Remaining: 5}),
+	)
+
+	result, err := c.CreateTransfer(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), result.Transfer.ID)
+	require.Equal(t, "invoice-1", result.Transfer.Reference)
+
+	_, err = c.CreateTransfer(context.Background(), req)
+	var limitErr *TransferLimitError
+	require.True(t, errors.As(err, &limitErr))
+	require.Equal(t, db.LimitDaily, limitErr.Period)
+	require.Equal(t, int64(100), limitErr.Limit)
+	require.Equal(t, int64(5), limitErr.Remaining)
+
+	ts.store.EXPECT().
+		ListOwnerTransfersByReference(gomock.Any(), gomock.Eq(db.ListOwnerTransfersByReferenceParams{
+			Reference:  "invoice-1",
+			Owner:      &user.Username,
+			LimitCount: 5,
+		})).
+		Times(1).
+		Return([]db.Transfer{{ID: 7, Reference: "invoice-1"}}, nil)
+
+	transfers, err := c.ListTransfers(context.Background(), ListTransfersRequest{
+		Reference: "invoice-1",
+		PageSize:  5,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	require.Equal(t, int64(7), transfers[0].ID)
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Errors matched with errors.Is by the responses of the matching status.
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrInternal     = errors.New("internal server error")
+)
+
+// ErrNoSession is returned when a token refresh is needed but the client
+// hasn't logged in, or its session expired.
+var ErrNoSession = errors.New("no active session")
+
+// Codes of the API errors, stable unlike their messages.
+const (
+	CodeValidationFailed      = "VALIDATION_FAILED"
+	CodeUnauthenticated       = "UNAUTHENTICATED"
+	CodeInvalidCredentials    = "INVALID_CREDENTIALS"
+	CodeForbidden             = "FORBIDDEN"
+	CodeAccountNotFound       = "ACCOUNT_NOT_FOUND"
+	CodeUserNotFound          = "USER_NOT_FOUND"
+	CodeSessionNotFound       = "SESSION_NOT_FOUND"
+	CodeWebhookNotFound       = "WEBHOOK_NOT_FOUND"
+	CodeAlreadyExists         = "ALREADY_EXISTS"
+	CodeSystemAccount         = "SYSTEM_ACCOUNT"
+	CodeAccountFrozen         = "ACCOUNT_FROZEN"
+	CodeAccountDeleted        = "ACCOUNT_DELETED"
+	CodeAccountNotEmpty       = "ACCOUNT_NOT_EMPTY"
+	CodePreconditionFailed    = "PRECONDITION_FAILED"
+	CodeCurrencyMismatch      = "CURRENCY_MISMATCH"
+	CodeInsufficientFunds     = "INSUFFICIENT_FUNDS"
+	CodeTransferLimitExceeded = "TRANSFER_LIMIT_EXCEEDED"
+	CodeInternal              = "INTERNAL"
+)
+
+// Error response of the API.
+type Error struct {
+	StatusCode int
+	// One of the Code constants.
+	Code    string
+	Message string
+	// Why each invalid field of the request was rejected.
+	Details   []FieldError
+	RequestID string
+}
+
+// Invalid field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("simplebank: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+	}
+	return fmt.Sprintf("simplebank: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusBadRequest:
+		return ErrBadRequest
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusInternalServerError:
+		return ErrInternal
+	}
+	return nil
+}
+
+// Returned when a transfer would exceed one of the sender's limits.
+type TransferLimitError struct {
+	Message string
+	// Period of the exceeded limit: per_transaction, daily or monthly.
+	Period string
+	// Amount of the exceeded limit.
+	Limit int64
+	// How much the sender can still move in the period.
+	Remaining int64
+	RequestID string
+}
+
+func (e *TransferLimitError) Error() string {
+	return "simplebank: " + e.Message
+}
+
+// Body of the error responses.
+type errorBody struct {
+	Error struct {
+		Code      string       `json:"code"`
+		Message   string       `json:"message"`
+		Details   []FieldError `json:"details"`
+		Period    string       `json:"period"`
+		Limit     int64        `json:"limit"`
+		Remaining int64        `json:"remaining"`
+		RequestID string       `json:"request_id"`
+	} `json:"error"`
+}
+
+func newError(statusCode int, body errorBody) error {
+	if body.Error.Code == CodeTransferLimitExceeded {
+		return &TransferLimitError{
+			Message:   body.Error.Message,
+			Period:    body.Error.Period,
+			Limit:     body.Error.Limit,
+			Remaining: body.Error.Remaining,
+			RequestID: body.Error.RequestID,
+		}
+	}
+	return &Error{
+		StatusCode: statusCode,
+		Code:       body.Error.Code,
+		Message:    body.Error.Message,
+		Details:    body.Error.Details,
+		RequestID:  body.Error.RequestID,
+	}
+}
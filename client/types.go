@@ -0,0 +1,134 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type CreateUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+}
+
+type User struct {
+	Username          string    `json:"username"`
+	FullName          string    `json:"full_name"`
+	Email             string    `json:"email"`
+	Role              string    `json:"role"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// Tokens of a login session, the client renews the access token with the
+// refresh token until the session expires.
+type Session struct {
+	ID                    string    `json:"session_id"`
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+type LoginResponse struct {
+	Session
+	User User `json:"user"`
+}
+
+type CreateAccountRequest struct {
+	Owner       string `json:"owner"`
+	Currency    string `json:"currency"`
+	AccountType string `json:"account_type,omitempty"`
+}
+
+type Account struct {
+	ID int64 `json:"id"`
+	// Owner is nil for system accounts.
+	Owner       *string   `json:"owner"`
+	Balance     int64     `json:"balance"`
+	Currency    string    `json:"currency"`
+	CreatedAt   time.Time `json:"created_at"`
+	AccountType string    `json:"account_type"`
+	SystemKind  *string   `json:"system_kind"`
+	// FrozenAt is nil unless the account is frozen.
+	FrozenAt     *time.Time `json:"frozen_at"`
+	FrozenReason string     `json:"frozen_reason"`
+	// Version is incremented on every change of the account.
+	Version int64 `json:"version"`
+}
+
+// A nullable integer, null when Valid is false.
+type NullInt64 struct {
+	Int64 int64 `json:"Int64"`
+	Valid bool  `json:"Valid"`
+}
+
+type Entry struct {
+	ID          int64           `json:"id"`
+	AccountID   int64           `json:"account_id"`
+	Amount      int64           `json:"amount"`
+	CreatedAt   time.Time       `json:"created_at"`
+	Description string          `json:"description"`
+	Reference   string          `json:"reference"`
+	Metadata    json.RawMessage `json:"metadata"`
+	JournalID   NullInt64       `json:"journal_id"`
+}
+
+type TransferRequest struct {
+	FromAccountID int64             `json:"from_account_id"`
+	ToAccountID   int64             `json:"to_account_id"`
+	Amount        int64             `json:"amount"`
+	Currency      string            `json:"currency"`
+	Description   string            `json:"description,omitempty"`
+	Reference     string            `json:"reference,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+}
+
+type Transfer struct {
+	ID            int64           `json:"id"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        int64           `json:"amount"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Description   string          `json:"description"`
+	Reference     string          `json:"reference"`
+	Metadata      json.RawMessage `json:"metadata"`
+}
+
+type JournalTransaction struct {
+	ID          int64     `json:"id"`
+	Kind        string    `json:"kind"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// FeeEntry is empty when the transfer has no fee.
+type TransferResult struct {
+	Transfer    Transfer           `json:"transfer"`
+	Journal     JournalTransaction `json:"journal"`
+	FromAccount Account            `json:"from_account"`
+	ToAccount   Account            `json:"to_account"`
+	FromEntry   Entry              `json:"from_entry"`
+	ToEntry     Entry              `json:"to_entry"`
+	Fee         int64              `json:"fee"`
+	FeeEntry    Entry              `json:"fee_entry"`
+}
+
+// The next page follows the CreatedAt and ID of the last entry of the
+// previous one, the zero values give the first page.
+type ListEntriesRequest struct {
+	PageSize       int32
+	AfterCreatedAt time.Time
+	AfterID        int64
+}
+
+// The next page follows the CreatedAt and ID of the last transfer of the
+// previous one, the zero values give the first page.
+type ListTransfersRequest struct {
+	// Only lists the transfers with this reference when set.
+	Reference      string
+	PageSize       int32
+	AfterCreatedAt time.Time
+	AfterID        int64
+}
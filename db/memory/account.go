@@ -0,0 +1,263 @@
+package memdb
+
+import (
+	"context"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/utils"
+)
+
+func accountsByID(a, b db.Account) bool {
+	return a.ID < b.ID
+}
+
+// Reports whether owner owns the account, a nil owner owns nothing.
+func isOwnedBy(account db.Account, owner *string) bool {
+	return account.Owner != nil && owner != nil && *account.Owner == *owner
+}
+
+// Checks the constraints of an account before it is written.
+func (t *tables) checkAccount(account db.Account) error {
+	if account.AccountType != utils.Checking && account.AccountType != utils.Savings {
+		return checkViolation("accounts", "account_type_check")
+	}
+	if (account.Owner == nil) != (account.SystemKind != nil) {
+		return checkViolation("accounts", "owner_or_system_check")
+	}
+	if account.Owner == nil {
+		return nil
+	}
+
+	if _, ok := t.users[*account.Owner]; !ok {
+		return foreignKeyViolation("accounts", "accounts_owner_fkey")
+	}
+	for _, other := range t.accounts {
+		if other.ID != account.ID && other.Owner != nil && *other.Owner == *account.Owner && other.Currency == account.Currency &&
+			other.DeletedAt == nil && account.DeletedAt == nil {
+			return uniqueViolation("owner_currency_key")
+		}
+	}
+	return nil
+}
+
+func (q *queries) CreateAccount(ctx context.Context, arg db.CreateAccountParams) (db.Account, error) {
+	t, release := q.begin()
+	defer release()
+
+	account := db.Account{
+		Owner:       arg.Owner,
+		Balance:     arg.Balance,
+		Currency:    arg.Currency,
+		CreatedAt:   now(),
+		AccountType: arg.AccountType,
+		Version:     1,
+	}
+	if err := t.checkAccount(account); err != nil {
+		return db.Account{}, err
+	}
+
+	account.ID = t.nextID("accounts")
+	setRow(t, t.accounts, account.ID, account)
+	return account, nil
+}
+
+func (q *queries) GetAccount(ctx context.Context, id int64) (db.Account, error) {
+	t, release := q.begin()
+	defer release()
+
+	account, ok := t.accounts[id]
+	if !ok {
+		return db.Account{}, db.ErrRecordNotFound
+	}
+	return account, nil
+}
+
+// Transactions hold the whole database, the account is locked with it.
+func (q *queries) GetAccountForUpdate(ctx context.Context, id int64) (db.Account, error) {
+	return q.GetAccount(ctx, id)
+}
+
+func (q *queries) GetSystemAccount(ctx context.Context, arg db.GetSystemAccountParams) (db.Account, error) {
+	t, release := q.begin()
+	defer release()
+
+	for _, account := range t.accounts {
+		if account.SystemKind != nil && *account.SystemKind == arg.SystemKind && account.Currency == arg.Currency {
+			return account, nil
+		}
+	}
+	return db.Account{}, db.ErrRecordNotFound
+}
+
+func (q *queries) ListAccounts(ctx context.Context, arg db.ListAccountsParams) ([]db.Account, error) {
+	t, release := q.begin()
+	defer release()
+
+	accounts := selectRows(t.accounts, func(account db.Account) bool {
+		return account.SystemKind == nil && account.DeletedAt == nil
+	}, accountsByID)
+	return page(accounts, arg.Limit, arg.Offset), nil
+}
+
+func (q *queries) ListAccountsByOwner(ctx context.Context, arg db.ListAccountsByOwnerParams) ([]db.Account, error) {
+	t, release := q.begin()
+	defer release()
+
+	accounts := selectRows(t.accounts, func(account db.Account) bool {
+		return isOwnedBy(account, arg.Owner) && account.DeletedAt == nil
+	}, accountsByID)
+	return page(accounts, arg.Limit, arg.Offset), nil
+}
+
+func (q *queries) ListOpenAccountIDsByOwner(ctx context.Context, owner *string) ([]int64, error) {
+	t, release := q.begin()
+	defer release()
+
+	accounts := selectRows(t.accounts, func(account db.Account) bool {
+		return isOwnedBy(account, owner) && account.DeletedAt == nil
+	}, accountsByID)
+
+	accountIDs := []int64{}
+	for _, account := range accounts {
+		accountIDs = append(accountIDs, account.ID)
+	}
+	return accountIDs, nil
+}
+
+func (q *queries) ListAccountsByType(ctx context.Context, arg db.ListAccountsByTypeParams) ([]db.Account, error) {
+	t, release := q.begin()
+	defer release()
+
+	accounts := selectRows(t.accounts, func(account db.Account) bool {
+		return account.AccountType == arg.AccountType && account.SystemKind == nil && account.DeletedAt == nil && account.ID > arg.AfterID
+	}, accountsByID)
+	return page(accounts, arg.LimitCount, 0), nil
+}
+
+// Replaces an account with the result of update, if it exists, and
+// increments its version.
+func (q *queries) updateAccount(id int64, update func(account *db.Account) bool) (db.Account, error) {
+	t, release := q.begin()
+	defer release()
+
+	account, ok := t.accounts[id]
+	if !ok || !update(&account) {
+		return db.Account{}, db.ErrRecordNotFound
+	}
+	account.Version++
+	setRow(t, t.accounts, id, account)
+	return account, nil
+}
+
+func (q *queries) UpdateAccount(ctx context.Context, arg db.UpdateAccountParams) (db.Account, error) {
+	return q.updateAccount(arg.ID, func(account *db.Account) bool {
+		account.Balance = arg.Balance
+		return true
+	})
+}
+
+func (q *queries) AddAccountBalance(ctx context.Context, arg db.AddAccountBalanceParams) (db.Account, error) {
+	return q.updateAccount(arg.ID, func(account *db.Account) bool {
+		account.Balance += arg.Amount
+		return true
+	})
+}
+
+func (q *queries) SoftDeleteAccount(ctx context.Context, id int64) (db.Account, error) {
+	return q.updateAccount(id, func(account *db.Account) bool {
+		if account.SystemKind != nil || account.DeletedAt != nil {
+			return false
+		}
+		deletedAt := now()
+		account.DeletedAt = &deletedAt
+		return true
+	})
+}
+
+func (q *queries) FreezeAccount(ctx context.Context, arg db.FreezeAccountParams) (db.Account, error) {
+	return q.updateAccount(arg.ID, func(account *db.Account) bool {
+		if account.SystemKind != nil {
+			return false
+		}
+		frozenAt := now()
+		account.FrozenAt = &frozenAt
+		account.FrozenReason = arg.Reason
+		return true
+	})
+}
+
+func (q *queries) UnfreezeAccount(ctx context.Context, id int64) (db.Account, error) {
+	return q.updateAccount(id, func(account *db.Account) bool {
+		account.FrozenAt = nil
+		account.FrozenReason = ""
+		return true
+	})
+}
+
+// Deletes an account and its limits. It fails if the account has entries,
+// transfers or interest accruals.
+func (q *queries) DeleteAccount(ctx context.Context, id int64) error {
+	t, release := q.begin()
+	defer release()
+
+	for _, entry := range t.entries {
+		if entry.AccountID == id {
+			return referencedRowViolation("accounts", "entries_account_id_fkey", "entries")
+		}
+	}
+	for _, transfer := range t.transfers {
+		if transfer.FromAccountID == id {
+			return referencedRowViolation("accounts", "transfers_from_account_id_fkey", "transfers")
+		}
+		if transfer.ToAccountID == id {
+			return referencedRowViolation("accounts", "transfers_to_account_id_fkey", "transfers")
+		}
+	}
+	for _, accrual := range t.interestAccruals {
+		if accrual.AccountID == id {
+			return referencedRowViolation("accounts", "interest_accruals_account_id_fkey", "interest_accruals")
+		}
+	}
+
+	deleteRow(t, t.accounts, id)
+	deleteRow(t, t.accountLimits, id)
+	return nil
+}
+
+func (q *queries) GetAccountLimit(ctx context.Context, accountID int64) (db.AccountLimit, error) {
+	t, release := q.begin()
+	defer release()
+
+	limit, ok := t.accountLimits[accountID]
+	if !ok {
+		return db.AccountLimit{}, db.ErrRecordNotFound
+	}
+	return limit, nil
+}
+
+func (q *queries) UpsertAccountLimit(ctx context.Context, arg db.UpsertAccountLimitParams) (db.AccountLimit, error) {
+	t, release := q.begin()
+	defer release()
+
+	if _, ok := t.accounts[arg.AccountID]; !ok {
+		return db.AccountLimit{}, foreignKeyViolation("account_limits", "account_limits_account_id_fkey")
+	}
+
+	limit := db.AccountLimit{
+		AccountID:      arg.AccountID,
+		PerTransaction: arg.PerTransaction,
+		Daily:          arg.Daily,
+		Monthly:        arg.Monthly,
+		UpdatedAt:      now(),
+	}
+	setRow(t, t.accountLimits, arg.AccountID, limit)
+	return limit, nil
+}
+
+func (q *queries) DeleteAccountLimit(ctx context.Context, accountID int64) error {
+	t, release := q.begin()
+	defer release()
+
+	deleteRow(t, t.accountLimits, accountID)
+	return nil
+}
@@ -0,0 +1,324 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+)
+
+// Returned for metadata that isn't valid JSON, like Postgres rejects it.
+var errInvalidJSON = errors.New("invalid input syntax for type json")
+
+func entriesByID(a, b db.Entry) bool {
+	return a.ID < b.ID
+}
+
+func entriesByCreation(a, b db.Entry) bool {
+	return byCreation(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+}
+
+func transfersByCreation(a, b db.Transfer) bool {
+	return byCreation(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+}
+
+// Checks a jsonb column before it is written.
+func checkJSON(table string, column string, value json.RawMessage) error {
+	if value == nil {
+		return notNullViolation(table, column)
+	}
+	if !json.Valid(value) {
+		return errInvalidJSON
+	}
+	return nil
+}
+
+func (q *queries) CreateEntry(ctx context.Context, arg db.CreateEntryParams) (db.Entry, error) {
+	t, release := q.begin()
+	defer release()
+
+	if err := checkJSON("entries", "metadata", arg.Metadata); err != nil {
+		return db.Entry{}, err
+	}
+	if _, ok := t.accounts[arg.AccountID]; !ok {
+		return db.Entry{}, foreignKeyViolation("entries", "entries_account_id_fkey")
+	}
+	if _, ok := t.journals[arg.JournalID.Int64]; arg.JournalID.Valid && !ok {
+		return db.Entry{}, foreignKeyViolation("entries", "entries_journal_id_fkey")
+	}
+
+	entry := db.Entry{
+		ID:          t.nextID("entries"),
+		AccountID:   arg.AccountID,
+		Amount:      arg.Amount,
+		CreatedAt:   now(),
+		Description: arg.Description,
+		Reference:   arg.Reference,
+		Metadata:    append(json.RawMessage{}, arg.Metadata...),
+		JournalID:   arg.JournalID,
+	}
+	setRow(t, t.entries, entry.ID, entry)
+	return entry, nil
+}
+
+func (q *queries) GetEntry(ctx context.Context, id int64) (db.Entry, error) {
+	t, release := q.begin()
+	defer release()
+
+	entry, ok := t.entries[id]
+	if !ok {
+		return db.Entry{}, db.ErrRecordNotFound
+	}
+	return entry, nil
+}
+
+func (q *queries) ListEntries(ctx context.Context, arg db.ListEntriesParams) ([]db.Entry, error) {
+	t, release := q.begin()
+	defer release()
+
+	entries := selectRows(t.entries, func(entry db.Entry) bool {
+		return afterCursor(entry.CreatedAt, entry.ID, arg.AfterCreatedAt, arg.AfterID)
+	}, entriesByCreation)
+	return page(entries, arg.LimitCount, 0), nil
+}
+
+func (q *queries) ListAccountEntries(ctx context.Context, arg db.ListAccountEntriesParams) ([]db.Entry, error) {
+	t, release := q.begin()
+	defer release()
+
+	entries := selectRows(t.entries, func(entry db.Entry) bool {
+		return entry.AccountID == arg.AccountID &&
+			afterCursor(entry.CreatedAt, entry.ID, arg.AfterCreatedAt, arg.AfterID)
+	}, entriesByCreation)
+	return page(entries, arg.LimitCount, 0), nil
+}
+
+func (q *queries) ListAccountEntriesAfter(ctx context.Context, arg db.ListAccountEntriesAfterParams) ([]db.Entry, error) {
+	t, release := q.begin()
+	defer release()
+
+	entries := selectRows(t.entries, func(entry db.Entry) bool {
+		return entry.AccountID == arg.AccountID && entry.ID > arg.AfterID
+	}, entriesByID)
+	return page(entries, arg.LimitCount, 0), nil
+}
+
+func (q *queries) ListAccountEntriesBetween(ctx context.Context, arg db.ListAccountEntriesBetweenParams) ([]db.Entry, error) {
+	t, release := q.begin()
+	defer release()
+
+	return selectRows(t.entries, func(entry db.Entry) bool {
+		return entry.AccountID == arg.AccountID && !entry.CreatedAt.Before(arg.FromTime) && entry.CreatedAt.Before(arg.ToTime)
+	}, entriesByID), nil
+}
+
+func (q *queries) ListJournalEntries(ctx context.Context, journalID sql.NullInt64) ([]db.Entry, error) {
+	t, release := q.begin()
+	defer release()
+
+	return selectRows(t.entries, func(entry db.Entry) bool {
+		return journalID.Valid && entry.JournalID == journalID
+	}, entriesByID), nil
+}
+
+func (q *queries) SumAccountEntriesSince(ctx context.Context, arg db.SumAccountEntriesSinceParams) (int64, error) {
+	t, release := q.begin()
+	defer release()
+
+	var total int64
+	for _, entry := range t.entries {
+		if entry.AccountID == arg.AccountID && !entry.CreatedAt.Before(arg.Since) {
+			total += entry.Amount
+		}
+	}
+	return total, nil
+}
+
+func (q *queries) UpdateEntry(ctx context.Context, arg db.UpdateEntryParams) (db.Entry, error) {
+	t, release := q.begin()
+	defer release()
+
+	entry, ok := t.entries[arg.ID]
+	if !ok {
+		return db.Entry{}, db.ErrRecordNotFound
+	}
+	entry.Amount = arg.Amount
+	setRow(t, t.entries, arg.ID, entry)
+	return entry, nil
+}
+
+func (q *queries) DeleteEntry(ctx context.Context, id int64) error {
+	t, release := q.begin()
+	defer release()
+
+	deleteRow(t, t.entries, id)
+	return nil
+}
+
+func (q *queries) CreateJournalTransaction(ctx context.Context, arg db.CreateJournalTransactionParams) (db.JournalTransaction, error) {
+	t, release := q.begin()
+	defer release()
+
+	journal := db.JournalTransaction{
+		ID:          t.nextID("journal_transactions"),
+		Kind:        arg.Kind,
+		Description: arg.Description,
+		CreatedAt:   now(),
+	}
+	setRow(t, t.journals, journal.ID, journal)
+	return journal, nil
+}
+
+func (q *queries) GetJournalTransaction(ctx context.Context, id int64) (db.JournalTransaction, error) {
+	t, release := q.begin()
+	defer release()
+
+	journal, ok := t.journals[id]
+	if !ok {
+		return db.JournalTransaction{}, db.ErrRecordNotFound
+	}
+	return journal, nil
+}
+
+// Checks the accounts of a transfer before it is written.
+func (t *tables) checkTransfer(transfer db.Transfer) error {
+	if _, ok := t.accounts[transfer.FromAccountID]; !ok {
+		return foreignKeyViolation("transfers", "transfers_from_account_id_fkey")
+	}
+	if _, ok := t.accounts[transfer.ToAccountID]; !ok {
+		return foreignKeyViolation("transfers", "transfers_to_account_id_fkey")
+	}
+	return nil
+}
+
+func (q *queries) CreateTransfer(ctx context.Context, arg db.CreateTransferParams) (db.Transfer, error) {
+	t, release := q.begin()
+	defer release()
+
+	if err := checkJSON("transfers", "metadata", arg.Metadata); err != nil {
+		return db.Transfer{}, err
+	}
+
+	transfer := db.Transfer{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		CreatedAt:     now(),
+		Description:   arg.Description,
+		Reference:     arg.Reference,
+		Metadata:      append(json.RawMessage{}, arg.Metadata...),
+	}
+	if err := t.checkTransfer(transfer); err != nil {
+		return db.Transfer{}, err
+	}
+
+	transfer.ID = t.nextID("transfers")
+	setRow(t, t.transfers, transfer.ID, transfer)
+	return transfer, nil
+}
+
+func (q *queries) GetTransfer(ctx context.Context, id int64) (db.Transfer, error) {
+	t, release := q.begin()
+	defer release()
+
+	transfer, ok := t.transfers[id]
+	if !ok {
+		return db.Transfer{}, db.ErrRecordNotFound
+	}
+	return transfer, nil
+}
+
+func (q *queries) ListTransfers(ctx context.Context, arg db.ListTransfersParams) ([]db.Transfer, error) {
+	t, release := q.begin()
+	defer release()
+
+	transfers := selectRows(t.transfers, func(transfer db.Transfer) bool {
+		return afterCursor(transfer.CreatedAt, transfer.ID, arg.AfterCreatedAt, arg.AfterID)
+	}, transfersByCreation)
+	return page(transfers, arg.LimitCount, 0), nil
+}
+
+func (q *queries) ListTransfersByReference(ctx context.Context, arg db.ListTransfersByReferenceParams) ([]db.Transfer, error) {
+	t, release := q.begin()
+	defer release()
+
+	transfers := selectRows(t.transfers, func(transfer db.Transfer) bool {
+		return transfer.Reference == arg.Reference &&
+			afterCursor(transfer.CreatedAt, transfer.ID, arg.AfterCreatedAt, arg.AfterID)
+	}, transfersByCreation)
+	return page(transfers, arg.LimitCount, 0), nil
+}
+
+// Reports whether the transfer is from or to an account of the owner.
+func (t *tables) ownerTransfer(transfer db.Transfer, owner *string) bool {
+	for _, id := range []int64{transfer.FromAccountID, transfer.ToAccountID} {
+		account := t.accounts[id]
+		if owner != nil && account.Owner != nil && *account.Owner == *owner {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *queries) ListOwnerTransfers(ctx context.Context, arg db.ListOwnerTransfersParams) ([]db.Transfer, error) {
+	t, release := q.begin()
+	defer release()
+
+	transfers := selectRows(t.transfers, func(transfer db.Transfer) bool {
+		return t.ownerTransfer(transfer, arg.Owner) &&
+			afterCursor(transfer.CreatedAt, transfer.ID, arg.AfterCreatedAt, arg.AfterID)
+	}, transfersByCreation)
+	return page(transfers, arg.LimitCount, 0), nil
+}
+
+func (q *queries) ListOwnerTransfersByReference(ctx context.Context, arg db.ListOwnerTransfersByReferenceParams) ([]db.Transfer, error) {
+	t, release := q.begin()
+	defer release()
+
+	transfers := selectRows(t.transfers, func(transfer db.Transfer) bool {
+		return transfer.Reference == arg.Reference && t.ownerTransfer(transfer, arg.Owner) &&
+			afterCursor(transfer.CreatedAt, transfer.ID, arg.AfterCreatedAt, arg.AfterID)
+	}, transfersByCreation)
+	return page(transfers, arg.LimitCount, 0), nil
+}
+
+func (q *queries) GetOutgoingTransfersTotal(ctx context.Context, arg db.GetOutgoingTransfersTotalParams) (int64, error) {
+	t, release := q.begin()
+	defer release()
+
+	var total int64
+	for _, transfer := range t.transfers {
+		if transfer.FromAccountID == arg.AccountID && !transfer.CreatedAt.Before(arg.Since) {
+			total += transfer.Amount
+		}
+	}
+	return total, nil
+}
+
+func (q *queries) UpdateTransfer(ctx context.Context, arg db.UpdateTransferParams) (db.Transfer, error) {
+	t, release := q.begin()
+	defer release()
+
+	transfer, ok := t.transfers[arg.ID]
+	if !ok {
+		return db.Transfer{}, db.ErrRecordNotFound
+	}
+	transfer.ToAccountID = arg.ToAccountID
+	transfer.Amount = arg.Amount
+	if err := t.checkTransfer(transfer); err != nil {
+		return db.Transfer{}, err
+	}
+
+	setRow(t, t.transfers, arg.ID, transfer)
+	return transfer, nil
+}
+
+func (q *queries) DeleteTransfer(ctx context.Context, id int64) error {
+	t, release := q.begin()
+	defer release()
+
+	deleteRow(t, t.transfers, id)
+	return nil
+}
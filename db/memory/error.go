@@ -0,0 +1,36 @@
+package memdb
+
+import (
+	"fmt"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+)
+
+// Errors of the constraints of the schema, with the messages of Postgres.
+
+func uniqueViolation(constraint string) error {
+	return db.NewConstraintError(db.UniqueViolation, constraint,
+		fmt.Sprintf("duplicate key value violates unique constraint %q", constraint))
+}
+
+// Returned when a row refers to a missing row of another table.
+func foreignKeyViolation(table string, constraint string) error {
+	return db.NewConstraintError(db.ForeignKeyViolation, constraint,
+		fmt.Sprintf("insert or update on table %q violates foreign key constraint %q", table, constraint))
+}
+
+// Returned when a deleted row is still referred to by a row of another table.
+func referencedRowViolation(table string, constraint string, referencingTable string) error {
+	return db.NewConstraintError(db.ForeignKeyViolation, constraint,
+		fmt.Sprintf("update or delete on table %q violates foreign key constraint %q on table %q", table, constraint, referencingTable))
+}
+
+func checkViolation(table string, constraint string) error {
+	return db.NewConstraintError(db.CheckViolation, constraint,
+		fmt.Sprintf("new row for relation %q violates check constraint %q", table, constraint))
+}
+
+func notNullViolation(table string, column string) error {
+	return db.NewConstraintError(db.NotNullViolation, "",
+		fmt.Sprintf("null value in column %q of relation %q violates not-null constraint", column, table))
+}
@@ -0,0 +1,344 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"time"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+)
+
+// Statuses of the webhook deliveries allowed by the schema.
+var webhookDeliveryStatuses = []string{"pending", "delivered", "dead"}
+
+func outboxEventsByID(a, b db.OutboxEvent) bool {
+	return a.ID < b.ID
+}
+
+func webhooksByID(a, b db.Webhook) bool {
+	return a.ID < b.ID
+}
+
+func (q *queries) CreateOutboxEvent(ctx context.Context, arg db.CreateOutboxEventParams) (db.OutboxEvent, error) {
+	t, release := q.begin()
+	defer release()
+
+	if err := checkJSON("outbox_events", "payload", arg.Payload); err != nil {
+		return db.OutboxEvent{}, err
+	}
+
+	event := db.OutboxEvent{
+		ID:            t.nextID("outbox_events"),
+		AggregateType: arg.AggregateType,
+		AggregateID:   arg.AggregateID,
+		EventType:     arg.EventType,
+		Payload:       append(json.RawMessage{}, arg.Payload...),
+		CreatedAt:     now(),
+		NextAttemptAt: now(),
+	}
+	setRow(t, t.outboxEvents, event.ID, event)
+	return event, nil
+}
+
+func (q *queries) ListUnpublishedOutboxEvents(ctx context.Context, limit int32) ([]db.OutboxEvent, error) {
+	t, release := q.begin()
+	defer release()
+
+	events := selectRows(t.outboxEvents, func(event db.OutboxEvent) bool {
+		return !event.PublishedAt.Valid && !event.DeadAt.Valid && !event.NextAttemptAt.After(now())
+	}, outboxEventsByID)
+	return page(events, limit, 0), nil
+}
+
+func (q *queries) ListOutboxEventsByAggregate(ctx context.Context, arg db.ListOutboxEventsByAggregateParams) ([]db.OutboxEvent, error) {
+	t, release := q.begin()
+	defer release()
+
+	return selectRows(t.outboxEvents, func(event db.OutboxEvent) bool {
+		return event.AggregateType == arg.AggregateType && event.AggregateID == arg.AggregateID
+	}, outboxEventsByID), nil
+}
+
+// Replaces an outbox event with the result of update, if it exists.
+func (q *queries) updateOutboxEvent(id int64, update func(event *db.OutboxEvent)) {
+	t, release := q.begin()
+	defer release()
+
+	event, ok := t.outboxEvents[id]
+	if ok {
+		update(&event)
+		setRow(t, t.outboxEvents, id, event)
+	}
+}
+
+func (q *queries) MarkOutboxEventPublished(ctx context.Context, id int64) error {
+	q.updateOutboxEvent(id, func(event *db.OutboxEvent) {
+		event.PublishedAt = sql.NullTime{Time: now(), Valid: true}
+		event.Attempts++
+		event.LastError = ""
+	})
+	return nil
+}
+
+func (q *queries) RecordOutboxEventFailure(ctx context.Context, arg db.RecordOutboxEventFailureParams) error {
+	q.updateOutboxEvent(arg.ID, func(event *db.OutboxEvent) {
+		event.Attempts++
+		event.LastError = arg.LastError
+		event.NextAttemptAt = arg.NextAttemptAt
+		event.DeadAt = sql.NullTime{Time: now(), Valid: arg.Dead}
+	})
+	return nil
+}
+
+// Removes the personal fields of the user from the payloads of its events.
+func (q *queries) RedactUserOutboxEvents(ctx context.Context, aggregateID string) error {
+	t, release := q.begin()
+	defer release()
+
+	for id, event := range t.outboxEvents {
+		if event.AggregateType != db.AggregateUser || event.AggregateID != aggregateID {
+			continue
+		}
+
+		var payload map[string]json.RawMessage
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			// like jsonb, only the fields of objects are removed.
+			continue
+		}
+		delete(payload, "full_name")
+		delete(payload, "email")
+
+		redacted, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		event.Payload = redacted
+		setRow(t, t.outboxEvents, id, event)
+	}
+	return nil
+}
+
+func (q *queries) CreateWebhook(ctx context.Context, arg db.CreateWebhookParams) (db.Webhook, error) {
+	t, release := q.begin()
+	defer release()
+
+	if arg.EventTypes == nil {
+		return db.Webhook{}, notNullViolation("webhooks", "event_types")
+	}
+	if _, ok := t.users[arg.Owner]; !ok {
+		return db.Webhook{}, foreignKeyViolation("webhooks", "webhooks_owner_fkey")
+	}
+
+	webhook := db.Webhook{
+		ID:         t.nextID("webhooks"),
+		Owner:      arg.Owner,
+		Url:        arg.Url,
+		Secret:     arg.Secret,
+		EventTypes: slices.Clone(arg.EventTypes),
+		CreatedAt:  now(),
+	}
+	setRow(t, t.webhooks, webhook.ID, webhook)
+	return webhook, nil
+}
+
+func (q *queries) GetWebhook(ctx context.Context, id int64) (db.Webhook, error) {
+	t, release := q.begin()
+	defer release()
+
+	webhook, ok := t.webhooks[id]
+	if !ok {
+		return db.Webhook{}, db.ErrRecordNotFound
+	}
+	return webhook, nil
+}
+
+func (q *queries) ListWebhooks(ctx context.Context, owner string) ([]db.Webhook, error) {
+	t, release := q.begin()
+	defer release()
+
+	return selectRows(t.webhooks, func(webhook db.Webhook) bool {
+		return webhook.Owner == owner
+	}, webhooksByID), nil
+}
+
+func (q *queries) ListWebhooksForEvent(ctx context.Context, arg db.ListWebhooksForEventParams) ([]db.Webhook, error) {
+	t, release := q.begin()
+	defer release()
+
+	return selectRows(t.webhooks, func(webhook db.Webhook) bool {
+		return slices.Contains(arg.Owners, webhook.Owner) &&
+			(len(webhook.EventTypes) == 0 || slices.Contains(webhook.EventTypes, arg.EventType))
+	}, webhooksByID), nil
+}
+
+// Deletes a webhook with its deliveries.
+func (q *queries) DeleteWebhook(ctx context.Context, id int64) error {
+	t, release := q.begin()
+	defer release()
+
+	t.deleteWebhook(id)
+	return nil
+}
+
+// Deletes the webhooks of a user with their deliveries.
+func (q *queries) DeleteUserWebhooks(ctx context.Context, owner string) error {
+	t, release := q.begin()
+	defer release()
+
+	for id, webhook := range t.webhooks {
+		if webhook.Owner == owner {
+			t.deleteWebhook(id)
+		}
+	}
+	return nil
+}
+
+func (t *tables) deleteWebhook(id int64) {
+	deleteRow(t, t.webhooks, id)
+	for deliveryID, delivery := range t.webhookDeliveries {
+		if delivery.WebhookID == id {
+			deleteRow(t, t.webhookDeliveries, deliveryID)
+		}
+	}
+}
+
+func (q *queries) CreateWebhookDelivery(ctx context.Context, arg db.CreateWebhookDeliveryParams) (int64, error) {
+	t, release := q.begin()
+	defer release()
+
+	if err := checkJSON("webhook_deliveries", "payload", arg.Payload); err != nil {
+		return 0, err
+	}
+	if _, ok := t.webhooks[arg.WebhookID]; !ok {
+		return 0, foreignKeyViolation("webhook_deliveries", "webhook_deliveries_webhook_id_fkey")
+	}
+	if _, ok := t.outboxEvents[arg.EventID]; !ok {
+		return 0, foreignKeyViolation("webhook_deliveries", "webhook_deliveries_event_id_fkey")
+	}
+	for _, delivery := range t.webhookDeliveries {
+		if delivery.WebhookID == arg.WebhookID && delivery.EventID == arg.EventID {
+			return 0, nil
+		}
+	}
+
+	createdAt := now()
+	delivery := db.WebhookDelivery{
+		ID:            t.nextID("webhook_deliveries"),
+		WebhookID:     arg.WebhookID,
+		EventID:       arg.EventID,
+		EventType:     arg.EventType,
+		Payload:       append(json.RawMessage{}, arg.Payload...),
+		Status:        "pending",
+		NextAttemptAt: createdAt,
+		CreatedAt:     createdAt,
+	}
+	setRow(t, t.webhookDeliveries, delivery.ID, delivery)
+	return 1, nil
+}
+
+// Transactions hold the whole database, no other worker claims the
+// deliveries meanwhile.
+func (q *queries) ClaimDueWebhookDeliveries(ctx context.Context, arg db.ClaimDueWebhookDeliveriesParams) ([]db.ClaimDueWebhookDeliveriesRow, error) {
+	t, release := q.begin()
+	defer release()
+
+	due := now()
+	deliveries := selectRows(t.webhookDeliveries, func(delivery db.WebhookDelivery) bool {
+		return delivery.Status == "pending" && !delivery.NextAttemptAt.After(due)
+	}, func(a, b db.WebhookDelivery) bool {
+		if !a.NextAttemptAt.Equal(b.NextAttemptAt) {
+			return a.NextAttemptAt.Before(b.NextAttemptAt)
+		}
+		return a.ID < b.ID
+	})
+
+	rows := []db.ClaimDueWebhookDeliveriesRow{}
+	for _, delivery := range page(deliveries, arg.LimitCount, 0) {
+		delivery.NextAttemptAt = arg.LeasedUntil
+		setRow(t, t.webhookDeliveries, delivery.ID, delivery)
+
+		webhook := t.webhooks[delivery.WebhookID]
+		rows = append(rows, db.ClaimDueWebhookDeliveriesRow{
+			ID:             delivery.ID,
+			WebhookID:      delivery.WebhookID,
+			EventID:        delivery.EventID,
+			EventType:      delivery.EventType,
+			Payload:        delivery.Payload,
+			Status:         delivery.Status,
+			Attempts:       delivery.Attempts,
+			NextAttemptAt:  delivery.NextAttemptAt,
+			LastError:      delivery.LastError,
+			ResponseStatus: delivery.ResponseStatus,
+			DeliveredAt:    delivery.DeliveredAt,
+			CreatedAt:      delivery.CreatedAt,
+			Url:            webhook.Url,
+			Secret:         webhook.Secret,
+		})
+	}
+	return rows, nil
+}
+
+// Replaces a webhook delivery with the result of update, if it exists.
+func (q *queries) updateWebhookDelivery(id int64, update func(delivery *db.WebhookDelivery)) error {
+	t, release := q.begin()
+	defer release()
+
+	delivery, ok := t.webhookDeliveries[id]
+	if !ok {
+		return nil
+	}
+	update(&delivery)
+	if !slices.Contains(webhookDeliveryStatuses, delivery.Status) {
+		return checkViolation("webhook_deliveries", "webhook_delivery_status_check")
+	}
+	setRow(t, t.webhookDeliveries, id, delivery)
+	return nil
+}
+
+func (q *queries) MarkWebhookDeliveryDelivered(ctx context.Context, arg db.MarkWebhookDeliveryDeliveredParams) error {
+	return q.updateWebhookDelivery(arg.ID, func(delivery *db.WebhookDelivery) {
+		delivery.Status = "delivered"
+		delivery.Attempts++
+		delivery.ResponseStatus = arg.ResponseStatus
+		delivery.LastError = ""
+		delivery.DeliveredAt = sql.NullTime{Time: now(), Valid: true}
+	})
+}
+
+func (q *queries) RecordWebhookDeliveryFailure(ctx context.Context, arg db.RecordWebhookDeliveryFailureParams) error {
+	return q.updateWebhookDelivery(arg.ID, func(delivery *db.WebhookDelivery) {
+		delivery.Status = arg.Status
+		delivery.Attempts++
+		delivery.ResponseStatus = arg.ResponseStatus
+		delivery.LastError = arg.LastError
+		delivery.NextAttemptAt = arg.NextAttemptAt
+	})
+}
+
+func (q *queries) ListWebhookDeliveries(ctx context.Context, arg db.ListWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	t, release := q.begin()
+	defer release()
+
+	deliveries := selectRows(t.webhookDeliveries, func(delivery db.WebhookDelivery) bool {
+		return delivery.WebhookID == arg.WebhookID
+	}, func(a, b db.WebhookDelivery) bool {
+		return a.ID > b.ID
+	})
+	return page(deliveries, arg.Limit, arg.Offset), nil
+}
+
+func (q *queries) DeleteFinishedWebhookDeliveries(ctx context.Context, createdBefore time.Time) (int64, error) {
+	t, release := q.begin()
+	defer release()
+
+	var deleted int64
+	for id, delivery := range t.webhookDeliveries {
+		if delivery.Status != "pending" && delivery.CreatedAt.Before(createdBefore) {
+			deleteRow(t, t.webhookDeliveries, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
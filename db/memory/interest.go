@@ -0,0 +1,135 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+)
+
+func (q *queries) CreateInterestAccrual(ctx context.Context, arg db.CreateInterestAccrualParams) (int64, error) {
+	t, release := q.begin()
+	defer release()
+
+	if _, ok := t.accounts[arg.AccountID]; !ok {
+		return 0, foreignKeyViolation("interest_accruals", "interest_accruals_account_id_fkey")
+	}
+
+	accrualDate := date(arg.AccrualDate)
+	for _, accrual := range t.interestAccruals {
+		if accrual.AccountID == arg.AccountID && accrual.AccrualDate.Equal(accrualDate) {
+			return 0, nil
+		}
+	}
+
+	accrual := db.InterestAccrual{
+		ID:              t.nextID("interest_accruals"),
+		AccountID:       arg.AccountID,
+		AccrualDate:     accrualDate,
+		Balance:         arg.Balance,
+		RateBasisPoints: arg.RateBasisPoints,
+		AmountMicros:    arg.AmountMicros,
+		CreatedAt:       now(),
+	}
+	setRow(t, t.interestAccruals, accrual.ID, accrual)
+	return 1, nil
+}
+
+func (q *queries) GetInterestAccrual(ctx context.Context, arg db.GetInterestAccrualParams) (db.InterestAccrual, error) {
+	t, release := q.begin()
+	defer release()
+
+	accrualDate := date(arg.AccrualDate)
+	for _, accrual := range t.interestAccruals {
+		if accrual.AccountID == arg.AccountID && accrual.AccrualDate.Equal(accrualDate) {
+			return accrual, nil
+		}
+	}
+	return db.InterestAccrual{}, db.ErrRecordNotFound
+}
+
+func (q *queries) GetLastInterestAccrualDate(ctx context.Context) (time.Time, error) {
+	t, release := q.begin()
+	defer release()
+
+	var last time.Time
+	for _, accrual := range t.interestAccruals {
+		if accrual.AccrualDate.After(last) {
+			last = accrual.AccrualDate
+		}
+	}
+	if last.IsZero() {
+		return time.Time{}, db.ErrRecordNotFound
+	}
+	return last, nil
+}
+
+// Reports whether an accrual of the account is waiting to be posted.
+func isUnposted(accrual db.InterestAccrual, before time.Time) bool {
+	return !accrual.PostedAt.Valid && accrual.AccrualDate.Before(date(before))
+}
+
+func (q *queries) ListAccountsWithUnpostedInterest(ctx context.Context, before time.Time) ([]int64, error) {
+	t, release := q.begin()
+	defer release()
+
+	accruals := selectRows(t.interestAccruals, func(accrual db.InterestAccrual) bool {
+		return isUnposted(accrual, before)
+	}, func(a, b db.InterestAccrual) bool {
+		return a.AccountID < b.AccountID
+	})
+
+	accountIDs := []int64{}
+	for _, accrual := range accruals {
+		if len(accountIDs) == 0 || accountIDs[len(accountIDs)-1] != accrual.AccountID {
+			accountIDs = append(accountIDs, accrual.AccountID)
+		}
+	}
+	return accountIDs, nil
+}
+
+func (q *queries) ListUnpostedInterestAccrualsForUpdate(ctx context.Context, arg db.ListUnpostedInterestAccrualsForUpdateParams) ([]db.InterestAccrual, error) {
+	t, release := q.begin()
+	defer release()
+
+	return selectRows(t.interestAccruals, func(accrual db.InterestAccrual) bool {
+		return accrual.AccountID == arg.AccountID && isUnposted(accrual, arg.Before)
+	}, func(a, b db.InterestAccrual) bool {
+		return a.AccrualDate.Before(b.AccrualDate)
+	}), nil
+}
+
+func (q *queries) MarkInterestAccrualsPosted(ctx context.Context, arg db.MarkInterestAccrualsPostedParams) (int64, error) {
+	t, release := q.begin()
+	defer release()
+
+	var posted int64
+	postedAt := sql.NullTime{Time: now(), Valid: true}
+	for id, accrual := range t.interestAccruals {
+		if accrual.AccountID == arg.AccountID && isUnposted(accrual, arg.Before) {
+			accrual.PostedAt = postedAt
+			accrual.EntryID = arg.EntryID
+			setRow(t, t.interestAccruals, id, accrual)
+			posted++
+		}
+	}
+	return posted, nil
+}
+
+func (q *queries) VoidInterestAccruals(ctx context.Context, accountID int64) (int64, error) {
+	t, release := q.begin()
+	defer release()
+
+	var voided int64
+	postedAt := sql.NullTime{Time: now(), Valid: true}
+	for id, accrual := range t.interestAccruals {
+		if accrual.AccountID == accountID && !accrual.PostedAt.Valid {
+			accrual.PostedAt = postedAt
+			accrual.EntryID = sql.NullInt64{}
+			setRow(t, t.interestAccruals, id, accrual)
+			voided++
+		}
+	}
+	return voided, nil
+}
@@ -0,0 +1,42 @@
+package memdb
+
+import (
+	"context"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+)
+
+func (q *queries) CreateArchivedPartition(ctx context.Context, arg db.CreateArchivedPartitionParams) (db.ArchivedPartition, error) {
+	t, release := q.begin()
+	defer release()
+
+	if _, ok := t.archivedPartitions[arg.Name]; ok {
+		return db.ArchivedPartition{}, uniqueViolation("archived_partitions_pkey")
+	}
+
+	archive := db.ArchivedPartition{
+		Name:        arg.Name,
+		ParentTable: arg.ParentTable,
+		Month:       arg.Month,
+		File:        arg.File,
+		Rows:        arg.Rows,
+		ArchivedAt:  now(),
+	}
+	setRow(t, t.archivedPartitions, archive.Name, archive)
+	return archive, nil
+}
+
+func (q *queries) ListArchivedPartitions(ctx context.Context) ([]db.ArchivedPartition, error) {
+	t, release := q.begin()
+	defer release()
+
+	return selectRows(t.archivedPartitions,
+		func(archive db.ArchivedPartition) bool { return true },
+		func(a, b db.ArchivedPartition) bool {
+			if a.ParentTable != b.ParentTable {
+				return a.ParentTable < b.ParentTable
+			}
+			return a.Month.Before(b.Month)
+		},
+	), nil
+}
@@ -0,0 +1,176 @@
+package memdb
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+)
+
+func (q *queries) GetTrialBalance(ctx context.Context) ([]db.GetTrialBalanceRow, error) {
+	t, release := q.begin()
+	defer release()
+
+	balances := map[string]*db.GetTrialBalanceRow{}
+	for _, account := range t.accounts {
+		row, ok := balances[account.Currency]
+		if !ok {
+			row = &db.GetTrialBalanceRow{Currency: account.Currency}
+			balances[account.Currency] = row
+		}
+		if account.SystemKind == nil {
+			row.CustomerAccounts++
+			row.CustomerBalance += account.Balance
+		} else {
+			row.SystemBalance += account.Balance
+		}
+		row.TotalBalance += account.Balance
+	}
+
+	rows := []db.GetTrialBalanceRow{}
+	for _, row := range balances {
+		rows = append(rows, *row)
+	}
+	slices.SortFunc(rows, func(a, b db.GetTrialBalanceRow) int {
+		return cmp.Compare(a.Currency, b.Currency)
+	})
+	return rows, nil
+}
+
+// Key of the rows of the daily transfer volume.
+type dayCurrency struct {
+	day      time.Time
+	currency string
+}
+
+func (q *queries) GetDailyTransferVolume(ctx context.Context, arg db.GetDailyTransferVolumeParams) ([]db.GetDailyTransferVolumeRow, error) {
+	t, release := q.begin()
+	defer release()
+
+	volumes := map[dayCurrency]*db.GetDailyTransferVolumeRow{}
+	for _, transfer := range t.transfers {
+		if transfer.CreatedAt.Before(arg.FromTime) || !transfer.CreatedAt.Before(arg.ToTime) {
+			continue
+		}
+		key := dayCurrency{
+			day:      utcDate(transfer.CreatedAt),
+			currency: t.accounts[transfer.FromAccountID].Currency,
+		}
+		row, ok := volumes[key]
+		if !ok {
+			row = &db.GetDailyTransferVolumeRow{Day: key.day, Currency: key.currency}
+			volumes[key] = row
+		}
+		row.Transfers++
+		row.Volume += transfer.Amount
+	}
+
+	rows := []db.GetDailyTransferVolumeRow{}
+	for _, row := range volumes {
+		rows = append(rows, *row)
+	}
+	slices.SortFunc(rows, func(a, b db.GetDailyTransferVolumeRow) int {
+		return cmp.Or(a.Day.Compare(b.Day), cmp.Compare(a.Currency, b.Currency))
+	})
+	return rows, nil
+}
+
+func (q *queries) ListTopAccountsByBalance(ctx context.Context, arg db.ListTopAccountsByBalanceParams) ([]db.Account, error) {
+	t, release := q.begin()
+	defer release()
+
+	accounts := selectRows(t.accounts, func(account db.Account) bool {
+		return account.SystemKind == nil && account.Currency == arg.Currency
+	}, func(a, b db.Account) bool {
+		if a.Balance != b.Balance {
+			return a.Balance > b.Balance
+		}
+		return a.ID < b.ID
+	})
+	return page(accounts, arg.Limit, 0), nil
+}
+
+func (q *queries) GetDailyNewUsers(ctx context.Context, arg db.GetDailyNewUsersParams) ([]db.GetDailyNewUsersRow, error) {
+	t, release := q.begin()
+	defer release()
+
+	counts := map[time.Time]int64{}
+	for _, user := range t.users {
+		if !user.CreatedAt.Before(arg.FromTime) && user.CreatedAt.Before(arg.ToTime) {
+			counts[utcDate(user.CreatedAt)]++
+		}
+	}
+
+	rows := []db.GetDailyNewUsersRow{}
+	for day, users := range counts {
+		rows = append(rows, db.GetDailyNewUsersRow{Day: day, Users: users})
+	}
+	slices.SortFunc(rows, func(a, b db.GetDailyNewUsersRow) int {
+		return a.Day.Compare(b.Day)
+	})
+	return rows, nil
+}
+
+func (q *queries) ListAccountBalanceMismatches(ctx context.Context) ([]db.ListAccountBalanceMismatchesRow, error) {
+	t, release := q.begin()
+	defer release()
+
+	totals := map[int64]int64{}
+	for _, entry := range t.entries {
+		totals[entry.AccountID] += entry.Amount
+	}
+
+	accounts := selectRows(t.accounts, func(account db.Account) bool {
+		return account.Balance != totals[account.ID]
+	}, accountsByID)
+
+	rows := []db.ListAccountBalanceMismatchesRow{}
+	for _, account := range accounts {
+		rows = append(rows, db.ListAccountBalanceMismatchesRow{
+			AccountID:    account.ID,
+			Currency:     account.Currency,
+			Balance:      account.Balance,
+			EntriesTotal: totals[account.ID],
+		})
+	}
+	return rows, nil
+}
+
+// Key of the totals of the journals.
+type journalCurrency struct {
+	journalID int64
+	currency  string
+}
+
+func (q *queries) ListUnbalancedJournals(ctx context.Context) ([]db.ListUnbalancedJournalsRow, error) {
+	t, release := q.begin()
+	defer release()
+
+	totals := map[journalCurrency]int64{}
+	for _, entry := range t.entries {
+		if entry.JournalID.Valid {
+			key := journalCurrency{
+				journalID: entry.JournalID.Int64,
+				currency:  t.accounts[entry.AccountID].Currency,
+			}
+			totals[key] += entry.Amount
+		}
+	}
+
+	rows := []db.ListUnbalancedJournalsRow{}
+	for key, total := range totals {
+		if total != 0 {
+			rows = append(rows, db.ListUnbalancedJournalsRow{
+				JournalID: key.journalID,
+				Currency:  key.currency,
+				Total:     total,
+			})
+		}
+	}
+	slices.SortFunc(rows, func(a, b db.ListUnbalancedJournalsRow) int {
+		return cmp.Or(cmp.Compare(a.JournalID, b.JournalID), cmp.Compare(a.Currency, b.Currency))
+	})
+	return rows, nil
+}
@@ -0,0 +1,292 @@
+// Package memdb implements db.Store in memory, for tests, demos and local
+// development without Postgres. It enforces the constraints of the schema
+// with the same errors as db.SQLStore, and shares its transactions.
+package memdb
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/utils"
+)
+
+// Currencies of the system accounts seeded by the migrations.
+var systemCurrencies = []string{utils.USD, utils.EUR, utils.CAD, utils.AUD}
+
+// Rows of every table, by primary key.
+type tables struct {
+	accounts          map[int64]db.Account
+	accountLimits     map[int64]db.AccountLimit
+	entries           map[int64]db.Entry
+	transfers         map[int64]db.Transfer
+	users             map[string]db.User
+	sessions          map[uuid.UUID]db.Session
+	journals          map[int64]db.JournalTransaction
+	interestAccruals  map[int64]db.InterestAccrual
+	outboxEvents      map[int64]db.OutboxEvent
+	webhooks          map[int64]db.Webhook
+	webhookDeliveries map[int64]db.WebhookDelivery
+	// The store has no partitions, the rows only come from
+	// CreateArchivedPartition.
+	archivedPartitions map[string]db.ArchivedPartition
+	// Last id given by the sequence of each table. As with Postgres, the ids
+	// of a failed transaction are not given back.
+	sequences map[string]int64
+	// Undo the writes of the running transaction, nil outside of one.
+	undo []func()
+}
+
+func newTables() *tables {
+	t := &tables{
+		accounts:           map[int64]db.Account{},
+		accountLimits:      map[int64]db.AccountLimit{},
+		entries:            map[int64]db.Entry{},
+		transfers:          map[int64]db.Transfer{},
+		users:              map[string]db.User{},
+		sessions:           map[uuid.UUID]db.Session{},
+		journals:           map[int64]db.JournalTransaction{},
+		interestAccruals:   map[int64]db.InterestAccrual{},
+		outboxEvents:       map[int64]db.OutboxEvent{},
+		webhooks:           map[int64]db.Webhook{},
+		webhookDeliveries:  map[int64]db.WebhookDelivery{},
+		archivedPartitions: map[string]db.ArchivedPartition{},
+		sequences:          map[string]int64{},
+	}
+
+	// the system accounts of the ledger, in the order of the migrations.
+	kinds := []string{db.SystemCashIn, db.SystemCashOut, db.SystemFees, db.SystemInterest}
+	for _, currency := range systemCurrencies {
+		for _, kind := range kinds {
+			t.insertSystemAccount(kind, currency)
+		}
+	}
+	for _, currency := range systemCurrencies {
+		t.insertSystemAccount(db.SystemAdjustments, currency)
+	}
+	return t
+}
+
+func (t *tables) insertSystemAccount(kind string, currency string) {
+	id := t.nextID("accounts")
+	setRow(t, t.accounts, id, db.Account{
+		ID:          id,
+		Currency:    currency,
+		CreatedAt:   now(),
+		AccountType: utils.Checking,
+		SystemKind:  &kind,
+		Version:     1,
+	})
+}
+
+// Returns the next id of the sequence of a table.
+func (t *tables) nextID(table string) int64 {
+	t.sequences[table]++
+	return t.sequences[table]
+}
+
+// Writes a row of a table. Within a transaction, the previous row is kept in
+// the undo log. The rows are never modified in place, so keeping the previous
+// value is enough.
+func setRow[K comparable, V any](t *tables, rows map[K]V, key K, row V) {
+	record(t, rows, key)
+	rows[key] = row
+}
+
+// Deletes a row of a table, keeping it in the undo log within a transaction.
+func deleteRow[K comparable, V any](t *tables, rows map[K]V, key K) {
+	record(t, rows, key)
+	delete(rows, key)
+}
+
+// Adds the restoration of a row, or its removal if it doesn't exist yet, to
+// the undo log of the running transaction.
+func record[K comparable, V any](t *tables, rows map[K]V, key K) {
+	if t.undo == nil {
+		return
+	}
+	row, ok := rows[key]
+	t.undo = append(t.undo, func() {
+		if ok {
+			rows[key] = row
+		} else {
+			delete(rows, key)
+		}
+	})
+}
+
+// Undoes the writes of the running transaction, the last one first.
+func (t *tables) rollback() {
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		t.undo[i]()
+	}
+}
+
+// State shared by the queries of a store.
+type database struct {
+	mu     sync.Mutex
+	tables *tables
+	notify func(db.AccountEntryNotification)
+}
+
+// Implements db.Querier on the tables of a database. Outside of a
+// transaction every query locks the database; within one, the transaction
+// holds the lock and its queries keep an undo log of their writes.
+type queries struct {
+	db *database
+	tx *transaction
+}
+
+var _ db.Querier = (*queries)(nil)
+
+// Tables of a transaction and the notifications sent once it commits.
+type transaction struct {
+	tables        *tables
+	notifications []db.AccountEntryNotification
+}
+
+// Creates a new Store in memory, with the system accounts of the ledger. The
+// entry notifications are passed to notify once their transaction commits,
+// notify may be nil.
+func NewStore(config utils.Config, notify func(db.AccountEntryNotification)) db.Store {
+	q := &queries{db: &database{
+		tables: newTables(),
+		notify: notify,
+	}}
+	return db.NewTxStore(q, q.execTx, config)
+}
+
+// Returns the tables to query and the function releasing them.
+func (q *queries) begin() (*tables, func()) {
+	if q.tx != nil {
+		return q.tx.tables, func() {}
+	}
+	q.db.mu.Lock()
+	return q.db.tables, q.db.mu.Unlock
+}
+
+// Runs fn within a transaction. Transactions hold the database for their
+// whole duration, so that they are serializable and never need a retry; the
+// changes of a failed transaction are undone.
+func (q *queries) execTx(ctx context.Context, opts pgx.TxOptions, fn func(db.Querier) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	tx := &transaction{}
+	err := func() error {
+		q.db.mu.Lock()
+		defer q.db.mu.Unlock()
+
+		tx.tables = q.db.tables
+		tx.tables.undo = []func(){}
+		committed := false
+		// the writes are also undone if fn panics.
+		defer func() {
+			if !committed {
+				tx.tables.rollback()
+			}
+			tx.tables.undo = nil
+		}()
+
+		if err := fn(&queries{db: q.db, tx: tx}); err != nil {
+			return err
+		}
+		committed = true
+		return nil
+	}()
+	if err != nil {
+		return err
+	}
+
+	for _, notification := range tx.notifications {
+		q.sendNotification(notification)
+	}
+	return nil
+}
+
+// Notifies the subscriber of an entry. Payloads are decoded like the
+// listener of the Postgres channel does.
+func (q *queries) NotifyAccountEntry(ctx context.Context, payload string) error {
+	var notification db.AccountEntryNotification
+	if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+		return err
+	}
+
+	if q.tx != nil {
+		q.tx.notifications = append(q.tx.notifications, notification)
+		return nil
+	}
+	q.sendNotification(notification)
+	return nil
+}
+
+func (q *queries) sendNotification(notification db.AccountEntryNotification) {
+	if q.db.notify != nil {
+		q.db.notify(notification)
+	}
+}
+
+// Returns the current time with the precision of Postgres timestamps.
+func now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// Returns the date of a time in UTC, as Postgres casts it to a date.
+func utcDate(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Returns the date of a time in its own location, as a date parameter is
+// sent to Postgres.
+func date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Reports whether the row of (createdAt, id) comes after the keyset cursor,
+// as the (created_at, id) > (after_created_at, after_id) row comparison.
+func afterCursor(createdAt time.Time, id int64, afterCreatedAt time.Time, afterID int64) bool {
+	if !createdAt.Equal(afterCreatedAt) {
+		return createdAt.After(afterCreatedAt)
+	}
+	return id > afterID
+}
+
+// Orders the rows by (created_at, id), as the keyset pages.
+func byCreation(aCreatedAt time.Time, aID int64, bCreatedAt time.Time, bID int64) bool {
+	if !aCreatedAt.Equal(bCreatedAt) {
+		return aCreatedAt.Before(bCreatedAt)
+	}
+	return aID < bID
+}
+
+// Returns the page of rows at offset, up to limit rows. Negative limits and
+// offsets are rejected by Postgres, they return nothing here.
+func page[T any](rows []T, limit int32, offset int32) []T {
+	if limit < 0 || offset < 0 || int(offset) >= len(rows) {
+		return []T{}
+	}
+	rows = rows[offset:]
+	if int(limit) < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows
+}
+
+// Returns the rows kept by keep, sorted by less.
+func selectRows[K comparable, V any](rows map[K]V, keep func(V) bool, less func(a, b V) bool) []V {
+	result := []V{}
+	for _, row := range rows {
+		if keep(row) {
+			result = append(result, row)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return less(result[i], result[j]) })
+	return result
+}
@@ -0,0 +1,662 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomUser(t *testing.T, store db.Store) db.User {
+	user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       utils.RandomOwner(),
+		HashedPassword: utils.RandomString(32),
+		FullName:       utils.RandomOwner(),
+		Email:          utils.RandomEmailAddress(),
+	})
+	require.NoError(t, err)
+	return user
+}
+
+func createRandomAccount(t *testing.T, store db.Store, currency string, balance int64) db.Account {
+	user := createRandomUser(t, store)
+	account, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:       &user.Username,
+		Balance:     balance,
+		Currency:    currency,
+		AccountType: utils.Checking,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func TestTransferTx(t *testing.T) {
+	var mu sync.Mutex
+	var notifications []db.AccountEntryNotification
+	store := NewStore(utils.Config{}, func(notification db.AccountEntryNotification) {
+		mu.Lock()
+		defer mu.Unlock()
+		notifications = append(notifications, notification)
+	})
+
+	account1 := createRandomAccount(t, store, utils.USD, 100)
+	account2 := createRandomAccount(t, store, utils.USD, 100)
+
+	// opposing transfers run concurrently without deadlocks.
+	n := 10
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		from, to := account1, account2
+		if i%2 == 1 {
+			from, to = account2, account1
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.TransferTx(context.Background(), db.TransferTxParams{
+				FromAccountID: from.ID,
+				ToAccountID:   to.ID,
+				Amount:        10,
+			})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	updated1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), updated1.Balance)
+
+	updated2, err := store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), updated2.Balance)
+
+	entries, err := store.ListAccountEntries(context.Background(), db.ListAccountEntriesParams{
+		AccountID:  account1.ID,
+		LimitCount: 100,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, n)
+
+	transfers, err := store.ListTransfers(context.Background(), db.ListTransfersParams{LimitCount: 100})
+	require.NoError(t, err)
+	require.Len(t, transfers, n)
+
+	journals, err := store.ListUnbalancedJournals(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, journals)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, notifications, 2*n)
+}
+
+func TestTransferTxFee(t *testing.T) {
+	store := NewStore(utils.Config{
+		FeeSchedule: utils.FeeSchedule{utils.USD: {Flat: 5}},
+	}, nil)
+
+	sender := createRandomAccount(t, store, utils.USD, 100)
+	recipient := createRandomAccount(t, store, utils.USD, 100)
+	cashOut, err := store.GetSystemAccount(context.Background(), db.GetSystemAccountParams{
+		SystemKind: db.SystemCashOut,
+		Currency:   utils.USD,
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: sender.ID,
+		ToAccountID:   recipient.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(5), result.Fee)
+	require.Equal(t, int64(85), result.FromAccount.Balance)
+
+	// transfers to system accounts are free.
+	result, err = store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: sender.ID,
+		ToAccountID:   cashOut.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Zero(t, result.Fee)
+	require.Empty(t, result.FeeEntry)
+	require.Equal(t, int64(75), result.FromAccount.Balance)
+}
+
+func TestTransferTxRollback(t *testing.T) {
+	var notifications []db.AccountEntryNotification
+	q := &queries{db: &database{
+		tables: newTables(),
+		notify: func(notification db.AccountEntryNotification) {
+			notifications = append(notifications, notification)
+		},
+	}}
+	store := db.NewTxStore(q, q.execTx, utils.Config{})
+
+	account1 := createRandomAccount(t, store, utils.USD, 100)
+	account2 := createRandomAccount(t, store, utils.USD, 100)
+
+	_, err := store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        101,
+	})
+	require.ErrorIs(t, err, db.ErrInsufficientFunds)
+
+	// a failing transaction leaves nothing behind.
+	err = q.execTx(context.Background(), pgx.TxOptions{}, func(q db.Querier) error {
+		_, err := q.AddAccountBalance(context.Background(), db.AddAccountBalanceParams{
+			ID:     account1.ID,
+			Amount: 50,
+		})
+		require.NoError(t, err)
+		return errors.New("rollback")
+	})
+	require.EqualError(t, err, "rollback")
+
+	updated1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updated1.Balance)
+
+	transfers, err := store.ListTransfers(context.Background(), db.ListTransfersParams{LimitCount: 100})
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+	require.Empty(t, notifications)
+}
+
+func TestExecTxUndo(t *testing.T) {
+	q := &queries{db: &database{tables: newTables()}}
+	store := db.NewTxStore(q, q.execTx, utils.Config{})
+
+	account := createRandomAccount(t, store, utils.USD, 100)
+	limit, err := store.UpsertAccountLimit(context.Background(), db.UpsertAccountLimitParams{
+		AccountID: account.ID,
+		Daily:     sql.NullInt64{Int64: 500, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// the updated, inserted and deleted rows of a failed transaction are
+	// restored, whether it returns an error or panics.
+	write := func(q db.Querier) {
+		_, err := q.AddAccountBalance(context.Background(), db.AddAccountBalanceParams{ID: account.ID, Amount: 50})
+		require.NoError(t, err)
+		_, err = q.CreateEntry(context.Background(), db.CreateEntryParams{AccountID: account.ID, Amount: 50, Metadata: json.RawMessage(`{}`)})
+		require.NoError(t, err)
+		require.NoError(t, q.DeleteAccountLimit(context.Background(), account.ID))
+	}
+	check := func() {
+		got, err := store.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account, got)
+
+		entries, err := store.ListEntries(context.Background(), db.ListEntriesParams{LimitCount: 10})
+		require.NoError(t, err)
+		require.Empty(t, entries)
+
+		gotLimit, err := store.GetAccountLimit(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, limit, gotLimit)
+	}
+
+	err = q.execTx(context.Background(), pgx.TxOptions{}, func(q db.Querier) error {
+		write(q)
+		return errors.New("rollback")
+	})
+	require.EqualError(t, err, "rollback")
+	check()
+
+	require.Panics(t, func() {
+		_ = q.execTx(context.Background(), pgx.TxOptions{}, func(q db.Querier) error {
+			write(q)
+			panic("rollback")
+		})
+	})
+	check()
+}
+
+func TestConstraintErrors(t *testing.T) {
+	store := NewStore(utils.Config{}, nil)
+	account := createRandomAccount(t, store, utils.USD, 100)
+	user, err := store.GetUser(context.Background(), *account.Owner)
+	require.NoError(t, err)
+
+	missingOwner := utils.RandomOwner()
+
+	testCases := []struct {
+		name       string
+		run        func() error
+		sentinel   error
+		constraint string
+	}{
+		{
+			name: "DuplicateEmail",
+			run: func() error {
+				_, err := store.CreateUser(context.Background(), db.CreateUserParams{
+					Username: utils.RandomOwner(),
+					Email:    user.Email,
+				})
+				return err
+			},
+			sentinel:   db.ErrUniqueViolation,
+			constraint: "email_key",
+		},
+		{
+			name: "DuplicateUsername",
+			run: func() error {
+				_, err := store.CreateUser(context.Background(), db.CreateUserParams{
+					Username: user.Username,
+					Email:    utils.RandomEmailAddress(),
+				})
+				return err
+			},
+			sentinel:   db.ErrUniqueViolation,
+			constraint: "users_pkey",
+		},
+		{
+			name: "DuplicateCurrency",
+			run: func() error {
+				_, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+					Owner:       account.Owner,
+					Currency:    account.Currency,
+					AccountType: utils.Savings,
+				})
+				return err
+			},
+			sentinel:   db.ErrUniqueViolation,
+			constraint: "owner_currency_key",
+		},
+		{
+			name: "MissingOwner",
+			run: func() error {
+				_, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+					Owner:       &missingOwner,
+					Currency:    utils.USD,
+					AccountType: utils.Checking,
+				})
+				return err
+			},
+			sentinel:   db.ErrForeignKeyViolation,
+			constraint: "accounts_owner_fkey",
+		},
+		{
+			name: "MissingAccount",
+			run: func() error {
+				_, err := store.TransferTx(context.Background(), db.TransferTxParams{
+					FromAccountID: account.ID,
+					ToAccountID:   account.ID + 1000,
+					Amount:        10,
+				})
+				return err
+			},
+			sentinel: db.ErrRecordNotFound,
+		},
+		{
+			name: "ReferencedAccount",
+			run: func() error {
+				_, err := store.CreateEntry(context.Background(), db.CreateEntryParams{
+					AccountID: account.ID,
+					Amount:    10,
+					Metadata:  json.RawMessage(`{}`),
+				})
+				require.NoError(t, err)
+				return store.DeleteAccount(context.Background(), account.ID)
+			},
+			sentinel:   db.ErrForeignKeyViolation,
+			constraint: "entries_account_id_fkey",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.run()
+			require.ErrorIs(t, err, tc.sentinel)
+			require.Equal(t, tc.constraint, db.ErrorConstraint(err))
+		})
+	}
+}
+
+func TestRecordOutboxEventFailure(t *testing.T) {
+	store := NewStore(utils.Config{}, nil)
+	events := make([]db.OutboxEvent, 3)
+	for i := range events {
+		var err error
+		events[i], err = store.CreateOutboxEvent(context.Background(), db.CreateOutboxEventParams{
+			AggregateType: db.AggregateUser,
+			AggregateID:   utils.RandomOwner(),
+			EventType:     db.EventUserRegistered,
+			Payload:       json.RawMessage(`{}`),
+		})
+		require.NoError(t, err)
+	}
+
+	// the first event waits for its backoff, the second is dead.
+	err := store.RecordOutboxEventFailure(context.Background(), db.RecordOutboxEventFailureParams{
+		ID:            events[0].ID,
+		LastError:     "sink is down",
+		NextAttemptAt: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	err = store.RecordOutboxEventFailure(context.Background(), db.RecordOutboxEventFailureParams{
+		ID:            events[1].ID,
+		LastError:     "sink is down",
+		NextAttemptAt: time.Now(),
+		Dead:          true,
+	})
+	require.NoError(t, err)
+
+	due, err := store.ListUnpublishedOutboxEvents(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	require.Equal(t, events[2].ID, due[0].ID)
+}
+
+func TestListAccountEntriesPages(t *testing.T) {
+	store := NewStore(utils.Config{}, nil)
+	account := createRandomAccount(t, store, utils.USD, 0)
+
+	entries := make([]db.Entry, 5)
+	for i := range entries {
+		var err error
+		entries[i], err = store.CreateEntry(context.Background(), db.CreateEntryParams{
+			AccountID: account.ID,
+			Amount:    int64(10 - i),
+			Metadata:  json.RawMessage(`{}`),
+		})
+		require.NoError(t, err)
+	}
+
+	// the pages follow the (created_at, id) of the last entry of the previous
+	// page, whatever the amounts.
+	var got []db.Entry
+	args := db.ListAccountEntriesParams{AccountID: account.ID, LimitCount: 2}
+	for {
+		page, err := store.ListAccountEntries(context.Background(), args)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		got = append(got, page...)
+
+		last := page[len(page)-1]
+		args.AfterCreatedAt = last.CreatedAt
+		args.AfterID = last.ID
+	}
+	require.Equal(t, entries, got)
+}
+
+func TestPostInterestTxSkipsFrozenAndDeletedAccounts(t *testing.T) {
+	store := NewStore(utils.Config{}, nil)
+	frozen := createRandomAccount(t, store, utils.USD, 10000)
+	deleted := createRandomAccount(t, store, utils.USD, 0)
+
+	day := time.Date(2024, time.August, 1, 0, 0, 0, 0, time.UTC)
+	for _, account := range []db.Account{frozen, deleted} {
+		_, err := store.CreateInterestAccrual(context.Background(), db.CreateInterestAccrualParams{
+			AccountID:       account.ID,
+			AccrualDate:     day,
+			Balance:         10000,
+			RateBasisPoints: 365,
+			AmountMicros:    utils.DailyInterestMicros(10000, 365),
+		})
+		require.NoError(t, err)
+	}
+	before := day.AddDate(0, 1, 0)
+
+	// the interest of a frozen account waits for it to be unfrozen.
+	_, err := store.FreezeAccount(context.Background(), db.FreezeAccountParams{ID: frozen.ID, Reason: "fraud"})
+	require.NoError(t, err)
+	result, err := store.PostInterestTx(context.Background(), db.PostInterestTxParams{AccountID: frozen.ID, Before: before})
+	require.NoError(t, err)
+	require.Zero(t, result.Accruals)
+	require.Equal(t, frozen.Balance, result.Account.Balance)
+
+	_, err = store.UnfreezeAccount(context.Background(), frozen.ID)
+	require.NoError(t, err)
+	result, err = store.PostInterestTx(context.Background(), db.PostInterestTxParams{AccountID: frozen.ID, Before: before})
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Accruals)
+	require.Equal(t, frozen.Balance+1, result.Account.Balance)
+
+	// deleting an account voids its interest, it is never paid.
+	_, err = store.DeleteAccountTx(context.Background(), db.DeleteAccountTxParams{AccountID: deleted.ID})
+	require.NoError(t, err)
+	accountIDs, err := store.ListAccountsWithUnpostedInterest(context.Background(), before)
+	require.NoError(t, err)
+	require.Empty(t, accountIDs)
+
+	accrual, err := store.GetInterestAccrual(context.Background(), db.GetInterestAccrualParams{AccountID: deleted.ID, AccrualDate: day})
+	require.NoError(t, err)
+	require.True(t, accrual.PostedAt.Valid)
+	require.False(t, accrual.EntryID.Valid)
+}
+
+func TestDeleteUserTx(t *testing.T) {
+	store := NewStore(utils.Config{}, nil)
+	account := createRandomAccount(t, store, utils.USD, 100)
+	other := createRandomAccount(t, store, utils.USD, 100)
+	owner := *account.Owner
+
+	_, err := store.DeleteUserTx(context.Background(), owner)
+	require.ErrorIs(t, err, db.ErrAccountNotEmpty)
+
+	_, err = store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: account.ID,
+		ToAccountID:   other.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+
+	user, err := store.DeleteUserTx(context.Background(), owner)
+	require.NoError(t, err)
+	require.Empty(t, user.FullName)
+	require.True(t, user.DeletedAt.Valid)
+
+	_, err = store.DeleteUserTx(context.Background(), owner)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	accounts, err := store.ListAccountsByOwner(context.Background(), db.ListAccountsByOwnerParams{
+		Owner: &owner,
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+
+	_, err = store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: other.ID,
+		ToAccountID:   account.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, db.ErrAccountDeleted)
+
+	_, err = store.CreateAccountTx(context.Background(), db.CreateAccountParams{
+		Owner:       &owner,
+		Currency:    utils.EUR,
+		AccountType: utils.Checking,
+	})
+	require.ErrorIs(t, err, db.ErrUserDeleted)
+}
+
+func TestPostJournalTxExpectedVersion(t *testing.T) {
+	store := NewStore(utils.Config{}, nil)
+	account := createRandomAccount(t, store, utils.USD, 100)
+	require.Equal(t, int64(1), account.Version)
+
+	adjustments, err := store.GetSystemAccount(context.Background(), db.GetSystemAccountParams{
+		SystemKind: db.SystemAdjustments,
+		Currency:   utils.USD,
+	})
+	require.NoError(t, err)
+
+	adjust := func(version int64, amount int64) (db.PostJournalTxResult, error) {
+		return store.PostJournalTx(context.Background(), db.PostJournalTxParams{
+			Kind: db.JournalAdjustment,
+			Lines: []db.JournalLine{
+				{AccountID: account.ID, Amount: amount, ExpectedVersion: version},
+				{AccountID: adjustments.ID, Amount: -amount},
+			},
+		})
+	}
+
+	result, err := adjust(account.Version, -50)
+	require.NoError(t, err)
+	require.Equal(t, int64(50), result.Accounts[0].Balance)
+	require.Equal(t, int64(2), result.Accounts[0].Version)
+
+	_, err = adjust(account.Version, -50)
+	require.ErrorIs(t, err, db.ErrVersionConflict)
+
+	_, err = store.DeleteAccountTx(context.Background(), db.DeleteAccountTxParams{
+		AccountID:       account.ID,
+		ExpectedVersion: account.Version,
+	})
+	require.ErrorIs(t, err, db.ErrVersionConflict)
+}
+
+func TestPostJournalTxInsufficientFunds(t *testing.T) {
+	store := NewStore(utils.Config{}, nil)
+	account := createRandomAccount(t, store, utils.USD, 100)
+
+	cashOut, err := store.GetSystemAccount(context.Background(), db.GetSystemAccountParams{
+		SystemKind: db.SystemCashOut,
+		Currency:   utils.USD,
+	})
+	require.NoError(t, err)
+
+	withdraw := func(amount int64) error {
+		_, err := store.PostJournalTx(context.Background(), db.PostJournalTxParams{
+			Kind: db.JournalWithdrawal,
+			Lines: []db.JournalLine{
+				{AccountID: account.ID, Amount: -amount},
+				{AccountID: cashOut.ID, Amount: amount},
+			},
+		})
+		return err
+	}
+
+	require.ErrorIs(t, withdraw(101), db.ErrInsufficientFunds)
+
+	// concurrent withdrawals are checked against the locked balance, only
+	// the ones it covers are booked.
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			errs <- withdraw(40)
+		}()
+	}
+
+	booked := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			booked++
+			continue
+		}
+		require.ErrorIs(t, err, db.ErrInsufficientFunds)
+	}
+	require.Equal(t, 2, booked)
+
+	updated, err := store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(20), updated.Balance)
+}
+
+// Returns a random key for a PIIKeyring, named id.
+func randomPIIKey(id string) string {
+	return id + ":" + utils.RandomPIIKey()
+}
+
+func newPIIConfig(t *testing.T, keys ...string) utils.Config {
+	keyring, err := utils.ParsePIIKeyring(strings.Join(keys, ","), base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	require.NoError(t, err)
+	return utils.Config{PIIKeyring: keyring}
+}
+
+func TestReencryptUsersTx(t *testing.T) {
+	q := &queries{db: &database{tables: newTables()}}
+	plainStore := db.NewTxStore(q, q.execTx, utils.Config{})
+	_, err := plainStore.ReencryptUsersTx(context.Background(), db.ReencryptUsersTxParams{Limit: 10})
+	require.ErrorIs(t, err, db.ErrEncryptionDisabled)
+
+	plain := createRandomUser(t, plainStore)
+	require.Nil(t, plain.EmailHash)
+
+	k1, k2 := randomPIIKey("k1"), randomPIIKey("k2")
+	store := db.NewTxStore(q, q.execTx, newPIIConfig(t, k1))
+	user := createRandomUser(t, store)
+	require.NotNil(t, user.EmailHash)
+
+	raw, err := q.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(raw.Email, "enc:v1:k1:"))
+	require.NotContains(t, raw.FullName, user.FullName)
+
+	// the emails are unique across encrypted and plaintext users.
+	for _, email := range []string{user.Email, plain.Email} {
+		_, err = store.CreateUser(context.Background(), db.CreateUserParams{
+			Username: utils.RandomOwner(),
+			Email:    email,
+		})
+		require.ErrorIs(t, err, db.ErrUniqueViolation)
+		require.Equal(t, "email_key", db.ErrorConstraint(err))
+	}
+
+	found, err := store.GetUserByEmail(context.Background(), db.GetUserByEmailParams{Email: plain.Email})
+	require.NoError(t, err)
+	require.Equal(t, plain.Username, found.Username)
+
+	// a new key re-encrypts the users, batch by batch.
+	store = db.NewTxStore(q, q.execTx, newPIIConfig(t, k2, k1))
+	var last string
+	for {
+		result, err := store.ReencryptUsersTx(context.Background(), db.ReencryptUsersTxParams{After: last, Limit: 1})
+		require.NoError(t, err)
+		if result.Count == 0 {
+			break
+		}
+		require.Equal(t, 1, result.Count)
+		last = result.Last
+	}
+
+	// the users on the current key are left as is.
+	before, err := q.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	result, err := store.ReencryptUsersTx(context.Background(), db.ReencryptUsersTxParams{Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Count)
+	require.Equal(t, 2, result.Skipped)
+	after, err := q.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, before.Email, after.Email)
+
+	// the old key isn't needed anymore.
+	store = db.NewTxStore(q, q.execTx, newPIIConfig(t, k2))
+	for _, want := range []db.User{plain, user} {
+		raw, err = q.GetUser(context.Background(), want.Username)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(raw.Email, "enc:v1:k2:"))
+
+		found, err = store.GetUserByEmail(context.Background(), db.GetUserByEmailParams{Email: want.Email})
+		require.NoError(t, err)
+		require.Equal(t, want.Username, found.Username)
+		require.Equal(t, want.FullName, found.FullName)
+		require.Equal(t, want.Email, found.Email)
+	}
+}
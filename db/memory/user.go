@@ -0,0 +1,244 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/utils"
+)
+
+func usersByUsername(a, b db.User) bool {
+	return a.Username < b.Username
+}
+
+func (q *queries) CreateUser(ctx context.Context, arg db.CreateUserParams) (db.User, error) {
+	t, release := q.begin()
+	defer release()
+
+	if _, ok := t.users[arg.Username]; ok {
+		return db.User{}, uniqueViolation("users_pkey")
+	}
+	role := arg.Role
+	if role == "" {
+		role = utils.DepositorRole
+	}
+	if !utils.IsSupportedRole(role) {
+		return db.User{}, checkViolation("users", "role_check")
+	}
+
+	user := db.User{
+		Username:       arg.Username,
+		HashedPassword: arg.HashedPassword,
+		FullName:       arg.FullName,
+		Email:          arg.Email,
+		EmailHash:      arg.EmailHash,
+		CreatedAt:      now(),
+		Role:           role,
+	}
+	if t.emailTaken(user) {
+		return db.User{}, uniqueViolation("email_key")
+	}
+	setRow(t, t.users, user.Username, user)
+	return user, nil
+}
+
+// Returns the key of the email_key index of a user: the blind index of its
+// email, or the email while it is in plaintext.
+func emailKey(user db.User) string {
+	if user.EmailHash != nil {
+		return hex.EncodeToString(user.EmailHash)
+	}
+	return user.Email
+}
+
+// Reports whether another user has the email key of user.
+func (t *tables) emailTaken(user db.User) bool {
+	for _, other := range t.users {
+		if other.Username != user.Username && emailKey(other) == emailKey(user) {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *queries) GetUser(ctx context.Context, username string) (db.User, error) {
+	t, release := q.begin()
+	defer release()
+
+	user, ok := t.users[username]
+	if !ok {
+		return db.User{}, db.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (q *queries) GetUserDeletedAt(ctx context.Context, username string) (sql.NullTime, error) {
+	t, release := q.begin()
+	defer release()
+
+	user, ok := t.users[username]
+	if !ok {
+		return sql.NullTime{}, db.ErrRecordNotFound
+	}
+	return user.DeletedAt, nil
+}
+
+func (q *queries) GetUserRole(ctx context.Context, username string) (db.GetUserRoleRow, error) {
+	t, release := q.begin()
+	defer release()
+
+	user, ok := t.users[username]
+	if !ok {
+		return db.GetUserRoleRow{}, db.ErrRecordNotFound
+	}
+	return db.GetUserRoleRow{Role: user.Role, DeletedAt: user.DeletedAt}, nil
+}
+
+// Transactions hold the whole database, the user is locked with it.
+func (q *queries) GetUserForShare(ctx context.Context, username string) (db.User, error) {
+	return q.GetUser(ctx, username)
+}
+
+func (q *queries) GetUserByEmail(ctx context.Context, arg db.GetUserByEmailParams) (db.User, error) {
+	t, release := q.begin()
+	defer release()
+
+	for _, user := range t.users {
+		if arg.EmailHash != nil && string(user.EmailHash) == string(arg.EmailHash) ||
+			user.EmailHash == nil && user.Email == arg.Email {
+			return user, nil
+		}
+	}
+	return db.User{}, db.ErrRecordNotFound
+}
+
+// Transactions hold the whole database, the users are locked with it.
+func (q *queries) ListUsersForUpdate(ctx context.Context, arg db.ListUsersForUpdateParams) ([]db.User, error) {
+	t, release := q.begin()
+	defer release()
+
+	users := selectRows(t.users, func(user db.User) bool {
+		return user.Username > arg.After && !user.DeletedAt.Valid
+	}, usersByUsername)
+	return page(users, arg.LimitCount, 0), nil
+}
+
+func (q *queries) UpdateUserPersonalData(ctx context.Context, arg db.UpdateUserPersonalDataParams) (db.User, error) {
+	t, release := q.begin()
+	defer release()
+
+	user, ok := t.users[arg.Username]
+	if !ok {
+		return db.User{}, db.ErrRecordNotFound
+	}
+
+	user.FullName = arg.FullName
+	user.Email = arg.Email
+	user.EmailHash = arg.EmailHash
+	if t.emailTaken(user) {
+		return db.User{}, uniqueViolation("email_key")
+	}
+	setRow(t, t.users, user.Username, user)
+	return user, nil
+}
+
+func (q *queries) UpdateUserRole(ctx context.Context, arg db.UpdateUserRoleParams) (db.User, error) {
+	t, release := q.begin()
+	defer release()
+
+	user, ok := t.users[arg.Username]
+	if !ok {
+		return db.User{}, db.ErrRecordNotFound
+	}
+	if !utils.IsSupportedRole(arg.Role) {
+		return db.User{}, checkViolation("users", "role_check")
+	}
+
+	user.Role = arg.Role
+	setRow(t, t.users, user.Username, user)
+	return user, nil
+}
+
+func (q *queries) AnonymizeUser(ctx context.Context, username string) (db.User, error) {
+	t, release := q.begin()
+	defer release()
+
+	user, ok := t.users[username]
+	if !ok || user.DeletedAt.Valid {
+		return db.User{}, db.ErrRecordNotFound
+	}
+
+	user.HashedPassword = ""
+	user.FullName = ""
+	user.Email = "deleted-" + user.Username + "@invalid"
+	user.EmailHash = nil
+	user.DeletedAt = sql.NullTime{Time: now(), Valid: true}
+	setRow(t, t.users, username, user)
+	return user, nil
+}
+
+func (q *queries) CreateSession(ctx context.Context, arg db.CreateSessionParams) (db.Session, error) {
+	t, release := q.begin()
+	defer release()
+
+	if _, ok := t.sessions[arg.ID]; ok {
+		return db.Session{}, uniqueViolation("sessions_pkey")
+	}
+	if _, ok := t.users[arg.Username]; !ok {
+		return db.Session{}, foreignKeyViolation("sessions", "sessions_username_fkey")
+	}
+
+	session := db.Session{
+		ID:           arg.ID,
+		Username:     arg.Username,
+		RefreshToken: arg.RefreshToken,
+		UserAgent:    arg.UserAgent,
+		ClientIp:     arg.ClientIp,
+		IsBlocked:    arg.IsBlocked,
+		ExpiresAt:    arg.ExpiresAt,
+		CreatedAt:    now(),
+	}
+	setRow(t, t.sessions, session.ID, session)
+	return session, nil
+}
+
+func (q *queries) GetSession(ctx context.Context, id uuid.UUID) (db.Session, error) {
+	t, release := q.begin()
+	defer release()
+
+	session, ok := t.sessions[id]
+	if !ok {
+		return db.Session{}, db.ErrRecordNotFound
+	}
+	return session, nil
+}
+
+func (q *queries) DeleteUserSessions(ctx context.Context, username string) error {
+	t, release := q.begin()
+	defer release()
+
+	for id, session := range t.sessions {
+		if session.Username == username {
+			deleteRow(t, t.sessions, id)
+		}
+	}
+	return nil
+}
+
+func (q *queries) DeleteExpiredSessions(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	t, release := q.begin()
+	defer release()
+
+	var deleted int64
+	for id, session := range t.sessions {
+		if session.ExpiresAt.Before(expiredBefore) {
+			deleteRow(t, t.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,17 @@
+CREATE TABLE "account_limits" (
+  "account_id" bigint PRIMARY KEY,
+  "per_transaction" bigint,
+  "daily" bigint,
+  "monthly" bigint,
+  "updated_at" timestamptz NOT NULL DEFAULT 'now()'
+);
+
+COMMENT ON COLUMN "account_limits"."per_transaction" IS 'overrides the default limit when set, 0 means unlimited.';
+
+COMMENT ON COLUMN "account_limits"."daily" IS 'overrides the default limit when set, 0 means unlimited.';
+
+COMMENT ON COLUMN "account_limits"."monthly" IS 'overrides the default limit when set, 0 means unlimited.';
+
+ALTER TABLE "account_limits" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
@@ -0,0 +1,11 @@
+ALTER TABLE "transfers" ADD COLUMN "description" varchar NOT NULL DEFAULT '';
+ALTER TABLE "transfers" ADD COLUMN "reference" varchar NOT NULL DEFAULT '';
+ALTER TABLE "transfers" ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}';
+
+ALTER TABLE "entries" ADD COLUMN "description" varchar NOT NULL DEFAULT '';
+ALTER TABLE "entries" ADD COLUMN "reference" varchar NOT NULL DEFAULT '';
+ALTER TABLE "entries" ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}';
+
+CREATE INDEX "transfers_reference_idx" ON "transfers" ("reference") WHERE "reference" <> '';
+
+COMMENT ON COLUMN "transfers"."reference" IS 'external reference provided by the client, empty if none.';
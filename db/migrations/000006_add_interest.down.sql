@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "interest_accruals";
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "account_type_check";
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "account_type";
@@ -0,0 +1,28 @@
+ALTER TABLE "accounts" ADD COLUMN "account_type" varchar NOT NULL DEFAULT 'checking';
+ALTER TABLE "accounts" ADD CONSTRAINT "account_type_check" CHECK ("account_type" IN ('checking', 'savings'));
+
+CREATE TABLE "interest_accruals" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "accrual_date" date NOT NULL,
+  "balance" bigint NOT NULL,
+  "rate_basis_points" bigint NOT NULL,
+  "amount_micros" bigint NOT NULL,
+  "posted_at" timestamptz,
+  "entry_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT 'now()'
+);
+
+CREATE UNIQUE INDEX ON "interest_accruals" ("account_id", "accrual_date");
+
+CREATE INDEX ON "interest_accruals" ("account_id") WHERE "posted_at" IS NULL;
+
+COMMENT ON COLUMN "interest_accruals"."rate_basis_points" IS 'annual rate applied on the day.';
+
+COMMENT ON COLUMN "interest_accruals"."amount_micros" IS 'interest of the day in millionths of a minor unit.';
+
+COMMENT ON COLUMN "interest_accruals"."entry_id" IS 'entry that paid the interest, null if it rounded to zero.';
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("entry_id") REFERENCES "entries" ("id");
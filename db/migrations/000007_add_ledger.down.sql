@@ -0,0 +1,11 @@
+DELETE FROM "entries" WHERE "account_id" IN (SELECT "id" FROM "accounts" WHERE "system_kind" IS NOT NULL);
+DELETE FROM "accounts" WHERE "system_kind" IS NOT NULL;
+
+DROP INDEX IF EXISTS "system_kind_currency_key";
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "owner_or_system_check";
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "system_kind_check";
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "system_kind";
+ALTER TABLE IF EXISTS "accounts" ALTER COLUMN "owner" SET NOT NULL;
+
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "journal_id";
+DROP TABLE IF EXISTS "journal_transactions";
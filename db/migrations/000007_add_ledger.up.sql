@@ -0,0 +1,29 @@
+CREATE TABLE "journal_transactions" (
+  "id" bigserial PRIMARY KEY,
+  "kind" varchar NOT NULL,
+  "description" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT 'now()'
+);
+
+COMMENT ON TABLE "journal_transactions" IS 'groups the entries of a balanced posting.';
+
+ALTER TABLE "entries" ADD COLUMN "journal_id" bigint;
+
+COMMENT ON COLUMN "entries"."journal_id" IS 'null for entries booked before the ledger existed.';
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("journal_id") REFERENCES "journal_transactions" ("id");
+
+CREATE INDEX ON "entries" ("journal_id");
+
+-- system accounts belong to the bank instead of a user.
+ALTER TABLE "accounts" ALTER COLUMN "owner" DROP NOT NULL;
+ALTER TABLE "accounts" ADD COLUMN "system_kind" varchar;
+ALTER TABLE "accounts" ADD CONSTRAINT "system_kind_check" CHECK ("system_kind" IN ('cash_in', 'cash_out', 'fees', 'interest'));
+ALTER TABLE "accounts" ADD CONSTRAINT "owner_or_system_check" CHECK (("owner" IS NULL) = ("system_kind" IS NOT NULL));
+
+CREATE UNIQUE INDEX "system_kind_currency_key" ON "accounts" ("system_kind", "currency") WHERE "system_kind" IS NOT NULL;
+
+INSERT INTO "accounts" ("owner", "balance", "currency", "system_kind")
+SELECT NULL, 0, "currency", "kind"
+FROM unnest(ARRAY['USD', 'EUR', 'CAD', 'AUD']) AS "currency"
+CROSS JOIN unnest(ARRAY['cash_in', 'cash_out', 'fees', 'interest']) AS "kind";
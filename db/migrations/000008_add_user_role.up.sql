@@ -0,0 +1,2 @@
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'depositor';
+ALTER TABLE "users" ADD CONSTRAINT "role_check" CHECK ("role" IN ('depositor', 'banker'));
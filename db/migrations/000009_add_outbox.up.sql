@@ -0,0 +1,15 @@
+CREATE TABLE "outbox_events" (
+  "id" bigserial PRIMARY KEY,
+  "aggregate_type" varchar NOT NULL,
+  "aggregate_id" varchar NOT NULL,
+  "event_type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "attempts" int NOT NULL DEFAULT 0,
+  "last_error" varchar NOT NULL DEFAULT '',
+  "published_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT 'now()'
+);
+
+COMMENT ON TABLE "outbox_events" IS 'domain events written in the same transaction as the change they describe.';
+
+CREATE INDEX "outbox_events_unpublished_idx" ON "outbox_events" ("id") WHERE "published_at" IS NULL;
@@ -0,0 +1,42 @@
+CREATE TABLE "webhooks" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "url" varchar NOT NULL,
+  "secret" varchar NOT NULL,
+  "event_types" varchar[] NOT NULL DEFAULT '{}',
+  "created_at" timestamptz NOT NULL DEFAULT 'now()'
+);
+
+COMMENT ON COLUMN "webhooks"."event_types" IS 'events delivered to the endpoint, empty means all.';
+
+ALTER TABLE "webhooks" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+CREATE INDEX ON "webhooks" ("owner");
+
+CREATE TABLE "webhook_deliveries" (
+  "id" bigserial PRIMARY KEY,
+  "webhook_id" bigint NOT NULL,
+  "event_id" bigint NOT NULL,
+  "event_type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" int NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamptz NOT NULL DEFAULT 'now()',
+  "last_error" varchar NOT NULL DEFAULT '',
+  "response_status" int NOT NULL DEFAULT 0,
+  "delivered_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT 'now()'
+);
+
+COMMENT ON COLUMN "webhook_deliveries"."status" IS 'dead deliveries failed too many times and are not retried.';
+
+ALTER TABLE "webhook_deliveries" ADD CONSTRAINT "webhook_delivery_status_check" CHECK ("status" IN ('pending', 'delivered', 'dead'));
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("webhook_id") REFERENCES "webhooks" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("event_id") REFERENCES "outbox_events" ("id");
+
+-- the outbox may publish an event twice, it must only be delivered once per webhook.
+CREATE UNIQUE INDEX ON "webhook_deliveries" ("webhook_id", "event_id");
+
+CREATE INDEX "webhook_deliveries_pending_idx" ON "webhook_deliveries" ("next_attempt_at") WHERE "status" = 'pending';
@@ -0,0 +1,4 @@
+ALTER TABLE "accounts" ADD COLUMN "frozen_at" timestamptz;
+ALTER TABLE "accounts" ADD COLUMN "frozen_reason" varchar NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN "accounts"."frozen_at" IS 'frozen accounts can neither send nor receive transfers, null if not frozen.';
@@ -0,0 +1,5 @@
+DELETE FROM "entries" WHERE "account_id" IN (SELECT "id" FROM "accounts" WHERE "system_kind" = 'adjustments');
+DELETE FROM "accounts" WHERE "system_kind" = 'adjustments';
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "system_kind_check";
+ALTER TABLE IF EXISTS "accounts" ADD CONSTRAINT "system_kind_check" CHECK ("system_kind" IN ('cash_in', 'cash_out', 'fees', 'interest'));
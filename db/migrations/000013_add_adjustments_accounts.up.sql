@@ -0,0 +1,7 @@
+-- manual adjustments booked by the operators are balanced against these accounts.
+ALTER TABLE "accounts" DROP CONSTRAINT "system_kind_check";
+ALTER TABLE "accounts" ADD CONSTRAINT "system_kind_check" CHECK ("system_kind" IN ('cash_in', 'cash_out', 'fees', 'interest', 'adjustments'));
+
+INSERT INTO "accounts" ("owner", "balance", "currency", "system_kind")
+SELECT NULL, 0, "currency", 'adjustments'
+FROM unnest(ARRAY['USD', 'EUR', 'CAD', 'AUD']) AS "currency";
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS "webhook_deliveries_finished_idx";
+DROP INDEX IF EXISTS "sessions_expires_at_idx";
+
+DROP INDEX IF EXISTS "owner_currency_key";
+ALTER TABLE IF EXISTS "accounts" ADD CONSTRAINT "owner_currency_key" UNIQUE ("owner", "currency");
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "deleted_at";
@@ -0,0 +1,13 @@
+ALTER TABLE "users" ADD COLUMN "deleted_at" timestamptz;
+ALTER TABLE "accounts" ADD COLUMN "deleted_at" timestamptz;
+
+COMMENT ON COLUMN "users"."deleted_at" IS 'deleted users are anonymized, their username is kept for the ledger, null if not deleted.';
+COMMENT ON COLUMN "accounts"."deleted_at" IS 'deleted accounts keep their ledger history but can neither send nor receive transfers, null if not deleted.';
+
+-- a user can open an account again in the currency of a deleted one.
+ALTER TABLE "accounts" DROP CONSTRAINT "owner_currency_key";
+CREATE UNIQUE INDEX "owner_currency_key" ON "accounts" ("owner", "currency") WHERE "deleted_at" IS NULL;
+
+-- the retention job purges expired sessions and finished webhook deliveries.
+CREATE INDEX ON "sessions" ("expires_at");
+CREATE INDEX "webhook_deliveries_finished_idx" ON "webhook_deliveries" ("created_at") WHERE "status" <> 'pending';
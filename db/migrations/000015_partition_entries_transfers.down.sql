@@ -0,0 +1,60 @@
+DROP TABLE IF EXISTS "archived_entry_totals";
+DROP TABLE IF EXISTS "archived_partitions";
+
+-- the rows of the detached partitions aren't brought back.
+ALTER TABLE "entries" RENAME TO "entries_partitioned";
+ALTER TABLE "transfers" RENAME TO "transfers_partitioned";
+
+CREATE TABLE "entries" (
+  "id" bigint PRIMARY KEY DEFAULT nextval('entries_id_seq'),
+  "account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT 'now()',
+  "description" varchar NOT NULL DEFAULT '',
+  "reference" varchar NOT NULL DEFAULT '',
+  "metadata" jsonb NOT NULL DEFAULT '{}',
+  "journal_id" bigint
+);
+
+CREATE TABLE "transfers" (
+  "id" bigint PRIMARY KEY DEFAULT nextval('transfers_id_seq'),
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT 'now()',
+  "description" varchar NOT NULL DEFAULT '',
+  "reference" varchar NOT NULL DEFAULT '',
+  "metadata" jsonb NOT NULL DEFAULT '{}'
+);
+
+ALTER SEQUENCE "entries_id_seq" OWNED BY "entries"."id";
+ALTER SEQUENCE "transfers_id_seq" OWNED BY "transfers"."id";
+
+INSERT INTO "entries" SELECT * FROM "entries_partitioned";
+INSERT INTO "transfers" SELECT * FROM "transfers_partitioned";
+
+DROP TABLE "entries_partitioned";
+DROP TABLE "transfers_partitioned";
+
+CREATE INDEX ON "entries" ("account_id");
+CREATE INDEX ON "entries" ("journal_id");
+
+CREATE INDEX ON "transfers" ("from_account_id");
+CREATE INDEX ON "transfers" ("to_account_id");
+CREATE INDEX ON "transfers" ("from_account_id", "to_account_id");
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+CREATE INDEX "transfers_reference_idx" ON "transfers" ("reference") WHERE "reference" <> '';
+
+COMMENT ON COLUMN "entries"."amount" IS 'can be negative or positive.';
+COMMENT ON COLUMN "entries"."journal_id" IS 'null for entries booked before the ledger existed.';
+COMMENT ON COLUMN "transfers"."amount" IS 'must be positive.';
+COMMENT ON COLUMN "transfers"."reference" IS 'external reference provided by the client, empty if none.';
+
+ALTER TABLE "entries" ADD CONSTRAINT "entries_account_id_fkey" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "entries" ADD CONSTRAINT "entries_journal_id_fkey" FOREIGN KEY ("journal_id") REFERENCES "journal_transactions" ("id");
+ALTER TABLE "transfers" ADD CONSTRAINT "transfers_from_account_id_fkey" FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "transfers" ADD CONSTRAINT "transfers_to_account_id_fkey" FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_accruals" ADD CONSTRAINT "interest_accruals_entry_id_fkey" FOREIGN KEY ("entry_id") REFERENCES "entries" ("id");
+
+DROP FUNCTION "create_monthly_partition"(text, date);
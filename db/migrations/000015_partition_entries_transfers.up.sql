@@ -0,0 +1,128 @@
+-- creates the partition of a table for the month of a day, with the bounds
+-- in UTC, and returns its name. Existing partitions are left untouched.
+CREATE FUNCTION "create_monthly_partition"("parent_table" text, "month" date) RETURNS text
+LANGUAGE plpgsql AS $$
+DECLARE
+  "from_date" date := date_trunc('month', "month")::date;
+  "partition_name" text := format('%s_y%sm%s', "parent_table", to_char("from_date", 'YYYY'), to_char("from_date", 'MM'));
+BEGIN
+  EXECUTE format(
+    'CREATE TABLE IF NOT EXISTS %I PARTITION OF %I FOR VALUES FROM (%L) TO (%L)',
+    "partition_name",
+    "parent_table",
+    "from_date"::timestamp AT TIME ZONE 'UTC',
+    ("from_date" + interval '1 month')::timestamp AT TIME ZONE 'UTC'
+  );
+  RETURN "partition_name";
+END;
+$$;
+
+-- the entries are archived by partition, so nothing can reference one with a
+-- foreign key, which would also need its created_at.
+ALTER TABLE "interest_accruals" DROP CONSTRAINT "interest_accruals_entry_id_fkey";
+
+ALTER TABLE "entries" RENAME TO "entries_unpartitioned";
+ALTER TABLE "transfers" RENAME TO "transfers_unpartitioned";
+
+CREATE TABLE "entries" (
+  "id" bigint NOT NULL DEFAULT nextval('entries_id_seq'),
+  "account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT 'now()',
+  "description" varchar NOT NULL DEFAULT '',
+  "reference" varchar NOT NULL DEFAULT '',
+  "metadata" jsonb NOT NULL DEFAULT '{}',
+  "journal_id" bigint
+) PARTITION BY RANGE ("created_at");
+
+CREATE TABLE "transfers" (
+  "id" bigint NOT NULL DEFAULT nextval('transfers_id_seq'),
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT 'now()',
+  "description" varchar NOT NULL DEFAULT '',
+  "reference" varchar NOT NULL DEFAULT '',
+  "metadata" jsonb NOT NULL DEFAULT '{}'
+) PARTITION BY RANGE ("created_at");
+
+ALTER SEQUENCE "entries_id_seq" OWNED BY "entries"."id";
+ALTER SEQUENCE "transfers_id_seq" OWNED BY "transfers"."id";
+
+-- the partitions cover the existing rows and the next months, the partition
+-- job keeps creating them ahead. The default partitions catch the rows of a
+-- month the job missed.
+SELECT "create_monthly_partition"("parent_table", "month"::date)
+FROM (
+  SELECT 'entries' AS "parent_table", min("created_at") AS "first" FROM "entries_unpartitioned"
+  UNION ALL
+  SELECT 'transfers', min("created_at") FROM "transfers_unpartitioned"
+) AS "tables"
+CROSS JOIN LATERAL generate_series(
+  date_trunc('month', COALESCE("first", now()) AT TIME ZONE 'UTC'),
+  date_trunc('month', now() AT TIME ZONE 'UTC') + interval '3 months',
+  interval '1 month'
+) AS "month";
+
+-- like the monthly partitions, they are created dynamically, so that they
+-- stay out of the generated models.
+DO $$
+BEGIN
+  EXECUTE 'CREATE TABLE "entries_default" PARTITION OF "entries" DEFAULT';
+  EXECUTE 'CREATE TABLE "transfers_default" PARTITION OF "transfers" DEFAULT';
+END;
+$$;
+
+INSERT INTO "entries" ("id", "account_id", "amount", "created_at", "description", "reference", "metadata", "journal_id")
+SELECT "id", "account_id", "amount", "created_at", "description", "reference", "metadata", "journal_id"
+FROM "entries_unpartitioned";
+
+INSERT INTO "transfers" ("id", "from_account_id", "to_account_id", "amount", "created_at", "description", "reference", "metadata")
+SELECT "id", "from_account_id", "to_account_id", "amount", "created_at", "description", "reference", "metadata"
+FROM "transfers_unpartitioned";
+
+DROP TABLE "entries_unpartitioned";
+DROP TABLE "transfers_unpartitioned";
+
+-- a partitioned table's keys must hold the partition key.
+ALTER TABLE "entries" ADD PRIMARY KEY ("id", "created_at");
+ALTER TABLE "transfers" ADD PRIMARY KEY ("id", "created_at");
+
+CREATE INDEX ON "entries" ("account_id");
+CREATE INDEX ON "entries" ("journal_id");
+
+CREATE INDEX ON "transfers" ("from_account_id");
+CREATE INDEX ON "transfers" ("to_account_id");
+CREATE INDEX ON "transfers" ("from_account_id", "to_account_id");
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+CREATE INDEX "transfers_reference_idx" ON "transfers" ("reference") WHERE "reference" <> '';
+
+COMMENT ON COLUMN "entries"."amount" IS 'can be negative or positive.';
+COMMENT ON COLUMN "entries"."journal_id" IS 'null for entries booked before the ledger existed.';
+COMMENT ON COLUMN "transfers"."amount" IS 'must be positive.';
+COMMENT ON COLUMN "transfers"."reference" IS 'external reference provided by the client, empty if none.';
+
+ALTER TABLE "entries" ADD CONSTRAINT "entries_account_id_fkey" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "entries" ADD CONSTRAINT "entries_journal_id_fkey" FOREIGN KEY ("journal_id") REFERENCES "journal_transactions" ("id");
+ALTER TABLE "transfers" ADD CONSTRAINT "transfers_from_account_id_fkey" FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "transfers" ADD CONSTRAINT "transfers_to_account_id_fkey" FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+CREATE TABLE "archived_partitions" (
+  "name" varchar PRIMARY KEY,
+  "parent_table" varchar NOT NULL,
+  "month" date NOT NULL,
+  "file" varchar NOT NULL,
+  "rows" bigint NOT NULL,
+  "archived_at" timestamptz NOT NULL DEFAULT 'now()'
+);
+
+COMMENT ON TABLE "archived_partitions" IS 'monthly partitions exported to a file and detached.';
+
+CREATE TABLE "archived_entry_totals" (
+  "account_id" bigint PRIMARY KEY,
+  "amount" bigint NOT NULL
+);
+
+COMMENT ON TABLE "archived_entry_totals" IS 'sum of the archived entries of each account, so the balances still reconcile.';
+
+ALTER TABLE "archived_entry_totals" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
@@ -0,0 +1,3 @@
+ALTER TABLE "accounts" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN "accounts"."version" IS 'incremented on every change of the account, for optimistic concurrency.';
@@ -0,0 +1,8 @@
+-- the encrypted values are left as they are, they need the keys to be read.
+DROP INDEX IF EXISTS "email_key";
+ALTER TABLE IF EXISTS "users" ADD CONSTRAINT "email_key" UNIQUE ("email");
+
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "email_hash";
+
+COMMENT ON COLUMN "users"."full_name" IS NULL;
+COMMENT ON COLUMN "users"."email" IS NULL;
@@ -0,0 +1,10 @@
+ALTER TABLE "users" ADD COLUMN "email_hash" bytea;
+
+COMMENT ON COLUMN "users"."full_name" IS 'encrypted and decrypted by the Store when encryption keys are configured.';
+COMMENT ON COLUMN "users"."email" IS 'encrypted and decrypted by the Store when encryption keys are configured.';
+COMMENT ON COLUMN "users"."email_hash" IS 'blind index of the email, its HMAC-SHA256, null while the email is in plaintext.';
+
+-- the encrypted emails differ for the same address, the unique constraint is
+-- on their blind index, and on the plaintext emails not encrypted yet.
+ALTER TABLE "users" DROP CONSTRAINT "email_key";
+CREATE UNIQUE INDEX "email_key" ON "users" ((COALESCE(encode("email_hash", 'hex'), "email")));
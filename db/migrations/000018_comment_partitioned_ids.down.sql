@@ -0,0 +1,2 @@
+COMMENT ON COLUMN "entries"."id" IS NULL;
+COMMENT ON COLUMN "transfers"."id" IS NULL;
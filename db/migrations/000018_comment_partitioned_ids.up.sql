@@ -0,0 +1,5 @@
+-- a partitioned table's keys must hold the partition key, so nothing
+-- constrains the ids alone. They stay unique as long as only the sequences
+-- give them, which the lookups by id rely on.
+COMMENT ON COLUMN "entries"."id" IS 'unique across the partitions: given by entries_id_seq only, never set explicitly.';
+COMMENT ON COLUMN "transfers"."id" IS 'unique across the partitions: given by transfers_id_seq only, never set explicitly.';
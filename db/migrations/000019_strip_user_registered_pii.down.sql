@@ -0,0 +1 @@
+-- the personal data removed from the events isn't restored.
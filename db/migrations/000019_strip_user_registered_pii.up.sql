@@ -0,0 +1,5 @@
+-- the UserRegistered events only hold the username now, the personal data of
+-- the users is kept encrypted in their table.
+UPDATE "outbox_events"
+SET "payload" = "payload" - 'full_name' - 'email'
+WHERE "event_type" = 'UserRegistered';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "outbox_events_unpublished_idx";
+CREATE INDEX "outbox_events_unpublished_idx" ON "outbox_events" ("id") WHERE "published_at" IS NULL;
+
+ALTER TABLE IF EXISTS "outbox_events" DROP COLUMN IF EXISTS "dead_at";
+ALTER TABLE IF EXISTS "outbox_events" DROP COLUMN IF EXISTS "next_attempt_at";
@@ -0,0 +1,8 @@
+ALTER TABLE "outbox_events" ADD COLUMN "next_attempt_at" timestamptz NOT NULL DEFAULT 'now()';
+ALTER TABLE "outbox_events" ADD COLUMN "dead_at" timestamptz;
+
+COMMENT ON COLUMN "outbox_events"."next_attempt_at" IS 'failed events are retried with exponential backoff, not before this time.';
+COMMENT ON COLUMN "outbox_events"."dead_at" IS 'set once the event failed too many times, it is not retried anymore.';
+
+DROP INDEX "outbox_events_unpublished_idx";
+CREATE INDEX "outbox_events_unpublished_idx" ON "outbox_events" ("id") WHERE "published_at" IS NULL AND "dead_at" IS NULL;
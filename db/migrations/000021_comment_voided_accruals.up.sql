@@ -0,0 +1 @@
+COMMENT ON COLUMN "interest_accruals"."entry_id" IS 'entry that paid the interest, null if it rounded to zero or was voided when the account was deleted.';
@@ -0,0 +1,16 @@
+CREATE OR REPLACE FUNCTION "create_monthly_partition"("parent_table" text, "month" date) RETURNS text
+LANGUAGE plpgsql AS $$
+DECLARE
+  "from_date" date := date_trunc('month', "month")::date;
+  "partition_name" text := format('%s_y%sm%s', "parent_table", to_char("from_date", 'YYYY'), to_char("from_date", 'MM'));
+BEGIN
+  EXECUTE format(
+    'CREATE TABLE IF NOT EXISTS %I PARTITION OF %I FOR VALUES FROM (%L) TO (%L)',
+    "partition_name",
+    "parent_table",
+    "from_date"::timestamp AT TIME ZONE 'UTC',
+    ("from_date" + interval '1 month')::timestamp AT TIME ZONE 'UTC'
+  );
+  RETURN "partition_name";
+END;
+$$;
@@ -0,0 +1,40 @@
+-- a partition can't be attached while the default partition holds rows of
+-- its month, so they are moved to the new partition before it is attached.
+-- The default partition is locked meanwhile, so no row of the month can land
+-- in it.
+CREATE OR REPLACE FUNCTION "create_monthly_partition"("parent_table" text, "month" date) RETURNS text
+LANGUAGE plpgsql AS $$
+DECLARE
+  "from_date" date := date_trunc('month', "month")::date;
+  "from_time" timestamptz := "from_date"::timestamp AT TIME ZONE 'UTC';
+  "to_time" timestamptz := ("from_date" + interval '1 month')::timestamp AT TIME ZONE 'UTC';
+  "partition_name" text := format('%s_y%sm%s', "parent_table", to_char("from_date", 'YYYY'), to_char("from_date", 'MM'));
+  "default_name" text := "parent_table" || '_default';
+BEGIN
+  IF to_regclass(quote_ident("partition_name")) IS NOT NULL THEN
+    RETURN "partition_name";
+  END IF;
+
+  EXECUTE format('LOCK TABLE %I IN ACCESS EXCLUSIVE MODE', "default_name");
+  EXECUTE format(
+    'CREATE TABLE %I (LIKE %I INCLUDING DEFAULTS INCLUDING CONSTRAINTS)',
+    "partition_name",
+    "parent_table"
+  );
+  EXECUTE format(
+    'WITH "moved" AS (DELETE FROM %I WHERE "created_at" >= %L AND "created_at" < %L RETURNING *) INSERT INTO %I SELECT * FROM "moved"',
+    "default_name",
+    "from_time",
+    "to_time",
+    "partition_name"
+  );
+  EXECUTE format(
+    'ALTER TABLE %I ATTACH PARTITION %I FOR VALUES FROM (%L) TO (%L)',
+    "parent_table",
+    "partition_name",
+    "from_time",
+    "to_time"
+  );
+  RETURN "partition_name";
+END;
+$$;
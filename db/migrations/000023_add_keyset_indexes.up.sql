@@ -0,0 +1,3 @@
+CREATE INDEX "entries_created_at_id_idx" ON "entries" ("created_at", "id");
+CREATE INDEX "entries_account_id_created_at_id_idx" ON "entries" ("account_id", "created_at", "id");
+CREATE INDEX "transfers_created_at_id_idx" ON "transfers" ("created_at", "id");
@@ -0,0 +1,100 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Returned by Run when the arguments are invalid, after printing the usage.
+var ErrUsage = errors.New("invalid usage")
+
+// Runs the migrate subcommand named by args[0]. up applies the pending
+// migrations and down reverts the last one; either takes how many migrations
+// to run, or all, as its argument.
+func Run(ctx context.Context, pool *pgxpool.Pool, out io.Writer, args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		printUsage(out)
+		return ErrUsage
+	}
+
+	// n is 0 for all the migrations, so only force takes a 0 argument.
+	var arg int64
+	if len(args) == 2 && args[1] != "all" {
+		var err error
+		arg, err = strconv.ParseInt(args[1], 10, 64)
+		if err != nil || arg < 0 || (arg == 0 && args[0] != "force") {
+			fmt.Fprintf(out, "invalid argument %q\n\n", args[1])
+			printUsage(out)
+			return ErrUsage
+		}
+	}
+
+	switch args[0] {
+	case "up":
+		return Up(ctx, pool, int(arg))
+	case "down":
+		if len(args) == 1 {
+			arg = 1
+		}
+		return Down(ctx, pool, int(arg))
+	case "force":
+		if len(args) == 1 || args[1] == "all" {
+			printUsage(out)
+			return ErrUsage
+		}
+		return Force(ctx, pool, arg)
+	case "status":
+		if len(args) == 2 {
+			printUsage(out)
+			return ErrUsage
+		}
+		status, err := GetStatus(ctx, pool)
+		if err != nil {
+			return err
+		}
+		printStatus(out, status)
+		return nil
+	case "help", "-h", "--help":
+		printUsage(out)
+		return nil
+	}
+
+	fmt.Fprintf(out, "unknown migrate command %q\n\n", args[0])
+	printUsage(out)
+	return ErrUsage
+}
+
+func printStatus(out io.Writer, status Status) {
+	for _, migration := range status.Migrations {
+		state := "pending"
+		switch {
+		case migration.Version == status.Version && status.Dirty:
+			state = "dirty"
+		case migration.Version <= status.Version:
+			state = "applied"
+		}
+		fmt.Fprintf(out, "%06d  %-8s %s\n", migration.Version, state, migration.Name)
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "version %d", status.Version)
+	if status.Dirty {
+		fmt.Fprint(out, " (dirty)")
+	}
+	fmt.Fprintln(out)
+}
+
+func printUsage(out io.Writer) {
+	fmt.Fprintln(out, "usage: simplebank migrate <command> [n | all]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "commands:")
+	fmt.Fprintf(out, "  %-18s %s\n", "up [n | all]", "applies the next n pending migrations, all of them by default")
+	fmt.Fprintf(out, "  %-18s %s\n", "down [n | all]", "reverts the last n migrations, one by default")
+	fmt.Fprintf(out, "  %-18s %s\n", "status", "lists the migrations and the version of the schema")
+	fmt.Fprintf(out, "  %-18s %s\n", "force <version>", "sets the version of a dirty schema after fixing it by hand")
+}
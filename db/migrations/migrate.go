@@ -0,0 +1,284 @@
+// Package migrations embeds the schema migrations of the database and applies
+// them. The version is kept in the schema_migrations table of the migrate CLI,
+// so that databases migrated with either stay compatible.
+package migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Key of the advisory lock held while migrating, so that servers starting
+// together don't apply the same migration twice.
+const lockKey = 7346395611892311000
+
+// Returned when a previous migration failed halfway. The schema has to be
+// fixed by hand and its version forced before migrating again.
+var ErrDirty = errors.New("database schema is dirty")
+
+// Migration of the schema, read from its up and down files.
+type Migration struct {
+	Version int64
+	Name    string
+	up      string
+	down    string
+}
+
+// State of the schema. Version is 0 before the first migration.
+type Status struct {
+	Version    int64
+	Dirty      bool
+	Migrations []Migration
+}
+
+// Returns the embedded migrations, sorted by version.
+func Load() ([]Migration, error) {
+	return load(files)
+}
+
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		base, ok := strings.CutSuffix(entry.Name(), ".sql")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		base, direction, _ := strings.Cut(base, ".")
+		versionText, name, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(versionText, 10, 64)
+		if err != nil || version <= 0 || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration := byVersion[version]
+		if migration == nil {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, name)
+		}
+		if direction == "up" {
+			migration.up = string(content)
+		} else {
+			migration.down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Applies the next n pending migrations, or all of them if n isn't positive.
+func Up(ctx context.Context, pool *pgxpool.Pool, n int) error {
+	migrations, err := Load()
+	if err != nil {
+		return err
+	}
+
+	return withLock(ctx, pool, func(conn *pgx.Conn) error {
+		version, err := cleanVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		applied := 0
+		for _, migration := range migrations {
+			if migration.Version <= version {
+				continue
+			}
+			if n > 0 && applied == n {
+				break
+			}
+
+			err := apply(ctx, conn, migration.Version, migration.up)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			log.Printf("applied migration %d_%s", migration.Version, migration.Name)
+			applied++
+		}
+		return nil
+	})
+}
+
+// Reverts the last n applied migrations, or all of them if n isn't positive.
+func Down(ctx context.Context, pool *pgxpool.Pool, n int) error {
+	migrations, err := Load()
+	if err != nil {
+		return err
+	}
+
+	return withLock(ctx, pool, func(conn *pgx.Conn) error {
+		version, err := cleanVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		reverted := 0
+		for i := len(migrations) - 1; i >= 0; i-- {
+			migration := migrations[i]
+			if migration.Version > version {
+				continue
+			}
+			if n > 0 && reverted == n {
+				break
+			}
+
+			// the schema goes back to the version of the previous migration.
+			var previous int64
+			if i > 0 {
+				previous = migrations[i-1].Version
+			}
+			err := apply(ctx, conn, previous, migration.down)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			log.Printf("reverted migration %d_%s", migration.Version, migration.Name)
+			reverted++
+		}
+		return nil
+	})
+}
+
+// Sets the version of the schema and clears its dirty flag, without running
+// any migration. It is meant to recover from a failed migration once the
+// schema has been fixed by hand.
+func Force(ctx context.Context, pool *pgxpool.Pool, version int64) error {
+	return withLock(ctx, pool, func(conn *pgx.Conn) error {
+		if err := createVersionTable(ctx, conn); err != nil {
+			return err
+		}
+		return setVersion(ctx, conn, version, false)
+	})
+}
+
+// Returns the version of the schema with the embedded migrations.
+func GetStatus(ctx context.Context, pool *pgxpool.Pool) (Status, error) {
+	migrations, err := Load()
+	if err != nil {
+		return Status{}, err
+	}
+
+	status := Status{Migrations: migrations}
+	err = withLock(ctx, pool, func(conn *pgx.Conn) error {
+		var err error
+		status.Version, status.Dirty, err = getVersion(ctx, conn)
+		return err
+	})
+	return status, err
+}
+
+// Runs fn on a connection of the pool while holding the migration lock.
+func withLock(ctx context.Context, pool *pgxpool.Pool, fn func(*pgx.Conn) error) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockKey)
+	if err != nil {
+		return fmt.Errorf("cannot lock migrations: %w", err)
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+
+	return fn(conn.Conn())
+}
+
+// Returns the version of the schema, failing if it is dirty.
+func cleanVersion(ctx context.Context, conn *pgx.Conn) (int64, error) {
+	if err := createVersionTable(ctx, conn); err != nil {
+		return 0, err
+	}
+
+	version, dirty, err := getVersion(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w at version %d: fix the schema, then run migrate force <version>", ErrDirty, version)
+	}
+	return version, nil
+}
+
+// Runs a migration script, marking the schema dirty at the target version
+// until it succeeds.
+func apply(ctx context.Context, conn *pgx.Conn, version int64, script string) error {
+	if err := setVersion(ctx, conn, version, true); err != nil {
+		return err
+	}
+
+	// scripts hold several statements, which only the simple protocol runs.
+	_, err := conn.Exec(ctx, script, pgx.QueryExecModeSimpleProtocol)
+	if err != nil {
+		return err
+	}
+	return setVersion(ctx, conn, version, false)
+}
+
+func createVersionTable(ctx context.Context, conn *pgx.Conn) error {
+	_, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint NOT NULL PRIMARY KEY,
+		dirty boolean NOT NULL
+	)`)
+	return err
+}
+
+// Returns the version of the schema and whether it is dirty. A database that
+// was never migrated is at version 0.
+func getVersion(ctx context.Context, conn *pgx.Conn) (version int64, dirty bool, err error) {
+	var exists bool
+	err = conn.QueryRow(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists)
+	if err != nil || !exists {
+		return 0, false, err
+	}
+
+	err = conn.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// Replaces the version of the schema. Version 0 is stored as no row, like the
+// migrate CLI does.
+func setVersion(ctx context.Context, conn *pgx.Conn, version int64, dirty bool) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "TRUNCATE schema_migrations")
+		if err != nil || version == 0 {
+			return err
+		}
+		_, err = tx.Exec(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)", version, dirty)
+		return err
+	})
+}
@@ -0,0 +1,131 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	// every embedded migration can be applied and reverted, in order.
+	for i, migration := range migrations {
+		require.Equal(t, int64(i+1), migration.Version)
+		require.NotEmpty(t, migration.Name)
+		require.NotEmpty(t, migration.up)
+		require.NotEmpty(t, migration.down)
+	}
+	require.Equal(t, "init_schema", migrations[0].Name)
+}
+
+func TestLoadFiles(t *testing.T) {
+	testCases := []struct {
+		name          string
+		files         fstest.MapFS
+		checkResponse func(t *testing.T, migrations []Migration, err error)
+	}{
+		{
+			name: "Sorted",
+			files: fstest.MapFS{
+				"000010_add_b.up.sql":   {Data: []byte("b up")},
+				"000010_add_b.down.sql": {Data: []byte("b down")},
+				"000002_add_a.up.sql":   {Data: []byte("a up")},
+				"000002_add_a.down.sql": {Data: []byte("a down")},
+				"migrate.go":            {Data: []byte("package migrations")},
+			},
+			checkResponse: func(t *testing.T, migrations []Migration, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []Migration{
+					{Version: 2, Name: "add_a", up: "a up", down: "a down"},
+					{Version: 10, Name: "add_b", up: "b up", down: "b down"},
+				}, migrations)
+			},
+		},
+		{
+			name: "InvalidVersion",
+			files: fstest.MapFS{
+				"first_add_a.up.sql": {Data: []byte("a up")},
+			},
+			checkResponse: func(t *testing.T, migrations []Migration, err error) {
+				require.ErrorContains(t, err, "invalid migration file name")
+			},
+		},
+		{
+			name: "InvalidDirection",
+			files: fstest.MapFS{
+				"000001_add_a.sideways.sql": {Data: []byte("a up")},
+			},
+			checkResponse: func(t *testing.T, migrations []Migration, err error) {
+				require.ErrorContains(t, err, "invalid migration file name")
+			},
+		},
+		{
+			name: "TwoNames",
+			files: fstest.MapFS{
+				"000001_add_a.up.sql":   {Data: []byte("a up")},
+				"000001_add_b.down.sql": {Data: []byte("b down")},
+			},
+			checkResponse: func(t *testing.T, migrations []Migration, err error) {
+				require.ErrorContains(t, err, "migration 1 has two names")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			migrations, err := load(tc.files)
+			tc.checkResponse(t, migrations, err)
+		})
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	testCases := []struct {
+		name string
+		args []string
+		out  string
+	}{
+		{name: "NoCommand", args: nil, out: "usage: simplebank migrate"},
+		{name: "UnknownCommand", args: []string{"sideways"}, out: `unknown migrate command "sideways"`},
+		{name: "InvalidCount", args: []string{"up", "many"}, out: `invalid argument "many"`},
+		{name: "ZeroCount", args: []string{"down", "0"}, out: `invalid argument "0"`},
+		{name: "ForceWithoutVersion", args: []string{"force"}, out: "force <version>"},
+		{name: "StatusWithArgument", args: []string{"status", "1"}, out: "usage: simplebank migrate"},
+		{name: "TooManyArguments", args: []string{"up", "1", "2"}, out: "usage: simplebank migrate"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := Run(context.Background(), nil, &out, tc.args)
+			require.ErrorIs(t, err, ErrUsage)
+			require.Contains(t, out.String(), tc.out)
+		})
+	}
+}
+
+func TestPrintStatus(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Name: "init_schema"},
+		{Version: 2, Name: "add_users"},
+		{Version: 3, Name: "add_constraint_to_users"},
+	}
+
+	var out bytes.Buffer
+	printStatus(&out, Status{Version: 2, Dirty: true, Migrations: migrations})
+	require.Equal(t, "000001  applied  init_schema\n"+
+		"000002  dirty    add_users\n"+
+		"000003  pending  add_constraint_to_users\n"+
+		"\n"+
+		"version 2 (dirty)\n", out.String())
+}
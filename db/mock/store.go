@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/kevtl/simplebank/db/sqlc (interfaces: Store)
+// Source: github.com/kvgtl/simplebank/db/sqlc (interfaces: Store)
 //
 // Generated by this command:
 //
-//	mockgen -package mockdb -destination db/mock/store.go github.com/kevtl/simplebank/db/sqlc Store
+//	mockgen -package mockdb -destination db/mock/store.go github.com/kvgtl/simplebank/db/sqlc Store
 //
 
 // Package mockdb is a generated GoMock package.
@@ -11,8 +11,11 @@ package mockdb
 
 import (
 	context "context"
+	sql "database/sql"
 	reflect "reflect"
+	time "time"
 
+	uuid "github.com/google/uuid"
 	db "github.com/kvgtl/simplebank/db/sqlc"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -55,6 +58,36 @@ func (mr *MockStoreMockRecorder) AddAccountBalance(arg0, arg1 any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountBalance", reflect.TypeOf((*MockStore)(nil).AddAccountBalance), arg0, arg1)
 }
 
+// AnonymizeUser mocks base method.
+func (m *MockStore) AnonymizeUser(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnonymizeUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AnonymizeUser indicates an expected call of AnonymizeUser.
+func (mr *MockStoreMockRecorder) AnonymizeUser(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnonymizeUser", reflect.TypeOf((*MockStore)(nil).AnonymizeUser), arg0, arg1)
+}
+
+// ClaimDueWebhookDeliveries mocks base method.
+func (m *MockStore) ClaimDueWebhookDeliveries(arg0 context.Context, arg1 db.ClaimDueWebhookDeliveriesParams) ([]db.ClaimDueWebhookDeliveriesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDueWebhookDeliveries", arg0, arg1)
+	ret0, _ := ret[0].([]db.ClaimDueWebhookDeliveriesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDueWebhookDeliveries indicates an expected call of ClaimDueWebhookDeliveries.
+func (mr *MockStoreMockRecorder) ClaimDueWebhookDeliveries(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDueWebhookDeliveries", reflect.TypeOf((*MockStore)(nil).ClaimDueWebhookDeliveries), arg0, arg1)
+}
+
 // CreateAccount mocks base method.
 func (m *MockStore) CreateAccount(arg0 context.Context, arg1 db.CreateAccountParams) (db.Account, error) {
 	m.ctrl.T.Helper()
@@ -70,6 +103,36 @@ func (mr *MockStoreMockRecorder) CreateAccount(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockStore)(nil).CreateAccount), arg0, arg1)
 }
 
+// CreateAccountTx mocks base method.
+func (m *MockStore) CreateAccountTx(arg0 context.Context, arg1 db.CreateAccountParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccountTx", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccountTx indicates an expected call of CreateAccountTx.
+func (mr *MockStoreMockRecorder) CreateAccountTx(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccountTx", reflect.TypeOf((*MockStore)(nil).CreateAccountTx), arg0, arg1)
+}
+
+// CreateArchivedPartition mocks base method.
+func (m *MockStore) CreateArchivedPartition(arg0 context.Context, arg1 db.CreateArchivedPartitionParams) (db.ArchivedPartition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateArchivedPartition", arg0, arg1)
+	ret0, _ := ret[0].(db.ArchivedPartition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateArchivedPartition indicates an expected call of CreateArchivedPartition.
+func (mr *MockStoreMockRecorder) CreateArchivedPartition(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateArchivedPartition", reflect.TypeOf((*MockStore)(nil).CreateArchivedPartition), arg0, arg1)
+}
+
 // CreateEntry mocks base method.
 func (m *MockStore) CreateEntry(arg0 context.Context, arg1 db.CreateEntryParams) (db.Entry, error) {
 	m.ctrl.T.Helper()
@@ -85,6 +148,66 @@ func (mr *MockStoreMockRecorder) CreateEntry(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEntry", reflect.TypeOf((*MockStore)(nil).CreateEntry), arg0, arg1)
 }
 
+// CreateInterestAccrual mocks base method.
+func (m *MockStore) CreateInterestAccrual(arg0 context.Context, arg1 db.CreateInterestAccrualParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInterestAccrual", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateInterestAccrual indicates an expected call of CreateInterestAccrual.
+func (mr *MockStoreMockRecorder) CreateInterestAccrual(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInterestAccrual", reflect.TypeOf((*MockStore)(nil).CreateInterestAccrual), arg0, arg1)
+}
+
+// CreateJournalTransaction mocks base method.
+func (m *MockStore) CreateJournalTransaction(arg0 context.Context, arg1 db.CreateJournalTransactionParams) (db.JournalTransaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateJournalTransaction", arg0, arg1)
+	ret0, _ := ret[0].(db.JournalTransaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateJournalTransaction indicates an expected call of CreateJournalTransaction.
+func (mr *MockStoreMockRecorder) CreateJournalTransaction(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateJournalTransaction", reflect.TypeOf((*MockStore)(nil).CreateJournalTransaction), arg0, arg1)
+}
+
+// CreateOutboxEvent mocks base method.
+func (m *MockStore) CreateOutboxEvent(arg0 context.Context, arg1 db.CreateOutboxEventParams) (db.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOutboxEvent", arg0, arg1)
+	ret0, _ := ret[0].(db.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOutboxEvent indicates an expected call of CreateOutboxEvent.
+func (mr *MockStoreMockRecorder) CreateOutboxEvent(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOutboxEvent", reflect.TypeOf((*MockStore)(nil).CreateOutboxEvent), arg0, arg1)
+}
+
+// CreateSession mocks base method.
+func (m *MockStore) CreateSession(arg0 context.Context, arg1 db.CreateSessionParams) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockStoreMockRecorder) CreateSession(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStore)(nil).CreateSession), arg0, arg1)
+}
+
 // CreateTransfer mocks base method.
 func (m *MockStore) CreateTransfer(arg0 context.Context, arg1 db.CreateTransferParams) (db.Transfer, error) {
 	m.ctrl.T.Helper()
@@ -115,6 +238,51 @@ func (mr *MockStoreMockRecorder) CreateUser(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0, arg1)
 }
 
+// CreateUserTx mocks base method.
+func (m *MockStore) CreateUserTx(arg0 context.Context, arg1 db.CreateUserParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserTx", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUserTx indicates an expected call of CreateUserTx.
+func (mr *MockStoreMockRecorder) CreateUserTx(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserTx", reflect.TypeOf((*MockStore)(nil).CreateUserTx), arg0, arg1)
+}
+
+// CreateWebhook mocks base method.
+func (m *MockStore) CreateWebhook(arg0 context.Context, arg1 db.CreateWebhookParams) (db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", arg0, arg1)
+	ret0, _ := ret[0].(db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockStoreMockRecorder) CreateWebhook(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockStore)(nil).CreateWebhook), arg0, arg1)
+}
+
+// CreateWebhookDelivery mocks base method.
+func (m *MockStore) CreateWebhookDelivery(arg0 context.Context, arg1 db.CreateWebhookDeliveryParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookDelivery", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookDelivery indicates an expected call of CreateWebhookDelivery.
+func (mr *MockStoreMockRecorder) CreateWebhookDelivery(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookDelivery", reflect.TypeOf((*MockStore)(nil).CreateWebhookDelivery), arg0, arg1)
+}
+
 // DeleteAccount mocks base method.
 func (m *MockStore) DeleteAccount(arg0 context.Context, arg1 int64) error {
 	m.ctrl.T.Helper()
@@ -129,6 +297,35 @@ func (mr *MockStoreMockRecorder) DeleteAccount(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockStore)(nil).DeleteAccount), arg0, arg1)
 }
 
+// DeleteAccountLimit mocks base method.
+func (m *MockStore) DeleteAccountLimit(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccountLimit", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccountLimit indicates an expected call of DeleteAccountLimit.
+func (mr *MockStoreMockRecorder) DeleteAccountLimit(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccountLimit", reflect.TypeOf((*MockStore)(nil).DeleteAccountLimit), arg0, arg1)
+}
+
+// DeleteAccountTx mocks base method.
+func (m *MockStore) DeleteAccountTx(arg0 context.Context, arg1 db.DeleteAccountTxParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccountTx", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAccountTx indicates an expected call of DeleteAccountTx.
+func (mr *MockStoreMockRecorder) DeleteAccountTx(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccountTx", reflect.TypeOf((*MockStore)(nil).DeleteAccountTx), arg0, arg1)
+}
+
 // DeleteEntry mocks base method.
 func (m *MockStore) DeleteEntry(arg0 context.Context, arg1 int64) error {
 	m.ctrl.T.Helper()
@@ -143,6 +340,36 @@ func (mr *MockStoreMockRecorder) DeleteEntry(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEntry", reflect.TypeOf((*MockStore)(nil).DeleteEntry), arg0, arg1)
 }
 
+// DeleteExpiredSessions mocks base method.
+func (m *MockStore) DeleteExpiredSessions(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredSessions", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredSessions indicates an expected call of DeleteExpiredSessions.
+func (mr *MockStoreMockRecorder) DeleteExpiredSessions(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredSessions", reflect.TypeOf((*MockStore)(nil).DeleteExpiredSessions), arg0, arg1)
+}
+
+// DeleteFinishedWebhookDeliveries mocks base method.
+func (m *MockStore) DeleteFinishedWebhookDeliveries(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFinishedWebhookDeliveries", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteFinishedWebhookDeliveries indicates an expected call of DeleteFinishedWebhookDeliveries.
+func (mr *MockStoreMockRecorder) DeleteFinishedWebhookDeliveries(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFinishedWebhookDeliveries", reflect.TypeOf((*MockStore)(nil).DeleteFinishedWebhookDeliveries), arg0, arg1)
+}
+
 // DeleteTransfer mocks base method.
 func (m *MockStore) DeleteTransfer(arg0 context.Context, arg1 int64) error {
 	m.ctrl.T.Helper()
@@ -157,6 +384,78 @@ func (mr *MockStoreMockRecorder) DeleteTransfer(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTransfer", reflect.TypeOf((*MockStore)(nil).DeleteTransfer), arg0, arg1)
 }
 
+// DeleteUserSessions mocks base method.
+func (m *MockStore) DeleteUserSessions(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserSessions", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserSessions indicates an expected call of DeleteUserSessions.
+func (mr *MockStoreMockRecorder) DeleteUserSessions(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserSessions", reflect.TypeOf((*MockStore)(nil).DeleteUserSessions), arg0, arg1)
+}
+
+// DeleteUserTx mocks base method.
+func (m *MockStore) DeleteUserTx(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserTx", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUserTx indicates an expected call of DeleteUserTx.
+func (mr *MockStoreMockRecorder) DeleteUserTx(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserTx", reflect.TypeOf((*MockStore)(nil).DeleteUserTx), arg0, arg1)
+}
+
+// DeleteUserWebhooks mocks base method.
+func (m *MockStore) DeleteUserWebhooks(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserWebhooks", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserWebhooks indicates an expected call of DeleteUserWebhooks.
+func (mr *MockStoreMockRecorder) DeleteUserWebhooks(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserWebhooks", reflect.TypeOf((*MockStore)(nil).DeleteUserWebhooks), arg0, arg1)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockStore) DeleteWebhook(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockStoreMockRecorder) DeleteWebhook(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockStore)(nil).DeleteWebhook), arg0, arg1)
+}
+
+// FreezeAccount mocks base method.
+func (m *MockStore) FreezeAccount(arg0 context.Context, arg1 db.FreezeAccountParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FreezeAccount", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FreezeAccount indicates an expected call of FreezeAccount.
+func (mr *MockStoreMockRecorder) FreezeAccount(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FreezeAccount", reflect.TypeOf((*MockStore)(nil).FreezeAccount), arg0, arg1)
+}
+
 // GetAccount mocks base method.
 func (m *MockStore) GetAccount(arg0 context.Context, arg1 int64) (db.Account, error) {
 	m.ctrl.T.Helper()
@@ -187,167 +486,956 @@ func (mr *MockStoreMockRecorder) GetAccountForUpdate(arg0, arg1 any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountForUpdate", reflect.TypeOf((*MockStore)(nil).GetAccountForUpdate), arg0, arg1)
 }
 
-// GetEntry mocks base method.
-func (m *MockStore) GetEntry(arg0 context.Context, arg1 int64) (db.Entry, error) {
+// GetAccountLimit mocks base method.
+func (m *MockStore) GetAccountLimit(arg0 context.Context, arg1 int64) (db.AccountLimit, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetEntry", arg0, arg1)
-	ret0, _ := ret[0].(db.Entry)
+	ret := m.ctrl.Call(m, "GetAccountLimit", arg0, arg1)
+	ret0, _ := ret[0].(db.AccountLimit)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetEntry indicates an expected call of GetEntry.
-func (mr *MockStoreMockRecorder) GetEntry(arg0, arg1 any) *gomock.Call {
+// GetAccountLimit indicates an expected call of GetAccountLimit.
+func (mr *MockStoreMockRecorder) GetAccountLimit(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntry", reflect.TypeOf((*MockStore)(nil).GetEntry), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountLimit", reflect.TypeOf((*MockStore)(nil).GetAccountLimit), arg0, arg1)
 }
 
-// GetTransfer mocks base method.
-func (m *MockStore) GetTransfer(arg0 context.Context, arg1 int64) (db.Transfer, error) {
+// GetDailyNewUsers mocks base method.
+func (m *MockStore) GetDailyNewUsers(arg0 context.Context, arg1 db.GetDailyNewUsersParams) ([]db.GetDailyNewUsersRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTransfer", arg0, arg1)
-	ret0, _ := ret[0].(db.Transfer)
+	ret := m.ctrl.Call(m, "GetDailyNewUsers", arg0, arg1)
+	ret0, _ := ret[0].([]db.GetDailyNewUsersRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetTransfer indicates an expected call of GetTransfer.
-func (mr *MockStoreMockRecorder) GetTransfer(arg0, arg1 any) *gomock.Call {
+// GetDailyNewUsers indicates an expected call of GetDailyNewUsers.
+func (mr *MockStoreMockRecorder) GetDailyNewUsers(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockStore)(nil).GetTransfer), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDailyNewUsers", reflect.TypeOf((*MockStore)(nil).GetDailyNewUsers), arg0, arg1)
 }
 
-// GetUser mocks base method.
-func (m *MockStore) GetUser(arg0 context.Context, arg1 string) (db.User, error) {
+// GetDailyTransferVolume mocks base method.
+func (m *MockStore) GetDailyTransferVolume(arg0 context.Context, arg1 db.GetDailyTransferVolumeParams) ([]db.GetDailyTransferVolumeRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
-	ret0, _ := ret[0].(db.User)
+	ret := m.ctrl.Call(m, "GetDailyTransferVolume", arg0, arg1)
+	ret0, _ := ret[0].([]db.GetDailyTransferVolumeRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUser indicates an expected call of GetUser.
-func (mr *MockStoreMockRecorder) GetUser(arg0, arg1 any) *gomock.Call {
+// GetDailyTransferVolume indicates an expected call of GetDailyTransferVolume.
+func (mr *MockStoreMockRecorder) GetDailyTransferVolume(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDailyTransferVolume", reflect.TypeOf((*MockStore)(nil).GetDailyTransferVolume), arg0, arg1)
 }
 
-// ListAccountEntries mocks base method.
-func (m *MockStore) ListAccountEntries(arg0 context.Context, arg1 db.ListAccountEntriesParams) ([]db.Entry, error) {
+// GetEntry mocks base method.
+func (m *MockStore) GetEntry(arg0 context.Context, arg1 int64) (db.Entry, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListAccountEntries", arg0, arg1)
-	ret0, _ := ret[0].([]db.Entry)
+	ret := m.ctrl.Call(m, "GetEntry", arg0, arg1)
+	ret0, _ := ret[0].(db.Entry)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListAccountEntries indicates an expected call of ListAccountEntries.
-func (mr *MockStoreMockRecorder) ListAccountEntries(arg0, arg1 any) *gomock.Call {
+// GetEntry indicates an expected call of GetEntry.
+func (mr *MockStoreMockRecorder) GetEntry(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountEntries", reflect.TypeOf((*MockStore)(nil).ListAccountEntries), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntry", reflect.TypeOf((*MockStore)(nil).GetEntry), arg0, arg1)
 }
 
-// ListAccounts mocks base method.
-func (m *MockStore) ListAccounts(arg0 context.Context, arg1 db.ListAccountsParams) ([]db.Account, error) {
+// GetInterestAccrual mocks base method.
+func (m *MockStore) GetInterestAccrual(arg0 context.Context, arg1 db.GetInterestAccrualParams) (db.InterestAccrual, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListAccounts", arg0, arg1)
-	ret0, _ := ret[0].([]db.Account)
+	ret := m.ctrl.Call(m, "GetInterestAccrual", arg0, arg1)
+	ret0, _ := ret[0].(db.InterestAccrual)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListAccounts indicates an expected call of ListAccounts.
-func (mr *MockStoreMockRecorder) ListAccounts(arg0, arg1 any) *gomock.Call {
+// GetInterestAccrual indicates an expected call of GetInterestAccrual.
+func (mr *MockStoreMockRecorder) GetInterestAccrual(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockStore)(nil).ListAccounts), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInterestAccrual", reflect.TypeOf((*MockStore)(nil).GetInterestAccrual), arg0, arg1)
 }
 
-// ListEntries mocks base method.
-func (m *MockStore) ListEntries(arg0 context.Context, arg1 db.ListEntriesParams) ([]db.Entry, error) {
+// GetJournalTransaction mocks base method.
+func (m *MockStore) GetJournalTransaction(arg0 context.Context, arg1 int64) (db.JournalTransaction, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListEntries", arg0, arg1)
-	ret0, _ := ret[0].([]db.Entry)
+	ret := m.ctrl.Call(m, "GetJournalTransaction", arg0, arg1)
+	ret0, _ := ret[0].(db.JournalTransaction)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListEntries indicates an expected call of ListEntries.
-func (mr *MockStoreMockRecorder) ListEntries(arg0, arg1 any) *gomock.Call {
+// GetJournalTransaction indicates an expected call of GetJournalTransaction.
+func (mr *MockStoreMockRecorder) GetJournalTransaction(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntries", reflect.TypeOf((*MockStore)(nil).ListEntries), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJournalTransaction", reflect.TypeOf((*MockStore)(nil).GetJournalTransaction), arg0, arg1)
 }
 
-// ListTransfers mocks base method.
-func (m *MockStore) ListTransfers(arg0 context.Context, arg1 db.ListTransfersParams) ([]db.Transfer, error) {
+// GetLastInterestAccrualDate mocks base method.
+func (m *MockStore) GetLastInterestAccrualDate(arg0 context.Context) (time.Time, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListTransfers", arg0, arg1)
-	ret0, _ := ret[0].([]db.Transfer)
+	ret := m.ctrl.Call(m, "GetLastInterestAccrualDate", arg0)
+	ret0, _ := ret[0].(time.Time)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListTransfers indicates an expected call of ListTransfers.
-func (mr *MockStoreMockRecorder) ListTransfers(arg0, arg1 any) *gomock.Call {
+// GetLastInterestAccrualDate indicates an expected call of GetLastInterestAccrualDate.
+func (mr *MockStoreMockRecorder) GetLastInterestAccrualDate(arg0 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockStore)(nil).ListTransfers), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastInterestAccrualDate", reflect.TypeOf((*MockStore)(nil).GetLastInterestAccrualDate), arg0)
 }
 
-// TransferTx mocks base method.
-func (m *MockStore) TransferTx(arg0 context.Context, arg1 db.TransferTxParams) (db.TransferTxResult, error) {
+// GetOutgoingTransfersTotal mocks base method.
+func (m *MockStore) GetOutgoingTransfersTotal(arg0 context.Context, arg1 db.GetOutgoingTransfersTotalParams) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TransferTx", arg0, arg1)
-	ret0, _ := ret[0].(db.TransferTxResult)
+	ret := m.ctrl.Call(m, "GetOutgoingTransfersTotal", arg0, arg1)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// TransferTx indicates an expected call of TransferTx.
-func (mr *MockStoreMockRecorder) TransferTx(arg0, arg1 any) *gomock.Call {
+// GetOutgoingTransfersTotal indicates an expected call of GetOutgoingTransfersTotal.
+func (mr *MockStoreMockRecorder) GetOutgoingTransfersTotal(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferTx", reflect.TypeOf((*MockStore)(nil).TransferTx), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOutgoingTransfersTotal", reflect.TypeOf((*MockStore)(nil).GetOutgoingTransfersTotal), arg0, arg1)
 }
 
-// UpdateAccount mocks base method.
-func (m *MockStore) UpdateAccount(arg0 context.Context, arg1 db.UpdateAccountParams) (db.Account, error) {
+// GetSession mocks base method.
+func (m *MockStore) GetSession(arg0 context.Context, arg1 uuid.UUID) (db.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateAccount", arg0, arg1)
-	ret0, _ := ret[0].(db.Account)
+	ret := m.ctrl.Call(m, "GetSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UpdateAccount indicates an expected call of UpdateAccount.
-func (mr *MockStoreMockRecorder) UpdateAccount(arg0, arg1 any) *gomock.Call {
+// GetSession indicates an expected call of GetSession.
+func (mr *MockStoreMockRecorder) GetSession(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccount", reflect.TypeOf((*MockStore)(nil).UpdateAccount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockStore)(nil).GetSession), arg0, arg1)
 }
 
-// UpdateEntry mocks base method.
-func (m *MockStore) UpdateEntry(arg0 context.Context, arg1 db.UpdateEntryParams) (db.Entry, error) {
+// GetSystemAccount mocks base method.
+func (m *MockStore) GetSystemAccount(arg0 context.Context, arg1 db.GetSystemAccountParams) (db.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateEntry", arg0, arg1)
-	ret0, _ := ret[0].(db.Entry)
+	ret := m.ctrl.Call(m, "GetSystemAccount", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UpdateEntry indicates an expected call of UpdateEntry.
-func (mr *MockStoreMockRecorder) UpdateEntry(arg0, arg1 any) *gomock.Call {
+// GetSystemAccount indicates an expected call of GetSystemAccount.
+func (mr *MockStoreMockRecorder) GetSystemAccount(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEntry", reflect.TypeOf((*MockStore)(nil).UpdateEntry), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSystemAccount", reflect.TypeOf((*MockStore)(nil).GetSystemAccount), arg0, arg1)
 }
 
-// UpdateTransfer mocks base method.
-func (m *MockStore) UpdateTransfer(arg0 context.Context, arg1 db.UpdateTransferParams) (db.Transfer, error) {
+// GetTransfer mocks base method.
+func (m *MockStore) GetTransfer(arg0 context.Context, arg1 int64) (db.Transfer, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateTransfer", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetTransfer", arg0, arg1)
 	ret0, _ := ret[0].(db.Transfer)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UpdateTransfer indicates an expected call of UpdateTransfer.
-func (mr *MockStoreMockRecorder) UpdateTransfer(arg0, arg1 any) *gomock.Call {
+// GetTransfer indicates an expected call of GetTransfer.
+func (mr *MockStoreMockRecorder) GetTransfer(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTransfer", reflect.TypeOf((*MockStore)(nil).UpdateTransfer), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockStore)(nil).GetTransfer), arg0, arg1)
+}
+
+// GetTrialBalance mocks base method.
+func (m *MockStore) GetTrialBalance(arg0 context.Context) ([]db.GetTrialBalanceRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrialBalance", arg0)
+	ret0, _ := ret[0].([]db.GetTrialBalanceRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrialBalance indicates an expected call of GetTrialBalance.
+func (mr *MockStoreMockRecorder) GetTrialBalance(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrialBalance", reflect.TypeOf((*MockStore)(nil).GetTrialBalance), arg0)
+}
+
+// GetUser mocks base method.
+func (m *MockStore) GetUser(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockStoreMockRecorder) GetUser(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), arg0, arg1)
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockStore) GetUserByEmail(arg0 context.Context, arg1 db.GetUserByEmailParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockStoreMockRecorder) GetUserByEmail(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockStore)(nil).GetUserByEmail), arg0, arg1)
+}
+
+// GetUserDeletedAt mocks base method.
+func (m *MockStore) GetUserDeletedAt(arg0 context.Context, arg1 string) (sql.NullTime, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserDeletedAt", arg0, arg1)
+	ret0, _ := ret[0].(sql.NullTime)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserDeletedAt indicates an expected call of GetUserDeletedAt.
+func (mr *MockStoreMockRecorder) GetUserDeletedAt(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserDeletedAt", reflect.TypeOf((*MockStore)(nil).GetUserDeletedAt), arg0, arg1)
+}
+
+// GetUserForShare mocks base method.
+func (m *MockStore) GetUserForShare(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserForShare", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserForShare indicates an expected call of GetUserForShare.
+func (mr *MockStoreMockRecorder) GetUserForShare(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserForShare", reflect.TypeOf((*MockStore)(nil).GetUserForShare), arg0, arg1)
+}
+
+// GetUserRole mocks base method.
+func (m *MockStore) GetUserRole(arg0 context.Context, arg1 string) (db.GetUserRoleRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRole", arg0, arg1)
+	ret0, _ := ret[0].(db.GetUserRoleRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRole indicates an expected call of GetUserRole.
+func (mr *MockStoreMockRecorder) GetUserRole(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRole", reflect.TypeOf((*MockStore)(nil).GetUserRole), arg0, arg1)
+}
+
+// GetWebhook mocks base method.
+func (m *MockStore) GetWebhook(arg0 context.Context, arg1 int64) (db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhook", arg0, arg1)
+	ret0, _ := ret[0].(db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhook indicates an expected call of GetWebhook.
+func (mr *MockStoreMockRecorder) GetWebhook(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhook", reflect.TypeOf((*MockStore)(nil).GetWebhook), arg0, arg1)
+}
+
+// ListAccountBalanceMismatches mocks base method.
+func (m *MockStore) ListAccountBalanceMismatches(arg0 context.Context) ([]db.ListAccountBalanceMismatchesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountBalanceMismatches", arg0)
+	ret0, _ := ret[0].([]db.ListAccountBalanceMismatchesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountBalanceMismatches indicates an expected call of ListAccountBalanceMismatches.
+func (mr *MockStoreMockRecorder) ListAccountBalanceMismatches(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountBalanceMismatches", reflect.TypeOf((*MockStore)(nil).ListAccountBalanceMismatches), arg0)
+}
+
+// ListAccountEntries mocks base method.
+func (m *MockStore) ListAccountEntries(arg0 context.Context, arg1 db.ListAccountEntriesParams) ([]db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountEntries", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountEntries indicates an expected call of ListAccountEntries.
+func (mr *MockStoreMockRecorder) ListAccountEntries(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountEntries", reflect.TypeOf((*MockStore)(nil).ListAccountEntries), arg0, arg1)
+}
+
+// ListAccountEntriesAfter mocks base method.
+func (m *MockStore) ListAccountEntriesAfter(arg0 context.Context, arg1 db.ListAccountEntriesAfterParams) ([]db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountEntriesAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountEntriesAfter indicates an expected call of ListAccountEntriesAfter.
+func (mr *MockStoreMockRecorder) ListAccountEntriesAfter(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountEntriesAfter", reflect.TypeOf((*MockStore)(nil).ListAccountEntriesAfter), arg0, arg1)
+}
+
+// ListAccountEntriesBetween mocks base method.
+func (m *MockStore) ListAccountEntriesBetween(arg0 context.Context, arg1 db.ListAccountEntriesBetweenParams) ([]db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountEntriesBetween", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountEntriesBetween indicates an expected call of ListAccountEntriesBetween.
+func (mr *MockStoreMockRecorder) ListAccountEntriesBetween(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountEntriesBetween", reflect.TypeOf((*MockStore)(nil).ListAccountEntriesBetween), arg0, arg1)
+}
+
+// ListAccounts mocks base method.
+func (m *MockStore) ListAccounts(arg0 context.Context, arg1 db.ListAccountsParams) ([]db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccounts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccounts indicates an expected call of ListAccounts.
+func (mr *MockStoreMockRecorder) ListAccounts(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockStore)(nil).ListAccounts), arg0, arg1)
+}
+
+// ListAccountsByOwner mocks base method.
+func (m *MockStore) ListAccountsByOwner(arg0 context.Context, arg1 db.ListAccountsByOwnerParams) ([]db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountsByOwner", arg0, arg1)
+	ret0, _ := ret[0].([]db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountsByOwner indicates an expected call of ListAccountsByOwner.
+func (mr *MockStoreMockRecorder) ListAccountsByOwner(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountsByOwner", reflect.TypeOf((*MockStore)(nil).ListAccountsByOwner), arg0, arg1)
+}
+
+// ListAccountsByType mocks base method.
+func (m *MockStore) ListAccountsByType(arg0 context.Context, arg1 db.ListAccountsByTypeParams) ([]db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountsByType", arg0, arg1)
+	ret0, _ := ret[0].([]db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountsByType indicates an expected call of ListAccountsByType.
+func (mr *MockStoreMockRecorder) ListAccountsByType(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountsByType", reflect.TypeOf((*MockStore)(nil).ListAccountsByType), arg0, arg1)
+}
+
+// ListAccountsWithUnpostedInterest mocks base method.
+func (m *MockStore) ListAccountsWithUnpostedInterest(arg0 context.Context, arg1 time.Time) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountsWithUnpostedInterest", arg0, arg1)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountsWithUnpostedInterest indicates an expected call of ListAccountsWithUnpostedInterest.
+func (mr *MockStoreMockRecorder) ListAccountsWithUnpostedInterest(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountsWithUnpostedInterest", reflect.TypeOf((*MockStore)(nil).ListAccountsWithUnpostedInterest), arg0, arg1)
+}
+
+// ListArchivedPartitions mocks base method.
+func (m *MockStore) ListArchivedPartitions(arg0 context.Context) ([]db.ArchivedPartition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListArchivedPartitions", arg0)
+	ret0, _ := ret[0].([]db.ArchivedPartition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListArchivedPartitions indicates an expected call of ListArchivedPartitions.
+func (mr *MockStoreMockRecorder) ListArchivedPartitions(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListArchivedPartitions", reflect.TypeOf((*MockStore)(nil).ListArchivedPartitions), arg0)
+}
+
+// ListEntries mocks base method.
+func (m *MockStore) ListEntries(arg0 context.Context, arg1 db.ListEntriesParams) ([]db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEntries", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEntries indicates an expected call of ListEntries.
+func (mr *MockStoreMockRecorder) ListEntries(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntries", reflect.TypeOf((*MockStore)(nil).ListEntries), arg0, arg1)
+}
+
+// ListJournalEntries mocks base method.
+func (m *MockStore) ListJournalEntries(arg0 context.Context, arg1 sql.NullInt64) ([]db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListJournalEntries", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListJournalEntries indicates an expected call of ListJournalEntries.
+func (mr *MockStoreMockRecorder) ListJournalEntries(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListJournalEntries", reflect.TypeOf((*MockStore)(nil).ListJournalEntries), arg0, arg1)
+}
+
+// ListOpenAccountIDsByOwner mocks base method.
+func (m *MockStore) ListOpenAccountIDsByOwner(arg0 context.Context, arg1 *string) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOpenAccountIDsByOwner", arg0, arg1)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOpenAccountIDsByOwner indicates an expected call of ListOpenAccountIDsByOwner.
+func (mr *MockStoreMockRecorder) ListOpenAccountIDsByOwner(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOpenAccountIDsByOwner", reflect.TypeOf((*MockStore)(nil).ListOpenAccountIDsByOwner), arg0, arg1)
+}
+
+// ListOutboxEventsByAggregate mocks base method.
+func (m *MockStore) ListOutboxEventsByAggregate(arg0 context.Context, arg1 db.ListOutboxEventsByAggregateParams) ([]db.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOutboxEventsByAggregate", arg0, arg1)
+	ret0, _ := ret[0].([]db.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOutboxEventsByAggregate indicates an expected call of ListOutboxEventsByAggregate.
+func (mr *MockStoreMockRecorder) ListOutboxEventsByAggregate(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOutboxEventsByAggregate", reflect.TypeOf((*MockStore)(nil).ListOutboxEventsByAggregate), arg0, arg1)
+}
+
+// ListOwnerTransfers mocks base method.
+func (m *MockStore) ListOwnerTransfers(arg0 context.Context, arg1 db.ListOwnerTransfersParams) ([]db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOwnerTransfers", arg0, arg1)
+	ret0, _ := ret[0].([]db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOwnerTransfers indicates an expected call of ListOwnerTransfers.
+func (mr *MockStoreMockRecorder) ListOwnerTransfers(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOwnerTransfers", reflect.TypeOf((*MockStore)(nil).ListOwnerTransfers), arg0, arg1)
+}
+
+// ListOwnerTransfersByReference mocks base method.
+func (m *MockStore) ListOwnerTransfersByReference(arg0 context.Context, arg1 db.ListOwnerTransfersByReferenceParams) ([]db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOwnerTransfersByReference", arg0, arg1)
+	ret0, _ := ret[0].([]db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOwnerTransfersByReference indicates an expected call of ListOwnerTransfersByReference.
+func (mr *MockStoreMockRecorder) ListOwnerTransfersByReference(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOwnerTransfersByReference", reflect.TypeOf((*MockStore)(nil).ListOwnerTransfersByReference), arg0, arg1)
+}
+
+// ListTopAccountsByBalance mocks base method.
+func (m *MockStore) ListTopAccountsByBalance(arg0 context.Context, arg1 db.ListTopAccountsByBalanceParams) ([]db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTopAccountsByBalance", arg0, arg1)
+	ret0, _ := ret[0].([]db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTopAccountsByBalance indicates an expected call of ListTopAccountsByBalance.
+func (mr *MockStoreMockRecorder) ListTopAccountsByBalance(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTopAccountsByBalance", reflect.TypeOf((*MockStore)(nil).ListTopAccountsByBalance), arg0, arg1)
+}
+
+// ListTransfers mocks base method.
+func (m *MockStore) ListTransfers(arg0 context.Context, arg1 db.ListTransfersParams) ([]db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransfers", arg0, arg1)
+	ret0, _ := ret[0].([]db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransfers indicates an expected call of ListTransfers.
+func (mr *MockStoreMockRecorder) ListTransfers(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockStore)(nil).ListTransfers), arg0, arg1)
+}
+
+// ListTransfersByReference mocks base method.
+func (m *MockStore) ListTransfersByReference(arg0 context.Context, arg1 db.ListTransfersByReferenceParams) ([]db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransfersByReference", arg0, arg1)
+	ret0, _ := ret[0].([]db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransfersByReference indicates an expected call of ListTransfersByReference.
+func (mr *MockStoreMockRecorder) ListTransfersByReference(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfersByReference", reflect.TypeOf((*MockStore)(nil).ListTransfersByReference), arg0, arg1)
+}
+
+// ListUnbalancedJournals mocks base method.
+func (m *MockStore) ListUnbalancedJournals(arg0 context.Context) ([]db.ListUnbalancedJournalsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUnbalancedJournals", arg0)
+	ret0, _ := ret[0].([]db.ListUnbalancedJournalsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUnbalancedJournals indicates an expected call of ListUnbalancedJournals.
+func (mr *MockStoreMockRecorder) ListUnbalancedJournals(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUnbalancedJournals", reflect.TypeOf((*MockStore)(nil).ListUnbalancedJournals), arg0)
+}
+
+// ListUnpostedInterestAccrualsForUpdate mocks base method.
+func (m *MockStore) ListUnpostedInterestAccrualsForUpdate(arg0 context.Context, arg1 db.ListUnpostedInterestAccrualsForUpdateParams) ([]db.InterestAccrual, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUnpostedInterestAccrualsForUpdate", arg0, arg1)
+	ret0, _ := ret[0].([]db.InterestAccrual)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUnpostedInterestAccrualsForUpdate indicates an expected call of ListUnpostedInterestAccrualsForUpdate.
+func (mr *MockStoreMockRecorder) ListUnpostedInterestAccrualsForUpdate(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUnpostedInterestAccrualsForUpdate", reflect.TypeOf((*MockStore)(nil).ListUnpostedInterestAccrualsForUpdate), arg0, arg1)
+}
+
+// ListUnpublishedOutboxEvents mocks base method.
+func (m *MockStore) ListUnpublishedOutboxEvents(arg0 context.Context, arg1 int32) ([]db.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUnpublishedOutboxEvents", arg0, arg1)
+	ret0, _ := ret[0].([]db.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUnpublishedOutboxEvents indicates an expected call of ListUnpublishedOutboxEvents.
+func (mr *MockStoreMockRecorder) ListUnpublishedOutboxEvents(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUnpublishedOutboxEvents", reflect.TypeOf((*MockStore)(nil).ListUnpublishedOutboxEvents), arg0, arg1)
+}
+
+// ListUsersForUpdate mocks base method.
+func (m *MockStore) ListUsersForUpdate(arg0 context.Context, arg1 db.ListUsersForUpdateParams) ([]db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsersForUpdate", arg0, arg1)
+	ret0, _ := ret[0].([]db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsersForUpdate indicates an expected call of ListUsersForUpdate.
+func (mr *MockStoreMockRecorder) ListUsersForUpdate(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsersForUpdate", reflect.TypeOf((*MockStore)(nil).ListUsersForUpdate), arg0, arg1)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockStore) ListWebhookDeliveries(arg0 context.Context, arg1 db.ListWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", arg0, arg1)
+	ret0, _ := ret[0].([]db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockStoreMockRecorder) ListWebhookDeliveries(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockStore)(nil).ListWebhookDeliveries), arg0, arg1)
+}
+
+// ListWebhooks mocks base method.
+func (m *MockStore) ListWebhooks(arg0 context.Context, arg1 string) ([]db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooks", arg0, arg1)
+	ret0, _ := ret[0].([]db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhooks indicates an expected call of ListWebhooks.
+func (mr *MockStoreMockRecorder) ListWebhooks(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooks", reflect.TypeOf((*MockStore)(nil).ListWebhooks), arg0, arg1)
+}
+
+// ListWebhooksForEvent mocks base method.
+func (m *MockStore) ListWebhooksForEvent(arg0 context.Context, arg1 db.ListWebhooksForEventParams) ([]db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooksForEvent", arg0, arg1)
+	ret0, _ := ret[0].([]db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhooksForEvent indicates an expected call of ListWebhooksForEvent.
+func (mr *MockStoreMockRecorder) ListWebhooksForEvent(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooksForEvent", reflect.TypeOf((*MockStore)(nil).ListWebhooksForEvent), arg0, arg1)
+}
+
+// MarkInterestAccrualsPosted mocks base method.
+func (m *MockStore) MarkInterestAccrualsPosted(arg0 context.Context, arg1 db.MarkInterestAccrualsPostedParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkInterestAccrualsPosted", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkInterestAccrualsPosted indicates an expected call of MarkInterestAccrualsPosted.
+func (mr *MockStoreMockRecorder) MarkInterestAccrualsPosted(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkInterestAccrualsPosted", reflect.TypeOf((*MockStore)(nil).MarkInterestAccrualsPosted), arg0, arg1)
+}
+
+// MarkOutboxEventPublished mocks base method.
+func (m *MockStore) MarkOutboxEventPublished(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkOutboxEventPublished", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkOutboxEventPublished indicates an expected call of MarkOutboxEventPublished.
+func (mr *MockStoreMockRecorder) MarkOutboxEventPublished(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkOutboxEventPublished", reflect.TypeOf((*MockStore)(nil).MarkOutboxEventPublished), arg0, arg1)
+}
+
+// MarkWebhookDeliveryDelivered mocks base method.
+func (m *MockStore) MarkWebhookDeliveryDelivered(arg0 context.Context, arg1 db.MarkWebhookDeliveryDeliveredParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkWebhookDeliveryDelivered", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkWebhookDeliveryDelivered indicates an expected call of MarkWebhookDeliveryDelivered.
+func (mr *MockStoreMockRecorder) MarkWebhookDeliveryDelivered(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkWebhookDeliveryDelivered", reflect.TypeOf((*MockStore)(nil).MarkWebhookDeliveryDelivered), arg0, arg1)
+}
+
+// NotifyAccountEntry mocks base method.
+func (m *MockStore) NotifyAccountEntry(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NotifyAccountEntry", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NotifyAccountEntry indicates an expected call of NotifyAccountEntry.
+func (mr *MockStoreMockRecorder) NotifyAccountEntry(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NotifyAccountEntry", reflect.TypeOf((*MockStore)(nil).NotifyAccountEntry), arg0, arg1)
+}
+
+// PostInterestTx mocks base method.
+func (m *MockStore) PostInterestTx(arg0 context.Context, arg1 db.PostInterestTxParams) (db.PostInterestTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostInterestTx", arg0, arg1)
+	ret0, _ := ret[0].(db.PostInterestTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PostInterestTx indicates an expected call of PostInterestTx.
+func (mr *MockStoreMockRecorder) PostInterestTx(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostInterestTx", reflect.TypeOf((*MockStore)(nil).PostInterestTx), arg0, arg1)
+}
+
+// PostJournalTx mocks base method.
+func (m *MockStore) PostJournalTx(arg0 context.Context, arg1 db.PostJournalTxParams) (db.PostJournalTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostJournalTx", arg0, arg1)
+	ret0, _ := ret[0].(db.PostJournalTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PostJournalTx indicates an expected call of PostJournalTx.
+func (mr *MockStoreMockRecorder) PostJournalTx(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostJournalTx", reflect.TypeOf((*MockStore)(nil).PostJournalTx), arg0, arg1)
+}
+
+// RecordOutboxEventFailure mocks base method.
+func (m *MockStore) RecordOutboxEventFailure(arg0 context.Context, arg1 db.RecordOutboxEventFailureParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordOutboxEventFailure", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordOutboxEventFailure indicates an expected call of RecordOutboxEventFailure.
+func (mr *MockStoreMockRecorder) RecordOutboxEventFailure(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordOutboxEventFailure", reflect.TypeOf((*MockStore)(nil).RecordOutboxEventFailure), arg0, arg1)
+}
+
+// RecordWebhookDeliveryFailure mocks base method.
+func (m *MockStore) RecordWebhookDeliveryFailure(arg0 context.Context, arg1 db.RecordWebhookDeliveryFailureParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordWebhookDeliveryFailure", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordWebhookDeliveryFailure indicates an expected call of RecordWebhookDeliveryFailure.
+func (mr *MockStoreMockRecorder) RecordWebhookDeliveryFailure(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordWebhookDeliveryFailure", reflect.TypeOf((*MockStore)(nil).RecordWebhookDeliveryFailure), arg0, arg1)
+}
+
+// RedactUserOutboxEvents mocks base method.
+func (m *MockStore) RedactUserOutboxEvents(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedactUserOutboxEvents", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RedactUserOutboxEvents indicates an expected call of RedactUserOutboxEvents.
+func (mr *MockStoreMockRecorder) RedactUserOutboxEvents(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedactUserOutboxEvents", reflect.TypeOf((*MockStore)(nil).RedactUserOutboxEvents), arg0, arg1)
+}
+
+// ReencryptUsersTx mocks base method.
+func (m *MockStore) ReencryptUsersTx(arg0 context.Context, arg1 db.ReencryptUsersTxParams) (db.ReencryptUsersTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReencryptUsersTx", arg0, arg1)
+	ret0, _ := ret[0].(db.ReencryptUsersTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReencryptUsersTx indicates an expected call of ReencryptUsersTx.
+func (mr *MockStoreMockRecorder) ReencryptUsersTx(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReencryptUsersTx", reflect.TypeOf((*MockStore)(nil).ReencryptUsersTx), arg0, arg1)
+}
+
+// SoftDeleteAccount mocks base method.
+func (m *MockStore) SoftDeleteAccount(arg0 context.Context, arg1 int64) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDeleteAccount", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SoftDeleteAccount indicates an expected call of SoftDeleteAccount.
+func (mr *MockStoreMockRecorder) SoftDeleteAccount(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDeleteAccount", reflect.TypeOf((*MockStore)(nil).SoftDeleteAccount), arg0, arg1)
+}
+
+// SumAccountEntriesSince mocks base method.
+func (m *MockStore) SumAccountEntriesSince(arg0 context.Context, arg1 db.SumAccountEntriesSinceParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SumAccountEntriesSince", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SumAccountEntriesSince indicates an expected call of SumAccountEntriesSince.
+func (mr *MockStoreMockRecorder) SumAccountEntriesSince(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumAccountEntriesSince", reflect.TypeOf((*MockStore)(nil).SumAccountEntriesSince), arg0, arg1)
+}
+
+// TransferTx mocks base method.
+func (m *MockStore) TransferTx(arg0 context.Context, arg1 db.TransferTxParams) (db.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferTx", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransferTx indicates an expected call of TransferTx.
+func (mr *MockStoreMockRecorder) TransferTx(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferTx", reflect.TypeOf((*MockStore)(nil).TransferTx), arg0, arg1)
+}
+
+// UnfreezeAccount mocks base method.
+func (m *MockStore) UnfreezeAccount(arg0 context.Context, arg1 int64) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnfreezeAccount", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnfreezeAccount indicates an expected call of UnfreezeAccount.
+func (mr *MockStoreMockRecorder) UnfreezeAccount(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnfreezeAccount", reflect.TypeOf((*MockStore)(nil).UnfreezeAccount), arg0, arg1)
+}
+
+// UpdateAccount mocks base method.
+func (m *MockStore) UpdateAccount(arg0 context.Context, arg1 db.UpdateAccountParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccount", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAccount indicates an expected call of UpdateAccount.
+func (mr *MockStoreMockRecorder) UpdateAccount(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccount", reflect.TypeOf((*MockStore)(nil).UpdateAccount), arg0, arg1)
+}
+
+// UpdateEntry mocks base method.
+func (m *MockStore) UpdateEntry(arg0 context.Context, arg1 db.UpdateEntryParams) (db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEntry", arg0, arg1)
+	ret0, _ := ret[0].(db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateEntry indicates an expected call of UpdateEntry.
+func (mr *MockStoreMockRecorder) UpdateEntry(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEntry", reflect.TypeOf((*MockStore)(nil).UpdateEntry), arg0, arg1)
+}
+
+// UpdateTransfer mocks base method.
+func (m *MockStore) UpdateTransfer(arg0 context.Context, arg1 db.UpdateTransferParams) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTransfer indicates an expected call of UpdateTransfer.
+func (mr *MockStoreMockRecorder) UpdateTransfer(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTransfer", reflect.TypeOf((*MockStore)(nil).UpdateTransfer), arg0, arg1)
+}
+
+// UpdateUserPersonalData mocks base method.
+func (m *MockStore) UpdateUserPersonalData(arg0 context.Context, arg1 db.UpdateUserPersonalDataParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserPersonalData", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserPersonalData indicates an expected call of UpdateUserPersonalData.
+func (mr *MockStoreMockRecorder) UpdateUserPersonalData(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserPersonalData", reflect.TypeOf((*MockStore)(nil).UpdateUserPersonalData), arg0, arg1)
+}
+
+// UpdateUserRole mocks base method.
+func (m *MockStore) UpdateUserRole(arg0 context.Context, arg1 db.UpdateUserRoleParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserRole", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserRole indicates an expected call of UpdateUserRole.
+func (mr *MockStoreMockRecorder) UpdateUserRole(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserRole", reflect.TypeOf((*MockStore)(nil).UpdateUserRole), arg0, arg1)
+}
+
+// UpsertAccountLimit mocks base method.
+func (m *MockStore) UpsertAccountLimit(arg0 context.Context, arg1 db.UpsertAccountLimitParams) (db.AccountLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertAccountLimit", arg0, arg1)
+	ret0, _ := ret[0].(db.AccountLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertAccountLimit indicates an expected call of UpsertAccountLimit.
+func (mr *MockStoreMockRecorder) UpsertAccountLimit(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertAccountLimit", reflect.TypeOf((*MockStore)(nil).UpsertAccountLimit), arg0, arg1)
+}
+
+// VoidInterestAccruals mocks base method.
+func (m *MockStore) VoidInterestAccruals(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VoidInterestAccruals", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VoidInterestAccruals indicates an expected call of VoidInterestAccruals.
+func (mr *MockStoreMockRecorder) VoidInterestAccruals(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VoidInterestAccruals", reflect.TypeOf((*MockStore)(nil).VoidInterestAccruals), arg0, arg1)
 }
@@ -2,9 +2,10 @@
 INSERT INTO accounts (
 	owner,
 	balance,
-	currency
+	currency,
+	account_type
 ) VALUES (
-	$1, $2, $3
+	$1, $2, $3, $4
 ) RETURNING *;
 
 -- name: GetAccount :one
@@ -16,24 +17,76 @@ SELECT * FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE;
 
+-- name: GetSystemAccount :one
+SELECT * FROM accounts
+WHERE system_kind = sqlc.arg(system_kind)::varchar
+AND currency = sqlc.arg(currency)
+LIMIT 1;
+
 -- name: ListAccounts :many
 SELECT * FROM accounts
+WHERE system_kind IS NULL
+AND deleted_at IS NULL
 ORDER BY id
 LIMIT $1
 OFFSET $2;
 
+-- name: ListAccountsByOwner :many
+SELECT * FROM accounts
+WHERE owner = $1
+AND deleted_at IS NULL
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: ListAccountsByType :many
+SELECT * FROM accounts
+WHERE account_type = sqlc.arg(account_type)
+AND system_kind IS NULL
+AND deleted_at IS NULL
+AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
 -- name: UpdateAccount :one
 UPDATE accounts
-SET balance = $2
+SET balance = $2, version = version + 1
 WHERE id = $1
 RETURNING *;
 
 -- name: AddAccountBalance :one
 UPDATE accounts
-SET balance = balance + sqlc.arg(amount)
+SET balance = balance + sqlc.arg(amount), version = version + 1
 WHERE id = sqlc.arg(id)
 RETURNING *;
 
 -- name: DeleteAccount :exec
 DELETE FROM accounts
 WHERE id = $1;
+
+-- name: SoftDeleteAccount :one
+UPDATE accounts
+SET deleted_at = now(), version = version + 1
+WHERE id = $1
+AND system_kind IS NULL
+AND deleted_at IS NULL
+RETURNING *;
+
+-- name: FreezeAccount :one
+UPDATE accounts
+SET frozen_at = now(), frozen_reason = sqlc.arg(reason), version = version + 1
+WHERE id = sqlc.arg(id)
+AND system_kind IS NULL
+RETURNING *;
+
+-- name: UnfreezeAccount :one
+UPDATE accounts
+SET frozen_at = NULL, frozen_reason = '', version = version + 1
+WHERE id = $1
+RETURNING *;
+
+-- name: ListOpenAccountIDsByOwner :many
+SELECT id FROM accounts
+WHERE owner = $1
+AND deleted_at IS NULL
+ORDER BY id;
@@ -0,0 +1,22 @@
+-- name: GetAccountLimit :one
+SELECT * FROM account_limits
+WHERE account_id = $1 LIMIT 1;
+
+-- name: UpsertAccountLimit :one
+INSERT INTO account_limits (
+	account_id,
+	per_transaction,
+	daily,
+	monthly
+) VALUES (
+	$1, $2, $3, $4
+) ON CONFLICT (account_id) DO UPDATE
+SET per_transaction = EXCLUDED.per_transaction,
+	daily = EXCLUDED.daily,
+	monthly = EXCLUDED.monthly,
+	updated_at = now()
+RETURNING *;
+
+-- name: DeleteAccountLimit :exec
+DELETE FROM account_limits
+WHERE account_id = $1;
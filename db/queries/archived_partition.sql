@@ -0,0 +1,14 @@
+-- name: CreateArchivedPartition :one
+INSERT INTO archived_partitions (
+	name,
+	parent_table,
+	month,
+	file,
+	rows
+) VALUES (
+	$1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ListArchivedPartitions :many
+SELECT * FROM archived_partitions
+ORDER BY parent_table, month;
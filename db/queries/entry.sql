@@ -1,27 +1,49 @@
 -- name: CreateEntry :one
 INSERT INTO entries (
 	account_id,
-	amount
+	amount,
+	description,
+	reference,
+	metadata,
+	journal_id
 ) VALUES (
-	$1, $2
+	$1, $2, $3, $4, $5, $6
 ) RETURNING *;
 
 -- name: GetEntry :one
+-- the ids are unique across the partitions, see the comment of entries.id.
 SELECT * FROM entries
 WHERE id = $1 LIMIT 1;
 
 -- name: ListEntries :many
+-- the entries after the (after_created_at, after_id) cursor, the zero values
+-- give the first page.
 SELECT * FROM entries
-ORDER BY id
-LIMIT $1
-OFFSET $2;
+WHERE (created_at, id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg(limit_count);
 
 -- name: ListAccountEntries :many
 SELECT * FROM entries
-WHERE account_id = $1
-ORDER BY amount
-LIMIT $2
-OFFSET $3;
+WHERE account_id = sqlc.arg(account_id)
+AND (created_at, id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg(limit_count);
+
+-- name: ListAccountEntriesAfter :many
+SELECT * FROM entries
+WHERE account_id = sqlc.arg(account_id)
+AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
+-- name: NotifyAccountEntry :exec
+SELECT pg_notify('account_entries', sqlc.arg(payload)::text);
+
+-- name: ListJournalEntries :many
+SELECT * FROM entries
+WHERE journal_id = $1
+ORDER BY id;
 
 -- name: UpdateEntry :one
 UPDATE entries
@@ -31,4 +53,17 @@ RETURNING *;
 
 -- name: DeleteEntry :exec
 DELETE FROM entries
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: ListAccountEntriesBetween :many
+SELECT * FROM entries
+WHERE account_id = sqlc.arg(account_id)
+AND created_at >= sqlc.arg(from_time)
+AND created_at < sqlc.arg(to_time)
+ORDER BY id;
+
+-- name: SumAccountEntriesSince :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM entries
+WHERE account_id = sqlc.arg(account_id)
+AND created_at >= sqlc.arg(since);
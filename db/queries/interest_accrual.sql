@@ -0,0 +1,48 @@
+-- name: CreateInterestAccrual :execrows
+INSERT INTO interest_accruals (
+	account_id,
+	accrual_date,
+	balance,
+	rate_basis_points,
+	amount_micros
+) VALUES (
+	$1, $2, $3, $4, $5
+) ON CONFLICT (account_id, accrual_date) DO NOTHING;
+
+-- name: GetInterestAccrual :one
+SELECT * FROM interest_accruals
+WHERE account_id = $1 AND accrual_date = $2 LIMIT 1;
+
+-- name: GetLastInterestAccrualDate :one
+-- the day the interest job accrued last, for it to catch up the days after.
+SELECT accrual_date FROM interest_accruals
+ORDER BY accrual_date DESC
+LIMIT 1;
+
+-- name: ListAccountsWithUnpostedInterest :many
+SELECT DISTINCT account_id FROM interest_accruals
+WHERE posted_at IS NULL
+AND accrual_date < sqlc.arg(before)
+ORDER BY account_id;
+
+-- name: ListUnpostedInterestAccrualsForUpdate :many
+SELECT * FROM interest_accruals
+WHERE account_id = sqlc.arg(account_id)
+AND posted_at IS NULL
+AND accrual_date < sqlc.arg(before)
+ORDER BY accrual_date
+FOR UPDATE;
+
+-- name: MarkInterestAccrualsPosted :execrows
+UPDATE interest_accruals
+SET posted_at = now(), entry_id = sqlc.narg(entry_id)
+WHERE account_id = sqlc.arg(account_id)
+AND posted_at IS NULL
+AND accrual_date < sqlc.arg(before);
+
+-- name: VoidInterestAccruals :execrows
+-- closes the unposted accruals of an account without paying them.
+UPDATE interest_accruals
+SET posted_at = now(), entry_id = NULL
+WHERE account_id = $1
+AND posted_at IS NULL;
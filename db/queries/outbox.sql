@@ -0,0 +1,45 @@
+-- name: CreateOutboxEvent :one
+INSERT INTO outbox_events (
+	aggregate_type,
+	aggregate_id,
+	event_type,
+	payload
+) VALUES (
+	$1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListUnpublishedOutboxEvents :many
+-- the events due for an attempt, failed events wait for their backoff.
+SELECT * FROM outbox_events
+WHERE published_at IS NULL
+AND dead_at IS NULL
+AND next_attempt_at <= now()
+ORDER BY id
+LIMIT $1;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox_events
+SET published_at = now(),
+	attempts = attempts + 1,
+	last_error = ''
+WHERE id = $1;
+
+-- name: RecordOutboxEventFailure :exec
+UPDATE outbox_events
+SET attempts = attempts + 1,
+	last_error = sqlc.arg(last_error),
+	next_attempt_at = sqlc.arg(next_attempt_at),
+	dead_at = CASE WHEN sqlc.arg(dead)::bool THEN now() END
+WHERE id = sqlc.arg(id);
+
+-- name: ListOutboxEventsByAggregate :many
+SELECT * FROM outbox_events
+WHERE aggregate_type = $1
+AND aggregate_id = $2
+ORDER BY id;
+
+-- name: RedactUserOutboxEvents :exec
+UPDATE outbox_events
+SET payload = payload - 'full_name' - 'email'
+WHERE aggregate_type = 'user'
+AND aggregate_id = $1;
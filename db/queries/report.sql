@@ -0,0 +1,66 @@
+-- name: GetTrialBalance :many
+SELECT
+	currency,
+	COUNT(*) FILTER (WHERE system_kind IS NULL)::bigint AS customer_accounts,
+	COALESCE(SUM(balance) FILTER (WHERE system_kind IS NULL), 0)::bigint AS customer_balance,
+	COALESCE(SUM(balance) FILTER (WHERE system_kind IS NOT NULL), 0)::bigint AS system_balance,
+	COALESCE(SUM(balance), 0)::bigint AS total_balance
+FROM accounts
+GROUP BY currency
+ORDER BY currency;
+
+-- name: GetDailyTransferVolume :many
+SELECT
+	(t.created_at AT TIME ZONE 'UTC')::date AS day,
+	a.currency,
+	COUNT(*)::bigint AS transfers,
+	COALESCE(SUM(t.amount), 0)::bigint AS volume
+FROM transfers t
+JOIN accounts a ON a.id = t.from_account_id
+WHERE t.created_at >= sqlc.arg(from_time)
+AND t.created_at < sqlc.arg(to_time)
+GROUP BY day, a.currency
+ORDER BY day, a.currency;
+
+-- name: ListTopAccountsByBalance :many
+SELECT * FROM accounts
+WHERE system_kind IS NULL
+AND currency = $1
+ORDER BY balance DESC, id
+LIMIT $2;
+
+-- name: GetDailyNewUsers :many
+SELECT
+	(created_at AT TIME ZONE 'UTC')::date AS day,
+	COUNT(*)::bigint AS users
+FROM users
+WHERE created_at >= sqlc.arg(from_time)
+AND created_at < sqlc.arg(to_time)
+GROUP BY day
+ORDER BY day;
+
+-- name: ListAccountBalanceMismatches :many
+-- the entries of the archived partitions count through their totals.
+SELECT
+	a.id AS account_id,
+	a.currency,
+	a.balance,
+	(COALESCE(SUM(e.amount), 0) + COALESCE(MAX(t.amount), 0))::bigint AS entries_total
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+LEFT JOIN archived_entry_totals t ON t.account_id = a.id
+GROUP BY a.id
+HAVING a.balance <> COALESCE(SUM(e.amount), 0) + COALESCE(MAX(t.amount), 0)
+ORDER BY a.id;
+
+-- name: ListUnbalancedJournals :many
+SELECT
+	e.journal_id::bigint AS journal_id,
+	a.currency,
+	SUM(e.amount)::bigint AS total
+FROM entries e
+JOIN accounts a ON a.id = e.account_id
+WHERE e.journal_id IS NOT NULL
+GROUP BY e.journal_id, a.currency
+HAVING SUM(e.amount) <> 0
+ORDER BY e.journal_id, a.currency;
@@ -2,20 +2,55 @@
 INSERT INTO transfers (
 	from_account_id,
 	to_account_id,
-	amount
+	amount,
+	description,
+	reference,
+	metadata
 ) VALUES (
-	$1, $2, $3
+	$1, $2, $3, $4, $5, $6
 ) RETURNING *;
 
 -- name: GetTransfer :one
+-- the ids are unique across the partitions, see the comment of transfers.id.
 SELECT * FROM transfers
 WHERE id = $1 LIMIT 1;
 
 -- name: ListTransfers :many
+-- the transfers after the (after_created_at, after_id) cursor, the zero
+-- values give the first page.
 SELECT * FROM transfers
-ORDER BY id
-LIMIT $1
-OFFSET $2;
+WHERE (created_at, id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg(limit_count);
+
+-- name: ListTransfersByReference :many
+SELECT * FROM transfers
+WHERE reference = sqlc.arg(reference)
+AND (created_at, id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg(limit_count);
+
+-- name: ListOwnerTransfers :many
+-- the transfers from or to the accounts of the owner.
+SELECT * FROM transfers
+WHERE (
+	from_account_id IN (SELECT id FROM accounts WHERE accounts.owner = sqlc.arg(owner))
+	OR to_account_id IN (SELECT id FROM accounts WHERE accounts.owner = sqlc.arg(owner))
+)
+AND (created_at, id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg(limit_count);
+
+-- name: ListOwnerTransfersByReference :many
+SELECT * FROM transfers
+WHERE reference = sqlc.arg(reference)
+AND (
+	from_account_id IN (SELECT id FROM accounts WHERE accounts.owner = sqlc.arg(owner))
+	OR to_account_id IN (SELECT id FROM accounts WHERE accounts.owner = sqlc.arg(owner))
+)
+AND (created_at, id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg(limit_count);
 
 -- name: UpdateTransfer :one
 UPDATE transfers
@@ -25,4 +60,9 @@ RETURNING *;
 
 -- name: DeleteTransfer :exec
 DELETE FROM transfers
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: GetOutgoingTransfersTotal :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total FROM transfers
+WHERE from_account_id = sqlc.arg(account_id)
+AND created_at >= sqlc.arg(since);
@@ -1,13 +1,70 @@
 -- name: CreateUser :one
+-- an empty role gets the default one, depositor.
 INSERT INTO users (
 	username,
 	hashed_password,
 	full_name,
-  email
+  email,
+  email_hash,
+  role
 ) VALUES (
-	$1, $2, $3, $4
+	$1, $2, $3, $4, $5, COALESCE(NULLIF(sqlc.arg(role)::varchar, ''), 'depositor')
 ) RETURNING *;
 
 -- name: GetUser :one
 SELECT * FROM users
 WHERE username = $1 LIMIT 1;
+
+-- name: GetUserDeletedAt :one
+-- checked on every authenticated request, without reading the personal data.
+SELECT deleted_at FROM users
+WHERE username = $1 LIMIT 1;
+
+-- name: GetUserRole :one
+-- read when renewing an access token, so that it gets the current role.
+SELECT role, deleted_at FROM users
+WHERE username = $1 LIMIT 1;
+
+-- name: GetUserForShare :one
+SELECT * FROM users
+WHERE username = $1 LIMIT 1
+FOR SHARE;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email_hash = sqlc.narg(email_hash)
+OR (email_hash IS NULL AND email = sqlc.arg(email))
+LIMIT 1;
+
+-- name: ListUsersForUpdate :many
+SELECT * FROM users
+WHERE username > sqlc.arg(after)
+AND deleted_at IS NULL
+ORDER BY username
+LIMIT sqlc.arg(limit_count)
+FOR UPDATE;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE username = $1
+RETURNING *;
+
+-- name: UpdateUserPersonalData :one
+UPDATE users
+SET full_name = $2,
+	email = $3,
+	email_hash = $4
+WHERE username = $1
+RETURNING *;
+
+-- name: AnonymizeUser :one
+UPDATE users
+SET hashed_password = '',
+	full_name = '',
+	email = 'deleted-' || username || '@invalid',
+	email_hash = NULL,
+	deleted_at = now()
+WHERE username = $1
+AND deleted_at IS NULL
+RETURNING *;
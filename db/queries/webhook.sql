@@ -0,0 +1,32 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (
+	owner,
+	url,
+	secret,
+	event_types
+) VALUES (
+	$1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetWebhook :one
+SELECT * FROM webhooks
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhooks :many
+SELECT * FROM webhooks
+WHERE owner = $1
+ORDER BY id;
+
+-- name: ListWebhooksForEvent :many
+SELECT * FROM webhooks
+WHERE owner = ANY(sqlc.arg(owners)::varchar[])
+AND (cardinality(event_types) = 0 OR sqlc.arg(event_type)::varchar = ANY(event_types))
+ORDER BY id;
+
+-- name: DeleteWebhook :exec
+DELETE FROM webhooks
+WHERE id = $1;
+
+-- name: DeleteUserWebhooks :exec
+DELETE FROM webhooks
+WHERE owner = $1;
@@ -0,0 +1,57 @@
+-- name: CreateWebhookDelivery :execrows
+INSERT INTO webhook_deliveries (
+	webhook_id,
+	event_id,
+	event_type,
+	payload
+) VALUES (
+	$1, $2, $3, $4
+) ON CONFLICT (webhook_id, event_id) DO NOTHING;
+
+-- name: ClaimDueWebhookDeliveries :many
+-- leases the due deliveries to one worker: the others skip the rows it locks,
+-- and don't see them as due again before the lease ends.
+WITH due AS (
+	SELECT id FROM webhook_deliveries
+	WHERE status = 'pending'
+	AND next_attempt_at <= now()
+	ORDER BY next_attempt_at, id
+	LIMIT sqlc.arg(limit_count)
+	FOR UPDATE SKIP LOCKED
+)
+UPDATE webhook_deliveries d
+SET next_attempt_at = sqlc.arg(leased_until)
+FROM due, webhooks w
+WHERE d.id = due.id
+AND w.id = d.webhook_id
+RETURNING d.*, w.url, w.secret;
+
+-- name: MarkWebhookDeliveryDelivered :exec
+UPDATE webhook_deliveries
+SET status = 'delivered',
+	attempts = attempts + 1,
+	response_status = sqlc.arg(response_status),
+	last_error = '',
+	delivered_at = now()
+WHERE id = sqlc.arg(id);
+
+-- name: RecordWebhookDeliveryFailure :exec
+UPDATE webhook_deliveries
+SET status = sqlc.arg(status),
+	attempts = attempts + 1,
+	response_status = sqlc.arg(response_status),
+	last_error = sqlc.arg(last_error),
+	next_attempt_at = sqlc.arg(next_attempt_at)
+WHERE id = sqlc.arg(id);
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: DeleteFinishedWebhookDeliveries :execrows
+DELETE FROM webhook_deliveries
+WHERE status <> 'pending'
+AND created_at < sqlc.arg(created_before);
@@ -11,9 +11,9 @@ import (
 
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
-SET balance = balance + $1
+SET balance = balance + $1, version = version + 1
 WHERE id = $2
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, account_type, system_kind, frozen_at, frozen_reason, deleted_at, version
 `
 
 type AddAccountBalanceParams struct {
@@ -22,7 +22,7 @@ type AddAccountBalanceParams struct {
 }
 
 func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, addAccountBalance, arg.Amount, arg.ID)
+	row := q.db.QueryRow(ctx, addAccountBalance, arg.Amount, arg.ID)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -30,6 +30,12 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.AccountType,
+		&i.SystemKind,
+		&i.FrozenAt,
+		&i.FrozenReason,
+		&i.DeletedAt,
+		&i.Version,
 	)
 	return i, err
 }
@@ -38,20 +44,27 @@ const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts (
 	owner,
 	balance,
-	currency
+	currency,
+	account_type
 ) VALUES (
-	$1, $2, $3
-) RETURNING id, owner, balance, currency, created_at
+	$1, $2, $3, $4
+) RETURNING id, owner, balance, currency, created_at, account_type, system_kind, frozen_at, frozen_reason, deleted_at, version
 `
 
 type CreateAccountParams struct {
-	Owner    string `json:"owner"`
-	Balance  int64  `json:"balance"`
-	Currency string `json:"currency"`
+	Owner       *string `json:"owner"`
+	Balance     int64   `json:"balance"`
+	Currency    string  `json:"currency"`
+	AccountType string  `json:"account_type"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, createAccount, arg.Owner, arg.Balance, arg.Currency)
+	row := q.db.QueryRow(ctx, createAccount,
+		arg.Owner,
+		arg.Balance,
+		arg.Currency,
+		arg.AccountType,
+	)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -59,6 +72,12 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.AccountType,
+		&i.SystemKind,
+		&i.FrozenAt,
+		&i.FrozenReason,
+		&i.DeletedAt,
+		&i.Version,
 	)
 	return i, err
 }
@@ -69,17 +88,49 @@ WHERE id = $1
 `
 
 func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteAccount, id)
+	_, err := q.db.Exec(ctx, deleteAccount, id)
 	return err
 }
 
+const freezeAccount = `-- name: FreezeAccount :one
+UPDATE accounts
+SET frozen_at = now(), frozen_reason = $1, version = version + 1
+WHERE id = $2
+AND system_kind IS NULL
+RETURNING id, owner, balance, currency, created_at, account_type, system_kind, frozen_at, frozen_reason, deleted_at, version
+`
+
+type FreezeAccountParams struct {
+	Reason string `json:"reason"`
+	ID     int64  `json:"id"`
+}
+
+func (q *Queries) FreezeAccount(ctx context.Context, arg FreezeAccountParams) (Account, error) {
+	row := q.db.QueryRow(ctx, freezeAccount, arg.Reason, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.AccountType,
+		&i.SystemKind,
+		&i.FrozenAt,
+		&i.FrozenReason,
+		&i.DeletedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, account_type, system_kind, frozen_at, frozen_reason, deleted_at, version FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
-	row := q.db.QueryRowContext(ctx, getAccount, id)
+	row := q.db.QueryRow(ctx, getAccount, id)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -87,18 +138,24 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.AccountType,
+		&i.SystemKind,
+		&i.FrozenAt,
+		&i.FrozenReason,
+		&i.DeletedAt,
+		&i.Version,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, account_type, system_kind, frozen_at, frozen_reason, deleted_at, version FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE
 `
 
 func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, error) {
-	row := q.db.QueryRowContext(ctx, getAccountForUpdate, id)
+	row := q.db.QueryRow(ctx, getAccountForUpdate, id)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -106,12 +163,51 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.AccountType,
+		&i.SystemKind,
+		&i.FrozenAt,
+		&i.FrozenReason,
+		&i.DeletedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const getSystemAccount = `-- name: GetSystemAccount :one
+SELECT id, owner, balance, currency, created_at, account_type, system_kind, frozen_at, frozen_reason, deleted_at, version FROM accounts
+WHERE system_kind = $1::varchar
+AND currency = $2
+LIMIT 1
+`
+
+type GetSystemAccountParams struct {
+	SystemKind string `json:"system_kind"`
+	Currency   string `json:"currency"`
+}
+
+func (q *Queries) GetSystemAccount(ctx context.Context, arg GetSystemAccountParams) (Account, error) {
+	row := q.db.QueryRow(ctx, getSystemAccount, arg.SystemKind, arg.Currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.AccountType,
+		&i.SystemKind,
+		&i.FrozenAt,
+		&i.FrozenReason,
+		&i.DeletedAt,
+		&i.Version,
 	)
 	return i, err
 }
 
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, account_type, system_kind, frozen_at, frozen_reason, deleted_at, version FROM accounts
+WHERE system_kind IS NULL
+AND deleted_at IS NULL
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -123,7 +219,102 @@ type ListAccountsParams struct {
 }
 
 func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
-	rows, err := q.db.QueryContext(ctx, listAccounts, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listAccounts, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.AccountType,
+			&i.SystemKind,
+			&i.FrozenAt,
+			&i.FrozenReason,
+			&i.DeletedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsByOwner = `-- name: ListAccountsByOwner :many
+SELECT id, owner, balance, currency, created_at, account_type, system_kind, frozen_at, frozen_reason, deleted_at, version FROM accounts
+WHERE owner = $1
+AND deleted_at IS NULL
+ORDER BY id
+LIMIT $2
+OFFSET $3
+`
+
+type ListAccountsByOwnerParams struct {
+	Owner  *string `json:"owner"`
+	Limit  int32   `json:"limit"`
+	Offset int32   `json:"offset"`
+}
+
+func (q *Queries) ListAccountsByOwner(ctx context.Context, arg ListAccountsByOwnerParams) ([]Account, error) {
+	rows, err := q.db.Query(ctx, listAccountsByOwner, arg.Owner, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.AccountType,
+			&i.SystemKind,
+			&i.FrozenAt,
+			&i.FrozenReason,
+			&i.DeletedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsByType = `-- name: ListAccountsByType :many
+SELECT id, owner, balance, currency, created_at, account_type, system_kind, frozen_at, frozen_reason, deleted_at, version FROM accounts
+WHERE account_type = $1
+AND system_kind IS NULL
+AND deleted_at IS NULL
+AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListAccountsByTypeParams struct {
+	AccountType string `json:"account_type"`
+	AfterID     int64  `json:"after_id"`
+	LimitCount  int32  `json:"limit_count"`
+}
+
+func (q *Queries) ListAccountsByType(ctx context.Context, arg ListAccountsByTypeParams) ([]Account, error) {
+	rows, err := q.db.Query(ctx, listAccountsByType, arg.AccountType, arg.AfterID, arg.LimitCount)
 	if err != nil {
 		return nil, err
 	}
@@ -137,25 +328,109 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.AccountType,
+			&i.SystemKind,
+			&i.FrozenAt,
+			&i.FrozenReason,
+			&i.DeletedAt,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
 	}
-	if err := rows.Close(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
+	return items, nil
+}
+
+const listOpenAccountIDsByOwner = `-- name: ListOpenAccountIDsByOwner :many
+SELECT id FROM accounts
+WHERE owner = $1
+AND deleted_at IS NULL
+ORDER BY id
+`
+
+func (q *Queries) ListOpenAccountIDsByOwner(ctx context.Context, owner *string) ([]int64, error) {
+	rows, err := q.db.Query(ctx, listOpenAccountIDsByOwner, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 	return items, nil
 }
 
+const softDeleteAccount = `-- name: SoftDeleteAccount :one
+UPDATE accounts
+SET deleted_at = now(), version = version + 1
+WHERE id = $1
+AND system_kind IS NULL
+AND deleted_at IS NULL
+RETURNING id, owner, balance, currency, created_at, account_type, system_kind, frozen_at, frozen_reason, deleted_at, version
+`
+
+func (q *Queries) SoftDeleteAccount(ctx context.Context, id int64) (Account, error) {
+	row := q.db.QueryRow(ctx, softDeleteAccount, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.AccountType,
+		&i.SystemKind,
+		&i.FrozenAt,
+		&i.FrozenReason,
+		&i.DeletedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const unfreezeAccount = `-- name: UnfreezeAccount :one
+UPDATE accounts
+SET frozen_at = NULL, frozen_reason = '', version = version + 1
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, account_type, system_kind, frozen_at, frozen_reason, deleted_at, version
+`
+
+func (q *Queries) UnfreezeAccount(ctx context.Context, id int64) (Account, error) {
+	row := q.db.QueryRow(ctx, unfreezeAccount, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.AccountType,
+		&i.SystemKind,
+		&i.FrozenAt,
+		&i.FrozenReason,
+		&i.DeletedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
-SET balance = $2
+SET balance = $2, version = version + 1
 WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, account_type, system_kind, frozen_at, frozen_reason, deleted_at, version
 `
 
 type UpdateAccountParams struct {
@@ -164,7 +439,7 @@ type UpdateAccountParams struct {
 }
 
 func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, updateAccount, arg.ID, arg.Balance)
+	row := q.db.QueryRow(ctx, updateAccount, arg.ID, arg.Balance)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -172,6 +447,12 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.AccountType,
+		&i.SystemKind,
+		&i.FrozenAt,
+		&i.FrozenReason,
+		&i.DeletedAt,
+		&i.Version,
 	)
 	return i, err
 }
@@ -0,0 +1,80 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: account_limit.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const deleteAccountLimit = `-- name: DeleteAccountLimit :exec
+DELETE FROM account_limits
+WHERE account_id = $1
+`
+
+func (q *Queries) DeleteAccountLimit(ctx context.Context, accountID int64) error {
+	_, err := q.db.Exec(ctx, deleteAccountLimit, accountID)
+	return err
+}
+
+const getAccountLimit = `-- name: GetAccountLimit :one
+SELECT account_id, per_transaction, daily, monthly, updated_at FROM account_limits
+WHERE account_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetAccountLimit(ctx context.Context, accountID int64) (AccountLimit, error) {
+	row := q.db.QueryRow(ctx, getAccountLimit, accountID)
+	var i AccountLimit
+	err := row.Scan(
+		&i.AccountID,
+		&i.PerTransaction,
+		&i.Daily,
+		&i.Monthly,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertAccountLimit = `-- name: UpsertAccountLimit :one
+INSERT INTO account_limits (
+	account_id,
+	per_transaction,
+	daily,
+	monthly
+) VALUES (
+	$1, $2, $3, $4
+) ON CONFLICT (account_id) DO UPDATE
+SET per_transaction = EXCLUDED.per_transaction,
+	daily = EXCLUDED.daily,
+	monthly = EXCLUDED.monthly,
+	updated_at = now()
+RETURNING account_id, per_transaction, daily, monthly, updated_at
+`
+
+type UpsertAccountLimitParams struct {
+	AccountID      int64         `json:"account_id"`
+	PerTransaction sql.NullInt64 `json:"per_transaction"`
+	Daily          sql.NullInt64 `json:"daily"`
+	Monthly        sql.NullInt64 `json:"monthly"`
+}
+
+func (q *Queries) UpsertAccountLimit(ctx context.Context, arg UpsertAccountLimitParams) (AccountLimit, error) {
+	row := q.db.QueryRow(ctx, upsertAccountLimit,
+		arg.AccountID,
+		arg.PerTransaction,
+		arg.Daily,
+		arg.Monthly,
+	)
+	var i AccountLimit
+	err := row.Scan(
+		&i.AccountID,
+		&i.PerTransaction,
+		&i.Daily,
+		&i.Monthly,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAccountLimit(t *testing.T) AccountLimit {
+	account := createRandomAccount(t)
+
+	args := UpsertAccountLimitParams{
+		AccountID:      account.ID,
+		PerTransaction: sql.NullInt64{Int64: utils.RandomMoneyAmount(), Valid: true},
+		Daily:          sql.NullInt64{Int64: utils.RandomMoneyAmount(), Valid: true},
+	}
+
+	limit, err := testQueries.UpsertAccountLimit(context.Background(), args)
+
+	require.NoError(t, err)
+	require.NotEmpty(t, limit)
+
+	require.Equal(t, args.AccountID, limit.AccountID)
+	require.Equal(t, args.PerTransaction, limit.PerTransaction)
+	require.Equal(t, args.Daily, limit.Daily)
+	require.False(t, limit.Monthly.Valid)
+	require.NotZero(t, limit.UpdatedAt)
+
+	return limit
+}
+
+func TestUpsertAccountLimit(t *testing.T) {
+	createdLimit := createRandomAccountLimit(t)
+
+	args := UpsertAccountLimitParams{
+		AccountID: createdLimit.AccountID,
+		Monthly:   sql.NullInt64{Int64: utils.RandomMoneyAmount(), Valid: true},
+	}
+
+	limit, err := testQueries.UpsertAccountLimit(context.Background(), args)
+
+	require.NoError(t, err)
+	require.Equal(t, createdLimit.AccountID, limit.AccountID)
+	require.False(t, limit.PerTransaction.Valid)
+	require.False(t, limit.Daily.Valid)
+	require.Equal(t, args.Monthly, limit.Monthly)
+}
+
+func TestGetAccountLimit(t *testing.T) {
+	createdLimit := createRandomAccountLimit(t)
+
+	limit, err := testQueries.GetAccountLimit(context.Background(), createdLimit.AccountID)
+
+	require.NoError(t, err)
+	require.Equal(t, createdLimit, limit)
+}
+
+func TestDeleteAccountLimit(t *testing.T) {
+	createdLimit := createRandomAccountLimit(t)
+
+	err := testQueries.DeleteAccountLimit(context.Background(), createdLimit.AccountID)
+	require.NoError(t, err)
+
+	limit, err := testQueries.GetAccountLimit(context.Background(), createdLimit.AccountID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+	require.Empty(t, limit)
+}
@@ -2,7 +2,6 @@ package db
 
 import (
 	"context"
-	"database/sql"
 	"testing"
 	"time"
 
@@ -11,12 +10,18 @@ import (
 )
 
 func createRandomAccount(t *testing.T) Account {
+	return createRandomAccountWithCurrency(t, utils.RandomCurrency())
+}
+
+func createRandomAccountWithCurrency(t *testing.T, currency string) Account {
 	user := createRandomUser(t)
 
+	// enough money for the transfers of the store tests.
 	args := CreateAccountParams{
-		Owner:    user.Username,
-		Balance:  utils.RandomMoneyAmount(),
-		Currency: utils.RandomCurrency(),
+		Owner:       &user.Username,
+		Balance:     utils.RandomInt(2000, 10000),
+		Currency:    currency,
+		AccountType: utils.Checking,
 	}
 
 	account, err := testQueries.CreateAccount(context.Background(), args)
@@ -26,6 +31,7 @@ func createRandomAccount(t *testing.T) Account {
 	require.Equal(t, args.Owner, account.Owner)
 	require.Equal(t, args.Balance, account.Balance)
 	require.Equal(t, args.Currency, account.Currency)
+	require.Equal(t, args.AccountType, account.AccountType)
 
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
@@ -83,7 +89,7 @@ func TestDeleteAccount(t *testing.T) {
 
 	accountDeleted, err := testQueries.GetAccount(context.Background(), account.ID)
 	require.Error(t, err)
-	require.EqualError(t, err, sql.ErrNoRows.Error())
+	require.ErrorIs(t, err, ErrRecordNotFound)
 	require.Empty(t, accountDeleted)
 }
 
@@ -106,3 +112,58 @@ func TestListAccounts(t *testing.T) {
 		require.NotEmpty(t, account)
 	}
 }
+
+func TestListAccountsByOwner(t *testing.T) {
+	account1 := createRandomAccountWithCurrency(t, utils.USD)
+
+	args := CreateAccountParams{
+		Owner:       account1.Owner,
+		Balance:     utils.RandomMoneyAmount(),
+		Currency:    utils.EUR,
+		AccountType: utils.Checking,
+	}
+	account2, err := testQueries.CreateAccount(context.Background(), args)
+	require.NoError(t, err)
+
+	accounts, err := testQueries.ListAccountsByOwner(context.Background(), ListAccountsByOwnerParams{
+		Owner:  account1.Owner,
+		Limit:  5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 2)
+	require.Equal(t, account1.ID, accounts[0].ID)
+	require.Equal(t, account2.ID, accounts[1].ID)
+}
+
+func TestFreezeAccount(t *testing.T) {
+	account := createRandomAccount(t)
+	require.Nil(t, account.FrozenAt)
+
+	frozen, err := testQueries.FreezeAccount(context.Background(), FreezeAccountParams{
+		ID:     account.ID,
+		Reason: "suspected fraud",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, frozen.FrozenAt)
+	require.WithinDuration(t, time.Now(), *frozen.FrozenAt, time.Second)
+	require.Equal(t, "suspected fraud", frozen.FrozenReason)
+
+	unfrozen, err := testQueries.UnfreezeAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Nil(t, unfrozen.FrozenAt)
+	require.Empty(t, unfrozen.FrozenReason)
+
+	// system accounts can't be frozen.
+	cashIn, err := testQueries.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		SystemKind: SystemCashIn,
+		Currency:   utils.USD,
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.FreezeAccount(context.Background(), FreezeAccountParams{
+		ID:     cashIn.ID,
+		Reason: "test",
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,83 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: archived_partition.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createArchivedPartition = `-- name: CreateArchivedPartition :one
+INSERT INTO archived_partitions (
+	name,
+	parent_table,
+	month,
+	file,
+	rows
+) VALUES (
+	$1, $2, $3, $4, $5
+) RETURNING name, parent_table, month, file, rows, archived_at
+`
+
+type CreateArchivedPartitionParams struct {
+	Name        string    `json:"name"`
+	ParentTable string    `json:"parent_table"`
+	Month       time.Time `json:"month"`
+	File        string    `json:"file"`
+	Rows        int64     `json:"rows"`
+}
+
+func (q *Queries) CreateArchivedPartition(ctx context.Context, arg CreateArchivedPartitionParams) (ArchivedPartition, error) {
+	row := q.db.QueryRow(ctx, createArchivedPartition,
+		arg.Name,
+		arg.ParentTable,
+		arg.Month,
+		arg.File,
+		arg.Rows,
+	)
+	var i ArchivedPartition
+	err := row.Scan(
+		&i.Name,
+		&i.ParentTable,
+		&i.Month,
+		&i.File,
+		&i.Rows,
+		&i.ArchivedAt,
+	)
+	return i, err
+}
+
+const listArchivedPartitions = `-- name: ListArchivedPartitions :many
+SELECT name, parent_table, month, file, rows, archived_at FROM archived_partitions
+ORDER BY parent_table, month
+`
+
+func (q *Queries) ListArchivedPartitions(ctx context.Context) ([]ArchivedPartition, error) {
+	rows, err := q.db.Query(ctx, listArchivedPartitions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ArchivedPartition{}
+	for rows.Next() {
+		var i ArchivedPartition
+		if err := rows.Scan(
+			&i.Name,
+			&i.ParentTable,
+			&i.Month,
+			&i.File,
+			&i.Rows,
+			&i.ArchivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Returned by DeleteAccountTx and DeleteUserTx when an account still holds money.
+var ErrAccountNotEmpty = errors.New("account balance is not zero")
+
+// Returned by CreateAccountTx when the owner is deleted.
+var ErrUserDeleted = errors.New("user is deleted")
+
+// Contains the parameters of the account deletion transaction.
+type DeleteAccountTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// Version the account must be at, 0 for any.
+	ExpectedVersion int64 `json:"expected_version"`
+}
+
+// Soft deletes an account. The account and its ledger history are kept, but
+// it is no longer listed and can neither send nor receive transfers. Its
+// unposted interest is voided, paying it would leave money in the account.
+// It fails with ErrRecordNotFound if the account is a system account or is
+// already deleted, with ErrVersionConflict if it isn't at the expected
+// version, and with ErrAccountNotEmpty if its balance isn't zero.
+func (store *txStore) DeleteAccountTx(ctx context.Context, arg DeleteAccountTxParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q Querier) error {
+		accounts, err := lockAccounts(ctx, q, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		locked := accounts[arg.AccountID]
+		if locked.SystemKind != nil || locked.DeletedAt != nil {
+			return ErrRecordNotFound
+		}
+		if err := checkVersion(locked, arg.ExpectedVersion); err != nil {
+			return err
+		}
+		if locked.Balance != 0 {
+			return fmt.Errorf("%w: account %d has %d", ErrAccountNotEmpty, arg.AccountID, locked.Balance)
+		}
+
+		_, err = q.VoidInterestAccruals(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		account, err = q.SoftDeleteAccount(ctx, arg.AccountID)
+		return err
+	})
+
+	return account, err
+}
+
+// Deletes a user: its personal fields are anonymized, its accounts soft
+// deleted with their unposted interest voided, and its sessions and webhooks removed within a single database
+// transaction. The username is kept, so the ledger history stays intact, and
+// the personal fields are also redacted from the user's outbox events.
+// It fails with ErrRecordNotFound if the user doesn't exist or is already
+// deleted, and with ErrAccountNotEmpty if one of its accounts holds money.
+func (store *txStore) DeleteUserTx(ctx context.Context, username string) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q Querier) error {
+		// anonymizing first locks the user, so no account can be opened meanwhile.
+		var err error
+		user, err = q.AnonymizeUser(ctx, username)
+		if err != nil {
+			return err
+		}
+
+		accountIDs, err := q.ListOpenAccountIDsByOwner(ctx, &username)
+		if err != nil {
+			return err
+		}
+
+		accounts, err := lockAccounts(ctx, q, accountIDs...)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range accountIDs {
+			if balance := accounts[id].Balance; balance != 0 {
+				return fmt.Errorf("%w: account %d has %d", ErrAccountNotEmpty, id, balance)
+			}
+
+			_, err = q.VoidInterestAccruals(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			_, err = q.SoftDeleteAccount(ctx, id)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = q.DeleteUserSessions(ctx, username)
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteUserWebhooks(ctx, username)
+		if err != nil {
+			return err
+		}
+
+		return q.RedactUserOutboxEvents(ctx, username)
+	})
+
+	return user, err
+}
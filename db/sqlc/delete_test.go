@@ -0,0 +1,156 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomSession(t *testing.T, q Querier, username string, expiresAt time.Time) Session {
+	session, err := q.CreateSession(context.Background(), CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     username,
+		RefreshToken: utils.RandomString(32),
+		UserAgent:    "test",
+		ClientIp:     "127.0.0.1",
+		ExpiresAt:    expiresAt,
+	})
+	require.NoError(t, err)
+	return session
+}
+
+func TestDeleteAccountTx(t *testing.T) {
+	store := NewStore(testDB, testConfig)
+	account := createRandomAccount(t)
+	other := createRandomAccountWithCurrency(t, account.Currency)
+
+	_, err := store.DeleteAccountTx(context.Background(), DeleteAccountTxParams{AccountID: account.ID})
+	require.ErrorIs(t, err, ErrAccountNotEmpty)
+
+	emptied, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{ID: account.ID})
+	require.NoError(t, err)
+
+	_, err = store.DeleteAccountTx(context.Background(), DeleteAccountTxParams{
+		AccountID:       account.ID,
+		ExpectedVersion: account.Version,
+	})
+	require.ErrorIs(t, err, ErrVersionConflict)
+
+	deleted, err := store.DeleteAccountTx(context.Background(), DeleteAccountTxParams{
+		AccountID:       account.ID,
+		ExpectedVersion: emptied.Version,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, deleted.DeletedAt)
+	require.WithinDuration(t, time.Now(), *deleted.DeletedAt, time.Second)
+
+	_, err = store.DeleteAccountTx(context.Background(), DeleteAccountTxParams{AccountID: account.ID})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	accounts, err := testQueries.ListAccountsByOwner(context.Background(), ListAccountsByOwnerParams{
+		Owner: account.Owner,
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: other.ID,
+		ToAccountID:   account.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrAccountDeleted)
+
+	// the owner can open an account in the same currency again.
+	_, err = testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:       account.Owner,
+		Currency:    account.Currency,
+		AccountType: utils.Checking,
+	})
+	require.NoError(t, err)
+}
+
+func TestDeleteUserTx(t *testing.T) {
+	store := NewStore(testDB, testConfig)
+	user := createRandomUser(t)
+
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:       &user.Username,
+		Currency:    utils.USD,
+		AccountType: utils.Checking,
+	})
+	require.NoError(t, err)
+	session := createRandomSession(t, testQueries, user.Username, time.Now().Add(time.Hour))
+	createRandomWebhook(t, user.Username, []string{})
+
+	deleted, err := store.DeleteUserTx(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, deleted.Username)
+	require.Empty(t, deleted.HashedPassword)
+	require.Empty(t, deleted.FullName)
+	require.NotEqual(t, user.Email, deleted.Email)
+	require.True(t, deleted.DeletedAt.Valid)
+
+	_, err = store.DeleteUserTx(context.Background(), user.Username)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	account, err = testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.NotNil(t, account.DeletedAt)
+
+	_, err = testQueries.GetSession(context.Background(), session.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	webhooks, err := testQueries.ListWebhooks(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Empty(t, webhooks)
+
+	_, err = store.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:       &user.Username,
+		Currency:    utils.EUR,
+		AccountType: utils.Checking,
+	})
+	require.ErrorIs(t, err, ErrUserDeleted)
+}
+
+func TestDeleteUserTxAccountNotEmpty(t *testing.T) {
+	store := NewStore(testDB, testConfig)
+	account := createRandomAccount(t)
+
+	_, err := store.DeleteUserTx(context.Background(), *account.Owner)
+	require.ErrorIs(t, err, ErrAccountNotEmpty)
+
+	// the user is left untouched.
+	user, err := testQueries.GetUser(context.Background(), *account.Owner)
+	require.NoError(t, err)
+	require.NotEmpty(t, user.FullName)
+	require.False(t, user.DeletedAt.Valid)
+}
+
+func TestDeleteExpiredSessions(t *testing.T) {
+	q := newIsolatedQueries(t)
+	user, err := q.CreateUser(context.Background(), CreateUserParams{
+		Username:       utils.RandomOwner(),
+		HashedPassword: utils.RandomString(32),
+		FullName:       utils.RandomOwner(),
+		Email:          utils.RandomEmailAddress(),
+	})
+	require.NoError(t, err)
+
+	expired := createRandomSession(t, q, user.Username, time.Now().Add(-2*time.Hour))
+	active := createRandomSession(t, q, user.Username, time.Now().Add(time.Hour))
+
+	deleted, err := q.DeleteExpiredSessions(context.Background(), time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+
+	_, err = q.GetSession(context.Background(), expired.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	_, err = q.GetSession(context.Background(), active.ID)
+	require.NoError(t, err)
+}
@@ -7,30 +7,52 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
 )
 
 const createEntry = `-- name: CreateEntry :one
 INSERT INTO entries (
 	account_id,
-	amount
+	amount,
+	description,
+	reference,
+	metadata,
+	journal_id
 ) VALUES (
-	$1, $2
-) RETURNING id, account_id, amount, created_at
+	$1, $2, $3, $4, $5, $6
+) RETURNING id, account_id, amount, created_at, description, reference, metadata, journal_id
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID   int64           `json:"account_id"`
+	Amount      int64           `json:"amount"`
+	Description string          `json:"description"`
+	Reference   string          `json:"reference"`
+	Metadata    json.RawMessage `json:"metadata"`
+	JournalID   sql.NullInt64   `json:"journal_id"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, createEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.Description,
+		arg.Reference,
+		arg.Metadata,
+		arg.JournalID,
+	)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
+		&i.JournalID,
 	)
 	return i, err
 }
@@ -41,43 +63,54 @@ WHERE id = $1
 `
 
 func (q *Queries) DeleteEntry(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteEntry, id)
+	_, err := q.db.Exec(ctx, deleteEntry, id)
 	return err
 }
 
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, description, reference, metadata, journal_id FROM entries
 WHERE id = $1 LIMIT 1
 `
 
+// the ids are unique across the partitions, see the comment of entries.id.
 func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, getEntry, id)
+	row := q.db.QueryRow(ctx, getEntry, id)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
+		&i.JournalID,
 	)
 	return i, err
 }
 
 const listAccountEntries = `-- name: ListAccountEntries :many
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, description, reference, metadata, journal_id FROM entries
 WHERE account_id = $1
-ORDER BY amount
-LIMIT $2
-OFFSET $3
+AND (created_at, id) > ($2::timestamptz, $3::bigint)
+ORDER BY created_at, id
+LIMIT $4
 `
 
 type ListAccountEntriesParams struct {
-	AccountID int64 `json:"account_id"`
-	Limit     int32 `json:"limit"`
-	Offset    int32 `json:"offset"`
+	AccountID      int64     `json:"account_id"`
+	AfterCreatedAt time.Time `json:"after_created_at"`
+	AfterID        int64     `json:"after_id"`
+	LimitCount     int32     `json:"limit_count"`
 }
 
 func (q *Queries) ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]Entry, error) {
-	rows, err := q.db.QueryContext(ctx, listAccountEntries, arg.AccountID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listAccountEntries,
+		arg.AccountID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.LimitCount,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -90,34 +123,124 @@ func (q *Queries) ListAccountEntries(ctx context.Context, arg ListAccountEntries
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
+			&i.JournalID,
 		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
 	}
-	if err := rows.Close(); err != nil {
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountEntriesAfter = `-- name: ListAccountEntriesAfter :many
+SELECT id, account_id, amount, created_at, description, reference, metadata, journal_id FROM entries
+WHERE account_id = $1
+AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListAccountEntriesAfterParams struct {
+	AccountID  int64 `json:"account_id"`
+	AfterID    int64 `json:"after_id"`
+	LimitCount int32 `json:"limit_count"`
+}
+
+func (q *Queries) ListAccountEntriesAfter(ctx context.Context, arg ListAccountEntriesAfterParams) ([]Entry, error) {
+	rows, err := q.db.Query(ctx, listAccountEntriesAfter, arg.AccountID, arg.AfterID, arg.LimitCount)
+	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
+			&i.JournalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 	return items, nil
 }
 
-const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at FROM entries
+const listAccountEntriesBetween = `-- name: ListAccountEntriesBetween :many
+SELECT id, account_id, amount, created_at, description, reference, metadata, journal_id FROM entries
+WHERE account_id = $1
+AND created_at >= $2
+AND created_at < $3
 ORDER BY id
-LIMIT $1
-OFFSET $2
+`
+
+type ListAccountEntriesBetweenParams struct {
+	AccountID int64     `json:"account_id"`
+	FromTime  time.Time `json:"from_time"`
+	ToTime    time.Time `json:"to_time"`
+}
+
+func (q *Queries) ListAccountEntriesBetween(ctx context.Context, arg ListAccountEntriesBetweenParams) ([]Entry, error) {
+	rows, err := q.db.Query(ctx, listAccountEntriesBetween, arg.AccountID, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
+			&i.JournalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEntries = `-- name: ListEntries :many
+SELECT id, account_id, amount, created_at, description, reference, metadata, journal_id FROM entries
+WHERE (created_at, id) > ($1::timestamptz, $2::bigint)
+ORDER BY created_at, id
+LIMIT $3
 `
 
 type ListEntriesParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	AfterCreatedAt time.Time `json:"after_created_at"`
+	AfterID        int64     `json:"after_id"`
+	LimitCount     int32     `json:"limit_count"`
 }
 
+// the entries after the (after_created_at, after_id) cursor, the zero values
+// give the first page.
 func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
-	rows, err := q.db.QueryContext(ctx, listEntries, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listEntries, arg.AfterCreatedAt, arg.AfterID, arg.LimitCount)
 	if err != nil {
 		return nil, err
 	}
@@ -130,25 +253,89 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
+			&i.JournalID,
 		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
 	}
-	if err := rows.Close(); err != nil {
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listJournalEntries = `-- name: ListJournalEntries :many
+SELECT id, account_id, amount, created_at, description, reference, metadata, journal_id FROM entries
+WHERE journal_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListJournalEntries(ctx context.Context, journalID sql.NullInt64) ([]Entry, error) {
+	rows, err := q.db.Query(ctx, listJournalEntries, journalID)
+	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
+			&i.JournalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 	return items, nil
 }
 
+const notifyAccountEntry = `-- name: NotifyAccountEntry :exec
+SELECT pg_notify('account_entries', $1::text)
+`
+
+func (q *Queries) NotifyAccountEntry(ctx context.Context, payload string) error {
+	_, err := q.db.Exec(ctx, notifyAccountEntry, payload)
+	return err
+}
+
+const sumAccountEntriesSince = `-- name: SumAccountEntriesSince :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM entries
+WHERE account_id = $1
+AND created_at >= $2
+`
+
+type SumAccountEntriesSinceParams struct {
+	AccountID int64     `json:"account_id"`
+	Since     time.Time `json:"since"`
+}
+
+func (q *Queries) SumAccountEntriesSince(ctx context.Context, arg SumAccountEntriesSinceParams) (int64, error) {
+	row := q.db.QueryRow(ctx, sumAccountEntriesSince, arg.AccountID, arg.Since)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
 const updateEntry = `-- name: UpdateEntry :one
 UPDATE entries
 SET amount = $2
 WHERE id = $1
-RETURNING id, account_id, amount, created_at
+RETURNING id, account_id, amount, created_at, description, reference, metadata, journal_id
 `
 
 type UpdateEntryParams struct {
@@ -157,13 +344,17 @@ type UpdateEntryParams struct {
 }
 
 func (q *Queries) UpdateEntry(ctx context.Context, arg UpdateEntryParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, updateEntry, arg.ID, arg.Amount)
+	row := q.db.QueryRow(ctx, updateEntry, arg.ID, arg.Amount)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
+		&i.JournalID,
 	)
 	return i, err
 }
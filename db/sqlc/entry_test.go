@@ -2,7 +2,7 @@ package db
 
 import (
 	"context"
-	"database/sql"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -17,8 +17,10 @@ func createRandomEntry(t *testing.T) Entry {
 
 func CreateRandomEntryAtSpecificAccount(t *testing.T, account Account) Entry {
 	args := CreateEntryParams{
-		AccountID: account.ID,
-		Amount:    utils.RandomMoneyAmountForEntries(),
+		AccountID:   account.ID,
+		Amount:      utils.RandomMoneyAmountForEntries(),
+		Description: utils.RandomString(20),
+		Metadata:    json.RawMessage(`{}`),
 	}
 
 	entry, err := testQueries.CreateEntry(context.Background(), args)
@@ -47,7 +49,7 @@ func TestDeleteEntry(t *testing.T) {
 
 	entryToDelete, err := testQueries.GetEntry(context.Background(), entry.ID)
 	require.Error(t, err)
-	require.EqualError(t, err, sql.ErrNoRows.Error())
+	require.ErrorIs(t, err, ErrRecordNotFound)
 	require.Empty(t, entryToDelete)
 }
 
@@ -91,8 +93,7 @@ func TestListEntries(t *testing.T) {
 	}
 
 	args := ListEntriesParams{
-		Limit:  5,
-		Offset: 5,
+		LimitCount: 5,
 	}
 
 	entries, err := testQueries.ListEntries(context.Background(), args)
@@ -113,17 +114,60 @@ func TestAccountEntries(t *testing.T) {
 	}
 
 	args := ListAccountEntriesParams{
-		AccountID: account.ID,
-		Limit:     5,
-		Offset:    5,
+		AccountID:  account.ID,
+		LimitCount: 5,
 	}
 
-	entries, err := testQueries.ListAccountEntries(context.Background(), args)
+	firstPage, err := testQueries.ListAccountEntries(context.Background(), args)
+	require.NoError(t, err)
+	require.Len(t, firstPage, 5)
+
+	// the next page follows the last entry of the first one.
+	last := firstPage[len(firstPage)-1]
+	args.AfterCreatedAt = last.CreatedAt
+	args.AfterID = last.ID
 
+	secondPage, err := testQueries.ListAccountEntries(context.Background(), args)
 	require.NoError(t, err)
-	require.Len(t, entries, 5)
+	require.Len(t, secondPage, 5)
 
-	for _, entry := range entries {
-		require.NotEmpty(t, entry)
+	for _, entry := range secondPage {
+		require.Equal(t, account.ID, entry.AccountID)
+		require.False(t, entry.CreatedAt.Before(last.CreatedAt))
+		require.NotEqual(t, last.ID, entry.ID)
 	}
 }
+
+func TestAccountEntriesBetween(t *testing.T) {
+	account := createRandomAccount(t)
+	from := time.Now().Add(-time.Minute)
+
+	var total int64
+	for i := 0; i < 3; i++ {
+		entry := CreateRandomEntryAtSpecificAccount(t, account)
+		total += entry.Amount
+	}
+
+	entries, err := testQueries.ListAccountEntriesBetween(context.Background(), ListAccountEntriesBetweenParams{
+		AccountID: account.ID,
+		FromTime:  from,
+		ToTime:    time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	sum, err := testQueries.SumAccountEntriesSince(context.Background(), SumAccountEntriesSinceParams{
+		AccountID: account.ID,
+		Since:     from,
+	})
+	require.NoError(t, err)
+	require.Equal(t, total, sum)
+
+	// nothing is booked in the future.
+	sum, err = testQueries.SumAccountEntriesSince(context.Background(), SumAccountEntriesSinceParams{
+		AccountID: account.ID,
+		Since:     time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Zero(t, sum)
+}
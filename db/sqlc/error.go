@@ -0,0 +1,150 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SQLSTATE codes of the constraint and concurrency errors.
+const (
+	NotNullViolation     = "23502"
+	ForeignKeyViolation  = "23503"
+	UniqueViolation      = "23505"
+	CheckViolation       = "23514"
+	SerializationFailure = "40001"
+	DeadlockDetected     = "40P01"
+)
+
+// Classes of driver errors, matched with errors.Is.
+var (
+	ErrRecordNotFound       = errors.New("record not found")
+	ErrUniqueViolation      = errors.New("unique violation")
+	ErrForeignKeyViolation  = errors.New("foreign key violation")
+	ErrSerializationFailure = errors.New("serialization failure")
+	ErrDeadlockDetected     = errors.New("deadlock detected")
+)
+
+// Error of the driver with its class. The driver error stays in the chain
+// for callers that need its details.
+type classifiedError struct {
+	class error
+	err   error
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Is(target error) bool {
+	return target == e.class
+}
+
+func (e *classifiedError) Unwrap() error {
+	return e.err
+}
+
+// Returns the SQLSTATE code of a pgx error, or an empty string if the error
+// doesn't come from the database.
+func ErrorCode(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+// Returns the name of the constraint violated by a pgx error.
+func ErrorConstraint(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.ConstraintName
+	}
+	return ""
+}
+
+// Returns the error of a statement violating a constraint of the schema, as
+// the driver reports it. It lets Store implementations that enforce the
+// constraints themselves fail like SQLStore.
+func NewConstraintError(code string, constraint string, message string) error {
+	return translateError(&pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           code,
+		Message:        message,
+		ConstraintName: constraint,
+	})
+}
+
+// Wraps a driver error so that it matches the sentinel of its class.
+// Other errors are returned as they are.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var class error
+	switch {
+	case errors.Is(err, pgx.ErrNoRows), errors.Is(err, sql.ErrNoRows):
+		class = ErrRecordNotFound
+	default:
+		switch ErrorCode(err) {
+		case UniqueViolation:
+			class = ErrUniqueViolation
+		case ForeignKeyViolation:
+			class = ErrForeignKeyViolation
+		case SerializationFailure:
+			class = ErrSerializationFailure
+		case DeadlockDetected:
+			class = ErrDeadlockDetected
+		default:
+			return err
+		}
+	}
+
+	if errors.Is(err, class) {
+		return err
+	}
+	return &classifiedError{class: class, err: err}
+}
+
+// Queries' connection that translates the errors of every query, so that the
+// store's callers can match them without knowing the driver.
+type translatingDBTX struct {
+	DBTX
+}
+
+func (db translatingDBTX) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	tag, err := db.DBTX.Exec(ctx, query, args...)
+	return tag, translateError(err)
+}
+
+func (db translatingDBTX) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	rows, err := db.DBTX.Query(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return translatingRows{rows}, nil
+}
+
+func (db translatingDBTX) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	return translatingRow{db.DBTX.QueryRow(ctx, query, args...)}
+}
+
+type translatingRows struct {
+	pgx.Rows
+}
+
+func (rows translatingRows) Err() error {
+	return translateError(rows.Rows.Err())
+}
+
+type translatingRow struct {
+	pgx.Row
+}
+
+func (row translatingRow) Scan(dest ...any) error {
+	return translateError(row.Row.Scan(dest...))
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateError(t *testing.T) {
+	testCases := []struct {
+		name  string
+		err   error
+		class error
+	}{
+		{"UniqueViolation", &pgconn.PgError{Code: UniqueViolation}, ErrUniqueViolation},
+		{"ForeignKeyViolation", &pgconn.PgError{Code: ForeignKeyViolation}, ErrForeignKeyViolation},
+		{"SerializationFailure", &pgconn.PgError{Code: SerializationFailure}, ErrSerializationFailure},
+		{"DeadlockDetected", &pgconn.PgError{Code: DeadlockDetected}, ErrDeadlockDetected},
+		{"Wrapped", fmt.Errorf("cannot create user: %w", &pgconn.PgError{Code: UniqueViolation}), ErrUniqueViolation},
+		{"SQLNoRows", sql.ErrNoRows, ErrRecordNotFound},
+		{"PgxNoRows", pgx.ErrNoRows, ErrRecordNotFound},
+		{"CheckViolation", &pgconn.PgError{Code: CheckViolation}, nil},
+		{"Other", errors.New("connection refused"), nil},
+	}
+
+	sentinels := []error{ErrRecordNotFound, ErrUniqueViolation, ErrForeignKeyViolation, ErrSerializationFailure, ErrDeadlockDetected}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			err := translateError(testCase.err)
+			require.ErrorIs(t, err, testCase.err)
+			require.Equal(t, testCase.err.Error(), err.Error())
+
+			for _, sentinel := range sentinels {
+				require.Equal(t, sentinel == testCase.class, errors.Is(err, sentinel), sentinel)
+			}
+		})
+	}
+
+	require.NoError(t, translateError(nil))
+}
+
+func TestErrorConstraint(t *testing.T) {
+	store := NewStore(testDB, testConfig)
+	user := createRandomUser(t)
+
+	_, err := store.CreateUserTx(context.Background(), CreateUserParams{
+		Username:       user.Username,
+		HashedPassword: user.HashedPassword,
+		FullName:       user.FullName,
+		Email:          utils.RandomEmailAddress(),
+	})
+	require.ErrorIs(t, err, ErrUniqueViolation)
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+	require.Equal(t, "users_pkey", ErrorConstraint(err))
+
+	_, err = store.CreateUserTx(context.Background(), CreateUserParams{
+		Username:       utils.RandomOwner(),
+		HashedPassword: user.HashedPassword,
+		FullName:       user.FullName,
+		Email:          user.Email,
+	})
+	require.ErrorIs(t, err, ErrUniqueViolation)
+	require.Equal(t, "email_key", ErrorConstraint(err))
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Types of the aggregates domain events are about.
+const (
+	AggregateAccount  = "account"
+	AggregateTransfer = "transfer"
+	AggregateUser     = "user"
+)
+
+// Types of domain events written to the outbox.
+const (
+	EventTransferCompleted = "TransferCompleted"
+	EventAccountCreated    = "AccountCreated"
+	EventUserRegistered    = "UserRegistered"
+)
+
+// Payload of a TransferCompleted event.
+type TransferCompletedEvent struct {
+	TransferID    int64  `json:"transfer_id"`
+	JournalID     int64  `json:"journal_id"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Fee           int64  `json:"fee"`
+	Currency      string `json:"currency"`
+	Reference     string `json:"reference"`
+}
+
+// Payload of an AccountCreated event.
+type AccountCreatedEvent struct {
+	AccountID   int64  `json:"account_id"`
+	Owner       string `json:"owner"`
+	Currency    string `json:"currency"`
+	AccountType string `json:"account_type"`
+}
+
+// Payload of a UserRegistered event. The outbox keeps the events in
+// plaintext and hands them to the sinks, so it leaves out the personal data
+// of the user, which can be read from the users table by its username.
+type UserRegisteredEvent struct {
+	Username string `json:"username"`
+}
+
+// Writes a domain event to the outbox with the given queries, so it is only
+// published if the surrounding transaction commits.
+func enqueueEvent(ctx context.Context, q Querier, aggregateType string, aggregateID string, eventType string, payload any) (OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return OutboxEvent{}, err
+	}
+
+	return q.CreateOutboxEvent(ctx, CreateOutboxEventParams{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       data,
+	})
+}
+
+// Creates an account and writes an AccountCreated event to the outbox
+// within a single database transaction. It fails with ErrUserDeleted if the
+// owner is deleted.
+func (store *txStore) CreateAccountTx(ctx context.Context, args CreateAccountParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q Querier) error {
+		// lock the owner so that it can't be deleted before the account is
+		// created. A missing owner fails on the foreign key below.
+		if args.Owner != nil {
+			owner, err := q.GetUserForShare(ctx, *args.Owner)
+			if err != nil && !errors.Is(err, ErrRecordNotFound) {
+				return err
+			}
+			if owner.DeletedAt.Valid {
+				return fmt.Errorf("%w: %s", ErrUserDeleted, owner.Username)
+			}
+		}
+
+		var err error
+		account, err = q.CreateAccount(ctx, args)
+		if err != nil {
+			return err
+		}
+
+		var owner string
+		if account.Owner != nil {
+			owner = *account.Owner
+		}
+
+		_, err = enqueueEvent(ctx, q, AggregateAccount, strconv.FormatInt(account.ID, 10), EventAccountCreated, AccountCreatedEvent{
+			AccountID:   account.ID,
+			Owner:       owner,
+			Currency:    account.Currency,
+			AccountType: account.AccountType,
+		})
+		return err
+	})
+
+	return account, err
+}
+
+// Creates a user and writes a UserRegistered event to the outbox
+// within a single database transaction.
+func (store *txStore) CreateUserTx(ctx context.Context, args CreateUserParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q Querier) error {
+		var err error
+		user, err = q.CreateUser(ctx, args)
+		if err != nil {
+			return err
+		}
+
+		_, err = enqueueEvent(ctx, q, AggregateUser, user.Username, EventUserRegistered, UserRegisteredEvent{
+			Username: user.Username,
+		})
+		return err
+	})
+
+	return user, err
+}
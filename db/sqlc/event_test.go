@@ -0,0 +1,151 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func requireSingleEvent(t *testing.T, aggregateType string, aggregateID string, eventType string) OutboxEvent {
+	events, err := testQueries.ListOutboxEventsByAggregate(context.Background(), ListOutboxEventsByAggregateParams{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	event := events[0]
+	require.Equal(t, eventType, event.EventType)
+	require.False(t, event.PublishedAt.Valid)
+	require.Zero(t, event.Attempts)
+	require.NotZero(t, event.CreatedAt)
+	return event
+}
+
+func TestCreateAccountTx(t *testing.T) {
+	store := NewStore(testDB, testConfig)
+	user := createRandomUser(t)
+
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:       &user.Username,
+		Balance:     0,
+		Currency:    utils.USD,
+		AccountType: utils.Checking,
+	})
+	require.NoError(t, err)
+	require.NotZero(t, account.ID)
+
+	event := requireSingleEvent(t, AggregateAccount, strconv.FormatInt(account.ID, 10), EventAccountCreated)
+
+	var payload AccountCreatedEvent
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, AccountCreatedEvent{
+		AccountID:   account.ID,
+		Owner:       user.Username,
+		Currency:    utils.USD,
+		AccountType: utils.Checking,
+	}, payload)
+}
+
+func TestCreateAccountTxRollback(t *testing.T) {
+	store := NewStore(testDB, testConfig)
+	owner := utils.RandomOwner()
+
+	// the owner doesn't exist, so neither the account nor its event are written.
+	_, err := store.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:       &owner,
+		Currency:    utils.USD,
+		AccountType: utils.Checking,
+	})
+	require.ErrorIs(t, err, ErrForeignKeyViolation)
+}
+
+func TestCreateUserTx(t *testing.T) {
+	store := NewStore(testDB, testConfig)
+
+	hashedPassword, err := utils.HashPassword(utils.RandomString(6))
+	require.NoError(t, err)
+
+	user, err := store.CreateUserTx(context.Background(), CreateUserParams{
+		Username:       utils.RandomOwner(),
+		HashedPassword: hashedPassword,
+		FullName:       utils.RandomOwner(),
+		Email:          utils.RandomEmailAddress(),
+	})
+	require.NoError(t, err)
+
+	event := requireSingleEvent(t, AggregateUser, user.Username, EventUserRegistered)
+
+	var payload UserRegisteredEvent
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, user.Username, payload.Username)
+	require.NotContains(t, string(event.Payload), user.Email)
+	require.NotContains(t, string(event.Payload), user.FullName)
+	require.NotContains(t, string(event.Payload), hashedPassword)
+}
+
+func TestTransferTxEvent(t *testing.T) {
+	store := NewStore(testDB, testConfig)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Reference:     utils.RandomString(12),
+	})
+	require.NoError(t, err)
+
+	event := requireSingleEvent(t, AggregateTransfer, strconv.FormatInt(result.Transfer.ID, 10), EventTransferCompleted)
+
+	var payload TransferCompletedEvent
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, result.Transfer.ID, payload.TransferID)
+	require.Equal(t, result.Journal.ID, payload.JournalID)
+	require.Equal(t, account1.ID, payload.FromAccountID)
+	require.Equal(t, account2.ID, payload.ToAccountID)
+	require.Equal(t, int64(10), payload.Amount)
+	require.Equal(t, account1.Currency, payload.Currency)
+	require.Equal(t, result.Transfer.Reference, payload.Reference)
+}
+
+func TestMarkOutboxEventPublished(t *testing.T) {
+	store := NewStore(testDB, testConfig)
+	account := createRandomAccount(t)
+
+	savings, err := store.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:       account.Owner,
+		Currency:    utils.EUR,
+		AccountType: utils.Savings,
+	})
+	require.NoError(t, err)
+
+	event := requireSingleEvent(t, AggregateAccount, strconv.FormatInt(savings.ID, 10), EventAccountCreated)
+
+	err = testQueries.RecordOutboxEventFailure(context.Background(), RecordOutboxEventFailureParams{
+		ID:            event.ID,
+		LastError:     "sink is down",
+		NextAttemptAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	err = testQueries.MarkOutboxEventPublished(context.Background(), event.ID)
+	require.NoError(t, err)
+
+	events, err := testQueries.ListOutboxEventsByAggregate(context.Background(), ListOutboxEventsByAggregateParams{
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.True(t, events[0].PublishedAt.Valid)
+	require.Equal(t, int32(2), events[0].Attempts)
+	require.Empty(t, events[0].LastError)
+}
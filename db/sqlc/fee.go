@@ -0,0 +1,35 @@
+package db
+
+import (
+	"context"
+)
+
+// Returns the fee charged on a transfer and the system account it is booked to.
+// Transfers in a currency without a fee schedule, and transfers from or to
+// system accounts, are free.
+func (store *txStore) transferFee(ctx context.Context, q Querier, args TransferTxParams) (fee int64, feeAccountID int64, err error) {
+	sender, err := q.GetAccount(ctx, args.FromAccountID)
+	if err != nil {
+		return
+	}
+
+	schedule, ok := store.config.FeeSchedule[sender.Currency]
+	if !ok || sender.SystemKind != nil {
+		return
+	}
+
+	recipient, err := q.GetAccount(ctx, args.ToAccountID)
+	if err != nil || recipient.SystemKind != nil {
+		return
+	}
+
+	feeAccount, err := q.GetSystemAccount(ctx, GetSystemAccountParams{
+		SystemKind: SystemFees,
+		Currency:   sender.Currency,
+	})
+	if err != nil {
+		return
+	}
+
+	return schedule.Compute(args.Amount), feeAccount.ID, nil
+}
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kvgtl/simplebank/utils"
+)
+
+// Contains the parameters of the interest posting transaction.
+type PostInterestTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// Only interest accrued before this day is posted.
+	Before time.Time `json:"before"`
+}
+
+// Contains the result of the interest posting transaction.
+type PostInterestTxResult struct {
+	Account  Account `json:"account"`
+	Entry    Entry   `json:"entry"`
+	Amount   int64   `json:"amount"`
+	Accruals int     `json:"accruals"`
+}
+
+// Pays the interest accrued by an account before a day.
+// The accruals are summed and rounded to minor units, booked as a journal
+// posting from the interest system account of the currency, and marked as
+// posted within a single database transaction, so running it twice doesn't
+// pay twice. The interest of a frozen account stays unposted until it is
+// unfrozen, the interest of a deleted account is voided.
+func (store *txStore) PostInterestTx(ctx context.Context, args PostInterestTxParams) (PostInterestTxResult, error) {
+	var result PostInterestTxResult
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q Querier) error {
+		account, err := q.GetAccount(ctx, args.AccountID)
+		if err != nil {
+			return err
+		}
+
+		expenseAccount, err := q.GetSystemAccount(ctx, GetSystemAccountParams{
+			SystemKind: SystemInterest,
+			Currency:   account.Currency,
+		})
+		if err != nil {
+			return err
+		}
+
+		locked, err := lockAccounts(ctx, q, account.ID, expenseAccount.ID)
+		if err != nil {
+			return err
+		}
+
+		account = locked[account.ID]
+		result.Account = account
+		if account.DeletedAt != nil {
+			_, err = q.VoidInterestAccruals(ctx, account.ID)
+			return err
+		}
+		if account.FrozenAt != nil {
+			return nil
+		}
+
+		accruals, err := q.ListUnpostedInterestAccrualsForUpdate(ctx, ListUnpostedInterestAccrualsForUpdateParams{
+			AccountID: args.AccountID,
+			Before:    args.Before,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Accruals = len(accruals)
+		if len(accruals) == 0 {
+			return nil
+		}
+
+		var micros int64
+		for _, accrual := range accruals {
+			micros += accrual.AmountMicros
+		}
+		result.Amount = utils.MicrosToMinorUnits(micros)
+
+		var entryID sql.NullInt64
+		if result.Amount > 0 {
+			metadata, err := json.Marshal(map[string]string{
+				"from": accruals[0].AccrualDate.Format(time.DateOnly),
+				"to":   accruals[len(accruals)-1].AccrualDate.Format(time.DateOnly),
+			})
+			if err != nil {
+				return err
+			}
+
+			posting, err := postJournal(ctx, q, PostJournalTxParams{
+				Kind:        JournalInterest,
+				Description: "interest",
+				Lines: []JournalLine{
+					{
+						AccountID:   account.ID,
+						Amount:      result.Amount,
+						Description: "interest",
+						Metadata:    metadata,
+					},
+					{
+						AccountID:   expenseAccount.ID,
+						Amount:      -result.Amount,
+						Description: "interest",
+						Metadata:    metadata,
+					},
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			result.Entry = posting.Entries[0]
+			for _, postedAccount := range posting.Accounts {
+				if postedAccount.ID == account.ID {
+					result.Account = postedAccount
+				}
+			}
+
+			entryID = sql.NullInt64{Int64: result.Entry.ID, Valid: true}
+		}
+
+		_, err = q.MarkInterestAccrualsPosted(ctx, MarkInterestAccrualsPostedParams{
+			EntryID:   entryID,
+			AccountID: args.AccountID,
+			Before:    args.Before,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,196 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: interest_accrual.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const createInterestAccrual = `-- name: CreateInterestAccrual :execrows
+INSERT INTO interest_accruals (
+	account_id,
+	accrual_date,
+	balance,
+	rate_basis_points,
+	amount_micros
+) VALUES (
+	$1, $2, $3, $4, $5
+) ON CONFLICT (account_id, accrual_date) DO NOTHING
+`
+
+type CreateInterestAccrualParams struct {
+	AccountID       int64     `json:"account_id"`
+	AccrualDate     time.Time `json:"accrual_date"`
+	Balance         int64     `json:"balance"`
+	RateBasisPoints int64     `json:"rate_basis_points"`
+	AmountMicros    int64     `json:"amount_micros"`
+}
+
+func (q *Queries) CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createInterestAccrual,
+		arg.AccountID,
+		arg.AccrualDate,
+		arg.Balance,
+		arg.RateBasisPoints,
+		arg.AmountMicros,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getInterestAccrual = `-- name: GetInterestAccrual :one
+SELECT id, account_id, accrual_date, balance, rate_basis_points, amount_micros, posted_at, entry_id, created_at FROM interest_accruals
+WHERE account_id = $1 AND accrual_date = $2 LIMIT 1
+`
+
+type GetInterestAccrualParams struct {
+	AccountID   int64     `json:"account_id"`
+	AccrualDate time.Time `json:"accrual_date"`
+}
+
+func (q *Queries) GetInterestAccrual(ctx context.Context, arg GetInterestAccrualParams) (InterestAccrual, error) {
+	row := q.db.QueryRow(ctx, getInterestAccrual, arg.AccountID, arg.AccrualDate)
+	var i InterestAccrual
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.AccrualDate,
+		&i.Balance,
+		&i.RateBasisPoints,
+		&i.AmountMicros,
+		&i.PostedAt,
+		&i.EntryID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLastInterestAccrualDate = `-- name: GetLastInterestAccrualDate :one
+SELECT accrual_date FROM interest_accruals
+ORDER BY accrual_date DESC
+LIMIT 1
+`
+
+// the day the interest job accrued last, for it to catch up the days after.
+func (q *Queries) GetLastInterestAccrualDate(ctx context.Context) (time.Time, error) {
+	row := q.db.QueryRow(ctx, getLastInterestAccrualDate)
+	var accrual_date time.Time
+	err := row.Scan(&accrual_date)
+	return accrual_date, err
+}
+
+const listAccountsWithUnpostedInterest = `-- name: ListAccountsWithUnpostedInterest :many
+SELECT DISTINCT account_id FROM interest_accruals
+WHERE posted_at IS NULL
+AND accrual_date < $1
+ORDER BY account_id
+`
+
+func (q *Queries) ListAccountsWithUnpostedInterest(ctx context.Context, before time.Time) ([]int64, error) {
+	rows, err := q.db.Query(ctx, listAccountsWithUnpostedInterest, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var account_id int64
+		if err := rows.Scan(&account_id); err != nil {
+			return nil, err
+		}
+		items = append(items, account_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnpostedInterestAccrualsForUpdate = `-- name: ListUnpostedInterestAccrualsForUpdate :many
+SELECT id, account_id, accrual_date, balance, rate_basis_points, amount_micros, posted_at, entry_id, created_at FROM interest_accruals
+WHERE account_id = $1
+AND posted_at IS NULL
+AND accrual_date < $2
+ORDER BY accrual_date
+FOR UPDATE
+`
+
+type ListUnpostedInterestAccrualsForUpdateParams struct {
+	AccountID int64     `json:"account_id"`
+	Before    time.Time `json:"before"`
+}
+
+func (q *Queries) ListUnpostedInterestAccrualsForUpdate(ctx context.Context, arg ListUnpostedInterestAccrualsForUpdateParams) ([]InterestAccrual, error) {
+	rows, err := q.db.Query(ctx, listUnpostedInterestAccrualsForUpdate, arg.AccountID, arg.Before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []InterestAccrual{}
+	for rows.Next() {
+		var i InterestAccrual
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.AccrualDate,
+			&i.Balance,
+			&i.RateBasisPoints,
+			&i.AmountMicros,
+			&i.PostedAt,
+			&i.EntryID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markInterestAccrualsPosted = `-- name: MarkInterestAccrualsPosted :execrows
+UPDATE interest_accruals
+SET posted_at = now(), entry_id = $1
+WHERE account_id = $2
+AND posted_at IS NULL
+AND accrual_date < $3
+`
+
+type MarkInterestAccrualsPostedParams struct {
+	EntryID   sql.NullInt64 `json:"entry_id"`
+	AccountID int64         `json:"account_id"`
+	Before    time.Time     `json:"before"`
+}
+
+func (q *Queries) MarkInterestAccrualsPosted(ctx context.Context, arg MarkInterestAccrualsPostedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, markInterestAccrualsPosted, arg.EntryID, arg.AccountID, arg.Before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const voidInterestAccruals = `-- name: VoidInterestAccruals :execrows
+UPDATE interest_accruals
+SET posted_at = now(), entry_id = NULL
+WHERE account_id = $1
+AND posted_at IS NULL
+`
+
+// closes the unposted accruals of an account without paying them.
+func (q *Queries) VoidInterestAccruals(ctx context.Context, accountID int64) (int64, error) {
+	result, err := q.db.Exec(ctx, voidInterestAccruals, accountID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
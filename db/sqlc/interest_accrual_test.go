@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomInterestAccrual(t *testing.T, account Account, day time.Time) {
+	args := CreateInterestAccrualParams{
+		AccountID:       account.ID,
+		AccrualDate:     day,
+		Balance:         account.Balance,
+		RateBasisPoints: 365,
+		AmountMicros:    utils.DailyInterestMicros(account.Balance, 365),
+	}
+
+	n, err := testQueries.CreateInterestAccrual(context.Background(), args)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+
+	accrual, err := testQueries.GetInterestAccrual(context.Background(), GetInterestAccrualParams{
+		AccountID:   account.ID,
+		AccrualDate: day,
+	})
+	require.NoError(t, err)
+	require.Equal(t, args.AmountMicros, accrual.AmountMicros)
+	require.Equal(t, day.Format(time.DateOnly), accrual.AccrualDate.Format(time.DateOnly))
+	require.False(t, accrual.PostedAt.Valid)
+}
+
+func TestCreateInterestAccrualIsIdempotent(t *testing.T) {
+	account := createRandomAccount(t)
+	day := time.Date(2024, time.August, 1, 0, 0, 0, 0, time.UTC)
+
+	createRandomInterestAccrual(t, account, day)
+
+	n, err := testQueries.CreateInterestAccrual(context.Background(), CreateInterestAccrualParams{
+		AccountID:       account.ID,
+		AccrualDate:     day,
+		Balance:         account.Balance,
+		RateBasisPoints: 365,
+		AmountMicros:    1,
+	})
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestPostInterestTx(t *testing.T) {
+	account := createRandomAccountWithCurrency(t, utils.USD)
+
+	expenseAccount, err := testQueries.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		SystemKind: SystemInterest,
+		Currency:   utils.USD,
+	})
+	require.NoError(t, err)
+
+	store := NewStore(testDB, testConfig)
+
+	// 100.00 at 3.65% earns 0.01 a day.
+	_, err = testQueries.UpdateAccount(context.Background(), UpdateAccountParams{ID: account.ID, Balance: 10000})
+	require.NoError(t, err)
+	account.Balance = 10000
+
+	august := time.Date(2024, time.August, 1, 0, 0, 0, 0, time.UTC)
+	september := august.AddDate(0, 1, 0)
+	for day := august; day.Before(september); day = day.AddDate(0, 0, 1) {
+		createRandomInterestAccrual(t, account, day)
+	}
+	createRandomInterestAccrual(t, account, september)
+
+	result, err := store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: account.ID,
+		Before:    september,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 31, result.Accruals)
+	require.Equal(t, int64(31), result.Amount)
+	require.Equal(t, int64(31), result.Entry.Amount)
+	require.Equal(t, account.Balance+31, result.Account.Balance)
+
+	updatedExpenseAccount, err := testQueries.GetAccount(context.Background(), expenseAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, expenseAccount.Balance-31, updatedExpenseAccount.Balance)
+
+	// posting again doesn't pay twice, and september stays unposted.
+	result, err = store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: account.ID,
+		Before:    september,
+	})
+	require.NoError(t, err)
+	require.Zero(t, result.Accruals)
+	require.Equal(t, account.Balance+31, result.Account.Balance)
+
+	accountIDs, err := testQueries.ListAccountsWithUnpostedInterest(context.Background(), september.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Contains(t, accountIDs, account.ID)
+}
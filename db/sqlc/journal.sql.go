@@ -0,0 +1,53 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: journal.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createJournalTransaction = `-- name: CreateJournalTransaction :one
+INSERT INTO journal_transactions (
+	kind,
+	description
+) VALUES (
+	$1, $2
+) RETURNING id, kind, description, created_at
+`
+
+type CreateJournalTransactionParams struct {
+	Kind        string `json:"kind"`
+	Description string `json:"description"`
+}
+
+func (q *Queries) CreateJournalTransaction(ctx context.Context, arg CreateJournalTransactionParams) (JournalTransaction, error) {
+	row := q.db.QueryRow(ctx, createJournalTransaction, arg.Kind, arg.Description)
+	var i JournalTransaction
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Description,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getJournalTransaction = `-- name: GetJournalTransaction :one
+SELECT id, kind, description, created_at FROM journal_transactions
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetJournalTransaction(ctx context.Context, id int64) (JournalTransaction, error) {
+	row := q.db.QueryRow(ctx, getJournalTransaction, id)
+	var i JournalTransaction
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Description,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,214 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Kinds of system accounts, owned by the bank instead of a user.
+// There is one of each per supported currency.
+const (
+	SystemCashIn   = "cash_in"
+	SystemCashOut  = "cash_out"
+	SystemFees     = "fees"
+	SystemInterest = "interest"
+	// Counterpart of the manual adjustments booked by the operators.
+	SystemAdjustments = "adjustments"
+)
+
+// Kinds of journal transactions.
+const (
+	JournalTransfer   = "transfer"
+	JournalDeposit    = "deposit"
+	JournalWithdrawal = "withdrawal"
+	JournalInterest   = "interest"
+	JournalAdjustment = "adjustment"
+)
+
+// Returned when the lines of a posting don't sum to zero in every currency.
+var ErrUnbalancedPosting = errors.New("unbalanced posting")
+
+// Returned by TransferTx when the sender or the recipient is frozen.
+var ErrAccountFrozen = errors.New("account is frozen")
+
+// Returned by TransferTx when the sender or the recipient is deleted.
+var ErrAccountDeleted = errors.New("account is deleted")
+
+// Returned when a posting would leave a customer account with a negative
+// balance, e.g. the sender's balance doesn't cover a transfer and its fee.
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// A line of a journal posting, Amount is added to the account's balance.
+type JournalLine struct {
+	AccountID   int64           `json:"account_id"`
+	Amount      int64           `json:"amount"`
+	Description string          `json:"description"`
+	Reference   string          `json:"reference"`
+	Metadata    json.RawMessage `json:"metadata"`
+	// Version the account must be at when the posting is booked, 0 for any.
+	ExpectedVersion int64 `json:"expected_version,omitempty"`
+}
+
+// Contains the parameters of the journal posting transaction.
+type PostJournalTxParams struct {
+	Kind        string        `json:"kind"`
+	Description string        `json:"description"`
+	Lines       []JournalLine `json:"lines"`
+}
+
+// Contains the result of the journal posting transaction.
+type PostJournalTxResult struct {
+	Journal JournalTransaction `json:"journal"`
+	// One entry per line, in the same order.
+	Entries []Entry `json:"entries"`
+	// The accounts of the lines with their updated balances, by ascending id.
+	Accounts []Account `json:"accounts"`
+}
+
+// Books a balanced posting: one entry per line, grouped under a new journal
+// transaction, and updates the accounts' balances within a single database
+// transaction. Each entry is notified on AccountEntriesChannel on commit.
+// It fails with ErrUnbalancedPosting, without booking anything, if the lines
+// don't sum to zero in every currency, with ErrInsufficientFunds if a
+// customer account would end with a negative balance, and with
+// ErrVersionConflict if an account isn't at the expected version of its line.
+func (store *txStore) PostJournalTx(ctx context.Context, args PostJournalTxParams) (PostJournalTxResult, error) {
+	var result PostJournalTxResult
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q Querier) error {
+		var err error
+		result, err = postJournal(ctx, q, args)
+		return err
+	})
+
+	return result, err
+}
+
+// Books a balanced posting with the given queries, see PostJournalTx.
+func postJournal(ctx context.Context, q Querier, args PostJournalTxParams) (PostJournalTxResult, error) {
+	var result PostJournalTxResult
+
+	if len(args.Lines) < 2 {
+		return result, fmt.Errorf("%w: a posting needs at least two lines", ErrUnbalancedPosting)
+	}
+
+	accountIDs := make([]int64, len(args.Lines))
+	for i, line := range args.Lines {
+		if line.Amount == 0 {
+			return result, fmt.Errorf("%w: line %d has no amount", ErrUnbalancedPosting, i)
+		}
+		accountIDs[i] = line.AccountID
+	}
+
+	accounts, err := lockAccounts(ctx, q, accountIDs...)
+	if err != nil {
+		return result, err
+	}
+
+	for _, line := range args.Lines {
+		if err := checkVersion(accounts[line.AccountID], line.ExpectedVersion); err != nil {
+			return result, err
+		}
+	}
+
+	sums := map[string]int64{}
+	changes := map[int64]int64{}
+	for _, line := range args.Lines {
+		sums[accounts[line.AccountID].Currency] += line.Amount
+		changes[line.AccountID] += line.Amount
+	}
+	for currency, sum := range sums {
+		if sum != 0 {
+			return result, fmt.Errorf("%w: %s lines sum to %d", ErrUnbalancedPosting, currency, sum)
+		}
+	}
+
+	// the accounts are locked, so the balances can't change until the commit.
+	// System accounts are the counterparts of the money entering and leaving
+	// the bank, their balances go below zero.
+	for id, change := range changes {
+		account := accounts[id]
+		if account.SystemKind == nil && change < 0 && account.Balance+change < 0 {
+			return result, fmt.Errorf("%w: account %d has %d, needs %d", ErrInsufficientFunds, id, account.Balance, -change)
+		}
+	}
+
+	result.Journal, err = q.CreateJournalTransaction(ctx, CreateJournalTransactionParams{
+		Kind:        args.Kind,
+		Description: args.Description,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	journalID := sql.NullInt64{Int64: result.Journal.ID, Valid: true}
+
+	for _, line := range args.Lines {
+		metadata := line.Metadata
+		if len(metadata) == 0 {
+			metadata = json.RawMessage("{}")
+		}
+
+		entry, err := q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:   line.AccountID,
+			Amount:      line.Amount,
+			Description: line.Description,
+			Reference:   line.Reference,
+			Metadata:    metadata,
+			JournalID:   journalID,
+		})
+		if err != nil {
+			return result, err
+		}
+		result.Entries = append(result.Entries, entry)
+
+		accounts[line.AccountID], err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     line.AccountID,
+			Amount: line.Amount,
+		})
+		if err != nil {
+			return result, err
+		}
+
+		err = sendEntryNotification(ctx, q, entry, accounts[line.AccountID].Balance)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	for _, account := range accounts {
+		result.Accounts = append(result.Accounts, account)
+	}
+	sort.Slice(result.Accounts, func(i, j int) bool { return result.Accounts[i].ID < result.Accounts[j].ID })
+
+	return result, nil
+}
+
+// Locks the accounts for update, always in the same (ascending id) order
+// to avoid deadlocks between transactions locking the same accounts.
+// It returns the locked accounts by id.
+func lockAccounts(ctx context.Context, q Querier, accountIDs ...int64) (map[int64]Account, error) {
+	ids := make([]int64, len(accountIDs))
+	copy(ids, accountIDs)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	accounts := map[int64]Account{}
+	for _, id := range ids {
+		if _, ok := accounts[id]; ok {
+			continue
+		}
+
+		account, err := q.GetAccountForUpdate(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		accounts[id] = account
+	}
+	return accounts, nil
+}
@@ -0,0 +1,186 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSystemAccount(t *testing.T) {
+	for _, kind := range []string{SystemCashIn, SystemCashOut, SystemFees, SystemInterest, SystemAdjustments} {
+		account, err := testQueries.GetSystemAccount(context.Background(), GetSystemAccountParams{
+			SystemKind: kind,
+			Currency:   utils.EUR,
+		})
+		require.NoError(t, err)
+		require.Nil(t, account.Owner)
+		require.Equal(t, kind, *account.SystemKind)
+		require.Equal(t, utils.EUR, account.Currency)
+	}
+}
+
+func TestPostJournalTx(t *testing.T) {
+	store := NewStore(testDB, testConfig)
+
+	account := createRandomAccountWithCurrency(t, utils.CAD)
+	cashIn, err := testQueries.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		SystemKind: SystemCashIn,
+		Currency:   utils.CAD,
+	})
+	require.NoError(t, err)
+
+	amount := int64(250)
+
+	result, err := store.PostJournalTx(context.Background(), PostJournalTxParams{
+		Kind:        JournalDeposit,
+		Description: "deposit",
+		Lines: []JournalLine{
+			{AccountID: account.ID, Amount: amount},
+			{AccountID: cashIn.ID, Amount: -amount},
+		},
+	})
+	require.NoError(t, err)
+
+	require.NotZero(t, result.Journal.ID)
+	require.Equal(t, JournalDeposit, result.Journal.Kind)
+	require.Len(t, result.Entries, 2)
+	require.Len(t, result.Accounts, 2)
+
+	entries, err := testQueries.ListJournalEntries(context.Background(), sql.NullInt64{Int64: result.Journal.ID, Valid: true})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	var sum int64
+	for _, entry := range entries {
+		require.Equal(t, result.Journal.ID, entry.JournalID.Int64)
+		sum += entry.Amount
+	}
+	require.Zero(t, sum)
+
+	for _, postedAccount := range result.Accounts {
+		switch postedAccount.ID {
+		case account.ID:
+			require.Equal(t, account.Balance+amount, postedAccount.Balance)
+		case cashIn.ID:
+			require.Equal(t, cashIn.Balance-amount, postedAccount.Balance)
+		default:
+			t.Fatalf("unexpected account %d", postedAccount.ID)
+		}
+	}
+}
+
+func TestPostJournalTxUnbalanced(t *testing.T) {
+	store := NewStore(testDB, testConfig)
+
+	usdAccount1 := createRandomAccountWithCurrency(t, utils.USD)
+	usdAccount2 := createRandomAccountWithCurrency(t, utils.USD)
+	eurAccount := createRandomAccountWithCurrency(t, utils.EUR)
+
+	testCases := []struct {
+		name  string
+		lines []JournalLine
+	}{
+		{
+			name:  "SingleLine",
+			lines: []JournalLine{{AccountID: usdAccount1.ID, Amount: 10}},
+		},
+		{
+			name: "DoesNotSumToZero",
+			lines: []JournalLine{
+				{AccountID: usdAccount1.ID, Amount: -10},
+				{AccountID: usdAccount2.ID, Amount: 9},
+			},
+		},
+		{
+			name: "CrossCurrency",
+			lines: []JournalLine{
+				{AccountID: usdAccount1.ID, Amount: -10},
+				{AccountID: eurAccount.ID, Amount: 10},
+			},
+		},
+		{
+			name: "ZeroAmount",
+			lines: []JournalLine{
+				{AccountID: usdAccount1.ID, Amount: 0},
+				{AccountID: usdAccount2.ID, Amount: 0},
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := store.PostJournalTx(context.Background(), PostJournalTxParams{
+				Kind:  JournalDeposit,
+				Lines: testCase.lines,
+			})
+			require.ErrorIs(t, err, ErrUnbalancedPosting)
+		})
+	}
+
+	// nothing was booked.
+	for _, account := range []Account{usdAccount1, usdAccount2, eurAccount} {
+		updatedAccount, err := testQueries.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, updatedAccount.Balance)
+	}
+}
+
+func TestPostJournalTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB, testConfig)
+
+	account := createRandomAccountWithCurrency(t, utils.CAD)
+	cashOut, err := testQueries.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		SystemKind: SystemCashOut,
+		Currency:   utils.CAD,
+	})
+	require.NoError(t, err)
+
+	withdraw := func(amount int64) (PostJournalTxResult, error) {
+		return store.PostJournalTx(context.Background(), PostJournalTxParams{
+			Kind: JournalWithdrawal,
+			Lines: []JournalLine{
+				{AccountID: account.ID, Amount: -amount},
+				{AccountID: cashOut.ID, Amount: amount},
+			},
+		})
+	}
+
+	_, err = withdraw(account.Balance + 1)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	updatedAccount, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, updatedAccount.Balance)
+
+	// concurrent withdrawals are checked against the locked balance, only
+	// the ones it covers are booked.
+	n := 5
+	amount := account.Balance / 2
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := withdraw(amount)
+			errs <- err
+		}()
+	}
+
+	booked := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			booked++
+			continue
+		}
+		require.ErrorIs(t, err, ErrInsufficientFunds)
+	}
+	require.Equal(t, 2, booked)
+
+	updatedAccount, err = testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance-2*amount, updatedAccount.Balance)
+}
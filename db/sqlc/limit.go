@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Periods a transfer limit applies to.
+const (
+	LimitPerTransaction = "per_transaction"
+	LimitDaily          = "daily"
+	LimitMonthly        = "monthly"
+)
+
+// Returned by TransferTx when a transfer would exceed one of the sender's limits.
+type TransferLimitError struct {
+	Period    string `json:"period"`
+	Limit     int64  `json:"limit"`
+	Remaining int64  `json:"remaining"`
+}
+
+func (e *TransferLimitError) Error() string {
+	return fmt.Sprintf("transfer exceeds %s limit of %d: remaining allowance is %d", e.Period, e.Limit, e.Remaining)
+}
+
+// Contains the outgoing limits in effect for an account, 0 means unlimited.
+type TransferLimits struct {
+	PerTransaction int64
+	Daily          int64
+	Monthly        int64
+}
+
+// Returns the limits of an account: its overrides from account_limits,
+// falling back to the defaults from the config.
+func (store *txStore) accountLimits(ctx context.Context, q Querier, accountID int64) (TransferLimits, error) {
+	limits := TransferLimits{
+		PerTransaction: store.config.TransferLimitPerTransaction,
+		Daily:          store.config.TransferLimitDaily,
+		Monthly:        store.config.TransferLimitMonthly,
+	}
+
+	override, err := q.GetAccountLimit(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return limits, nil
+		}
+		return limits, err
+	}
+
+	if override.PerTransaction.Valid {
+		limits.PerTransaction = override.PerTransaction.Int64
+	}
+	if override.Daily.Valid {
+		limits.Daily = override.Daily.Int64
+	}
+	if override.Monthly.Valid {
+		limits.Monthly = override.Monthly.Int64
+	}
+	return limits, nil
+}
+
+// Checks that sending amount from the account stays within its limits,
+// using the transfers already made in the current day and month (UTC).
+func (store *txStore) checkTransferLimits(ctx context.Context, q Querier, accountID int64, amount int64) error {
+	limits, err := store.accountLimits(ctx, q, accountID)
+	if err != nil {
+		return err
+	}
+
+	if limits.PerTransaction > 0 && amount > limits.PerTransaction {
+		return &TransferLimitError{
+			Period:    LimitPerTransaction,
+			Limit:     limits.PerTransaction,
+			Remaining: limits.PerTransaction,
+		}
+	}
+
+	now := time.Now().UTC()
+	windows := []struct {
+		period string
+		limit  int64
+		since  time.Time
+	}{
+		{LimitDaily, limits.Daily, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)},
+		{LimitMonthly, limits.Monthly, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, window := range windows {
+		if window.limit <= 0 {
+			continue
+		}
+
+		spent, err := q.GetOutgoingTransfersTotal(ctx, GetOutgoingTransfersTotalParams{
+			AccountID: accountID,
+			Since:     window.since,
+		})
+		if err != nil {
+			return err
+		}
+
+		if spent+amount > window.limit {
+			return &TransferLimitError{
+				Period:    window.period,
+				Limit:     window.limit,
+				Remaining: max(window.limit-spent, 0),
+			}
+		}
+	}
+	return nil
+}
@@ -1,29 +1,68 @@
 package db
 
 import (
-	"database/sql"
+	"context"
 	"log"
 	"os"
 	"testing"
 
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kvgtl/simplebank/db/dbtest"
 	"github.com/kvgtl/simplebank/utils"
-	_ "github.com/lib/pq"
 )
 
 var testQueries *Queries
-var testDB *sql.DB
+var testDB *pgxpool.Pool
+var testConfig utils.Config
 
 func TestMain(m *testing.M) {
 	config, err := utils.LoadConfig("../../")
 	if err != nil {
 		log.Panic("cannot load config:", err)
 	}
-
-	testDB, err = sql.Open(config.DBDriver, config.DBSource)
+	config, err = withTestPIIKeys(config)
 	if err != nil {
-		log.Fatal("cannot connect to database:", err)
+		log.Panic("cannot set encryption keys:", err)
 	}
-	testQueries = New(testDB)
 
-	os.Exit(m.Run())
+	os.Exit(dbtest.Main(m, func(source string) error {
+		config.DBSource = source
+		testDB, err = NewConnPool(context.Background(), config)
+		if err != nil {
+			return err
+		}
+		testQueries = New(translatingDBTX{testDB})
+		testConfig = config
+		return nil
+	}))
+}
+
+// Encrypts the personal data of the test users with random keys, app.env
+// ships without any.
+func withTestPIIKeys(config utils.Config) (utils.Config, error) {
+	config.PIIEncryptionKeys = "test:" + utils.RandomPIIKey()
+	config.PIIIndexKey = utils.RandomPIIKey()
+
+	var err error
+	config.PIIKeyring, err = utils.ParsePIIKeyring(config.PIIEncryptionKeys, config.PIIIndexKey)
+	return config, err
+}
+
+// Returns queries on a schema of the test alone, for the tests that need a
+// database without the rows of the other tests.
+func newIsolatedQueries(t *testing.T) *Queries {
+	return New(translatingDBTX{newIsolatedPool(t)})
+}
+
+// Returns a pool on a schema of the test alone, see newIsolatedQueries.
+func newIsolatedPool(t *testing.T) *pgxpool.Pool {
+	config := testConfig
+	config.DBSource = dbtest.Source(t)
+
+	pool, err := NewConnPool(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
 }
@@ -5,39 +5,175 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type Account struct {
-	ID        int64     `json:"id"`
-	Owner     string    `json:"owner"`
-	Balance   int64     `json:"balance"`
-	Currency  string    `json:"currency"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          int64     `json:"id"`
+	Owner       *string   `json:"owner"`
+	Balance     int64     `json:"balance"`
+	Currency    string    `json:"currency"`
+	CreatedAt   time.Time `json:"created_at"`
+	AccountType string    `json:"account_type"`
+	SystemKind  *string   `json:"system_kind"`
+	// frozen accounts can neither send nor receive transfers, null if not frozen.
+	FrozenAt     *time.Time `json:"frozen_at"`
+	FrozenReason string     `json:"frozen_reason"`
+	// deleted accounts keep their ledger history but can neither send nor receive transfers, null if not deleted.
+	DeletedAt *time.Time `json:"deleted_at"`
+	// incremented on every change of the account, for optimistic concurrency.
+	Version int64 `json:"version"`
+}
+
+type AccountLimit struct {
+	AccountID int64 `json:"account_id"`
+	// overrides the default limit when set, 0 means unlimited.
+	PerTransaction sql.NullInt64 `json:"per_transaction"`
+	// overrides the default limit when set, 0 means unlimited.
+	Daily sql.NullInt64 `json:"daily"`
+	// overrides the default limit when set, 0 means unlimited.
+	Monthly   sql.NullInt64 `json:"monthly"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// sum of the archived entries of each account, so the balances still reconcile.
+type ArchivedEntryTotal struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+}
+
+// monthly partitions exported to a file and detached.
+type ArchivedPartition struct {
+	Name        string    `json:"name"`
+	ParentTable string    `json:"parent_table"`
+	Month       time.Time `json:"month"`
+	File        string    `json:"file"`
+	Rows        int64     `json:"rows"`
+	ArchivedAt  time.Time `json:"archived_at"`
 }
 
 type Entry struct {
+	// unique across the partitions: given by entries_id_seq only, never set explicitly.
 	ID        int64 `json:"id"`
 	AccountID int64 `json:"account_id"`
 	// can be negative or positive.
-	Amount    int64     `json:"amount"`
-	CreatedAt time.Time `json:"created_at"`
+	Amount      int64           `json:"amount"`
+	CreatedAt   time.Time       `json:"created_at"`
+	Description string          `json:"description"`
+	Reference   string          `json:"reference"`
+	Metadata    json.RawMessage `json:"metadata"`
+	// null for entries booked before the ledger existed.
+	JournalID sql.NullInt64 `json:"journal_id"`
+}
+
+type InterestAccrual struct {
+	ID          int64     `json:"id"`
+	AccountID   int64     `json:"account_id"`
+	AccrualDate time.Time `json:"accrual_date"`
+	Balance     int64     `json:"balance"`
+	// annual rate applied on the day.
+	RateBasisPoints int64 `json:"rate_basis_points"`
+	// interest of the day in millionths of a minor unit.
+	AmountMicros int64        `json:"amount_micros"`
+	PostedAt     sql.NullTime `json:"posted_at"`
+	// entry that paid the interest, null if it rounded to zero or was voided when the account was deleted.
+	EntryID   sql.NullInt64 `json:"entry_id"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// groups the entries of a balanced posting.
+type JournalTransaction struct {
+	ID          int64     `json:"id"`
+	Kind        string    `json:"kind"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// domain events written in the same transaction as the change they describe.
+type OutboxEvent struct {
+	ID            int64           `json:"id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      int32           `json:"attempts"`
+	LastError     string          `json:"last_error"`
+	PublishedAt   sql.NullTime    `json:"published_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	// failed events are retried with exponential backoff, not before this time.
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	// set once the event failed too many times, it is not retried anymore.
+	DeadAt sql.NullTime `json:"dead_at"`
+}
+
+type Session struct {
+	// id of the refresh token payload.
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 type Transfer struct {
+	// unique across the partitions: given by transfers_id_seq only, never set explicitly.
 	ID            int64 `json:"id"`
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	// must be positive.
-	Amount    int64     `json:"amount"`
-	CreatedAt time.Time `json:"created_at"`
+	Amount      int64     `json:"amount"`
+	CreatedAt   time.Time `json:"created_at"`
+	Description string    `json:"description"`
+	// external reference provided by the client, empty if none.
+	Reference string          `json:"reference"`
+	Metadata  json.RawMessage `json:"metadata"`
 }
 
 type User struct {
-	Username          string    `json:"username"`
-	HashedPassword    string    `json:"hashed_password"`
-	FullName          string    `json:"full_name"`
+	Username       string `json:"username"`
+	HashedPassword string `json:"hashed_password"`
+	// encrypted and decrypted by the Store when encryption keys are configured.
+	FullName string `json:"full_name"`
+	// encrypted and decrypted by the Store when encryption keys are configured.
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	Role              string    `json:"role"`
+	// deleted users are anonymized, their username is kept for the ledger, null if not deleted.
+	DeletedAt sql.NullTime `json:"deleted_at"`
+	// blind index of the email, its HMAC-SHA256, null while the email is in plaintext.
+	EmailHash []byte `json:"email_hash"`
+}
+
+type Webhook struct {
+	ID     int64  `json:"id"`
+	Owner  string `json:"owner"`
+	Url    string `json:"url"`
+	Secret string `json:"secret"`
+	// events delivered to the endpoint, empty means all.
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID        int64           `json:"id"`
+	WebhookID int64           `json:"webhook_id"`
+	EventID   int64           `json:"event_id"`
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+	// dead deliveries failed too many times and are not retried.
+	Status         string       `json:"status"`
+	Attempts       int32        `json:"attempts"`
+	NextAttemptAt  time.Time    `json:"next_attempt_at"`
+	LastError      string       `json:"last_error"`
+	ResponseStatus int32        `json:"response_status"`
+	DeliveredAt    sql.NullTime `json:"delivered_at"`
+	CreatedAt      time.Time    `json:"created_at"`
 }
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Postgres channel notified of every entry booked by a journal posting,
+// it must match the channel of the NotifyAccountEntry query.
+// Notifications are only delivered once the posting's transaction commits.
+const AccountEntriesChannel = "account_entries"
+
+// Payload of a notification on AccountEntriesChannel. It leaves out the
+// entry's metadata, notification payloads are limited to 8000 bytes.
+type AccountEntryNotification struct {
+	EntryID     int64     `json:"entry_id"`
+	AccountID   int64     `json:"account_id"`
+	JournalID   int64     `json:"journal_id"`
+	Amount      int64     `json:"amount"`
+	Description string    `json:"description"`
+	Reference   string    `json:"reference"`
+	CreatedAt   time.Time `json:"created_at"`
+	// Balance of the account right after the entry.
+	Balance int64 `json:"balance"`
+}
+
+// Notifies AccountEntriesChannel of an entry with the given queries.
+func sendEntryNotification(ctx context.Context, q Querier, entry Entry, balance int64) error {
+	payload, err := json.Marshal(AccountEntryNotification{
+		EntryID:     entry.ID,
+		AccountID:   entry.AccountID,
+		JournalID:   entry.JournalID.Int64,
+		Amount:      entry.Amount,
+		Description: entry.Description,
+		Reference:   entry.Reference,
+		CreatedAt:   entry.CreatedAt,
+		Balance:     balance,
+	})
+	if err != nil {
+		return err
+	}
+
+	return q.NotifyAccountEntry(ctx, string(payload))
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferTxNotifiesEntries(t *testing.T) {
+	conn, err := pgx.Connect(context.Background(), testConfig.DBSource)
+	require.NoError(t, err)
+	defer conn.Close(context.Background())
+	_, err = conn.Exec(context.Background(), "LISTEN "+AccountEntriesChannel)
+	require.NoError(t, err)
+
+	store := NewStore(testDB, testConfig)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	// other tests may book entries concurrently, only look for this transfer's.
+	expected := map[int64]AccountEntryNotification{
+		result.FromEntry.ID: {
+			EntryID:   result.FromEntry.ID,
+			AccountID: account1.ID,
+			JournalID: result.Journal.ID,
+			Amount:    -10,
+			CreatedAt: result.FromEntry.CreatedAt,
+			Balance:   result.FromAccount.Balance,
+		},
+		result.ToEntry.ID: {
+			EntryID:   result.ToEntry.ID,
+			AccountID: account2.ID,
+			JournalID: result.Journal.ID,
+			Amount:    10,
+			CreatedAt: result.ToEntry.CreatedAt,
+			Balance:   result.ToAccount.Balance,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for len(expected) > 0 {
+		n, err := conn.WaitForNotification(ctx)
+		require.NoError(t, err, "missing notifications for entries %v", expected)
+
+		var notification AccountEntryNotification
+		require.NoError(t, json.Unmarshal([]byte(n.Payload), &notification))
+
+		want, ok := expected[notification.EntryID]
+		if !ok {
+			continue
+		}
+		require.True(t, want.CreatedAt.Equal(notification.CreatedAt))
+		notification.CreatedAt = want.CreatedAt
+		require.Equal(t, want, notification)
+		delete(expected, notification.EntryID)
+	}
+}
+
+func TestListAccountEntriesAfter(t *testing.T) {
+	account := createRandomAccount(t)
+
+	var entries []Entry
+	for i := 0; i < 5; i++ {
+		entries = append(entries, CreateRandomEntryAtSpecificAccount(t, account))
+	}
+
+	got, err := testQueries.ListAccountEntriesAfter(context.Background(), ListAccountEntriesAfterParams{
+		AccountID:  account.ID,
+		AfterID:    entries[1].ID,
+		LimitCount: 2,
+	})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, entries[2].ID, got[0].ID)
+	require.Equal(t, entries[3].ID, got[1].ID)
+}
@@ -0,0 +1,191 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: outbox.sql
+
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const createOutboxEvent = `-- name: CreateOutboxEvent :one
+INSERT INTO outbox_events (
+	aggregate_type,
+	aggregate_id,
+	event_type,
+	payload
+) VALUES (
+	$1, $2, $3, $4
+) RETURNING id, aggregate_type, aggregate_id, event_type, payload, attempts, last_error, published_at, created_at, next_attempt_at, dead_at
+`
+
+type CreateOutboxEventParams struct {
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+func (q *Queries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (OutboxEvent, error) {
+	row := q.db.QueryRow(ctx, createOutboxEvent,
+		arg.AggregateType,
+		arg.AggregateID,
+		arg.EventType,
+		arg.Payload,
+	)
+	var i OutboxEvent
+	err := row.Scan(
+		&i.ID,
+		&i.AggregateType,
+		&i.AggregateID,
+		&i.EventType,
+		&i.Payload,
+		&i.Attempts,
+		&i.LastError,
+		&i.PublishedAt,
+		&i.CreatedAt,
+		&i.NextAttemptAt,
+		&i.DeadAt,
+	)
+	return i, err
+}
+
+const listOutboxEventsByAggregate = `-- name: ListOutboxEventsByAggregate :many
+SELECT id, aggregate_type, aggregate_id, event_type, payload, attempts, last_error, published_at, created_at, next_attempt_at, dead_at FROM outbox_events
+WHERE aggregate_type = $1
+AND aggregate_id = $2
+ORDER BY id
+`
+
+type ListOutboxEventsByAggregateParams struct {
+	AggregateType string `json:"aggregate_type"`
+	AggregateID   string `json:"aggregate_id"`
+}
+
+func (q *Queries) ListOutboxEventsByAggregate(ctx context.Context, arg ListOutboxEventsByAggregateParams) ([]OutboxEvent, error) {
+	rows, err := q.db.Query(ctx, listOutboxEventsByAggregate, arg.AggregateType, arg.AggregateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OutboxEvent{}
+	for rows.Next() {
+		var i OutboxEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateType,
+			&i.AggregateID,
+			&i.EventType,
+			&i.Payload,
+			&i.Attempts,
+			&i.LastError,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.NextAttemptAt,
+			&i.DeadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnpublishedOutboxEvents = `-- name: ListUnpublishedOutboxEvents :many
+SELECT id, aggregate_type, aggregate_id, event_type, payload, attempts, last_error, published_at, created_at, next_attempt_at, dead_at FROM outbox_events
+WHERE published_at IS NULL
+AND dead_at IS NULL
+AND next_attempt_at <= now()
+ORDER BY id
+LIMIT $1
+`
+
+// the events due for an attempt, failed events wait for their backoff.
+func (q *Queries) ListUnpublishedOutboxEvents(ctx context.Context, limit int32) ([]OutboxEvent, error) {
+	rows, err := q.db.Query(ctx, listUnpublishedOutboxEvents, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OutboxEvent{}
+	for rows.Next() {
+		var i OutboxEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateType,
+			&i.AggregateID,
+			&i.EventType,
+			&i.Payload,
+			&i.Attempts,
+			&i.LastError,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.NextAttemptAt,
+			&i.DeadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markOutboxEventPublished = `-- name: MarkOutboxEventPublished :exec
+UPDATE outbox_events
+SET published_at = now(),
+	attempts = attempts + 1,
+	last_error = ''
+WHERE id = $1
+`
+
+func (q *Queries) MarkOutboxEventPublished(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, markOutboxEventPublished, id)
+	return err
+}
+
+const recordOutboxEventFailure = `-- name: RecordOutboxEventFailure :exec
+UPDATE outbox_events
+SET attempts = attempts + 1,
+	last_error = $1,
+	next_attempt_at = $2,
+	dead_at = CASE WHEN $3::bool THEN now() END
+WHERE id = $4
+`
+
+type RecordOutboxEventFailureParams struct {
+	LastError     string    `json:"last_error"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	Dead          bool      `json:"dead"`
+	ID            int64     `json:"id"`
+}
+
+func (q *Queries) RecordOutboxEventFailure(ctx context.Context, arg RecordOutboxEventFailureParams) error {
+	_, err := q.db.Exec(ctx, recordOutboxEventFailure,
+		arg.LastError,
+		arg.NextAttemptAt,
+		arg.Dead,
+		arg.ID,
+	)
+	return err
+}
+
+const redactUserOutboxEvents = `-- name: RedactUserOutboxEvents :exec
+UPDATE outbox_events
+SET payload = payload - 'full_name' - 'email'
+WHERE aggregate_type = 'user'
+AND aggregate_id = $1
+`
+
+func (q *Queries) RedactUserOutboxEvents(ctx context.Context, aggregateID string) error {
+	_, err := q.db.Exec(ctx, redactUserOutboxEvents, aggregateID)
+	return err
+}
@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kvgtl/simplebank/utils"
+)
+
+// Returned by ReencryptUsersTx when the store has no encryption keys.
+var ErrEncryptionDisabled = errors.New("personal data encryption is disabled")
+
+// Queries encrypting the personal data of the users they write, and
+// decrypting it in the users they return. The emails are looked up and kept
+// unique by their blind index.
+type piiQuerier struct {
+	Querier
+	keyring *utils.PIIKeyring
+}
+
+// Wraps q to encrypt the personal data of the users with the keyring. A nil
+// keyring returns q, which keeps the personal data in plaintext.
+func withPII(q Querier, keyring *utils.PIIKeyring) Querier {
+	if keyring == nil {
+		return q
+	}
+	return &piiQuerier{Querier: q, keyring: keyring}
+}
+
+// Returns the context binding an encrypted column to the row of a user.
+func piiContext(column string, username string) string {
+	return "users." + column + "/" + username
+}
+
+func (q *piiQuerier) encrypt(username, fullName, email string) (string, string, []byte, error) {
+	encryptedFullName, err := q.keyring.Encrypt(fullName, piiContext("full_name", username))
+	if err != nil {
+		return "", "", nil, err
+	}
+	encryptedEmail, err := q.keyring.Encrypt(email, piiContext("email", username))
+	if err != nil {
+		return "", "", nil, err
+	}
+	return encryptedFullName, encryptedEmail, q.keyring.BlindIndex(email), nil
+}
+
+func (q *piiQuerier) decrypt(user User, err error) (User, error) {
+	if err != nil {
+		return user, err
+	}
+
+	user.FullName, err = q.keyring.Decrypt(user.FullName, piiContext("full_name", user.Username))
+	if err != nil {
+		return User{}, fmt.Errorf("user %s: %w", user.Username, err)
+	}
+	user.Email, err = q.keyring.Decrypt(user.Email, piiContext("email", user.Username))
+	if err != nil {
+		return User{}, fmt.Errorf("user %s: %w", user.Username, err)
+	}
+	return user, nil
+}
+
+// Creates a user with its personal data encrypted. The email_key index only
+// compares the blind index with the other encrypted emails, so the email is
+// first looked up among the plaintext ones.
+func (q *piiQuerier) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	_, err := q.Querier.GetUserByEmail(ctx, GetUserByEmailParams{Email: arg.Email})
+	if err == nil {
+		return User{}, NewConstraintError(UniqueViolation, "email_key",
+			`duplicate key value violates unique constraint "email_key"`)
+	}
+	if !errors.Is(err, ErrRecordNotFound) {
+		return User{}, err
+	}
+
+	arg.FullName, arg.Email, arg.EmailHash, err = q.encrypt(arg.Username, arg.FullName, arg.Email)
+	if err != nil {
+		return User{}, err
+	}
+	return q.decrypt(q.Querier.CreateUser(ctx, arg))
+}
+
+func (q *piiQuerier) UpdateUserPersonalData(ctx context.Context, arg UpdateUserPersonalDataParams) (User, error) {
+	var err error
+	arg.FullName, arg.Email, arg.EmailHash, err = q.encrypt(arg.Username, arg.FullName, arg.Email)
+	if err != nil {
+		return User{}, err
+	}
+	return q.decrypt(q.Querier.UpdateUserPersonalData(ctx, arg))
+}
+
+// Looks the user up by the blind index of the email, or by the email itself
+// while it is in plaintext.
+func (q *piiQuerier) GetUserByEmail(ctx context.Context, arg GetUserByEmailParams) (User, error) {
+	arg.EmailHash = q.keyring.BlindIndex(arg.Email)
+	return q.decrypt(q.Querier.GetUserByEmail(ctx, arg))
+}
+
+func (q *piiQuerier) GetUser(ctx context.Context, username string) (User, error) {
+	return q.decrypt(q.Querier.GetUser(ctx, username))
+}
+
+func (q *piiQuerier) GetUserForShare(ctx context.Context, username string) (User, error) {
+	return q.decrypt(q.Querier.GetUserForShare(ctx, username))
+}
+
+func (q *piiQuerier) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	return q.decrypt(q.Querier.UpdateUserRole(ctx, arg))
+}
+
+func (q *piiQuerier) AnonymizeUser(ctx context.Context, username string) (User, error) {
+	return q.decrypt(q.Querier.AnonymizeUser(ctx, username))
+}
+
+func (q *piiQuerier) ListUsersForUpdate(ctx context.Context, arg ListUsersForUpdateParams) ([]User, error) {
+	users, err := q.Querier.ListUsersForUpdate(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	for i := range users {
+		users[i], err = q.decrypt(users[i], nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return users, nil
+}
+
+// Contains the parameters of the re-encryption transaction.
+type ReencryptUsersTxParams struct {
+	// Username after which the batch starts, empty for the first batch.
+	After string `json:"after"`
+	Limit int32  `json:"limit"`
+}
+
+// Contains the result of the re-encryption transaction.
+type ReencryptUsersTxResult struct {
+	Count int `json:"count"`
+	// Number of users of the batch already encrypted with the current key,
+	// which were left as is.
+	Skipped int `json:"skipped"`
+	// Username of the last user of the batch, where the next batch starts.
+	Last string `json:"last"`
+}
+
+// Re-encrypts the personal data of a batch of users, ordered by username,
+// with the current key and recomputes the blind index of their emails. The
+// plaintext rows written before encryption was enabled are encrypted as well,
+// while the users already on the current key are skipped. Deleted users are
+// skipped too, their personal data is anonymized already.
+// Once every batch ran, the previous keys can be removed from the keyring.
+// It fails with ErrEncryptionDisabled if the store has no encryption keys.
+func (store *txStore) ReencryptUsersTx(ctx context.Context, arg ReencryptUsersTxParams) (ReencryptUsersTxResult, error) {
+	var result ReencryptUsersTxResult
+	if store.config.PIIKeyring == nil {
+		return result, ErrEncryptionDisabled
+	}
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q Querier) error {
+		// the users are listed as stored, to tell which key encrypts them.
+		pii := q.(*piiQuerier)
+		users, err := pii.Querier.ListUsersForUpdate(ctx, ListUsersForUpdateParams{
+			After:      arg.After,
+			LimitCount: arg.Limit,
+		})
+		if err != nil {
+			return err
+		}
+
+		result = ReencryptUsersTxResult{Count: len(users)}
+		for _, user := range users {
+			result.Last = user.Username
+			if pii.keyring.IsCurrent(user.FullName) && pii.keyring.IsCurrent(user.Email) {
+				result.Skipped++
+				continue
+			}
+
+			user, err = pii.decrypt(user, nil)
+			if err != nil {
+				return err
+			}
+			_, err = q.UpdateUserPersonalData(ctx, UpdateUserPersonalDataParams{
+				Username: user.Username,
+				FullName: user.FullName,
+				Email:    user.Email,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return result, err
+}
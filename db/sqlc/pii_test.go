@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// Returns a random key for a PIIKeyring, named id.
+func randomPIIKey(id string) string {
+	return id + ":" + utils.RandomPIIKey()
+}
+
+// Returns the test config encrypting with the keys, the first one current.
+func piiConfig(t *testing.T, keys ...string) utils.Config {
+	config := testConfig
+	var err error
+	config.PIIKeyring, err = utils.ParsePIIKeyring(strings.Join(keys, ","), config.PIIIndexKey)
+	require.NoError(t, err)
+	return config
+}
+
+func TestStoreEncryptsUsers(t *testing.T) {
+	pool := newIsolatedPool(t)
+	q := New(translatingDBTX{pool})
+
+	// a user written before encryption was enabled.
+	plain, err := q.CreateUser(context.Background(), CreateUserParams{
+		Username: utils.RandomOwner(),
+		FullName: utils.RandomOwner(),
+		Email:    utils.RandomEmailAddress(),
+	})
+	require.NoError(t, err)
+
+	store := NewStore(pool, piiConfig(t, randomPIIKey("k1")))
+	user, err := store.CreateUserTx(context.Background(), CreateUserParams{
+		Username: utils.RandomOwner(),
+		FullName: utils.RandomOwner(),
+		Email:    utils.RandomEmailAddress(),
+	})
+	require.NoError(t, err)
+
+	raw, err := q.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(raw.FullName, "enc:v1:k1:"))
+	require.True(t, strings.HasPrefix(raw.Email, "enc:v1:k1:"))
+	require.Len(t, raw.EmailHash, 32)
+
+	found, err := store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.FullName, found.FullName)
+	require.Equal(t, user.Email, found.Email)
+
+	for _, want := range []User{plain, user} {
+		found, err = store.GetUserByEmail(context.Background(), GetUserByEmailParams{Email: want.Email})
+		require.NoError(t, err)
+		require.Equal(t, want.Username, found.Username)
+
+		_, err = store.CreateUserTx(context.Background(), CreateUserParams{
+			Username: utils.RandomOwner(),
+			Email:    want.Email,
+		})
+		require.ErrorIs(t, err, ErrUniqueViolation)
+		require.Equal(t, "email_key", ErrorConstraint(err))
+	}
+
+	// the values can't be read without their key.
+	_, err = NewStore(pool, piiConfig(t, randomPIIKey("k2"))).GetUser(context.Background(), user.Username)
+	require.ErrorIs(t, err, utils.ErrPIIDecrypt)
+}
+
+func TestReencryptUsersTx(t *testing.T) {
+	pool := newIsolatedPool(t)
+	k1, k2 := randomPIIKey("k1"), randomPIIKey("k2")
+
+	_, err := NewStore(pool, utils.Config{}).ReencryptUsersTx(context.Background(), ReencryptUsersTxParams{Limit: 10})
+	require.ErrorIs(t, err, ErrEncryptionDisabled)
+
+	store := NewStore(pool, piiConfig(t, k1))
+	users := make([]User, 5)
+	for i := range users {
+		users[i], err = store.CreateUserTx(context.Background(), CreateUserParams{
+			Username: utils.RandomOwner(),
+			FullName: utils.RandomOwner(),
+			Email:    utils.RandomEmailAddress(),
+		})
+		require.NoError(t, err)
+	}
+	_, err = store.DeleteUserTx(context.Background(), users[0].Username)
+	require.NoError(t, err)
+
+	store = NewStore(pool, piiConfig(t, k2, k1))
+	var count int
+	var last string
+	for {
+		result, err := store.ReencryptUsersTx(context.Background(), ReencryptUsersTxParams{After: last, Limit: 2})
+		require.NoError(t, err)
+		count += result.Count
+		if result.Count < 2 {
+			break
+		}
+		last = result.Last
+	}
+	require.Equal(t, len(users)-1, count)
+
+	store = NewStore(pool, piiConfig(t, k2))
+	for _, want := range users[1:] {
+		found, err := store.GetUserByEmail(context.Background(), GetUserByEmailParams{Email: want.Email})
+		require.NoError(t, err)
+		require.Equal(t, want.Username, found.Username)
+		require.Equal(t, want.FullName, found.FullName)
+	}
+
+	deleted, err := store.GetUser(context.Background(), users[0].Username)
+	require.NoError(t, err)
+	require.Empty(t, deleted.FullName)
+	require.Nil(t, deleted.EmailHash)
+}
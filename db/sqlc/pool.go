@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kvgtl/simplebank/utils"
+)
+
+// Creates a connection pool to the database of the config, sized and tuned
+// by its DB_* settings. Zero settings keep the defaults of pgxpool.
+func NewConnPool(ctx context.Context, config utils.Config) (*pgxpool.Pool, error) {
+	return newConnPool(ctx, config.DBSource, config)
+}
+
+// Creates a connection pool to the read replica of the config, tuned like the
+// pool of the primary. The pool connects lazily, so that the server starts
+// while the replica is down.
+func NewReplicaConnPool(ctx context.Context, config utils.Config) (*pgxpool.Pool, error) {
+	return newConnPool(ctx, config.DBReplicaSource, config)
+}
+
+func newConnPool(ctx context.Context, source string, config utils.Config) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(source)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse database source: %w", err)
+	}
+
+	if config.DBMaxConns > 0 {
+		poolConfig.MaxConns = config.DBMaxConns
+	}
+	if config.DBMinConns > 0 {
+		poolConfig.MinConns = config.DBMinConns
+	}
+	if config.DBMaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = config.DBMaxConnIdleTime
+	}
+	if config.DBStatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(config.DBStatementTimeout.Milliseconds(), 10)
+	}
+
+	return pgxpool.NewWithConfig(ctx, poolConfig)
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConnPool(t *testing.T) {
+	config := testConfig
+	config.DBMaxConns = 7
+	config.DBMinConns = 0
+	config.DBMaxConnIdleTime = time.Minute
+	config.DBStatementTimeout = 1500 * time.Millisecond
+
+	pool, err := NewConnPool(context.Background(), config)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	poolConfig := pool.Config()
+	require.Equal(t, int32(7), poolConfig.MaxConns)
+	require.Equal(t, time.Minute, poolConfig.MaxConnIdleTime)
+	require.Equal(t, "1500", poolConfig.ConnConfig.RuntimeParams["statement_timeout"])
+
+	var timeout string
+	err = pool.QueryRow(context.Background(), "SHOW statement_timeout").Scan(&timeout)
+	require.NoError(t, err)
+	require.Equal(t, "1500ms", timeout)
+
+	config.DBSource = "postgresql://%zz"
+	_, err = NewConnPool(context.Background(), config)
+	require.Error(t, err)
+}
@@ -6,29 +6,115 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	AnonymizeUser(ctx context.Context, username string) (User, error)
+	// leases the due deliveries to one worker: the others skip the rows it locks,
+	// and don't see them as due again before the lease ends.
+	ClaimDueWebhookDeliveries(ctx context.Context, arg ClaimDueWebhookDeliveriesParams) ([]ClaimDueWebhookDeliveriesRow, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateArchivedPartition(ctx context.Context, arg CreateArchivedPartitionParams) (ArchivedPartition, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) (int64, error)
+	CreateJournalTransaction(ctx context.Context, arg CreateJournalTransactionParams) (JournalTransaction, error)
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (OutboxEvent, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	// an empty role gets the default one, depositor.
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (int64, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteAccountLimit(ctx context.Context, accountID int64) error
 	DeleteEntry(ctx context.Context, id int64) error
+	DeleteExpiredSessions(ctx context.Context, expiredBefore time.Time) (int64, error)
+	DeleteFinishedWebhookDeliveries(ctx context.Context, createdBefore time.Time) (int64, error)
 	DeleteTransfer(ctx context.Context, id int64) error
+	DeleteUserSessions(ctx context.Context, username string) error
+	DeleteUserWebhooks(ctx context.Context, owner string) error
+	DeleteWebhook(ctx context.Context, id int64) error
+	FreezeAccount(ctx context.Context, arg FreezeAccountParams) (Account, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetAccountLimit(ctx context.Context, accountID int64) (AccountLimit, error)
+	GetDailyNewUsers(ctx context.Context, arg GetDailyNewUsersParams) ([]GetDailyNewUsersRow, error)
+	GetDailyTransferVolume(ctx context.Context, arg GetDailyTransferVolumeParams) ([]GetDailyTransferVolumeRow, error)
+	// the ids are unique across the partitions, see the comment of entries.id.
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetInterestAccrual(ctx context.Context, arg GetInterestAccrualParams) (InterestAccrual, error)
+	GetJournalTransaction(ctx context.Context, id int64) (JournalTransaction, error)
+	// the day the interest job accrued last, for it to catch up the days after.
+	GetLastInterestAccrualDate(ctx context.Context) (time.Time, error)
+	GetOutgoingTransfersTotal(ctx context.Context, arg GetOutgoingTransfersTotalParams) (int64, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetSystemAccount(ctx context.Context, arg GetSystemAccountParams) (Account, error)
+	// the ids are unique across the partitions, see the comment of transfers.id.
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTrialBalance(ctx context.Context) ([]GetTrialBalanceRow, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, arg GetUserByEmailParams) (User, error)
+	// checked on every authenticated request, without reading the personal data.
+	GetUserDeletedAt(ctx context.Context, username string) (sql.NullTime, error)
+	GetUserForShare(ctx context.Context, username string) (User, error)
+	// read when renewing an access token, so that it gets the current role.
+	GetUserRole(ctx context.Context, username string) (GetUserRoleRow, error)
+	GetWebhook(ctx context.Context, id int64) (Webhook, error)
+	// the entries of the archived partitions count through their totals.
+	ListAccountBalanceMismatches(ctx context.Context) ([]ListAccountBalanceMismatchesRow, error)
 	ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]Entry, error)
+	ListAccountEntriesAfter(ctx context.Context, arg ListAccountEntriesAfterParams) ([]Entry, error)
+	ListAccountEntriesBetween(ctx context.Context, arg ListAccountEntriesBetweenParams) ([]Entry, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListAccountsByOwner(ctx context.Context, arg ListAccountsByOwnerParams) ([]Account, error)
+	ListAccountsByType(ctx context.Context, arg ListAccountsByTypeParams) ([]Account, error)
+	ListAccountsWithUnpostedInterest(ctx context.Context, before time.Time) ([]int64, error)
+	ListArchivedPartitions(ctx context.Context) ([]ArchivedPartition, error)
+	// the entries after the (after_created_at, after_id) cursor, the zero values
+	// give the first page.
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListJournalEntries(ctx context.Context, journalID sql.NullInt64) ([]Entry, error)
+	ListOpenAccountIDsByOwner(ctx context.Context, owner *string) ([]int64, error)
+	ListOutboxEventsByAggregate(ctx context.Context, arg ListOutboxEventsByAggregateParams) ([]OutboxEvent, error)
+	// the transfers from or to the accounts of the owner.
+	ListOwnerTransfers(ctx context.Context, arg ListOwnerTransfersParams) ([]Transfer, error)
+	ListOwnerTransfersByReference(ctx context.Context, arg ListOwnerTransfersByReferenceParams) ([]Transfer, error)
+	ListTopAccountsByBalance(ctx context.Context, arg ListTopAccountsByBalanceParams) ([]Account, error)
+	// the transfers after the (after_created_at, after_id) cursor, the zero
+	// values give the first page.
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListTransfersByReference(ctx context.Context, arg ListTransfersByReferenceParams) ([]Transfer, error)
+	ListUnbalancedJournals(ctx context.Context) ([]ListUnbalancedJournalsRow, error)
+	ListUnpostedInterestAccrualsForUpdate(ctx context.Context, arg ListUnpostedInterestAccrualsForUpdateParams) ([]InterestAccrual, error)
+	// the events due for an attempt, failed events wait for their backoff.
+	ListUnpublishedOutboxEvents(ctx context.Context, limit int32) ([]OutboxEvent, error)
+	ListUsersForUpdate(ctx context.Context, arg ListUsersForUpdateParams) ([]User, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhooks(ctx context.Context, owner string) ([]Webhook, error)
+	ListWebhooksForEvent(ctx context.Context, arg ListWebhooksForEventParams) ([]Webhook, error)
+	MarkInterestAccrualsPosted(ctx context.Context, arg MarkInterestAccrualsPostedParams) (int64, error)
+	MarkOutboxEventPublished(ctx context.Context, id int64) error
+	MarkWebhookDeliveryDelivered(ctx context.Context, arg MarkWebhookDeliveryDeliveredParams) error
+	NotifyAccountEntry(ctx context.Context, payload string) error
+	RecordOutboxEventFailure(ctx context.Context, arg RecordOutboxEventFailureParams) error
+	RecordWebhookDeliveryFailure(ctx context.Context, arg RecordWebhookDeliveryFailureParams) error
+	RedactUserOutboxEvents(ctx context.Context, aggregateID string) error
+	SoftDeleteAccount(ctx context.Context, id int64) (Account, error)
+	SumAccountEntriesSince(ctx context.Context, arg SumAccountEntriesSinceParams) (int64, error)
+	UnfreezeAccount(ctx context.Context, id int64) (Account, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
 	UpdateEntry(ctx context.Context, arg UpdateEntryParams) (Entry, error)
 	UpdateTransfer(ctx context.Context, arg UpdateTransferParams) (Transfer, error)
+	UpdateUserPersonalData(ctx context.Context, arg UpdateUserPersonalDataParams) (User, error)
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
+	UpsertAccountLimit(ctx context.Context, arg UpsertAccountLimitParams) (AccountLimit, error)
+	// closes the unposted accruals of an account without paying them.
+	VoidInterestAccruals(ctx context.Context, accountID int64) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
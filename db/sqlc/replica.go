@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Connection that runs the queries on a replica, and runs them again on the
+// primary when the replica can't be reached.
+type fallbackDBTX struct {
+	replica DBTX
+	primary DBTX
+}
+
+func (db fallbackDBTX) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	tag, err := db.replica.Exec(ctx, query, args...)
+	if isUnavailable(ctx, err) {
+		logFallback(err)
+		return db.primary.Exec(ctx, query, args...)
+	}
+	return tag, err
+}
+
+func (db fallbackDBTX) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	rows, err := db.replica.Query(ctx, query, args...)
+	if isUnavailable(ctx, err) {
+		logFallback(err)
+		return db.primary.Query(ctx, query, args...)
+	}
+	return rows, err
+}
+
+func (db fallbackDBTX) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	return fallbackRow{
+		Row:     db.replica.QueryRow(ctx, query, args...),
+		primary: func() pgx.Row { return db.primary.QueryRow(ctx, query, args...) },
+		ctx:     ctx,
+	}
+}
+
+// Row of the replica, whose errors only come up when it is scanned.
+type fallbackRow struct {
+	pgx.Row
+	primary func() pgx.Row
+	ctx     context.Context
+}
+
+func (row fallbackRow) Scan(dest ...any) error {
+	err := row.Row.Scan(dest...)
+	if isUnavailable(row.ctx, err) {
+		logFallback(err)
+		return row.primary().Scan(dest...)
+	}
+	return err
+}
+
+// Reports whether a query failed because the database couldn't be reached
+// or is shutting down, rather than because of the query itself.
+func isUnavailable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || errors.Is(err, pgx.ErrNoRows) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// connection exceptions and operator interventions, such as a
+		// replica that is shutting down or still starting up.
+		return strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "57P")
+	}
+	return true
+}
+
+func logFallback(err error) {
+	log.Printf("replica unavailable, reading from the primary: %v", err)
+}
+
+// The list and report endpoints read from the replica. The reads that money
+// is posted from, such as the interest accrual and the statements, and the
+// reconciliation, whose mismatches a lagging replica would fake, stay on the
+// primary.
+
+// Lists the accounts on the replica.
+func (store *SQLStore) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	return store.replica.ListAccounts(ctx, arg)
+}
+
+// Lists the accounts of an owner on the replica.
+func (store *SQLStore) ListAccountsByOwner(ctx context.Context, arg ListAccountsByOwnerParams) ([]Account, error) {
+	return store.replica.ListAccountsByOwner(ctx, arg)
+}
+
+// Lists the entries on the replica.
+func (store *SQLStore) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
+	return store.replica.ListEntries(ctx, arg)
+}
+
+// Lists the entries of an account on the replica.
+func (store *SQLStore) ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]Entry, error) {
+	return store.replica.ListAccountEntries(ctx, arg)
+}
+
+// Lists the transfers on the replica.
+func (store *SQLStore) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
+	return store.replica.ListTransfers(ctx, arg)
+}
+
+// Lists the transfers of an owner on the replica.
+func (store *SQLStore) ListOwnerTransfers(ctx context.Context, arg ListOwnerTransfersParams) ([]Transfer, error) {
+	return store.replica.ListOwnerTransfers(ctx, arg)
+}
+
+// Reports the daily new users on the replica.
+func (store *SQLStore) GetDailyNewUsers(ctx context.Context, arg GetDailyNewUsersParams) ([]GetDailyNewUsersRow, error) {
+	return store.replica.GetDailyNewUsers(ctx, arg)
+}
+
+// Reports the daily transfer volume on the replica.
+func (store *SQLStore) GetDailyTransferVolume(ctx context.Context, arg GetDailyTransferVolumeParams) ([]GetDailyTransferVolumeRow, error) {
+	return store.replica.GetDailyTransferVolume(ctx, arg)
+}
+
+// Reports the top accounts by balance on the replica.
+func (store *SQLStore) ListTopAccountsByBalance(ctx context.Context, arg ListTopAccountsByBalanceParams) ([]Account, error) {
+	return store.replica.ListTopAccountsByBalance(ctx, arg)
+}
+
+// Reports the trial balance on the replica.
+func (store *SQLStore) GetTrialBalance(ctx context.Context) ([]GetTrialBalanceRow, error) {
+	return store.replica.GetTrialBalance(ctx)
+}
@@ -0,0 +1,280 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: report.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const getDailyNewUsers = `-- name: GetDailyNewUsers :many
+SELECT
+	(created_at AT TIME ZONE 'UTC')::date AS day,
+	COUNT(*)::bigint AS users
+FROM users
+WHERE created_at >= $1
+AND created_at < $2
+GROUP BY day
+ORDER BY day
+`
+
+type GetDailyNewUsersParams struct {
+	FromTime time.Time `json:"from_time"`
+	ToTime   time.Time `json:"to_time"`
+}
+
+type GetDailyNewUsersRow struct {
+	Day   time.Time `json:"day"`
+	Users int64     `json:"users"`
+}
+
+func (q *Queries) GetDailyNewUsers(ctx context.Context, arg GetDailyNewUsersParams) ([]GetDailyNewUsersRow, error) {
+	rows, err := q.db.Query(ctx, getDailyNewUsers, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetDailyNewUsersRow{}
+	for rows.Next() {
+		var i GetDailyNewUsersRow
+		if err := rows.Scan(&i.Day, &i.Users); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDailyTransferVolume = `-- name: GetDailyTransferVolume :many
+SELECT
+	(t.created_at AT TIME ZONE 'UTC')::date AS day,
+	a.currency,
+	COUNT(*)::bigint AS transfers,
+	COALESCE(SUM(t.amount), 0)::bigint AS volume
+FROM transfers t
+JOIN accounts a ON a.id = t.from_account_id
+WHERE t.created_at >= $1
+AND t.created_at < $2
+GROUP BY day, a.currency
+ORDER BY day, a.currency
+`
+
+type GetDailyTransferVolumeParams struct {
+	FromTime time.Time `json:"from_time"`
+	ToTime   time.Time `json:"to_time"`
+}
+
+type GetDailyTransferVolumeRow struct {
+	Day       time.Time `json:"day"`
+	Currency  string    `json:"currency"`
+	Transfers int64     `json:"transfers"`
+	Volume    int64     `json:"volume"`
+}
+
+func (q *Queries) GetDailyTransferVolume(ctx context.Context, arg GetDailyTransferVolumeParams) ([]GetDailyTransferVolumeRow, error) {
+	rows, err := q.db.Query(ctx, getDailyTransferVolume, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetDailyTransferVolumeRow{}
+	for rows.Next() {
+		var i GetDailyTransferVolumeRow
+		if err := rows.Scan(
+			&i.Day,
+			&i.Currency,
+			&i.Transfers,
+			&i.Volume,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTrialBalance = `-- name: GetTrialBalance :many
+SELECT
+	currency,
+	COUNT(*) FILTER (WHERE system_kind IS NULL)::bigint AS customer_accounts,
+	COALESCE(SUM(balance) FILTER (WHERE system_kind IS NULL), 0)::bigint AS customer_balance,
+	COALESCE(SUM(balance) FILTER (WHERE system_kind IS NOT NULL), 0)::bigint AS system_balance,
+	COALESCE(SUM(balance), 0)::bigint AS total_balance
+FROM accounts
+GROUP BY currency
+ORDER BY currency
+`
+
+type GetTrialBalanceRow struct {
+	Currency         string `json:"currency"`
+	CustomerAccounts int64  `json:"customer_accounts"`
+	CustomerBalance  int64  `json:"customer_balance"`
+	SystemBalance    int64  `json:"system_balance"`
+	TotalBalance     int64  `json:"total_balance"`
+}
+
+func (q *Queries) GetTrialBalance(ctx context.Context) ([]GetTrialBalanceRow, error) {
+	rows, err := q.db.Query(ctx, getTrialBalance)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetTrialBalanceRow{}
+	for rows.Next() {
+		var i GetTrialBalanceRow
+		if err := rows.Scan(
+			&i.Currency,
+			&i.CustomerAccounts,
+			&i.CustomerBalance,
+			&i.SystemBalance,
+			&i.TotalBalance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountBalanceMismatches = `-- name: ListAccountBalanceMismatches :many
+SELECT
+	a.id AS account_id,
+	a.currency,
+	a.balance,
+	(COALESCE(SUM(e.amount), 0) + COALESCE(MAX(t.amount), 0))::bigint AS entries_total
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+LEFT JOIN archived_entry_totals t ON t.account_id = a.id
+GROUP BY a.id
+HAVING a.balance <> COALESCE(SUM(e.amount), 0) + COALESCE(MAX(t.amount), 0)
+ORDER BY a.id
+`
+
+type ListAccountBalanceMismatchesRow struct {
+	AccountID    int64  `json:"account_id"`
+	Currency     string `json:"currency"`
+	Balance      int64  `json:"balance"`
+	EntriesTotal int64  `json:"entries_total"`
+}
+
+// the entries of the archived partitions count through their totals.
+func (q *Queries) ListAccountBalanceMismatches(ctx context.Context) ([]ListAccountBalanceMismatchesRow, error) {
+	rows, err := q.db.Query(ctx, listAccountBalanceMismatches)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountBalanceMismatchesRow{}
+	for rows.Next() {
+		var i ListAccountBalanceMismatchesRow
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Currency,
+			&i.Balance,
+			&i.EntriesTotal,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTopAccountsByBalance = `-- name: ListTopAccountsByBalance :many
+SELECT id, owner, balance, currency, created_at, account_type, system_kind, frozen_at, frozen_reason, deleted_at, version FROM accounts
+WHERE system_kind IS NULL
+AND currency = $1
+ORDER BY balance DESC, id
+LIMIT $2
+`
+
+type ListTopAccountsByBalanceParams struct {
+	Currency string `json:"currency"`
+	Limit    int32  `json:"limit"`
+}
+
+func (q *Queries) ListTopAccountsByBalance(ctx context.Context, arg ListTopAccountsByBalanceParams) ([]Account, error) {
+	rows, err := q.db.Query(ctx, listTopAccountsByBalance, arg.Currency, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.AccountType,
+			&i.SystemKind,
+			&i.FrozenAt,
+			&i.FrozenReason,
+			&i.DeletedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnbalancedJournals = `-- name: ListUnbalancedJournals :many
+SELECT
+	e.journal_id::bigint AS journal_id,
+	a.currency,
+	SUM(e.amount)::bigint AS total
+FROM entries e
+JOIN accounts a ON a.id = e.account_id
+WHERE e.journal_id IS NOT NULL
+GROUP BY e.journal_id, a.currency
+HAVING SUM(e.amount) <> 0
+ORDER BY e.journal_id, a.currency
+`
+
+type ListUnbalancedJournalsRow struct {
+	JournalID int64  `json:"journal_id"`
+	Currency  string `json:"currency"`
+	Total     int64  `json:"total"`
+}
+
+func (q *Queries) ListUnbalancedJournals(ctx context.Context) ([]ListUnbalancedJournalsRow, error) {
+	rows, err := q.db.Query(ctx, listUnbalancedJournals)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUnbalancedJournalsRow{}
+	for rows.Next() {
+		var i ListUnbalancedJournalsRow
+		if err := rows.Scan(&i.JournalID, &i.Currency, &i.Total); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
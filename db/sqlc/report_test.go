@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTrialBalance(t *testing.T) {
+	createRandomAccount(t)
+
+	rows, err := testQueries.GetTrialBalance(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, rows)
+
+	for _, row := range rows {
+		require.NotEmpty(t, row.Currency)
+		require.Equal(t, row.TotalBalance, row.CustomerBalance+row.SystemBalance)
+	}
+}
+
+func TestGetDailyTransferVolume(t *testing.T) {
+	transfer := createRandomTransfer(t)
+	fromAccount, err := testQueries.GetAccount(context.Background(), transfer.FromAccountID)
+	require.NoError(t, err)
+
+	day := transfer.CreatedAt.UTC().Truncate(24 * time.Hour)
+	rows, err := testQueries.GetDailyTransferVolume(context.Background(), GetDailyTransferVolumeParams{
+		FromTime: day,
+		ToTime:   day.AddDate(0, 0, 1),
+	})
+	require.NoError(t, err)
+
+	var found bool
+	for _, row := range rows {
+		require.True(t, row.Day.Equal(day))
+		if row.Currency == fromAccount.Currency {
+			found = true
+			require.GreaterOrEqual(t, row.Transfers, int64(1))
+			require.GreaterOrEqual(t, row.Volume, transfer.Amount)
+		}
+	}
+	require.True(t, found)
+}
+
+func TestListTopAccountsByBalance(t *testing.T) {
+	account := createRandomAccount(t)
+
+	accounts, err := testQueries.ListTopAccountsByBalance(context.Background(), ListTopAccountsByBalanceParams{
+		Currency: account.Currency,
+		Limit:    5,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, accounts)
+	require.LessOrEqual(t, len(accounts), 5)
+
+	for i, a := range accounts {
+		require.Equal(t, account.Currency, a.Currency)
+		require.Nil(t, a.SystemKind)
+		if i > 0 {
+			require.GreaterOrEqual(t, accounts[i-1].Balance, a.Balance)
+		}
+	}
+}
+
+func TestGetDailyNewUsers(t *testing.T) {
+	// the other tests create users on the same day.
+	queries := newIsolatedQueries(t)
+
+	n := 3
+	var user User
+	for i := 0; i < n; i++ {
+		var err error
+		user, err = queries.CreateUser(context.Background(), CreateUserParams{
+			Username:       utils.RandomOwner(),
+			HashedPassword: utils.RandomString(32),
+			FullName:       utils.RandomOwner(),
+			Email:          utils.RandomEmailAddress(),
+		})
+		require.NoError(t, err)
+	}
+
+	day := user.CreatedAt.UTC().Truncate(24 * time.Hour)
+	rows, err := queries.GetDailyNewUsers(context.Background(), GetDailyNewUsersParams{
+		FromTime: day,
+		ToTime:   day.AddDate(0, 0, 1),
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.True(t, rows[0].Day.Equal(day))
+	require.Equal(t, int64(n), rows[0].Users)
+}
+
+func TestListAccountBalanceMismatches(t *testing.T) {
+	// random accounts get a balance without any entries behind it.
+	account := createRandomAccount(t)
+
+	rows, err := testQueries.ListAccountBalanceMismatches(context.Background())
+	require.NoError(t, err)
+
+	var found bool
+	for _, row := range rows {
+		require.NotEqual(t, row.Balance, row.EntriesTotal)
+		if row.AccountID == account.ID {
+			found = true
+			require.Equal(t, account.Balance, row.Balance)
+		}
+	}
+	require.Equal(t, account.Balance != 0, found)
+}
+
+func TestListUnbalancedJournals(t *testing.T) {
+	rows, err := testQueries.ListUnbalancedJournals(context.Background())
+	require.NoError(t, err)
+
+	for _, row := range rows {
+		require.NotZero(t, row.Total)
+	}
+}
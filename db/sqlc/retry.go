@@ -0,0 +1,24 @@
+package db
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// Backoff before the first retry of a transaction, doubled on each retry.
+const txRetryBaseBackoff = 10 * time.Millisecond
+
+// Reports whether a transaction failed only because of concurrent ones, so
+// that running it again may succeed.
+func isRetryable(err error) bool {
+	return errors.Is(err, ErrSerializationFailure) || errors.Is(err, ErrDeadlockDetected)
+}
+
+// Returns a random wait before the retry following the attempt, up to an
+// exponentially growing bound, so that the conflicting transactions don't
+// retry in lockstep.
+func txRetryBackoff(attempt int) time.Duration {
+	bound := txRetryBaseBackoff << min(attempt, 6)
+	return bound/2 + time.Duration(rand.Int63n(int64(bound/2)))
+}
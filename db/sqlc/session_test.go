@@ -0,0 +1,35 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateSession(t *testing.T) {
+	user := createRandomUser(t)
+
+	args := CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: utils.RandomString(32),
+		UserAgent:    "go-test",
+		ClientIp:     "127.0.0.1",
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+
+	session, err := testQueries.CreateSession(context.Background(), args)
+	require.NoError(t, err)
+	require.Equal(t, args.ID, session.ID)
+	require.Equal(t, args.Username, session.Username)
+	require.False(t, session.IsBlocked)
+
+	got, err := testQueries.GetSession(context.Background(), args.ID)
+	require.NoError(t, err)
+	require.Equal(t, session.RefreshToken, got.RefreshToken)
+	require.WithinDuration(t, args.ExpiresAt, got.ExpiresAt, time.Second)
+}
@@ -2,148 +2,296 @@ package db
 
 import (
 	"context"
-	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kvgtl/simplebank/utils"
 )
 
 // Provides all functions to execute database queries and transactions.
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, args TransferTxParams) (TransferTxResult, error)
+	PostJournalTx(ctx context.Context, args PostJournalTxParams) (PostJournalTxResult, error)
+	PostInterestTx(ctx context.Context, args PostInterestTxParams) (PostInterestTxResult, error)
+	CreateAccountTx(ctx context.Context, args CreateAccountParams) (Account, error)
+	CreateUserTx(ctx context.Context, args CreateUserParams) (User, error)
+	DeleteAccountTx(ctx context.Context, arg DeleteAccountTxParams) (Account, error)
+	DeleteUserTx(ctx context.Context, username string) (User, error)
+	ReencryptUsersTx(ctx context.Context, arg ReencryptUsersTxParams) (ReencryptUsersTxResult, error)
+}
+
+// Runs fn within a transaction with the options. fn may run more than once
+// if the transaction is retried.
+type TxFunc func(ctx context.Context, opts pgx.TxOptions, fn func(Querier) error) error
+
+// Provides the transactions of a Store on top of its queries. Every Store
+// implementation builds on it, so that their transactions behave the same.
+type txStore struct {
+	Querier
+	execTx TxFunc
+	config utils.Config
+}
+
+// Creates a Store running its queries with q and its transactions with
+// execTx. It lets other implementations than SQLStore share its transactions.
+func NewTxStore(q Querier, execTx TxFunc, config utils.Config) Store {
+	store := newTxStore(q, execTx, config)
+	return &store
+}
+
+// Builds the txStore of q and execTx. The queries, in and out of the
+// transactions, encrypt the personal data of the users when the config has
+// encryption keys.
+func newTxStore(q Querier, execTx TxFunc, config utils.Config) txStore {
+	return txStore{
+		Querier: withPII(q, config.PIIKeyring),
+		execTx: func(ctx context.Context, opts pgx.TxOptions, fn func(Querier) error) error {
+			return execTx(ctx, opts, func(q Querier) error {
+				return fn(withPII(q, config.PIIKeyring))
+			})
+		},
+		config: config,
+	}
 }
 
 // Provides all functions to execute SQL queries and transactions.
+// The errors of its queries are translated to match the sentinels of error.go.
 type SQLStore struct {
-	*Queries
-	db *sql.DB
+	txStore
+	connPool *pgxpool.Pool
+	// Queries of the list and report endpoints, run on the replica if any.
+	replica *Queries
 }
 
 // Creates a new Store.
-func NewStore(db *sql.DB) Store {
-	return &SQLStore{
-		db:      db,
-		Queries: New(db),
+func NewStore(connPool *pgxpool.Pool, config utils.Config) Store {
+	return NewStoreWithReplica(connPool, nil, config)
+}
+
+// Creates a new Store reading the results of the list and report endpoints
+// from a replica, or from the primary while the replica is unavailable.
+// Transactions and the other reads always run on the primary, so that they
+// see their own writes. A nil replica runs everything on the primary.
+func NewStoreWithReplica(connPool, replicaPool *pgxpool.Pool, config utils.Config) Store {
+	queries := New(translatingDBTX{connPool})
+	replica := queries
+	if replicaPool != nil {
+		replica = New(translatingDBTX{fallbackDBTX{replica: replicaPool, primary: connPool}})
+	}
+
+	store := &SQLStore{
+		connPool: connPool,
+		replica:  replica,
 	}
+	store.txStore = newTxStore(queries, store.execTx, config)
+	return store
 }
 
-// Executes a function within a database transaction.
-func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := store.db.BeginTx(ctx, nil)
+// Executes a function within a database transaction with the options.
+// Transactions failing on a serialization failure or a deadlock are retried
+// from the start, with a jittered backoff, up to DBTxMaxRetries times, so fn
+// must not keep state between its calls.
+func (store *SQLStore) execTx(ctx context.Context, opts pgx.TxOptions, fn func(Querier) error) error {
+	for attempt := 0; ; attempt++ {
+		err := store.runTx(ctx, opts, fn)
+		if !isRetryable(err) || attempt >= store.config.DBTxMaxRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(txRetryBackoff(attempt)):
+		}
+	}
+}
+
+// Runs a function once within a database transaction.
+func (store *SQLStore) runTx(ctx context.Context, opts pgx.TxOptions, fn func(Querier) error) error {
+	tx, err := store.connPool.BeginTx(ctx, opts)
 	if err != nil {
-		return err
+		return translateError(err)
 	}
-	q := New(tx)
+	q := New(translatingDBTX{tx})
 	err = fn(q)
 	if err != nil {
-		if rbErr := tx.Rollback(); rbErr != nil {
-			return fmt.Errorf("tx error: %v, rb error: %v", err, rbErr)
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			return fmt.Errorf("tx error: %w, rb error: %v", err, rbErr)
 		}
 		return err
 	}
-	return tx.Commit()
+	return translateError(tx.Commit(ctx))
 }
 
 // Contains the parameters of the transfer transaction.
 type TransferTxParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        int64           `json:"amount"`
+	Description   string          `json:"description"`
+	Reference     string          `json:"reference"`
+	Metadata      json.RawMessage `json:"metadata"`
 }
 
 // Contains the result of the transfer transaction.
 type TransferTxResult struct {
-	Transfer    Transfer `json:"transfer"`
-	FromAccount Account  `json:"from_account"`
-	ToAccount   Account  `json:"to_account"`
-	FromEntry   Entry    `json:"from_entry"`
-	ToEntry     Entry    `json:"to_entry"`
+	Transfer    Transfer           `json:"transfer"`
+	Journal     JournalTransaction `json:"journal"`
+	FromAccount Account            `json:"from_account"`
+	ToAccount   Account            `json:"to_account"`
+	FromEntry   Entry              `json:"from_entry"`
+	ToEntry     Entry              `json:"to_entry"`
+	Fee         int64              `json:"fee"`
+	FeeEntry    Entry              `json:"fee_entry"`
 }
 
 // Performs a money transfer from one account to another.
-// It creates a transfer record (Transfer), add account entries (Entry), and
-// updates accounts' balance (Account) within a single database transaction.
-func (store *SQLStore) TransferTx(ctx context.Context, args TransferTxParams) (TransferTxResult, error) {
+// It creates a transfer record (Transfer) and books it as a journal posting,
+// adding account entries (Entry) and updating accounts' balance (Account)
+// within a single database transaction. The fee of the transfer, if any, is
+// charged to the sender in the same posting, and a TransferCompleted event is
+// written to the outbox.
+// It fails with a *TransferLimitError if the sender's limits would be exceeded
+// and with ErrAccountFrozen or ErrAccountDeleted if either account is frozen
+// or deleted. It fails with ErrInsufficientFunds if the sender's balance
+// doesn't cover the amount and the fee, which postJournal checks.
+func (store *txStore) TransferTx(ctx context.Context, args TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
-	err := store.execTx(ctx, func(q *Queries) error {
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q Querier) error {
 		var err error
 
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: args.FromAccountID,
-			ToAccountID:   args.ToAccountID,
-			Amount:        args.Amount,
-		})
+		fee, feeAccountID, err := store.transferFee(ctx, q, args)
 		if err != nil {
 			return err
 		}
 
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: args.FromAccountID,
-			Amount:    -args.Amount,
-		})
+		// lock the accounts before reading the sender's history, so concurrent
+		// transfers from the same account can't overrun its limits.
+		accountIDs := []int64{args.FromAccountID, args.ToAccountID}
+		if fee > 0 {
+			accountIDs = append(accountIDs, feeAccountID)
+		}
+		accounts, err := lockAccounts(ctx, q, accountIDs...)
 		if err != nil {
 			return err
 		}
 
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: args.ToAccountID,
-			Amount:    args.Amount,
+		for _, id := range []int64{args.FromAccountID, args.ToAccountID} {
+			if accounts[id].DeletedAt != nil {
+				return fmt.Errorf("%w: account %d", ErrAccountDeleted, id)
+			}
+			if accounts[id].FrozenAt != nil {
+				return fmt.Errorf("%w: account %d", ErrAccountFrozen, id)
+			}
+		}
+
+		err = store.checkTransferLimits(ctx, q, args.FromAccountID, args.Amount)
+		if err != nil {
+			return err
+		}
+
+		metadata := args.Metadata
+		if len(metadata) == 0 {
+			metadata = json.RawMessage("{}")
+		}
+
+		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+			FromAccountID: args.FromAccountID,
+			ToAccountID:   args.ToAccountID,
+			Amount:        args.Amount,
+			Description:   args.Description,
+			Reference:     args.Reference,
+			Metadata:      metadata,
 		})
 		if err != nil {
 			return err
 		}
 
-		// TODO: update accounts' balance.
-		// get accounts and update its balances.
-
-		// senderAccount, err := q.GetAccountForUpdate(ctx, args.FromAccountID)
-		// if err != nil {
-		// 	return err
-		// }
-		// result.FromAccount, err = q.UpdateAccount(ctx, UpdateAccountParams{
-		// 	ID:      args.FromAccountID,
-		// 	Balance: senderAccount.Balance - args.Amount,
-		// })
-		// if err != nil {
-		// 	return err
-		// }
-
-		if args.FromAccountID < args.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, args.FromAccountID, -args.Amount, args.ToAccountID, args.Amount)
+		lines := []JournalLine{
+			{
+				AccountID:   args.FromAccountID,
+				Amount:      -args.Amount,
+				Description: args.Description,
+				Reference:   args.Reference,
+				Metadata:    metadata,
+			},
+			{
+				AccountID:   args.ToAccountID,
+				Amount:      args.Amount,
+				Description: args.Description,
+				Reference:   args.Reference,
+				Metadata:    metadata,
+			},
+		}
+
+		if fee > 0 {
+			feeMetadata, err := json.Marshal(map[string]int64{"transfer_id": result.Transfer.ID})
 			if err != nil {
 				return err
 			}
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, args.ToAccountID, args.Amount, args.FromAccountID, -args.Amount)
-			if err != nil {
-				return err
+
+			lines = append(lines,
+				JournalLine{
+					AccountID:   args.FromAccountID,
+					Amount:      -fee,
+					Description: "transfer fee",
+					Reference:   args.Reference,
+					Metadata:    feeMetadata,
+				},
+				JournalLine{
+					AccountID:   feeAccountID,
+					Amount:      fee,
+					Description: "transfer fee",
+					Reference:   args.Reference,
+					Metadata:    feeMetadata,
+				},
+			)
+		}
+
+		posting, err := postJournal(ctx, q, PostJournalTxParams{
+			Kind:        JournalTransfer,
+			Description: args.Description,
+			Lines:       lines,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Journal = posting.Journal
+		result.FromEntry = posting.Entries[0]
+		result.ToEntry = posting.Entries[1]
+		if fee > 0 {
+			result.Fee = fee
+			result.FeeEntry = posting.Entries[2]
+		}
+
+		for _, account := range posting.Accounts {
+			switch account.ID {
+			case args.FromAccountID:
+				result.FromAccount = account
+			case args.ToAccountID:
+				result.ToAccount = account
 			}
 		}
 
-		return nil
+		_, err = enqueueEvent(ctx, q, AggregateTransfer, strconv.FormatInt(result.Transfer.ID, 10), EventTransferCompleted, TransferCompletedEvent{
+			TransferID:    result.Transfer.ID,
+			JournalID:     result.Journal.ID,
+			FromAccountID: args.FromAccountID,
+			ToAccountID:   args.ToAccountID,
+			Amount:        args.Amount,
+			Fee:           result.Fee,
+			Currency:      result.FromAccount.Currency,
+			Reference:     args.Reference,
+		})
+		return err
 	})
 
 	return result, err
 }
-
-func addMoney(
-	ctx context.Context,
-	q *Queries,
-	accountID1 int64,
-	amount1 int64,
-	accountID2 int64,
-	amount2 int64,
-) (account1 Account, account2 Account, err error) {
-	account1, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     accountID1,
-		Amount: amount1,
-	})
-	if err != nil {
-		return
-	}
-	account2, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     accountID2,
-		Amount: amount2,
-	})
-	return
-}
@@ -2,17 +2,23 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"testing"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/kvgtl/simplebank/utils"
 	"github.com/stretchr/testify/require"
 )
 
 func TestTransferTx(t *testing.T) {
-	store := NewStore(testDB)
+	store := NewStore(testDB, testConfig)
 
 	senderAccount := createRandomAccount(t)
-	receiverAccount := createRandomAccount(t)
+	receiverAccount := createRandomAccountWithCurrency(t, senderAccount.Currency)
 
 	fmt.Println("before transaction>>Sender:", senderAccount.Balance)
 	fmt.Println("before transaction>>Receiver:", receiverAccount.Balance)
@@ -127,10 +133,10 @@ func TestTransferTx(t *testing.T) {
 }
 
 func TestTransferTxDeadlock(t *testing.T) {
-	store := NewStore(testDB)
+	store := NewStore(testDB, testConfig)
 
 	senderAccount := createRandomAccount(t)
-	receiverAccount := createRandomAccount(t)
+	receiverAccount := createRandomAccountWithCurrency(t, senderAccount.Currency)
 
 	// run n concurrent transfer transactions.
 	n := 10
@@ -171,3 +177,362 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, senderAccount.Balance, updatedSenderAccount.Balance)
 	require.Equal(t, receiverAccount.Balance, updatedReceiverAccount.Balance)
 }
+
+func TestTransferTxDetails(t *testing.T) {
+	store := NewStore(testDB, testConfig)
+
+	senderAccount := createRandomAccount(t)
+	receiverAccount := createRandomAccountWithCurrency(t, senderAccount.Currency)
+
+	args := TransferTxParams{
+		FromAccountID: senderAccount.ID,
+		ToAccountID:   receiverAccount.ID,
+		Amount:        10,
+		Description:   "rent",
+		Reference:     utils.RandomString(12),
+		Metadata:      json.RawMessage(`{"invoice":"2024-08"}`),
+	}
+
+	result, err := store.TransferTx(context.Background(), args)
+	require.NoError(t, err)
+
+	for _, details := range []struct {
+		description string
+		reference   string
+		metadata    json.RawMessage
+	}{
+		{result.Transfer.Description, result.Transfer.Reference, result.Transfer.Metadata},
+		{result.FromEntry.Description, result.FromEntry.Reference, result.FromEntry.Metadata},
+		{result.ToEntry.Description, result.ToEntry.Reference, result.ToEntry.Metadata},
+	} {
+		require.Equal(t, args.Description, details.description)
+		require.Equal(t, args.Reference, details.reference)
+		require.JSONEq(t, string(args.Metadata), string(details.metadata))
+	}
+
+	// metadata defaults to an empty object.
+	args.Metadata = nil
+	result, err = store.TransferTx(context.Background(), args)
+	require.NoError(t, err)
+	require.JSONEq(t, `{}`, string(result.Transfer.Metadata))
+}
+
+func TestTransferTxFee(t *testing.T) {
+	senderAccount := createRandomAccountWithCurrency(t, utils.USD)
+	receiverAccount := createRandomAccountWithCurrency(t, utils.USD)
+
+	feeAccount, err := testQueries.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		SystemKind: SystemFees,
+		Currency:   utils.USD,
+	})
+	require.NoError(t, err)
+
+	config := testConfig
+	config.FeeSchedule = utils.FeeSchedule{utils.USD: {Flat: 5, BasisPoints: 100}}
+
+	store := NewStore(testDB, config)
+
+	amount := int64(1000)
+	fee := int64(15)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: senderAccount.ID,
+		ToAccountID:   receiverAccount.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, fee, result.Fee)
+	require.Equal(t, result.Journal.ID, result.FeeEntry.JournalID.Int64)
+	require.Equal(t, senderAccount.ID, result.FeeEntry.AccountID)
+	require.Equal(t, -fee, result.FeeEntry.Amount)
+	require.JSONEq(t, fmt.Sprintf(`{"transfer_id":%d}`, result.Transfer.ID), string(result.FeeEntry.Metadata))
+
+	// the receiver gets the full amount, the sender pays the fee on top.
+	require.Equal(t, amount, result.Transfer.Amount)
+	require.Equal(t, senderAccount.Balance-amount-fee, result.FromAccount.Balance)
+	require.Equal(t, receiverAccount.Balance+amount, result.ToAccount.Balance)
+
+	updatedFeeAccount, err := testQueries.GetAccount(context.Background(), feeAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, feeAccount.Balance+fee, updatedFeeAccount.Balance)
+
+	// transfers in currencies without a schedule are free.
+	eurAccount1 := createRandomAccountWithCurrency(t, utils.EUR)
+	eurAccount2 := createRandomAccountWithCurrency(t, utils.EUR)
+
+	result, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: eurAccount1.ID,
+		ToAccountID:   eurAccount2.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+	require.Zero(t, result.Fee)
+	require.Empty(t, result.FeeEntry)
+	require.Equal(t, eurAccount1.Balance-amount, result.FromAccount.Balance)
+
+	// so are transfers to system accounts.
+	cashOut, err := testQueries.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		SystemKind: SystemCashOut,
+		Currency:   utils.USD,
+	})
+	require.NoError(t, err)
+
+	result, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: senderAccount.ID,
+		ToAccountID:   cashOut.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+	require.Zero(t, result.Fee)
+	require.Empty(t, result.FeeEntry)
+	require.Equal(t, senderAccount.Balance-amount-fee-100, result.FromAccount.Balance)
+}
+
+func TestTransferTxLimits(t *testing.T) {
+	config := testConfig
+	config.TransferLimitPerTransaction = 100
+	config.TransferLimitDaily = 150
+	config.TransferLimitMonthly = 0
+
+	store := NewStore(testDB, config)
+
+	senderAccount := createRandomAccount(t)
+	receiverAccount := createRandomAccountWithCurrency(t, senderAccount.Currency)
+
+	transfer := func(amount int64) error {
+		_, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: senderAccount.ID,
+			ToAccountID:   receiverAccount.ID,
+			Amount:        amount,
+		})
+		return err
+	}
+
+	// above the per transaction limit.
+	err := transfer(101)
+	var limitErr *TransferLimitError
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, LimitPerTransaction, limitErr.Period)
+	require.Equal(t, int64(100), limitErr.Remaining)
+
+	require.NoError(t, transfer(100))
+
+	// only 50 left for today.
+	err = transfer(60)
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, LimitDaily, limitErr.Period)
+	require.Equal(t, int64(150), limitErr.Limit)
+	require.Equal(t, int64(50), limitErr.Remaining)
+
+	require.NoError(t, transfer(50))
+
+	// the account override takes precedence over the defaults.
+	_, err = store.UpsertAccountLimit(context.Background(), UpsertAccountLimitParams{
+		AccountID: senderAccount.ID,
+		Daily:     sql.NullInt64{Int64: 500, Valid: true},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, transfer(100))
+
+	updatedSenderAccount, err := testQueries.GetAccount(context.Background(), senderAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, senderAccount.Balance-250, updatedSenderAccount.Balance)
+}
+
+func TestTransferTxConcurrentLimit(t *testing.T) {
+	config := testConfig
+	config.TransferLimitPerTransaction = 0
+	config.TransferLimitDaily = 30
+	config.TransferLimitMonthly = 0
+
+	store := NewStore(testDB, config)
+
+	senderAccount := createRandomAccount(t)
+	receiverAccount := createRandomAccountWithCurrency(t, senderAccount.Currency)
+
+	n := 5
+	amount := int64(10)
+
+	errs := make(chan error)
+
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: senderAccount.ID,
+				ToAccountID:   receiverAccount.ID,
+				Amount:        amount,
+			})
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		var limitErr *TransferLimitError
+		require.ErrorAs(t, err, &limitErr)
+	}
+
+	// the daily limit only allows 3 of them.
+	require.Equal(t, 3, succeeded)
+}
+
+func TestTransferTxFrozenAccount(t *testing.T) {
+	store := NewStore(testDB, testConfig)
+
+	senderAccount := createRandomAccount(t)
+	receiverAccount := createRandomAccountWithCurrency(t, senderAccount.Currency)
+
+	_, err := testQueries.FreezeAccount(context.Background(), FreezeAccountParams{
+		ID:     receiverAccount.ID,
+		Reason: "closed by the owner",
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: senderAccount.ID,
+		ToAccountID:   receiverAccount.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	_, err = testQueries.UnfreezeAccount(context.Background(), receiverAccount.ID)
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: senderAccount.ID,
+		ToAccountID:   receiverAccount.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+}
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	config := testConfig
+	config.FeeSchedule = utils.FeeSchedule{utils.USD: {Flat: 5}}
+
+	store := NewStore(testDB, config)
+
+	senderAccount := createRandomAccountWithCurrency(t, utils.USD)
+	receiverAccount := createRandomAccountWithCurrency(t, utils.USD)
+
+	// the balance covers the amount but not the fee.
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: senderAccount.ID,
+		ToAccountID:   receiverAccount.ID,
+		Amount:        senderAccount.Balance,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	updatedSenderAccount, err := testQueries.GetAccount(context.Background(), senderAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, senderAccount.Balance, updatedSenderAccount.Balance)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: senderAccount.ID,
+		ToAccountID:   receiverAccount.ID,
+		Amount:        senderAccount.Balance - 5,
+	})
+	require.NoError(t, err)
+	require.Zero(t, result.FromAccount.Balance)
+}
+
+func TestExecTxRetry(t *testing.T) {
+	config := testConfig
+	config.DBTxMaxRetries = 50
+	store := NewStore(testDB, config).(*SQLStore)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
+	// run n concurrent serializable moves in opposing directions, each reading
+	// both balances before updating them, so that they conflict.
+	n := 10
+	amount := int64(10)
+	var attempts atomic.Int64
+
+	errs := make(chan error)
+
+	for i := 0; i < n; i++ {
+		fromAccountID := account1.ID
+		toAccountID := account2.ID
+
+		if i%2 == 1 {
+			fromAccountID = account2.ID
+			toAccountID = account1.ID
+		}
+		go func() {
+			errs <- store.execTx(context.Background(), pgx.TxOptions{IsoLevel: pgx.Serializable}, func(q Querier) error {
+				attempts.Add(1)
+
+				fromAccount, err := q.GetAccount(context.Background(), fromAccountID)
+				if err != nil {
+					return err
+				}
+				_, err = q.GetAccount(context.Background(), toAccountID)
+				if err != nil {
+					return err
+				}
+
+				_, err = q.UpdateAccount(context.Background(), UpdateAccountParams{
+					ID:      fromAccountID,
+					Balance: fromAccount.Balance - amount,
+				})
+				if err != nil {
+					return err
+				}
+				_, err = q.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+					ID:     toAccountID,
+					Amount: amount,
+				})
+				return err
+			})
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		err := <-errs
+		require.NoError(t, err)
+	}
+	require.Greater(t, attempts.Load(), int64(n))
+
+	// check that every move was applied exactly once.
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+
+	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+}
+
+func TestExecTxNoRetry(t *testing.T) {
+	config := testConfig
+	config.DBTxMaxRetries = 3
+	store := NewStore(testDB, config).(*SQLStore)
+
+	// errors that don't come from concurrent transactions are returned at once.
+	attempts := 0
+	err := store.execTx(context.Background(), pgx.TxOptions{}, func(q Querier) error {
+		attempts++
+		_, err := q.GetAccount(context.Background(), -1)
+		return err
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+	require.Equal(t, 1, attempts)
+
+	attempts = 0
+	err = store.execTx(context.Background(), pgx.TxOptions{}, func(q Querier) error {
+		attempts++
+		return &classifiedError{class: ErrSerializationFailure, err: errors.New("could not serialize access")}
+	})
+	require.ErrorIs(t, err, ErrSerializationFailure)
+	require.Equal(t, config.DBTxMaxRetries+1, attempts)
+}
@@ -7,26 +7,41 @@ package db
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 )
 
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
 	from_account_id,
 	to_account_id,
-	amount
+	amount,
+	description,
+	reference,
+	metadata
 ) VALUES (
-	$1, $2, $3
-) RETURNING id, from_account_id, to_account_id, amount, created_at
+	$1, $2, $3, $4, $5, $6
+) RETURNING id, from_account_id, to_account_id, amount, created_at, description, reference, metadata
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        int64           `json:"amount"`
+	Description   string          `json:"description"`
+	Reference     string          `json:"reference"`
+	Metadata      json.RawMessage `json:"metadata"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Description,
+		arg.Reference,
+		arg.Metadata,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -34,6 +49,9 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
 	)
 	return i, err
 }
@@ -44,17 +62,36 @@ WHERE id = $1
 `
 
 func (q *Queries) DeleteTransfer(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteTransfer, id)
+	_, err := q.db.Exec(ctx, deleteTransfer, id)
 	return err
 }
 
+const getOutgoingTransfersTotal = `-- name: GetOutgoingTransfersTotal :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total FROM transfers
+WHERE from_account_id = $1
+AND created_at >= $2
+`
+
+type GetOutgoingTransfersTotalParams struct {
+	AccountID int64     `json:"account_id"`
+	Since     time.Time `json:"since"`
+}
+
+func (q *Queries) GetOutgoingTransfersTotal(ctx context.Context, arg GetOutgoingTransfersTotalParams) (int64, error) {
+	row := q.db.QueryRow(ctx, getOutgoingTransfersTotal, arg.AccountID, arg.Since)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, description, reference, metadata FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
+// the ids are unique across the partitions, see the comment of transfers.id.
 func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, getTransfer, id)
+	row := q.db.QueryRow(ctx, getTransfer, id)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -62,24 +99,138 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
 	)
 	return i, err
 }
 
+const listOwnerTransfers = `-- name: ListOwnerTransfers :many
+SELECT id, from_account_id, to_account_id, amount, created_at, description, reference, metadata FROM transfers
+WHERE (
+	from_account_id IN (SELECT id FROM accounts WHERE accounts.owner = $1)
+	OR to_account_id IN (SELECT id FROM accounts WHERE accounts.owner = $1)
+)
+AND (created_at, id) > ($2::timestamptz, $3::bigint)
+ORDER BY created_at, id
+LIMIT $4
+`
+
+type ListOwnerTransfersParams struct {
+	Owner          *string   `json:"owner"`
+	AfterCreatedAt time.Time `json:"after_created_at"`
+	AfterID        int64     `json:"after_id"`
+	LimitCount     int32     `json:"limit_count"`
+}
+
+// the transfers from or to the accounts of the owner.
+func (q *Queries) ListOwnerTransfers(ctx context.Context, arg ListOwnerTransfersParams) ([]Transfer, error) {
+	rows, err := q.db.Query(ctx, listOwnerTransfers,
+		arg.Owner,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOwnerTransfersByReference = `-- name: ListOwnerTransfersByReference :many
+SELECT id, from_account_id, to_account_id, amount, created_at, description, reference, metadata FROM transfers
+WHERE reference = $1
+AND (
+	from_account_id IN (SELECT id FROM accounts WHERE accounts.owner = $2)
+	OR to_account_id IN (SELECT id FROM accounts WHERE accounts.owner = $2)
+)
+AND (created_at, id) > ($3::timestamptz, $4::bigint)
+ORDER BY created_at, id
+LIMIT $5
+`
+
+type ListOwnerTransfersByReferenceParams struct {
+	Reference      string    `json:"reference"`
+	Owner          *string   `json:"owner"`
+	AfterCreatedAt time.Time `json:"after_created_at"`
+	AfterID        int64     `json:"after_id"`
+	LimitCount     int32     `json:"limit_count"`
+}
+
+func (q *Queries) ListOwnerTransfersByReference(ctx context.Context, arg ListOwnerTransfersByReferenceParams) ([]Transfer, error) {
+	rows, err := q.db.Query(ctx, listOwnerTransfersByReference,
+		arg.Reference,
+		arg.Owner,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
-ORDER BY id
-LIMIT $1
-OFFSET $2
+SELECT id, from_account_id, to_account_id, amount, created_at, description, reference, metadata FROM transfers
+WHERE (created_at, id) > ($1::timestamptz, $2::bigint)
+ORDER BY created_at, id
+LIMIT $3
 `
 
 type ListTransfersParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	AfterCreatedAt time.Time `json:"after_created_at"`
+	AfterID        int64     `json:"after_id"`
+	LimitCount     int32     `json:"limit_count"`
 }
 
+// the transfers after the (after_created_at, after_id) cursor, the zero
+// values give the first page.
 func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
-	rows, err := q.db.QueryContext(ctx, listTransfers, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listTransfers, arg.AfterCreatedAt, arg.AfterID, arg.LimitCount)
 	if err != nil {
 		return nil, err
 	}
@@ -93,14 +244,63 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
 		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
 	}
-	if err := rows.Close(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
+	return items, nil
+}
+
+const listTransfersByReference = `-- name: ListTransfersByReference :many
+SELECT id, from_account_id, to_account_id, amount, created_at, description, reference, metadata FROM transfers
+WHERE reference = $1
+AND (created_at, id) > ($2::timestamptz, $3::bigint)
+ORDER BY created_at, id
+LIMIT $4
+`
+
+type ListTransfersByReferenceParams struct {
+	Reference      string    `json:"reference"`
+	AfterCreatedAt time.Time `json:"after_created_at"`
+	AfterID        int64     `json:"after_id"`
+	LimitCount     int32     `json:"limit_count"`
+}
+
+func (q *Queries) ListTransfersByReference(ctx context.Context, arg ListTransfersByReferenceParams) ([]Transfer, error) {
+	rows, err := q.db.Query(ctx, listTransfersByReference,
+		arg.Reference,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
@@ -111,7 +311,7 @@ const updateTransfer = `-- name: UpdateTransfer :one
 UPDATE transfers
 SET to_account_id = $2, amount = $3
 WHERE id = $1
-RETURNING id, from_account_id, to_account_id, amount, created_at
+RETURNING id, from_account_id, to_account_id, amount, created_at, description, reference, metadata
 `
 
 type UpdateTransferParams struct {
@@ -121,7 +321,7 @@ type UpdateTransferParams struct {
 }
 
 func (q *Queries) UpdateTransfer(ctx context.Context, arg UpdateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, updateTransfer, arg.ID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, updateTransfer, arg.ID, arg.ToAccountID, arg.Amount)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -129,6 +329,9 @@ func (q *Queries) UpdateTransfer(ctx context.Context, arg UpdateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
 	)
 	return i, err
 }
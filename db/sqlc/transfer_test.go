@@ -2,7 +2,7 @@ package db
 
 import (
 	"context"
-	"database/sql"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -18,6 +18,9 @@ func createRandomTransfer(t *testing.T) Transfer {
 		FromAccountID: senderAccount.ID,
 		ToAccountID:   receiverAccount.ID,
 		Amount:        utils.RandomMoneyAmount(),
+		Description:   utils.RandomString(20),
+		Reference:     utils.RandomString(12),
+		Metadata:      json.RawMessage(`{"channel":"test"}`),
 	}
 
 	transfer, err := testQueries.CreateTransfer(context.Background(), args)
@@ -28,6 +31,9 @@ func createRandomTransfer(t *testing.T) Transfer {
 	require.Equal(t, transfer.FromAccountID, args.FromAccountID)
 	require.Equal(t, transfer.ToAccountID, args.ToAccountID)
 	require.Equal(t, transfer.Amount, args.Amount)
+	require.Equal(t, transfer.Description, args.Description)
+	require.Equal(t, transfer.Reference, args.Reference)
+	require.JSONEq(t, string(args.Metadata), string(transfer.Metadata))
 
 	require.NotZero(t, transfer.ID)
 	require.NotZero(t, transfer.CreatedAt)
@@ -49,7 +55,7 @@ func TestDeleteTransfer(t *testing.T) {
 	deletedTransfer, err := testQueries.GetTransfer(context.Background(), createdTransfer.ID)
 
 	require.Error(t, err)
-	require.EqualError(t, err, sql.ErrNoRows.Error())
+	require.ErrorIs(t, err, ErrRecordNotFound)
 	require.Empty(t, deletedTransfer)
 }
 
@@ -73,8 +79,7 @@ func TestListTransfers(t *testing.T) {
 	}
 
 	args := ListTransfersParams{
-		Limit:  5,
-		Offset: 5,
+		LimitCount: 5,
 	}
 
 	transfers, err := testQueries.ListTransfers(context.Background(), args)
@@ -87,6 +92,47 @@ func TestListTransfers(t *testing.T) {
 	}
 }
 
+func TestListTransfersByReference(t *testing.T) {
+	createdTransfer := createRandomTransfer(t)
+
+	args := ListTransfersByReferenceParams{
+		Reference:  createdTransfer.Reference,
+		LimitCount: 5,
+	}
+
+	transfers, err := testQueries.ListTransfersByReference(context.Background(), args)
+
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	require.Equal(t, createdTransfer.ID, transfers[0].ID)
+}
+
+func TestListOwnerTransfers(t *testing.T) {
+	createdTransfer := createRandomTransfer(t)
+
+	// both the sender and the recipient see the transfer.
+	for _, accountID := range []int64{createdTransfer.FromAccountID, createdTransfer.ToAccountID} {
+		account, err := testQueries.GetAccount(context.Background(), accountID)
+		require.NoError(t, err)
+
+		transfers, err := testQueries.ListOwnerTransfers(context.Background(), ListOwnerTransfersParams{
+			Owner:      account.Owner,
+			LimitCount: 5,
+		})
+		require.NoError(t, err)
+		require.Len(t, transfers, 1)
+		require.Equal(t, createdTransfer.ID, transfers[0].ID)
+	}
+
+	owner := createRandomUser(t).Username
+	transfers, err := testQueries.ListOwnerTransfers(context.Background(), ListOwnerTransfersParams{
+		Owner:      &owner,
+		LimitCount: 5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+}
+
 func TestUpdateTransfer(t *testing.T) {
 	createdTransfer := createRandomTransfer(t)
 
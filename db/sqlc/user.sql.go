@@ -7,17 +7,49 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
+const anonymizeUser = `-- name: AnonymizeUser :one
+UPDATE users
+SET hashed_password = '',
+	full_name = '',
+	email = 'deleted-' || username || '@invalid',
+	email_hash = NULL,
+	deleted_at = now()
+WHERE username = $1
+AND deleted_at IS NULL
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, role, deleted_at, email_hash
+`
+
+func (q *Queries) AnonymizeUser(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, anonymizeUser, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.DeletedAt,
+		&i.EmailHash,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
 	username,
 	hashed_password,
 	full_name,
-  email
+  email,
+  email_hash,
+  role
 ) VALUES (
-	$1, $2, $3, $4
-) RETURNING username, hashed_password, full_name, email, password_changed_at, created_at
+	$1, $2, $3, $4, $5, COALESCE(NULLIF($6::varchar, ''), 'depositor')
+) RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, role, deleted_at, email_hash
 `
 
 type CreateUserParams struct {
@@ -25,14 +57,19 @@ type CreateUserParams struct {
 	HashedPassword string `json:"hashed_password"`
 	FullName       string `json:"full_name"`
 	Email          string `json:"email"`
+	EmailHash      []byte `json:"email_hash"`
+	Role           string `json:"role"`
 }
 
+// an empty role gets the default one, depositor.
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser,
+	row := q.db.QueryRow(ctx, createUser,
 		arg.Username,
 		arg.HashedPassword,
 		arg.FullName,
 		arg.Email,
+		arg.EmailHash,
+		arg.Role,
 	)
 	var i User
 	err := row.Scan(
@@ -42,17 +79,214 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.Role,
+		&i.DeletedAt,
+		&i.EmailHash,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, full_name, email, password_changed_at, created_at FROM users
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, role, deleted_at, email_hash FROM users
 WHERE username = $1 LIMIT 1
 `
 
 func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
-	row := q.db.QueryRowContext(ctx, getUser, username)
+	row := q.db.QueryRow(ctx, getUser, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.DeletedAt,
+		&i.EmailHash,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, role, deleted_at, email_hash FROM users
+WHERE email_hash = $1
+OR (email_hash IS NULL AND email = $2)
+LIMIT 1
+`
+
+type GetUserByEmailParams struct {
+	EmailHash []byte `json:"email_hash"`
+	Email     string `json:"email"`
+}
+
+func (q *Queries) GetUserByEmail(ctx context.Context, arg GetUserByEmailParams) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, arg.EmailHash, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.DeletedAt,
+		&i.EmailHash,
+	)
+	return i, err
+}
+
+const getUserDeletedAt = `-- name: GetUserDeletedAt :one
+SELECT deleted_at FROM users
+WHERE username = $1 LIMIT 1
+`
+
+// checked on every authenticated request, without reading the personal data.
+func (q *Queries) GetUserDeletedAt(ctx context.Context, username string) (sql.NullTime, error) {
+	row := q.db.QueryRow(ctx, getUserDeletedAt, username)
+	var deleted_at sql.NullTime
+	err := row.Scan(&deleted_at)
+	return deleted_at, err
+}
+
+const getUserForShare = `-- name: GetUserForShare :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, role, deleted_at, email_hash FROM users
+WHERE username = $1 LIMIT 1
+FOR SHARE
+`
+
+func (q *Queries) GetUserForShare(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserForShare, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.DeletedAt,
+		&i.EmailHash,
+	)
+	return i, err
+}
+
+const getUserRole = `-- name: GetUserRole :one
+SELECT role, deleted_at FROM users
+WHERE username = $1 LIMIT 1
+`
+
+type GetUserRoleRow struct {
+	Role      string       `json:"role"`
+	DeletedAt sql.NullTime `json:"deleted_at"`
+}
+
+// read when renewing an access token, so that it gets the current role.
+func (q *Queries) GetUserRole(ctx context.Context, username string) (GetUserRoleRow, error) {
+	row := q.db.QueryRow(ctx, getUserRole, username)
+	var i GetUserRoleRow
+	err := row.Scan(&i.Role, &i.DeletedAt)
+	return i, err
+}
+
+const listUsersForUpdate = `-- name: ListUsersForUpdate :many
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, role, deleted_at, email_hash FROM users
+WHERE username > $1
+AND deleted_at IS NULL
+ORDER BY username
+LIMIT $2
+FOR UPDATE
+`
+
+type ListUsersForUpdateParams struct {
+	After      string `json:"after"`
+	LimitCount int32  `json:"limit_count"`
+}
+
+func (q *Queries) ListUsersForUpdate(ctx context.Context, arg ListUsersForUpdateParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsersForUpdate, arg.After, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.Username,
+			&i.HashedPassword,
+			&i.FullName,
+			&i.Email,
+			&i.PasswordChangedAt,
+			&i.CreatedAt,
+			&i.Role,
+			&i.DeletedAt,
+			&i.EmailHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateUserPersonalData = `-- name: UpdateUserPersonalData :one
+UPDATE users
+SET full_name = $2,
+	email = $3,
+	email_hash = $4
+WHERE username = $1
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, role, deleted_at, email_hash
+`
+
+type UpdateUserPersonalDataParams struct {
+	Username  string `json:"username"`
+	FullName  string `json:"full_name"`
+	Email     string `json:"email"`
+	EmailHash []byte `json:"email_hash"`
+}
+
+func (q *Queries) UpdateUserPersonalData(ctx context.Context, arg UpdateUserPersonalDataParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserPersonalData,
+		arg.Username,
+		arg.FullName,
+		arg.Email,
+		arg.EmailHash,
+	)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.DeletedAt,
+		&i.EmailHash,
+	)
+	return i, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE username = $1
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, role, deleted_at, email_hash
+`
+
+type UpdateUserRoleParams struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserRole, arg.Username, arg.Role)
 	var i User
 	err := row.Scan(
 		&i.Username,
@@ -61,6 +295,9 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.Role,
+		&i.DeletedAt,
+		&i.EmailHash,
 	)
 	return i, err
 }
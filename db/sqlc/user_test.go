@@ -28,6 +28,7 @@ func createRandomUser(t *testing.T) User {
 	require.Equal(t, args.HashedPassword, user.HashedPassword)
 	require.Equal(t, args.FullName, user.FullName)
 	require.Equal(t, args.Email, user.Email)
+	require.Equal(t, utils.DepositorRole, user.Role)
 
 	require.True(t, user.PasswordChangedAt.IsZero())
 	require.NotZero(t, user.CreatedAt)
@@ -54,3 +55,20 @@ func TestGetUser(t *testing.T) {
 	require.WithinDuration(t, user.PasswordChangedAt, createdUser.PasswordChangedAt, time.Second)
 	require.WithinDuration(t, user.CreatedAt, createdUser.CreatedAt, time.Second)
 }
+
+func TestUpdateUserRole(t *testing.T) {
+	user := createRandomUser(t)
+
+	updated, err := testQueries.UpdateUserRole(context.Background(), UpdateUserRoleParams{
+		Username: user.Username,
+		Role:     utils.BankerRole,
+	})
+	require.NoError(t, err)
+	require.Equal(t, utils.BankerRole, updated.Role)
+
+	_, err = testQueries.UpdateUserRole(context.Background(), UpdateUserRoleParams{
+		Username: user.Username,
+		Role:     "owner",
+	})
+	require.Error(t, err)
+}
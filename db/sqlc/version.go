@@ -0,0 +1,21 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Returned when an account changed since the version the caller expected.
+var ErrVersionConflict = errors.New("account version conflict")
+
+// Checks that a locked account is at the expected version, 0 expects any.
+func checkVersion(account Account, expected int64) error {
+	if expected != 0 && account.Version != expected {
+		return versionConflict(account, expected)
+	}
+	return nil
+}
+
+func versionConflict(account Account, expected int64) error {
+	return fmt.Errorf("%w: account %d is at version %d, not %d", ErrVersionConflict, account.ID, account.Version, expected)
+}
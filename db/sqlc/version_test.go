@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountVersion(t *testing.T) {
+	account := createRandomAccount(t)
+	require.Equal(t, int64(1), account.Version)
+
+	// every change of the account increments its version.
+	account, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{ID: account.ID, Amount: 10})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), account.Version)
+
+	account, err = testQueries.FreezeAccount(context.Background(), FreezeAccountParams{ID: account.ID, Reason: "test"})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), account.Version)
+
+	account, err = testQueries.UnfreezeAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), account.Version)
+}
+
+func TestAdjustmentExpectedVersion(t *testing.T) {
+	store := NewStore(testDB, testConfig)
+	account := createRandomAccount(t)
+
+	adjustments, err := testQueries.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		SystemKind: SystemAdjustments,
+		Currency:   account.Currency,
+	})
+	require.NoError(t, err)
+
+	adjust := func(version int64, amount int64) (PostJournalTxResult, error) {
+		return store.PostJournalTx(context.Background(), PostJournalTxParams{
+			Kind: JournalAdjustment,
+			Lines: []JournalLine{
+				{AccountID: account.ID, Amount: amount, ExpectedVersion: version},
+				{AccountID: adjustments.ID, Amount: -amount},
+			},
+		})
+	}
+
+	result, err := adjust(account.Version, 10)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+10, result.Accounts[0].Balance)
+	require.Equal(t, account.Version+1, result.Accounts[0].Version)
+
+	// a second writer holding the old version loses.
+	_, err = adjust(account.Version, -account.Balance)
+	require.ErrorIs(t, err, ErrVersionConflict)
+
+	current, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.Accounts[0], current)
+}
+
+func TestPostJournalTxExpectedVersion(t *testing.T) {
+	store := NewStore(testDB, testConfig)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
+	args := PostJournalTxParams{
+		Kind: JournalAdjustment,
+		Lines: []JournalLine{
+			{AccountID: account1.ID, Amount: -10, ExpectedVersion: account1.Version + 1},
+			{AccountID: account2.ID, Amount: 10},
+		},
+	}
+	_, err := store.PostJournalTx(context.Background(), args)
+	require.ErrorIs(t, err, ErrVersionConflict)
+
+	args.Lines[0].ExpectedVersion = account1.Version
+	result, err := store.PostJournalTx(context.Background(), args)
+	require.NoError(t, err)
+	require.Equal(t, account1.Version+1, result.Accounts[0].Version)
+}
@@ -0,0 +1,8 @@
+package db
+
+// Statuses of a webhook delivery.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead"
+)
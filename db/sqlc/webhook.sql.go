@@ -0,0 +1,158 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: webhook.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createWebhook = `-- name: CreateWebhook :one
+INSERT INTO webhooks (
+	owner,
+	url,
+	secret,
+	event_types
+) VALUES (
+	$1, $2, $3, $4
+) RETURNING id, owner, url, secret, event_types, created_at
+`
+
+type CreateWebhookParams struct {
+	Owner      string   `json:"owner"`
+	Url        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+}
+
+func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	row := q.db.QueryRow(ctx, createWebhook,
+		arg.Owner,
+		arg.Url,
+		arg.Secret,
+		arg.EventTypes,
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Url,
+		&i.Secret,
+		&i.EventTypes,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteUserWebhooks = `-- name: DeleteUserWebhooks :exec
+DELETE FROM webhooks
+WHERE owner = $1
+`
+
+func (q *Queries) DeleteUserWebhooks(ctx context.Context, owner string) error {
+	_, err := q.db.Exec(ctx, deleteUserWebhooks, owner)
+	return err
+}
+
+const deleteWebhook = `-- name: DeleteWebhook :exec
+DELETE FROM webhooks
+WHERE id = $1
+`
+
+func (q *Queries) DeleteWebhook(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, deleteWebhook, id)
+	return err
+}
+
+const getWebhook = `-- name: GetWebhook :one
+SELECT id, owner, url, secret, event_types, created_at FROM webhooks
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetWebhook(ctx context.Context, id int64) (Webhook, error) {
+	row := q.db.QueryRow(ctx, getWebhook, id)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Url,
+		&i.Secret,
+		&i.EventTypes,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listWebhooks = `-- name: ListWebhooks :many
+SELECT id, owner, url, secret, event_types, created_at FROM webhooks
+WHERE owner = $1
+ORDER BY id
+`
+
+func (q *Queries) ListWebhooks(ctx context.Context, owner string) ([]Webhook, error) {
+	rows, err := q.db.Query(ctx, listWebhooks, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Webhook{}
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Url,
+			&i.Secret,
+			&i.EventTypes,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhooksForEvent = `-- name: ListWebhooksForEvent :many
+SELECT id, owner, url, secret, event_types, created_at FROM webhooks
+WHERE owner = ANY($1::varchar[])
+AND (cardinality(event_types) = 0 OR $2::varchar = ANY(event_types))
+ORDER BY id
+`
+
+type ListWebhooksForEventParams struct {
+	Owners    []string `json:"owners"`
+	EventType string   `json:"event_type"`
+}
+
+func (q *Queries) ListWebhooksForEvent(ctx context.Context, arg ListWebhooksForEventParams) ([]Webhook, error) {
+	rows, err := q.db.Query(ctx, listWebhooksForEvent, arg.Owners, arg.EventType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Webhook{}
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Url,
+			&i.Secret,
+			&i.EventTypes,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
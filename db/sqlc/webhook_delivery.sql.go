@@ -0,0 +1,230 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: webhook_delivery.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+const claimDueWebhookDeliveries = `-- name: ClaimDueWebhookDeliveries :many
+WITH due AS (
+	SELECT id FROM webhook_deliveries
+	WHERE status = 'pending'
+	AND next_attempt_at <= now()
+	ORDER BY next_attempt_at, id
+	LIMIT $2
+	FOR UPDATE SKIP LOCKED
+)
+UPDATE webhook_deliveries d
+SET next_attempt_at = $1
+FROM due, webhooks w
+WHERE d.id = due.id
+AND w.id = d.webhook_id
+RETURNING d.id, d.webhook_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at, d.last_error, d.response_status, d.delivered_at, d.created_at, w.url, w.secret
+`
+
+type ClaimDueWebhookDeliveriesParams struct {
+	LeasedUntil time.Time `json:"leased_until"`
+	LimitCount  int32     `json:"limit_count"`
+}
+
+type ClaimDueWebhookDeliveriesRow struct {
+	ID             int64           `json:"id"`
+	WebhookID      int64           `json:"webhook_id"`
+	EventID        int64           `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastError      string          `json:"last_error"`
+	ResponseStatus int32           `json:"response_status"`
+	DeliveredAt    sql.NullTime    `json:"delivered_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Url            string          `json:"url"`
+	Secret         string          `json:"secret"`
+}
+
+// leases the due deliveries to one worker: the others skip the rows it locks,
+// and don't see them as due again before the lease ends.
+func (q *Queries) ClaimDueWebhookDeliveries(ctx context.Context, arg ClaimDueWebhookDeliveriesParams) ([]ClaimDueWebhookDeliveriesRow, error) {
+	rows, err := q.db.Query(ctx, claimDueWebhookDeliveries, arg.LeasedUntil, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ClaimDueWebhookDeliveriesRow{}
+	for rows.Next() {
+		var i ClaimDueWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.ResponseStatus,
+			&i.DeliveredAt,
+			&i.CreatedAt,
+			&i.Url,
+			&i.Secret,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createWebhookDelivery = `-- name: CreateWebhookDelivery :execrows
+INSERT INTO webhook_deliveries (
+	webhook_id,
+	event_id,
+	event_type,
+	payload
+) VALUES (
+	$1, $2, $3, $4
+) ON CONFLICT (webhook_id, event_id) DO NOTHING
+`
+
+type CreateWebhookDeliveryParams struct {
+	WebhookID int64           `json:"webhook_id"`
+	EventID   int64           `json:"event_id"`
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createWebhookDelivery,
+		arg.WebhookID,
+		arg.EventID,
+		arg.EventType,
+		arg.Payload,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteFinishedWebhookDeliveries = `-- name: DeleteFinishedWebhookDeliveries :execrows
+DELETE FROM webhook_deliveries
+WHERE status <> 'pending'
+AND created_at < $1
+`
+
+func (q *Queries) DeleteFinishedWebhookDeliveries(ctx context.Context, createdBefore time.Time) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteFinishedWebhookDeliveries, createdBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_error, response_status, delivered_at, created_at FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3
+`
+
+type ListWebhookDeliveriesParams struct {
+	WebhookID int64 `json:"webhook_id"`
+	Limit     int32 `json:"limit"`
+	Offset    int32 `json:"offset"`
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, listWebhookDeliveries, arg.WebhookID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookDelivery{}
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.ResponseStatus,
+			&i.DeliveredAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markWebhookDeliveryDelivered = `-- name: MarkWebhookDeliveryDelivered :exec
+UPDATE webhook_deliveries
+SET status = 'delivered',
+	attempts = attempts + 1,
+	response_status = $1,
+	last_error = '',
+	delivered_at = now()
+WHERE id = $2
+`
+
+type MarkWebhookDeliveryDeliveredParams struct {
+	ResponseStatus int32 `json:"response_status"`
+	ID             int64 `json:"id"`
+}
+
+func (q *Queries) MarkWebhookDeliveryDelivered(ctx context.Context, arg MarkWebhookDeliveryDeliveredParams) error {
+	_, err := q.db.Exec(ctx, markWebhookDeliveryDelivered, arg.ResponseStatus, arg.ID)
+	return err
+}
+
+const recordWebhookDeliveryFailure = `-- name: RecordWebhookDeliveryFailure :exec
+UPDATE webhook_deliveries
+SET status = $1,
+	attempts = attempts + 1,
+	response_status = $2,
+	last_error = $3,
+	next_attempt_at = $4
+WHERE id = $5
+`
+
+type RecordWebhookDeliveryFailureParams struct {
+	Status         string    `json:"status"`
+	ResponseStatus int32     `json:"response_status"`
+	LastError      string    `json:"last_error"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	ID             int64     `json:"id"`
+}
+
+func (q *Queries) RecordWebhookDeliveryFailure(ctx context.Context, arg RecordWebhookDeliveryFailureParams) error {
+	_, err := q.db.Exec(ctx, recordWebhookDeliveryFailure,
+		arg.Status,
+		arg.ResponseStatus,
+		arg.LastError,
+		arg.NextAttemptAt,
+		arg.ID,
+	)
+	return err
+}
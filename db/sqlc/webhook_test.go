@@ -0,0 +1,203 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomWebhook(t *testing.T, owner string, eventTypes []string) Webhook {
+	args := CreateWebhookParams{
+		Owner:      owner,
+		Url:        "https://example.com/" + utils.RandomString(8),
+		Secret:     utils.RandomString(32),
+		EventTypes: eventTypes,
+	}
+
+	webhook, err := testQueries.CreateWebhook(context.Background(), args)
+	require.NoError(t, err)
+	require.NotZero(t, webhook.ID)
+	require.Equal(t, args.Owner, webhook.Owner)
+	require.Equal(t, args.Url, webhook.Url)
+	require.Equal(t, args.Secret, webhook.Secret)
+	require.Equal(t, args.EventTypes, webhook.EventTypes)
+	require.NotZero(t, webhook.CreatedAt)
+
+	return webhook
+}
+
+func createRandomOutboxEvent(t *testing.T) OutboxEvent {
+	event, err := testQueries.CreateOutboxEvent(context.Background(), CreateOutboxEventParams{
+		AggregateType: AggregateTransfer,
+		AggregateID:   utils.RandomString(6),
+		EventType:     EventTransferCompleted,
+		Payload:       json.RawMessage(`{}`),
+	})
+	require.NoError(t, err)
+	return event
+}
+
+func TestListWebhooksForEvent(t *testing.T) {
+	user1 := createRandomUser(t)
+	user2 := createRandomUser(t)
+
+	all := createRandomWebhook(t, user1.Username, []string{})
+	transfers := createRandomWebhook(t, user2.Username, []string{EventTransferCompleted})
+	createRandomWebhook(t, user2.Username, []string{EventAccountCreated})
+
+	webhooks, err := testQueries.ListWebhooksForEvent(context.Background(), ListWebhooksForEventParams{
+		Owners:    []string{user1.Username, user2.Username},
+		EventType: EventTransferCompleted,
+	})
+	require.NoError(t, err)
+	require.Len(t, webhooks, 2)
+	require.Equal(t, all.ID, webhooks[0].ID)
+	require.Equal(t, transfers.ID, webhooks[1].ID)
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	user := createRandomUser(t)
+	webhook := createRandomWebhook(t, user.Username, []string{})
+	event := createRandomOutboxEvent(t)
+
+	args := CreateWebhookDeliveryParams{
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		EventType: event.EventType,
+		Payload:   event.Payload,
+	}
+
+	n, err := testQueries.CreateWebhookDelivery(context.Background(), args)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+
+	// the same event is only queued once.
+	n, err = testQueries.CreateWebhookDelivery(context.Background(), args)
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	deliveries, err := testQueries.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		WebhookID: webhook.ID,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	delivery := deliveries[0]
+	require.Equal(t, WebhookDeliveryPending, delivery.Status)
+	require.Zero(t, delivery.Attempts)
+
+	err = testQueries.RecordWebhookDeliveryFailure(context.Background(), RecordWebhookDeliveryFailureParams{
+		ID:             delivery.ID,
+		Status:         WebhookDeliveryPending,
+		ResponseStatus: 500,
+		LastError:      "unexpected response status 500",
+		NextAttemptAt:  time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	// the delivery is not due anymore.
+	due, err := testQueries.ClaimDueWebhookDeliveries(context.Background(), ClaimDueWebhookDeliveriesParams{
+		LeasedUntil: time.Now().Add(time.Minute),
+		LimitCount:  1000,
+	})
+	require.NoError(t, err)
+	for _, d := range due {
+		require.NotEqual(t, delivery.ID, d.ID)
+	}
+
+	err = testQueries.MarkWebhookDeliveryDelivered(context.Background(), MarkWebhookDeliveryDeliveredParams{
+		ID:             delivery.ID,
+		ResponseStatus: 200,
+	})
+	require.NoError(t, err)
+
+	deliveries, err = testQueries.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		WebhookID: webhook.ID,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, WebhookDeliveryDelivered, deliveries[0].Status)
+	require.Equal(t, int32(2), deliveries[0].Attempts)
+	require.Equal(t, int32(200), deliveries[0].ResponseStatus)
+	require.Empty(t, deliveries[0].LastError)
+	require.True(t, deliveries[0].DeliveredAt.Valid)
+}
+
+func TestClaimDueWebhookDeliveries(t *testing.T) {
+	pool := newIsolatedPool(t)
+	q := New(translatingDBTX{pool})
+	ctx := context.Background()
+
+	user, err := q.CreateUser(ctx, CreateUserParams{
+		Username: utils.RandomOwner(),
+		FullName: utils.RandomOwner(),
+		Email:    utils.RandomEmailAddress(),
+	})
+	require.NoError(t, err)
+	webhook, err := q.CreateWebhook(ctx, CreateWebhookParams{
+		Owner:      user.Username,
+		Url:        "https://example.com/hooks",
+		Secret:     utils.RandomString(32),
+		EventTypes: []string{},
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		event, err := q.CreateOutboxEvent(ctx, CreateOutboxEventParams{
+			AggregateType: AggregateTransfer,
+			AggregateID:   utils.RandomString(6),
+			EventType:     EventTransferCompleted,
+			Payload:       json.RawMessage(`{}`),
+		})
+		require.NoError(t, err)
+		_, err = q.CreateWebhookDelivery(ctx, CreateWebhookDeliveryParams{
+			WebhookID: webhook.ID,
+			EventID:   event.ID,
+			EventType: event.EventType,
+			Payload:   event.Payload,
+		})
+		require.NoError(t, err)
+	}
+
+	leasedUntil := time.Now().Add(time.Minute)
+	claim := func(q *Queries) []ClaimDueWebhookDeliveriesRow {
+		deliveries, err := q.ClaimDueWebhookDeliveries(ctx, ClaimDueWebhookDeliveriesParams{
+			LeasedUntil: leasedUntil,
+			LimitCount:  1,
+		})
+		require.NoError(t, err)
+		return deliveries
+	}
+
+	// a worker claiming while another one holds its rows gets the others.
+	tx, err := pool.Begin(ctx)
+	require.NoError(t, err)
+	first := claim(New(translatingDBTX{tx}))
+	require.Len(t, first, 1)
+	require.Equal(t, webhook.Url, first[0].Url)
+	require.WithinDuration(t, leasedUntil, first[0].NextAttemptAt, time.Millisecond)
+
+	second := claim(q)
+	require.Len(t, second, 1)
+	require.NotEqual(t, first[0].ID, second[0].ID)
+	require.NoError(t, tx.Commit(ctx))
+
+	// both are leased now.
+	require.Empty(t, claim(q))
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	user := createRandomUser(t)
+	webhook := createRandomWebhook(t, user.Username, []string{})
+
+	err := testQueries.DeleteWebhook(context.Background(), webhook.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetWebhook(context.Background(), webhook.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
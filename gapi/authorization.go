@@ -0,0 +1,60 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/token"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	authorizationHeader = "authorization"
+	authorizationBearer = "bearer"
+)
+
+// Verifies the bearer access token of the request's metadata, and that its
+// user isn't deleted, and returns its payload.
+func (server *Server) authorizeUser(ctx context.Context) (*token.Payload, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing metadata")
+	}
+
+	values := md.Get(authorizationHeader)
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing authorization header")
+	}
+
+	fields := strings.Fields(values[0])
+	if len(fields) != 2 {
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization header format")
+	}
+
+	authType := strings.ToLower(fields[0])
+	if authType != authorizationBearer {
+		return nil, status.Errorf(codes.Unauthenticated, "unsupported authorization type %s", authType)
+	}
+
+	payload, err := server.tokenMaker.VerifyToken(fields[1], token.TokenTypeAccessToken)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid access token: %s", err)
+	}
+
+	// the tokens of a user stay valid until they expire, the user is checked
+	// on every request.
+	deletedAt, err := server.store.GetUserDeletedAt(ctx, payload.Username)
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		return nil, internalError(ctx, fmt.Errorf("cannot get user: %w", err))
+	}
+	if err != nil || deletedAt.Valid {
+		return nil, status.Errorf(codes.Unauthenticated, "user %s doesn't exist anymore", payload.Username)
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,53 @@
+package gapi
+
+import (
+	"encoding/json"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func convertUser(user db.User) *pb.User {
+	return &pb.User{
+		Username:          user.Username,
+		FullName:          user.FullName,
+		Email:             user.Email,
+		Role:              user.Role,
+		PasswordChangedAt: timestamppb.New(user.PasswordChangedAt),
+		CreatedAt:         timestamppb.New(user.CreatedAt),
+	}
+}
+
+func convertAccount(account db.Account) *pb.Account {
+	var owner string
+	if account.Owner != nil {
+		owner = *account.Owner
+	}
+
+	return &pb.Account{
+		Id:          account.ID,
+		Owner:       owner,
+		Balance:     account.Balance,
+		Currency:    account.Currency,
+		AccountType: account.AccountType,
+		CreatedAt:   timestamppb.New(account.CreatedAt),
+	}
+}
+
+func convertTransfer(transfer db.Transfer) *pb.Transfer {
+	// metadata written by the API is always a flat object of strings.
+	var metadata map[string]string
+	json.Unmarshal(transfer.Metadata, &metadata)
+
+	return &pb.Transfer{
+		Id:            transfer.ID,
+		FromAccountId: transfer.FromAccountID,
+		ToAccountId:   transfer.ToAccountID,
+		Amount:        transfer.Amount,
+		Description:   transfer.Description,
+		Reference:     transfer.Reference,
+		Metadata:      metadata,
+		CreatedAt:     timestamppb.New(transfer.CreatedAt),
+	}
+}
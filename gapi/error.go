@@ -0,0 +1,18 @@
+package gapi
+
+import (
+	"context"
+	"log"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Returns the status of an unexpected error. The error is logged with the
+// method rather than sent to the client, as it may come from the database.
+func internalError(ctx context.Context, err error) error {
+	method, _ := grpc.Method(ctx)
+	log.Printf("%s: %v", method, err)
+	return status.Error(codes.Internal, "internal server error")
+}
@@ -0,0 +1,45 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	mockdb "github.com/kvgtl/simplebank/db/mock"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/token"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/metadata"
+)
+
+func newTestServer(t *testing.T, store db.Store) *Server {
+	config := utils.Config{
+		TokenSymmetricKey:   utils.RandomString(32),
+		AccessTokenDuration: time.Minute,
+	}
+
+	server, err := NewServer(config, store)
+	require.NoError(t, err)
+
+	return server
+}
+
+// Lets the authorized requests of the tests through the check of the deleted
+// users in authorizeUser.
+func expectActiveUsers(store *mockdb.MockStore) {
+	store.EXPECT().GetUserDeletedAt(gomock.Any(), gomock.Any()).AnyTimes().Return(sql.NullTime{}, nil)
+}
+
+func newContextWithBearerToken(t *testing.T, tokenMaker token.Maker, username string, duration time.Duration) context.Context {
+	accessToken, _, err := tokenMaker.CreateToken(username, utils.DepositorRole, duration, token.TokenTypeAccessToken)
+	require.NoError(t, err)
+
+	md := metadata.MD{
+		authorizationHeader: []string{fmt.Sprintf("%s %s", authorizationBearer, accessToken)},
+	}
+	return metadata.NewIncomingContext(context.Background(), md)
+}
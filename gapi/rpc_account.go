@@ -0,0 +1,118 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/pb"
+	"github.com/kvgtl/simplebank/token"
+	"github.com/kvgtl/simplebank/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+	authPayload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateFields(
+		fieldRule{"currency", req.GetCurrency(), "required,currency"},
+		fieldRule{"account_type", req.GetAccountType(), "omitempty,account_type"},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	accountType := req.GetAccountType()
+	if accountType == "" {
+		accountType = utils.Checking
+	}
+
+	account, err := server.store.CreateAccountTx(ctx, db.CreateAccountParams{
+		Owner:       &authPayload.Username,
+		Currency:    req.GetCurrency(),
+		Balance:     0,
+		AccountType: accountType,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrForeignKeyViolation) || errors.Is(err, db.ErrUniqueViolation) {
+			return nil, status.Errorf(codes.PermissionDenied, "cannot create account: %s", err)
+		}
+		return nil, internalError(ctx, fmt.Errorf("cannot create account: %w", err))
+	}
+
+	return &pb.CreateAccountResponse{Account: convertAccount(account)}, nil
+}
+
+func (server *Server) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
+	authPayload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateFields(fieldRule{"id", req.GetId(), "min=1"})
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := server.getOwnAccount(ctx, authPayload, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetAccountResponse{Account: convertAccount(account)}, nil
+}
+
+func (server *Server) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	authPayload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateFields(
+		fieldRule{"page", req.GetPage(), "min=1"},
+		fieldRule{"page_size", req.GetPageSize(), "min=1,max=10"},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := server.store.ListAccountsByOwner(ctx, db.ListAccountsByOwnerParams{
+		Owner:  &authPayload.Username,
+		Limit:  req.GetPageSize(),
+		Offset: (req.GetPage() - 1) * req.GetPageSize(),
+	})
+	if err != nil {
+		return nil, internalError(ctx, fmt.Errorf("cannot list accounts: %w", err))
+	}
+
+	response := &pb.ListAccountsResponse{}
+	for _, account := range accounts {
+		response.Accounts = append(response.Accounts, convertAccount(account))
+	}
+	return response, nil
+}
+
+// Returns an account if it belongs to the authenticated user and isn't
+// deleted.
+func (server *Server) getOwnAccount(ctx context.Context, authPayload *token.Payload, accountID int64) (db.Account, error) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return account, status.Errorf(codes.NotFound, "account [%d] not found", accountID)
+		}
+		return account, internalError(ctx, fmt.Errorf("cannot get account: %w", err))
+	}
+	if account.DeletedAt != nil {
+		return db.Account{}, status.Errorf(codes.NotFound, "account [%d] not found", accountID)
+	}
+
+	if account.Owner == nil || *account.Owner != authPayload.Username {
+		return account, status.Errorf(codes.PermissionDenied, "account [%d] doesn't belong to the authenticated user", accountID)
+	}
+	return account, nil
+}
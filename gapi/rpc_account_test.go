@@ -0,0 +1,242 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/kvgtl/simplebank/db/mock"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/pb"
+	"github.com/kvgtl/simplebank/token"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func randomAccount(owner string) db.Account {
+	return db.Account{
+		ID:          utils.RandomInt(1, 1000),
+		Owner:       &owner,
+		Balance:     utils.RandomMoneyAmount(),
+		Currency:    utils.RandomCurrency(),
+		AccountType: utils.Checking,
+	}
+}
+
+func TestGetAccountRPC(t *testing.T) {
+	owner := utils.RandomOwner()
+	account := randomAccount(owner)
+
+	testCases := []struct {
+		name          string
+		req           *pb.GetAccountRequest
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.GetAccountResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, account.ID, res.GetAccount().GetId())
+				require.Equal(t, owner, res.GetAccount().GetOwner())
+				require.Equal(t, account.Balance, res.GetAccount().GetBalance())
+			},
+		},
+		{
+			name: "InternalError",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(db.Account{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				// the database error is logged, not sent to the client.
+				require.Equal(t, codes.Internal, status.Code(err))
+				require.Equal(t, "internal server error", status.Convert(err).Message())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "ExpiredToken",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, owner, -time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "OtherUsersAccount",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, utils.RandomOwner(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "NotFound",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(db.Account{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "DeletedAccount",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				deleted := account
+				deletedAt := time.Now()
+				deleted.DeletedAt = &deletedAt
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(deleted, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "DeletedUser",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserDeletedAt(gomock.Any(), gomock.Eq(owner)).
+					Times(1).
+					Return(sql.NullTime{Time: time.Now(), Valid: true}, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidID",
+			req:  &pb.GetAccountRequest{Id: 0},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			testCase.buildStubs(store)
+			expectActiveUsers(store)
+
+			server := newTestServer(t, store)
+			ctx := testCase.buildContext(t, server.tokenMaker)
+
+			res, err := server.GetAccount(ctx, testCase.req)
+			testCase.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestCreateAccountRPC(t *testing.T) {
+	owner := utils.RandomOwner()
+	account := randomAccount(owner)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	expectActiveUsers(store)
+	store.EXPECT().
+		CreateAccountTx(gomock.Any(), gomock.Eq(db.CreateAccountParams{
+			Owner:       &owner,
+			Currency:    account.Currency,
+			AccountType: utils.Checking,
+		})).
+		Times(1).
+		Return(account, nil)
+
+	server := newTestServer(t, store)
+	ctx := newContextWithBearerToken(t, server.tokenMaker, owner, time.Minute)
+
+	res, err := server.CreateAccount(ctx, &pb.CreateAccountRequest{Currency: account.Currency})
+	require.NoError(t, err)
+	require.Equal(t, account.ID, res.GetAccount().GetId())
+
+	_, err = server.CreateAccount(ctx, &pb.CreateAccountRequest{Currency: "XYZ"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,86 @@
+package gapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	authPayload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateFields(
+		fieldRule{"from_account_id", req.GetFromAccountId(), "min=1"},
+		fieldRule{"to_account_id", req.GetToAccountId(), "min=1"},
+		fieldRule{"amount", req.GetAmount(), "gt=0"},
+		fieldRule{"currency", req.GetCurrency(), "required,currency"},
+		fieldRule{"description", req.GetDescription(), "max=255"},
+		fieldRule{"reference", req.GetReference(), "max=64,printascii"},
+		fieldRule{"metadata", req.GetMetadata(), "max=20,dive,keys,min=1,max=40,endkeys,max=500"},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	fromAccount, err := server.getOwnAccount(ctx, authPayload, req.GetFromAccountId())
+	if err != nil {
+		return nil, err
+	}
+	if fromAccount.Currency != req.GetCurrency() {
+		return nil, status.Errorf(codes.InvalidArgument, "account [%d] currency mismatch: %s vs %s", fromAccount.ID, fromAccount.Currency, req.GetCurrency())
+	}
+
+	toAccount, err := server.store.GetAccount(ctx, req.GetToAccountId())
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "account [%d] not found", req.GetToAccountId())
+		}
+		return nil, internalError(ctx, fmt.Errorf("cannot get account: %w", err))
+	}
+	if toAccount.Currency != req.GetCurrency() {
+		return nil, status.Errorf(codes.InvalidArgument, "account [%d] currency mismatch: %s vs %s", toAccount.ID, toAccount.Currency, req.GetCurrency())
+	}
+
+	args := db.TransferTxParams{
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID:   req.GetToAccountId(),
+		Amount:        req.GetAmount(),
+		Description:   req.GetDescription(),
+		Reference:     req.GetReference(),
+	}
+
+	if len(req.GetMetadata()) > 0 {
+		args.Metadata, err = json.Marshal(req.GetMetadata())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid metadata: %s", err)
+		}
+	}
+
+	result, err := server.store.TransferTx(ctx, args)
+	if err != nil {
+		var limitErr *db.TransferLimitError
+		if errors.As(err, &limitErr) {
+			return nil, status.Error(codes.ResourceExhausted, limitErr.Error())
+		}
+		if errors.Is(err, db.ErrAccountFrozen) || errors.Is(err, db.ErrAccountDeleted) || errors.Is(err, db.ErrInsufficientFunds) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, internalError(ctx, fmt.Errorf("cannot transfer: %w", err))
+	}
+
+	return &pb.CreateTransferResponse{
+		Transfer:    convertTransfer(result.Transfer),
+		FromAccount: convertAccount(result.FromAccount),
+		ToAccount:   convertAccount(result.ToAccount),
+		Fee:         result.Fee,
+	}, nil
+}
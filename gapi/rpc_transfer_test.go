@@ -0,0 +1,164 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "github.com/kvgtl/simplebank/db/mock"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/pb"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateTransferRPC(t *testing.T) {
+	sender := utils.RandomOwner()
+	account1 := randomAccount(sender)
+	account2 := randomAccount(utils.RandomOwner())
+	account1.Currency = utils.USD
+	account2.Currency = utils.USD
+	amount := int64(10)
+
+	testCases := []struct {
+		name          string
+		username      string
+		req           *pb.CreateTransferRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.CreateTransferResponse, err error)
+	}{
+		{
+			name:     "OK",
+			username: sender,
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        amount,
+				Currency:      account1.Currency,
+				Metadata:      map[string]string{"order": "42"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, args db.TransferTxParams) (db.TransferTxResult, error) {
+						require.Equal(t, account1.ID, args.FromAccountID)
+						require.Equal(t, account2.ID, args.ToAccountID)
+						require.Equal(t, amount, args.Amount)
+						require.JSONEq(t, `{"order":"42"}`, string(args.Metadata))
+						return db.TransferTxResult{
+							Transfer: db.Transfer{
+								ID:            1,
+								FromAccountID: account1.ID,
+								ToAccountID:   account2.ID,
+								Amount:        amount,
+								Metadata:      args.Metadata,
+							},
+							FromAccount: account1,
+							ToAccount:   account2,
+						}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, amount, res.GetTransfer().GetAmount())
+				require.Equal(t, map[string]string{"order": "42"}, res.GetTransfer().GetMetadata())
+			},
+		},
+		{
+			name:     "NotSendersAccount",
+			username: utils.RandomOwner(),
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        amount,
+				Currency:      account1.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name:     "CurrencyMismatch",
+			username: sender,
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        amount,
+				Currency:      utils.EUR,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name:     "LimitExceeded",
+			username: sender,
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        amount,
+				Currency:      account1.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, &db.TransferLimitError{Period: db.LimitDaily, Limit: 5, Remaining: 0})
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.ResourceExhausted, status.Code(err))
+			},
+		},
+		{
+			name:     "InvalidAmount",
+			username: sender,
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        -amount,
+				Currency:      account1.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			testCase.buildStubs(store)
+			expectActiveUsers(store)
+
+			server := newTestServer(t, store)
+			ctx := newContextWithBearerToken(t, server.tokenMaker, testCase.username, time.Minute)
+
+			res, err := server.CreateTransfer(ctx, testCase.req)
+			testCase.checkResponse(t, res, err)
+		})
+	}
+}
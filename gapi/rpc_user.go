@@ -0,0 +1,85 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/pb"
+	"github.com/kvgtl/simplebank/token"
+	"github.com/kvgtl/simplebank/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (server *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	err := validateFields(
+		fieldRule{"username", req.GetUsername(), "required,alphanum"},
+		fieldRule{"password", req.GetPassword(), "required,min=6"},
+		fieldRule{"full_name", req.GetFullName(), "required"},
+		fieldRule{"email", req.GetEmail(), "required,email"},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := utils.HashPassword(req.GetPassword())
+	if err != nil {
+		return nil, internalError(ctx, fmt.Errorf("cannot hash password: %w", err))
+	}
+
+	user, err := server.store.CreateUserTx(ctx, db.CreateUserParams{
+		Username:       req.GetUsername(),
+		HashedPassword: hashedPassword,
+		FullName:       req.GetFullName(),
+		Email:          req.GetEmail(),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrUniqueViolation) {
+			return nil, status.Errorf(codes.AlreadyExists, "user already exists: %s", err)
+		}
+		return nil, internalError(ctx, fmt.Errorf("cannot create user: %w", err))
+	}
+
+	return &pb.CreateUserResponse{User: convertUser(user)}, nil
+}
+
+func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	err := validateFields(
+		fieldRule{"username", req.GetUsername(), "required,alphanum"},
+		fieldRule{"password", req.GetPassword(), "required,min=6"},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := server.store.GetUser(ctx, req.GetUsername())
+	if err == nil && user.DeletedAt.Valid {
+		// deleted users can't log in anymore.
+		err = db.ErrRecordNotFound
+	}
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, internalError(ctx, fmt.Errorf("cannot get user: %w", err))
+	}
+
+	err = utils.CheckPassword(req.GetPassword(), user.HashedPassword)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "incorrect password")
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.AccessTokenDuration, token.TokenTypeAccessToken)
+	if err != nil {
+		return nil, internalError(ctx, fmt.Errorf("cannot create access token: %w", err))
+	}
+
+	return &pb.LoginUserResponse{
+		User:                 convertUser(user),
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: timestamppb.New(accessPayload.ExpiredAt),
+	}, nil
+}
@@ -0,0 +1,115 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+
+	mockdb "github.com/kvgtl/simplebank/db/mock"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/pb"
+	"github.com/kvgtl/simplebank/token"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func randomUser(t *testing.T) (db.User, string) {
+	password := utils.RandomString(6)
+	hashedPassword, err := utils.HashPassword(password)
+	require.NoError(t, err)
+
+	return db.User{
+		Username:       utils.RandomOwner(),
+		HashedPassword: hashedPassword,
+		FullName:       utils.RandomString(10),
+		Email:          utils.RandomEmailAddress(),
+		Role:           utils.DepositorRole,
+	}, password
+}
+
+func TestLoginUserRPC(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		req           *pb.LoginUserRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.LoginUserRequest{Username: user.Username, Password: password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user.Username, res.GetUser().GetUsername())
+
+				payload, err := server.tokenMaker.VerifyToken(res.GetAccessToken(), token.TokenTypeAccessToken)
+				require.NoError(t, err)
+				require.Equal(t, user.Username, payload.Username)
+				require.Equal(t, user.Role, payload.Role)
+			},
+		},
+		{
+			name: "UserNotFound",
+			req:  &pb.LoginUserRequest{Username: user.Username, Password: password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "IncorrectPassword",
+			req:  &pb.LoginUserRequest{Username: user.Username, Password: "incorrect"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidUsername",
+			req:  &pb.LoginUserRequest{Username: "invalid-user#1", Password: password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			testCase.buildStubs(store)
+
+			server := newTestServer(t, store)
+			res, err := server.LoginUser(context.Background(), testCase.req)
+			testCase.checkResponse(t, server, res, err)
+		})
+	}
+}
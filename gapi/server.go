@@ -0,0 +1,33 @@
+package gapi
+
+import (
+	"fmt"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/pb"
+	"github.com/kvgtl/simplebank/token"
+	"github.com/kvgtl/simplebank/utils"
+)
+
+// Serves gRPC requests for the banking system.
+type Server struct {
+	pb.UnimplementedSimpleBankServer
+	config     utils.Config
+	store      db.Store
+	tokenMaker token.Maker
+}
+
+// Creates a new gRPC server.
+func NewServer(config utils.Config, store db.Store) (*Server, error) {
+	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %w", err)
+	}
+
+	server := &Server{
+		config:     config,
+		store:      store,
+		tokenMaker: tokenMaker,
+	}
+	return server, nil
+}
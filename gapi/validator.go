@@ -0,0 +1,39 @@
+package gapi
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/kvgtl/simplebank/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Validates request fields with the same rules as the HTTP API.
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterValidation("currency", func(fl validator.FieldLevel) bool {
+		return utils.IsSupportedCurrency(fl.Field().String())
+	})
+	v.RegisterValidation("account_type", func(fl validator.FieldLevel) bool {
+		return utils.IsSupportedAccountType(fl.Field().String())
+	})
+	return v
+}
+
+// A field of a request and its validation tag.
+type fieldRule struct {
+	name  string
+	value any
+	tag   string
+}
+
+// Returns an InvalidArgument error for the first field breaking its rule.
+func validateFields(rules ...fieldRule) error {
+	for _, rule := range rules {
+		if err := validate.Var(rule.value, rule.tag); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid %s: %s", rule.name, err)
+		}
+	}
+	return nil
+}
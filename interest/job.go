@@ -0,0 +1,168 @@
+package interest
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/utils"
+)
+
+// Number of accounts read at once while accruing interest.
+const pageSize = 100
+
+// Accrues interest daily and posts it monthly to accounts of the types
+// and currencies that have a rate.
+type Job struct {
+	store db.Store
+	rates utils.InterestRates
+}
+
+// Creates a new interest Job.
+func NewJob(store db.Store, rates utils.InterestRates) *Job {
+	return &Job{
+		store: store,
+		rates: rates,
+	}
+}
+
+// Accrues one day of interest on every account with a rate, based on its
+// balance at the end of the day, so that past days can be caught up. It
+// returns the number of new accruals, accruing the same day twice is a no-op.
+func (job *Job) Accrue(ctx context.Context, day time.Time) (int, error) {
+	accrued := 0
+	dayEnd := day.AddDate(0, 0, 1)
+
+	for accountType := range job.rates {
+		afterID := int64(0)
+
+		for {
+			accounts, err := job.store.ListAccountsByType(ctx, db.ListAccountsByTypeParams{
+				AccountType: accountType,
+				AfterID:     afterID,
+				LimitCount:  pageSize,
+			})
+			if err != nil {
+				return accrued, err
+			}
+
+			for _, account := range accounts {
+				rate := job.rates.Rate(account.AccountType, account.Currency)
+				if rate == 0 || !account.CreatedAt.Before(dayEnd) {
+					continue
+				}
+
+				// the entries booked since the end of the day are not part of
+				// its balance.
+				since, err := job.store.SumAccountEntriesSince(ctx, db.SumAccountEntriesSinceParams{
+					AccountID: account.ID,
+					Since:     dayEnd,
+				})
+				if err != nil {
+					return accrued, err
+				}
+				balance := account.Balance - since
+
+				n, err := job.store.CreateInterestAccrual(ctx, db.CreateInterestAccrualParams{
+					AccountID:       account.ID,
+					AccrualDate:     day,
+					Balance:         balance,
+					RateBasisPoints: rate,
+					AmountMicros:    utils.DailyInterestMicros(balance, rate),
+				})
+				if err != nil {
+					return accrued, err
+				}
+				accrued += int(n)
+			}
+
+			if len(accounts) < pageSize {
+				break
+			}
+			afterID = accounts[len(accounts)-1].ID
+		}
+	}
+	return accrued, nil
+}
+
+// Posts the interest accrued before a day on every account.
+// It returns the number of accounts that got interest posted.
+func (job *Job) Post(ctx context.Context, before time.Time) (int, error) {
+	accountIDs, err := job.store.ListAccountsWithUnpostedInterest(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, accountID := range accountIDs {
+		_, err := job.store.PostInterestTx(ctx, db.PostInterestTxParams{
+			AccountID: accountID,
+			Before:    before,
+		})
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(accountIDs), nil
+}
+
+// Runs the job until the context is canceled. Right after each midnight (UTC)
+// it accrues interest for the days that ended since the last accrual and
+// posts the interest of the months that ended. It also runs once on start to
+// catch up, which is safe because both steps are idempotent.
+func (job *Job) Run(ctx context.Context) error {
+	for {
+		today := truncateToDay(time.Now().UTC())
+		job.runDay(ctx, today)
+
+		timer := time.NewTimer(time.Until(today.AddDate(0, 0, 1)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (job *Job) runDay(ctx context.Context, today time.Time) {
+	// the last accrued day is accrued again, in case the previous run stopped
+	// in the middle of it.
+	from := today.AddDate(0, 0, -1)
+	last, err := job.store.GetLastInterestAccrualDate(ctx)
+	switch {
+	case err == nil:
+		if last = truncateToDay(last); last.Before(from) {
+			from = last
+		}
+	case !errors.Is(err, db.ErrRecordNotFound):
+		log.Printf("cannot get the last interest accrual: %v", err)
+		return
+	}
+
+	for day := from; day.Before(today); day = day.AddDate(0, 0, 1) {
+		accrued, err := job.Accrue(ctx, day)
+		if err != nil {
+			log.Printf("cannot accrue interest for %s: %v", day.Format(time.DateOnly), err)
+			return
+		}
+		log.Printf("accrued interest for %s on %d accounts", day.Format(time.DateOnly), accrued)
+	}
+
+	// the interest of every month that ended is posted, nothing is left
+	// after the first run of a month but for frozen accounts.
+	monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	posted, err := job.Post(ctx, monthStart)
+	if err != nil {
+		log.Printf("cannot post interest: %v", err)
+		return
+	}
+	if posted > 0 {
+		log.Printf("posted interest on %d accounts", posted)
+	}
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,189 @@
+package interest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "github.com/kvgtl/simplebank/db/mock"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAccrue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	rates := utils.InterestRates{utils.Savings: {utils.USD: 365}}
+	day := time.Date(2024, time.August, 1, 0, 0, 0, 0, time.UTC)
+
+	usdAccount := db.Account{ID: 1, Balance: 12000, Currency: utils.USD, AccountType: utils.Savings, CreatedAt: day}
+	eurAccount := db.Account{ID: 2, Balance: 10000, Currency: utils.EUR, AccountType: utils.Savings, CreatedAt: day}
+	newAccount := db.Account{ID: 3, Balance: 10000, Currency: utils.USD, AccountType: utils.Savings, CreatedAt: day.AddDate(0, 0, 1)}
+
+	store.EXPECT().
+		ListAccountsByType(gomock.Any(), gomock.Eq(db.ListAccountsByTypeParams{
+			AccountType: utils.Savings,
+			AfterID:     0,
+			LimitCount:  pageSize,
+		})).
+		Times(1).
+		Return([]db.Account{usdAccount, eurAccount, newAccount}, nil)
+
+	// the accrual is on the balance at the end of the day.
+	store.EXPECT().
+		SumAccountEntriesSince(gomock.Any(), gomock.Eq(db.SumAccountEntriesSinceParams{
+			AccountID: usdAccount.ID,
+			Since:     day.AddDate(0, 0, 1),
+		})).
+		Times(1).
+		Return(int64(2000), nil)
+
+	// EUR savings accounts have no rate, and the account created after the day
+	// gets nothing.
+	store.EXPECT().
+		CreateInterestAccrual(gomock.Any(), gomock.Eq(db.CreateInterestAccrualParams{
+			AccountID:       usdAccount.ID,
+			AccrualDate:     day,
+			Balance:         10000,
+			RateBasisPoints: 365,
+			AmountMicros:    utils.MicrosPerMinorUnit,
+		})).
+		Times(1).
+		Return(int64(1), nil)
+
+	job := NewJob(store, rates)
+	accrued, err := job.Accrue(context.Background(), day)
+	require.NoError(t, err)
+	require.Equal(t, 1, accrued)
+}
+
+func TestAccruePages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	rates := utils.InterestRates{utils.Savings: {utils.USD: 100}}
+	day := time.Date(2024, time.August, 1, 0, 0, 0, 0, time.UTC)
+
+	firstPage := make([]db.Account, pageSize)
+	for i := range firstPage {
+		firstPage[i] = db.Account{ID: int64(i + 1), Balance: 100, Currency: utils.USD, AccountType: utils.Savings}
+	}
+
+	gomock.InOrder(
+		store.EXPECT().
+			ListAccountsByType(gomock.Any(), gomock.Eq(db.ListAccountsByTypeParams{AccountType: utils.Savings, AfterID: 0, LimitCount: pageSize})).
+			Return(firstPage, nil),
+		store.EXPECT().
+			ListAccountsByType(gomock.Any(), gomock.Eq(db.ListAccountsByTypeParams{AccountType: utils.Savings, AfterID: pageSize, LimitCount: pageSize})).
+			Return([]db.Account{}, nil),
+	)
+
+	// accruals that already exist for the day are not counted.
+	store.EXPECT().SumAccountEntriesSince(gomock.Any(), gomock.Any()).Times(pageSize).Return(int64(0), nil)
+	store.EXPECT().CreateInterestAccrual(gomock.Any(), gomock.Any()).Times(pageSize).Return(int64(0), nil)
+
+	job := NewJob(store, rates)
+	accrued, err := job.Accrue(context.Background(), day)
+	require.NoError(t, err)
+	require.Zero(t, accrued)
+}
+
+func TestPost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	before := time.Date(2024, time.September, 1, 0, 0, 0, 0, time.UTC)
+
+	store.EXPECT().
+		ListAccountsWithUnpostedInterest(gomock.Any(), gomock.Eq(before)).
+		Times(1).
+		Return([]int64{1, 2}, nil)
+
+	for _, accountID := range []int64{1, 2} {
+		store.EXPECT().
+			PostInterestTx(gomock.Any(), gomock.Eq(db.PostInterestTxParams{AccountID: accountID, Before: before})).
+			Times(1)
+	}
+
+	job := NewJob(store, utils.InterestRates{})
+	posted, err := job.Post(context.Background(), before)
+	require.NoError(t, err)
+	require.Equal(t, 2, posted)
+}
+
+func TestRunDayCatchesUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	rates := utils.InterestRates{utils.Savings: {utils.USD: 365}}
+	today := time.Date(2024, time.September, 2, 0, 0, 0, 0, time.UTC)
+	account := db.Account{ID: 1, Balance: 10000, Currency: utils.USD, AccountType: utils.Savings}
+
+	// the job last ran on August 30, it accrues that day again up to
+	// September 1 and posts August.
+	store.EXPECT().
+		GetLastInterestAccrualDate(gomock.Any()).
+		Times(1).
+		Return(time.Date(2024, time.August, 30, 0, 0, 0, 0, time.UTC), nil)
+	store.EXPECT().
+		ListAccountsByType(gomock.Any(), gomock.Any()).
+		Times(3).
+		Return([]db.Account{account}, nil)
+	store.EXPECT().SumAccountEntriesSince(gomock.Any(), gomock.Any()).Times(3).Return(int64(0), nil)
+	for _, day := range []int{30, 31, 32} {
+		store.EXPECT().
+			CreateInterestAccrual(gomock.Any(), gomock.Eq(db.CreateInterestAccrualParams{
+				AccountID:       account.ID,
+				AccrualDate:     time.Date(2024, time.August, day, 0, 0, 0, 0, time.UTC),
+				Balance:         account.Balance,
+				RateBasisPoints: 365,
+				AmountMicros:    utils.MicrosPerMinorUnit,
+			})).
+			Times(1).
+			Return(int64(1), nil)
+	}
+
+	monthStart := time.Date(2024, time.September, 1, 0, 0, 0, 0, time.UTC)
+	store.EXPECT().
+		ListAccountsWithUnpostedInterest(gomock.Any(), gomock.Eq(monthStart)).
+		Times(1).
+		Return([]int64{account.ID}, nil)
+	store.EXPECT().
+		PostInterestTx(gomock.Any(), gomock.Eq(db.PostInterestTxParams{AccountID: account.ID, Before: monthStart})).
+		Times(1)
+
+	job := NewJob(store, rates)
+	job.runDay(context.Background(), today)
+}
+
+func TestRunDayFirstRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	today := time.Date(2024, time.September, 15, 0, 0, 0, 0, time.UTC)
+
+	// without any accrual, only yesterday is accrued.
+	store.EXPECT().
+		GetLastInterestAccrualDate(gomock.Any()).
+		Times(1).
+		Return(time.Time{}, db.ErrRecordNotFound)
+	store.EXPECT().
+		ListAccountsByType(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.Account{}, nil)
+	store.EXPECT().
+		ListAccountsWithUnpostedInterest(gomock.Any(), gomock.Eq(time.Date(2024, time.September, 1, 0, 0, 0, 0, time.UTC))).
+		Times(1).
+		Return([]int64{}, nil)
+
+	job := NewJob(store, utils.InterestRates{utils.Savings: {utils.USD: 365}})
+	job.runDay(context.Background(), today)
+}
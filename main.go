@@ -1,13 +1,30 @@
 package main
 
 import (
-	"database/sql"
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net"
+	"os"
 
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kvgtl/simplebank/admin"
 	"github.com/kvgtl/simplebank/api"
+	memdb "github.com/kvgtl/simplebank/db/memory"
+	"github.com/kvgtl/simplebank/db/migrations"
 	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/gapi"
+	"github.com/kvgtl/simplebank/interest"
+	"github.com/kvgtl/simplebank/notify"
+	"github.com/kvgtl/simplebank/outbox"
+	"github.com/kvgtl/simplebank/partition"
+	"github.com/kvgtl/simplebank/pb"
+	"github.com/kvgtl/simplebank/retention"
 	"github.com/kvgtl/simplebank/utils"
-	_ "github.com/lib/pq"
+	"github.com/kvgtl/simplebank/webhook"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
 func main() {
@@ -15,16 +32,167 @@ func main() {
 	if err != nil {
 		log.Fatal("cannot load config:", err)
 	}
-	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	broker := notify.NewBroker()
+	if config.DBDriver == "memory" {
+		if len(os.Args) > 1 && (os.Args[1] == "migrate" || os.Args[1] == "partitions") {
+			log.Fatalf("cannot run %s on the memory database", os.Args[1])
+		}
+		store := memdb.NewStore(config, broker.Publish)
+		run(config, store, broker)
+		return
+	}
+
+	connPool, err := db.NewConnPool(context.Background(), config)
 	if err != nil {
 		log.Fatal("cannot connect to database:", err)
 	}
 
-	store := db.NewStore(conn)
-	server := api.NewServer(store)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(connPool, os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "partitions" {
+		runPartitions(connPool, os.Args[2:])
+		return
+	}
+
+	if config.DBMigrateOnStart {
+		err = migrations.Up(context.Background(), connPool, 0)
+		if err != nil {
+			log.Fatal("cannot migrate database:", err)
+		}
+	}
+
+	store := db.NewStore(connPool, config)
+	if config.DBReplicaEnabled {
+		replicaPool, err := db.NewReplicaConnPool(context.Background(), config)
+		if err != nil {
+			log.Fatal("cannot connect to replica database:", err)
+		}
+		store = db.NewStoreWithReplica(connPool, replicaPool, config)
+	}
+
+	// the partitions are created when serving only, not for admin commands.
+	// Those of this month and the next ones are created before serving, so
+	// that no row goes to the default partitions.
+	if config.PartitionJobEnabled && (len(os.Args) < 2 || os.Args[1] == "serve") {
+		job := partition.NewJob(connPool, config.PartitionMonthsAhead, config.PartitionInterval)
+		if err := job.RunOnce(context.Background()); err != nil {
+			log.Println("cannot create partitions:", err)
+		}
+		go job.Run(context.Background())
+	}
+
+	go func() {
+		err := notify.Listen(context.Background(), config.DBSource, broker)
+		log.Println("account entries listener stopped:", err)
+	}()
+	run(config, store, broker)
+}
+
+// Runs the admin command of the arguments, or else the servers and the
+// background jobs.
+func run(config utils.Config, store db.Store, broker *notify.Broker) {
+	// every argument but serve, migrate and partitions is an admin command.
+	if len(os.Args) > 1 && os.Args[1] != "serve" {
+		runAdmin(store, os.Args[1:])
+		return
+	}
+
+	if config.InterestJobEnabled {
+		job := interest.NewJob(store, config.InterestRates)
+		go job.Run(context.Background())
+	}
+
+	if config.OutboxDispatcherEnabled {
+		sinks := []outbox.Sink{outbox.LogSink{}}
+		if config.WebhookDeliveryEnabled {
+			sinks = append(sinks, webhook.NewSink(store))
+		}
+		dispatcher := outbox.NewDispatcher(store, config.OutboxMaxAttempts, config.OutboxPollInterval, sinks...)
+		go dispatcher.Run(context.Background())
+	}
+
+	if config.WebhookDeliveryEnabled {
+		worker := webhook.NewWorker(store, config.WebhookMaxAttempts, config.WebhookTimeout, config.WebhookPollInterval)
+		go worker.Run(context.Background())
+	}
+
+	if config.RetentionJobEnabled {
+		job := retention.NewJob(store, retention.Policy{
+			Sessions:          config.SessionRetention,
+			WebhookDeliveries: config.WebhookDeliveryRetention,
+		}, config.RetentionInterval)
+		go job.Run(context.Background())
+	}
+
+	if config.GRPCServerAddress != "" {
+		go runGrpcServer(config, store)
+	}
+	runGinServer(config, store, broker)
+}
+
+func runAdmin(store db.Store, args []string) {
+	err := admin.Run(context.Background(), store, os.Stdout, args)
+	if err != nil {
+		if !errors.Is(err, admin.ErrUsage) {
+			fmt.Fprintln(os.Stderr, "error:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func runMigrate(connPool *pgxpool.Pool, args []string) {
+	err := migrations.Run(context.Background(), connPool, os.Stdout, args)
+	if err != nil {
+		if !errors.Is(err, migrations.ErrUsage) {
+			fmt.Fprintln(os.Stderr, "error:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func runPartitions(connPool *pgxpool.Pool, args []string) {
+	err := partition.Run(context.Background(), connPool, os.Stdout, args)
+	if err != nil {
+		if !errors.Is(err, partition.ErrUsage) {
+			fmt.Fprintln(os.Stderr, "error:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func runGinServer(config utils.Config, store db.Store, broker *notify.Broker) {
+	server, err := api.NewServer(config, store, broker)
+	if err != nil {
+		log.Fatal("cannot create server:", err)
+	}
 
 	err = server.Start(config.ServerAddress)
 	if err != nil {
 		log.Fatal("cannot start server:", err)
 	}
 }
+
+func runGrpcServer(config utils.Config, store db.Store) {
+	server, err := gapi.NewServer(config, store)
+	if err != nil {
+		log.Fatal("cannot create gRPC server:", err)
+	}
+
+	grpcServer := grpc.NewServer()
+	pb.RegisterSimpleBankServer(grpcServer, server)
+	reflection.Register(grpcServer)
+
+	listener, err := net.Listen("tcp", config.GRPCServerAddress)
+	if err != nil {
+		log.Fatal("cannot create gRPC listener:", err)
+	}
+
+	log.Printf("start gRPC server at %s", listener.Addr())
+	err = grpcServer.Serve(listener)
+	if err != nil {
+		log.Fatal("cannot start gRPC server:", err)
+	}
+}
@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"sync"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+)
+
+// Number of notifications buffered per subscriber. A subscriber that falls
+// further behind is unsubscribed, it gets the buffered notifications and then
+// its channel is closed, so that it resumes from the entries.
+const subscriberBuffer = 64
+
+// Fans out account entry notifications to the subscribers of each account.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[chan db.AccountEntryNotification]struct{}
+}
+
+// Creates a new Broker.
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: map[int64]map[chan db.AccountEntryNotification]struct{}{},
+	}
+}
+
+// Subscribes to the notifications of an account. The returned function
+// unsubscribes and closes the channel, it must be called once done, even if
+// the channel was closed because the subscriber fell behind.
+func (broker *Broker) Subscribe(accountID int64) (<-chan db.AccountEntryNotification, func()) {
+	ch := make(chan db.AccountEntryNotification, subscriberBuffer)
+
+	broker.mu.Lock()
+	if broker.subscribers[accountID] == nil {
+		broker.subscribers[accountID] = map[chan db.AccountEntryNotification]struct{}{}
+	}
+	broker.subscribers[accountID][ch] = struct{}{}
+	broker.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			broker.mu.Lock()
+			defer broker.mu.Unlock()
+
+			// Publish already closed the channel of a slow subscriber.
+			if _, ok := broker.subscribers[accountID][ch]; ok {
+				broker.remove(accountID, ch)
+			}
+		})
+	}
+	return ch, unsubscribe
+}
+
+// Sends a notification to the subscribers of its account, without blocking.
+// The subscribers whose buffer is full are removed and their channel closed,
+// rather than silently missing the notification.
+func (broker *Broker) Publish(notification db.AccountEntryNotification) {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	for ch := range broker.subscribers[notification.AccountID] {
+		select {
+		case ch <- notification:
+		default:
+			broker.remove(notification.AccountID, ch)
+		}
+	}
+}
+
+// Removes a subscriber and closes its channel, the caller holds the mutex.
+func (broker *Broker) remove(accountID int64, ch chan db.AccountEntryNotification) {
+	delete(broker.subscribers[accountID], ch)
+	if len(broker.subscribers[accountID]) == 0 {
+		delete(broker.subscribers, accountID)
+	}
+	close(ch)
+}
@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"testing"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker(t *testing.T) {
+	broker := NewBroker()
+
+	ch1, unsubscribe1 := broker.Subscribe(1)
+	ch2, unsubscribe2 := broker.Subscribe(1)
+	other, unsubscribeOther := broker.Subscribe(2)
+	defer unsubscribeOther()
+
+	notification := db.AccountEntryNotification{EntryID: 10, AccountID: 1, Amount: 5, Balance: 105}
+	broker.Publish(notification)
+
+	require.Equal(t, notification, <-ch1)
+	require.Equal(t, notification, <-ch2)
+	require.Empty(t, other)
+
+	unsubscribe1()
+	unsubscribe1()
+	_, ok := <-ch1
+	require.False(t, ok)
+
+	broker.Publish(notification)
+	require.Equal(t, notification, <-ch2)
+
+	unsubscribe2()
+	require.NotContains(t, broker.subscribers, int64(1))
+}
+
+func TestBrokerSlowSubscriber(t *testing.T) {
+	broker := NewBroker()
+
+	ch, unsubscribe := broker.Subscribe(1)
+	defer unsubscribe()
+	fast, unsubscribeFast := broker.Subscribe(1)
+	defer unsubscribeFast()
+
+	// publishing never blocks, the subscriber that falls behind gets its
+	// buffered notifications and then its channel is closed.
+	for i := 0; i < subscriberBuffer+10; i++ {
+		notification := db.AccountEntryNotification{EntryID: int64(i), AccountID: 1}
+		broker.Publish(notification)
+		require.Equal(t, notification, <-fast)
+	}
+
+	for i := 0; i < subscriberBuffer; i++ {
+		require.Equal(t, int64(i), (<-ch).EntryID)
+	}
+	_, ok := <-ch
+	require.False(t, ok)
+
+	// the other subscriber of the account keeps its notifications.
+	require.Len(t, broker.subscribers[1], 1)
+	broker.Publish(db.AccountEntryNotification{EntryID: 100, AccountID: 1})
+	require.Equal(t, int64(100), (<-fast).EntryID)
+
+	// unsubscribing after the channel was closed is a no-op.
+	unsubscribe()
+	require.Len(t, broker.subscribers[1], 1)
+}
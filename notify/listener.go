@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+)
+
+// Delays between the reconnections of the listener, doubled after every
+// failure up to the maximum.
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+)
+
+// Listens to db.AccountEntriesChannel and publishes the notifications to the
+// broker until the context is canceled. It holds a dedicated connection,
+// reconnected when it is lost. Notifications sent while the connection is
+// lost are missed, subscribers can resume from the entries.
+func Listen(ctx context.Context, dbSource string, broker *Broker) error {
+	delay := minReconnectDelay
+	for {
+		started := time.Now()
+		err := listen(ctx, dbSource, broker)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("account entries listener: %v", err)
+
+		// a connection that lived long enough reconnects right away.
+		if time.Since(started) > maxReconnectDelay {
+			delay = minReconnectDelay
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, maxReconnectDelay)
+	}
+}
+
+// Listens on a new connection until it fails or the context is canceled.
+func listen(ctx context.Context, dbSource string, broker *Broker) error {
+	conn, err := pgx.Connect(ctx, dbSource)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{db.AccountEntriesChannel}.Sanitize())
+	if err != nil {
+		return err
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var notification db.AccountEntryNotification
+		if err := json.Unmarshal([]byte(n.Payload), &notification); err != nil {
+			log.Printf("cannot decode account entry notification: %v", err)
+			continue
+		}
+		broker.Publish(notification)
+	}
+}
@@ -0,0 +1,131 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+)
+
+// Number of events read from the outbox at once.
+const batchSize = 100
+
+// Delay before the first retry of a failed event, doubled on every following
+// failure up to maxBackoff.
+const (
+	baseBackoff = 10 * time.Second
+	maxBackoff  = time.Hour
+)
+
+// Publishes the events of the outbox to sinks.
+// An event is marked as published only once every sink accepted it, so a
+// failing sink or a crash means the event is published again later
+// (at-least-once delivery). Events are published in the order they were
+// written, but a failing event doesn't hold back the ones after it: it is
+// retried with exponential backoff, and marked dead once it failed
+// maxAttempts times.
+type Dispatcher struct {
+	store       db.Store
+	sinks       []Sink
+	maxAttempts int32
+	interval    time.Duration
+	now         func() time.Time
+}
+
+// Creates a new Dispatcher polling the outbox every interval.
+func NewDispatcher(store db.Store, maxAttempts int32, interval time.Duration, sinks ...Sink) *Dispatcher {
+	return &Dispatcher{
+		store:       store,
+		sinks:       sinks,
+		maxAttempts: maxAttempts,
+		interval:    interval,
+		now:         time.Now,
+	}
+}
+
+// Publishes one batch of unpublished events.
+// It returns the number of events published.
+func (dispatcher *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	events, err := dispatcher.store.ListUnpublishedOutboxEvents(ctx, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, event := range events {
+		err := dispatcher.publish(ctx, newEvent(event))
+		if err != nil {
+			attempts := event.Attempts + 1
+			dead := attempts >= dispatcher.maxAttempts
+			if dead {
+				log.Printf("event %d is dead after %d attempts: %v", event.ID, attempts, err)
+			} else {
+				log.Printf("cannot publish event %d: %v", event.ID, err)
+			}
+
+			err = dispatcher.store.RecordOutboxEventFailure(ctx, db.RecordOutboxEventFailureParams{
+				ID:            event.ID,
+				LastError:     err.Error(),
+				NextAttemptAt: dispatcher.now().Add(backoff(attempts)),
+				Dead:          dead,
+			})
+			if err != nil {
+				return published, err
+			}
+			continue
+		}
+
+		err = dispatcher.store.MarkOutboxEventPublished(ctx, event.ID)
+		if err != nil {
+			return published, err
+		}
+		published++
+	}
+	return published, nil
+}
+
+func (dispatcher *Dispatcher) publish(ctx context.Context, event Event) error {
+	for _, sink := range dispatcher.sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			return fmt.Errorf("%T: %w", sink, err)
+		}
+	}
+	return nil
+}
+
+// Returns the delay before retrying an event that failed attempts times.
+func backoff(attempts int32) time.Duration {
+	delay := baseBackoff
+	for i := int32(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
+
+// Runs the dispatcher until the context is canceled. Full batches are
+// dispatched right away, otherwise it waits for the interval.
+func (dispatcher *Dispatcher) Run(ctx context.Context) error {
+	for {
+		published, err := dispatcher.DispatchOnce(ctx)
+		if err != nil {
+			log.Printf("cannot dispatch events: %v", err)
+		}
+
+		if err == nil && published == batchSize {
+			continue
+		}
+
+		timer := time.NewTimer(dispatcher.interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
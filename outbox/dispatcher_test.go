@@ -0,0 +1,168 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	mockdb "github.com/kvgtl/simplebank/db/mock"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type failingSink struct{}
+
+func (failingSink) Publish(ctx context.Context, event Event) error {
+	return errors.New("sink is down")
+}
+
+func randomOutboxEvents() []db.OutboxEvent {
+	return []db.OutboxEvent{
+		{
+			ID:            1,
+			AggregateType: db.AggregateUser,
+			AggregateID:   "alice",
+			EventType:     db.EventUserRegistered,
+			Payload:       json.RawMessage(`{"username":"alice"}`),
+			CreatedAt:     time.Now(),
+		},
+		{
+			ID:            2,
+			AggregateType: db.AggregateAccount,
+			AggregateID:   "7",
+			EventType:     db.EventAccountCreated,
+			Payload:       json.RawMessage(`{"account_id":7}`),
+			CreatedAt:     time.Now(),
+		},
+	}
+}
+
+func TestDispatchOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	events := randomOutboxEvents()
+
+	store.EXPECT().
+		ListUnpublishedOutboxEvents(gomock.Any(), gomock.Eq(int32(batchSize))).
+		Times(1).
+		Return(events, nil)
+
+	gomock.InOrder(
+		store.EXPECT().MarkOutboxEventPublished(gomock.Any(), gomock.Eq(events[0].ID)).Times(1).Return(nil),
+		store.EXPECT().MarkOutboxEventPublished(gomock.Any(), gomock.Eq(events[1].ID)).Times(1).Return(nil),
+	)
+
+	sink := NewMemorySink()
+	dispatcher := NewDispatcher(store, 3, time.Second, sink)
+
+	published, err := dispatcher.DispatchOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, published)
+
+	got := sink.Events()
+	require.Len(t, got, 2)
+	for i, event := range got {
+		require.Equal(t, events[i].ID, event.ID)
+		require.Equal(t, events[i].EventType, event.Type)
+		require.Equal(t, events[i].AggregateID, event.AggregateID)
+		require.JSONEq(t, string(events[i].Payload), string(event.Payload))
+	}
+}
+
+func TestDispatchOnceSinkFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	events := randomOutboxEvents()
+	// the second event fails for the last time.
+	events[1].Attempts = 2
+
+	store.EXPECT().
+		ListUnpublishedOutboxEvents(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(events, nil)
+
+	// events stay unpublished so they are retried after their backoff.
+	now := time.Now()
+	store.EXPECT().
+		MarkOutboxEventPublished(gomock.Any(), gomock.Any()).
+		Times(0)
+	gomock.InOrder(
+		store.EXPECT().
+			RecordOutboxEventFailure(gomock.Any(), gomock.Eq(db.RecordOutboxEventFailureParams{
+				ID:            events[0].ID,
+				LastError:     "outbox.failingSink: sink is down",
+				NextAttemptAt: now.Add(baseBackoff),
+				Dead:          false,
+			})).
+			Times(1).
+			Return(nil),
+		store.EXPECT().
+			RecordOutboxEventFailure(gomock.Any(), gomock.Eq(db.RecordOutboxEventFailureParams{
+				ID:            events[1].ID,
+				LastError:     "outbox.failingSink: sink is down",
+				NextAttemptAt: now.Add(4 * baseBackoff),
+				Dead:          true,
+			})).
+			Times(1).
+			Return(nil),
+	)
+
+	// the memory sink still gets the events, delivery is at-least-once.
+	sink := NewMemorySink()
+	dispatcher := NewDispatcher(store, 3, time.Second, sink, failingSink{})
+	dispatcher.now = func() time.Time { return now }
+
+	published, err := dispatcher.DispatchOnce(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, published)
+	require.Len(t, sink.Events(), 2)
+}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, baseBackoff, backoff(1))
+	require.Equal(t, 2*baseBackoff, backoff(2))
+	require.Equal(t, 8*baseBackoff, backoff(4))
+	require.Equal(t, maxBackoff, backoff(20))
+}
+
+func TestDispatchOnceStoreError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().
+		ListUnpublishedOutboxEvents(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil, errors.New("connection refused"))
+
+	sink := NewMemorySink()
+	dispatcher := NewDispatcher(store, 3, time.Second, sink)
+
+	published, err := dispatcher.DispatchOnce(context.Background())
+	require.Error(t, err)
+	require.Zero(t, published)
+	require.Empty(t, sink.Events())
+}
+
+func TestLogSink(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	event := newEvent(randomOutboxEvents()[0])
+	require.NoError(t, LogSink{}.Publish(context.Background(), event))
+
+	require.Contains(t, buf.String(), "UserRegistered user/alice")
+	require.NotContains(t, buf.String(), string(event.Payload))
+}
@@ -0,0 +1,79 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+)
+
+// A domain event read from the outbox.
+type Event struct {
+	ID            int64           `json:"id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	Type          string          `json:"type"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+func newEvent(event db.OutboxEvent) Event {
+	return Event{
+		ID:            event.ID,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		Type:          event.EventType,
+		Payload:       event.Payload,
+		CreatedAt:     event.CreatedAt,
+	}
+}
+
+// Receives the events published by the Dispatcher.
+// Delivery is at-least-once, so sinks may see the same event (same ID) twice
+// and must be idempotent.
+type Sink interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// Keeps published events in memory, mostly useful for tests.
+type MemorySink struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// Creates a new MemorySink.
+func NewMemorySink() *MemorySink {
+	return &MemorySink{}
+}
+
+// Stores the event.
+func (sink *MemorySink) Publish(ctx context.Context, event Event) error {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	sink.events = append(sink.events, event)
+	return nil
+}
+
+// Returns a copy of the events published so far, in order.
+func (sink *MemorySink) Events() []Event {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	events := make([]Event, len(sink.events))
+	copy(events, sink.events)
+	return events
+}
+
+// Writes published events to the standard logger. Only their headers are
+// logged, the payloads may hold data that doesn't belong in the logs.
+type LogSink struct{}
+
+// Logs the event.
+func (LogSink) Publish(ctx context.Context, event Event) error {
+	log.Printf("event %d %s %s/%s", event.ID, event.Type, event.AggregateType, event.AggregateID)
+	return nil
+}
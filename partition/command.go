@@ -0,0 +1,122 @@
+package partition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Returned by Run when the arguments are invalid, after printing the usage.
+var ErrUsage = errors.New("invalid usage")
+
+// Layout of the months of the arguments.
+const argMonthLayout = "2006-01"
+
+// Partitions created ahead by default.
+const defaultAhead = 3
+
+// Runs the partitions subcommand named by args[0]. list prints the attached
+// partitions, create creates those of the coming months, archive exports
+// and detaches those of the months before the given one and archived prints
+// the archived ones.
+func Run(ctx context.Context, pool *pgxpool.Pool, out io.Writer, args []string) error {
+	if len(args) == 0 {
+		printUsage(out)
+		return ErrUsage
+	}
+
+	switch args[0] {
+	case "list":
+		if len(args) > 1 {
+			printUsage(out)
+			return ErrUsage
+		}
+		partitions, err := List(ctx, pool)
+		if err != nil {
+			return err
+		}
+		printPartitions(out, partitions)
+		return nil
+	case "create":
+		if len(args) > 2 {
+			printUsage(out)
+			return ErrUsage
+		}
+		ahead := defaultAhead
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 0 {
+				fmt.Fprintf(out, "invalid argument %q\n\n", args[1])
+				printUsage(out)
+				return ErrUsage
+			}
+			ahead = n
+		}
+		names, err := Create(ctx, pool, time.Now(), ahead)
+		for _, name := range names {
+			fmt.Fprintln(out, name)
+		}
+		return err
+	case "archive":
+		if len(args) < 2 || len(args) > 3 {
+			printUsage(out)
+			return ErrUsage
+		}
+		before, err := time.Parse(argMonthLayout, args[1])
+		if err != nil {
+			fmt.Fprintf(out, "invalid month %q\n\n", args[1])
+			printUsage(out)
+			return ErrUsage
+		}
+		dir := "."
+		if len(args) == 3 {
+			dir = args[2]
+		}
+		archived, err := ArchiveBefore(ctx, pool, before, dir)
+		for _, archive := range archived {
+			fmt.Fprintf(out, "%s  %d rows  %s\n", archive.Name, archive.Rows, archive.File)
+		}
+		return err
+	case "archived":
+		if len(args) > 1 {
+			printUsage(out)
+			return ErrUsage
+		}
+		archived, err := ListArchived(ctx, pool)
+		if err != nil {
+			return err
+		}
+		for _, archive := range archived {
+			fmt.Fprintf(out, "%-10s %s  %s  %d rows  %s\n", archive.ParentTable, archive.Month.Format(argMonthLayout), archive.Name, archive.Rows, archive.File)
+		}
+		return nil
+	case "help", "-h", "--help":
+		printUsage(out)
+		return nil
+	}
+
+	fmt.Fprintf(out, "unknown partitions command %q\n\n", args[0])
+	printUsage(out)
+	return ErrUsage
+}
+
+func printPartitions(out io.Writer, partitions []Partition) {
+	for _, partition := range partitions {
+		fmt.Fprintf(out, "%-10s %s  %s\n", partition.Table, partition.Month.Format(argMonthLayout), partition.Name)
+	}
+}
+
+func printUsage(out io.Writer) {
+	fmt.Fprintln(out, "usage: simplebank partitions <command> [args]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "commands:")
+	fmt.Fprintf(out, "  %-24s %s\n", "list", "lists the monthly partitions of the entries and transfers")
+	fmt.Fprintf(out, "  %-24s %s\n", "create [n]", "creates the partitions of this month and the next n, 3 by default")
+	fmt.Fprintf(out, "  %-24s %s\n", "archive <yyyy-mm> [dir]", "exports the partitions of the months before to dir and detaches them")
+	fmt.Fprintf(out, "  %-24s %s\n", "archived", "lists the archived partitions and their files")
+}
@@ -0,0 +1,54 @@
+package partition
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Creates the partitions of the coming months before their rows arrive.
+type Job struct {
+	pool     *pgxpool.Pool
+	ahead    int
+	interval time.Duration
+}
+
+// Creates a new partition Job keeping the partitions of the next ahead
+// months, running every interval.
+func NewJob(pool *pgxpool.Pool, ahead int, interval time.Duration) *Job {
+	return &Job{
+		pool:     pool,
+		ahead:    ahead,
+		interval: interval,
+	}
+}
+
+// Creates the missing partitions once.
+func (job *Job) RunOnce(ctx context.Context) error {
+	names, err := Create(ctx, job.pool, time.Now(), job.ahead)
+	if err != nil {
+		return err
+	}
+	log.Printf("checked %d partitions", len(names))
+	return nil
+}
+
+// Runs the job every interval until the context is canceled. The first run
+// is an interval away, RunOnce covers the start.
+func (job *Job) Run(ctx context.Context) error {
+	for {
+		timer := time.NewTimer(job.interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		if err := job.RunOnce(ctx); err != nil {
+			log.Printf("cannot create partitions: %v", err)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Package partition maintains the monthly partitions of the entries and
+// transfers tables, and archives the closed ones to compressed files.
+//
+// The partitions are named after their table and month, like
+// entries_y2024m03, and hold the rows created during that month in UTC.
+package partition
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+)
+
+// Tables partitioned by month on their created_at column.
+var Tables = []string{"entries", "transfers"}
+
+// Layout of the month suffix of the partition names.
+const monthLayout = "_y2006m01"
+
+// Monthly partition of a table.
+type Partition struct {
+	Name  string
+	Table string
+	// First day of the month, in UTC.
+	Month time.Time
+}
+
+// Returns the first day of the month of t, in UTC.
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Returns the name of the partition of a table for a month.
+func partitionName(table string, month time.Time) string {
+	return table + startOfMonth(month).Format(monthLayout)
+}
+
+// Parses the name of a monthly partition of a table. It reports false for
+// the other partitions, like the default one.
+func parsePartition(table, name string) (Partition, bool) {
+	suffix, ok := strings.CutPrefix(name, table)
+	if !ok {
+		return Partition{}, false
+	}
+	month, err := time.Parse(monthLayout, suffix)
+	if err != nil {
+		return Partition{}, false
+	}
+	return Partition{Name: name, Table: table, Month: month}, true
+}
+
+// Creates the partitions of the tables for the month of now and the months
+// ahead of it, skipping the existing ones. The rows of those months that went
+// to the default partitions meanwhile are moved to the new ones. It returns
+// the names of the partitions.
+func Create(ctx context.Context, pool *pgxpool.Pool, now time.Time, ahead int) ([]string, error) {
+	var names []string
+	var errs []error
+	for _, table := range Tables {
+		for i := 0; i <= ahead; i++ {
+			month := startOfMonth(now).AddDate(0, i, 0)
+
+			var name string
+			err := pool.QueryRow(ctx, "SELECT create_monthly_partition($1, $2::date)", table, month).Scan(&name)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot create partition %s: %w", partitionName(table, month), err))
+				continue
+			}
+			names = append(names, name)
+		}
+	}
+	return names, errors.Join(errs...)
+}
+
+// Lists the monthly partitions attached to the tables, sorted by table and
+// month.
+func List(ctx context.Context, pool *pgxpool.Pool) ([]Partition, error) {
+	var partitions []Partition
+	for _, table := range Tables {
+		rows, err := pool.Query(ctx, `SELECT c.relname FROM pg_inherits i
+			JOIN pg_class c ON c.oid = i.inhrelid
+			WHERE i.inhparent = $1::regclass`, table)
+		if err != nil {
+			return nil, err
+		}
+		names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range names {
+			if partition, ok := parsePartition(table, name); ok {
+				partitions = append(partitions, partition)
+			}
+		}
+	}
+
+	sort.Slice(partitions, func(i, j int) bool {
+		if partitions[i].Table != partitions[j].Table {
+			return partitions[i].Table < partitions[j].Table
+		}
+		return partitions[i].Month.Before(partitions[j].Month)
+	})
+	return partitions, nil
+}
+
+// Lists the partitions archived so far, sorted by table and month.
+func ListArchived(ctx context.Context, pool *pgxpool.Pool) ([]db.ArchivedPartition, error) {
+	return db.New(pool).ListArchivedPartitions(ctx)
+}
+
+// Returned by ArchiveBefore when the partitions would include the current
+// month, which still receives rows.
+var ErrOpenMonth = errors.New("cannot archive the partitions of the current month or later")
+
+// Archives the partitions of the months before the month of before, see
+// Archive. Only the months that are over can be archived.
+func ArchiveBefore(ctx context.Context, pool *pgxpool.Pool, before time.Time, dir string) ([]db.ArchivedPartition, error) {
+	before = startOfMonth(before)
+	if before.After(startOfMonth(time.Now())) {
+		return nil, ErrOpenMonth
+	}
+
+	partitions, err := List(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+
+	archived := []db.ArchivedPartition{}
+	for _, partition := range partitions {
+		if !partition.Month.Before(before) {
+			continue
+		}
+		archive, err := Archive(ctx, pool, partition, dir)
+		if err != nil {
+			return archived, fmt.Errorf("cannot archive partition %s: %w", partition.Name, err)
+		}
+		archived = append(archived, archive)
+	}
+	return archived, nil
+}
+
+// Exports the rows of a partition to a gzip compressed CSV file of dir, named
+// after the partition, then detaches the partition from its table and records
+// it in archived_partitions. The sums of the archived entries are added to
+// archived_entry_totals, so that the balances still reconcile.
+//
+// The detached table is kept, it can be dropped once the file is stored
+// safely.
+func Archive(ctx context.Context, pool *pgxpool.Pool, partition Partition, dir string) (db.ArchivedPartition, error) {
+	path := filepath.Join(dir, partition.Name+".csv.gz")
+	tmp, err := os.CreateTemp(dir, partition.Name+".*.tmp")
+	if err != nil {
+		return db.ArchivedPartition{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	var archive db.ArchivedPartition
+	err = pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		name := pgx.Identifier{partition.Name}.Sanitize()
+
+		// no row can be written to the partition until it is detached.
+		_, err := tx.Exec(ctx, "LOCK TABLE "+name+" IN SHARE MODE")
+		if err != nil {
+			return err
+		}
+
+		rows, err := export(ctx, tx, name, tmp)
+		if err != nil {
+			return err
+		}
+
+		if partition.Table == "entries" {
+			_, err = tx.Exec(ctx, `INSERT INTO archived_entry_totals (account_id, amount)
+				SELECT account_id, SUM(amount) FROM `+name+` GROUP BY account_id
+				ON CONFLICT (account_id) DO UPDATE SET amount = archived_entry_totals.amount + EXCLUDED.amount`)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Exec(ctx, "ALTER TABLE "+pgx.Identifier{partition.Table}.Sanitize()+" DETACH PARTITION "+name)
+		if err != nil {
+			return err
+		}
+
+		archive, err = db.New(tx).CreateArchivedPartition(ctx, db.CreateArchivedPartitionParams{
+			Name:        partition.Name,
+			ParentTable: partition.Table,
+			Month:       partition.Month,
+			File:        path,
+			Rows:        rows,
+		})
+		if err != nil {
+			return err
+		}
+
+		// the file is complete before the partition is detached for good. If
+		// the commit fails, archiving again replaces it.
+		return os.Rename(tmp.Name(), path)
+	})
+	return archive, err
+}
+
+// Copies the rows of a partition to a gzip compressed CSV file, with a header
+// line. It returns the number of rows.
+func export(ctx context.Context, tx pgx.Tx, name string, file *os.File) (int64, error) {
+	zw := gzip.NewWriter(file)
+	tag, err := tx.Conn().PgConn().CopyTo(ctx, zw, "COPY (SELECT * FROM "+name+" ORDER BY id) TO STDOUT WITH (FORMAT csv, HEADER)")
+	if err != nil {
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+	if err := file.Sync(); err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
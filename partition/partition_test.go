@@ -0,0 +1,245 @@
+package partition
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kvgtl/simplebank/db/dbtest"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	dbtest.Stop()
+	os.Exit(code)
+}
+
+func TestParsePartition(t *testing.T) {
+	testCases := []struct {
+		name      string
+		table     string
+		partition string
+		ok        bool
+		month     time.Time
+	}{
+		{name: "Monthly", table: "entries", partition: "entries_y2024m03", ok: true, month: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Default", table: "entries", partition: "entries_default"},
+		{name: "OtherTable", table: "transfers", partition: "entries_y2024m03"},
+		{name: "InvalidMonth", table: "entries", partition: "entries_y2024m13"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			partition, ok := parsePartition(tc.table, tc.partition)
+			require.Equal(t, tc.ok, ok)
+			if tc.ok {
+				require.Equal(t, Partition{Name: tc.partition, Table: tc.table, Month: tc.month}, partition)
+				require.Equal(t, tc.partition, partitionName(tc.table, tc.month))
+			}
+		})
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	testCases := []struct {
+		name string
+		args []string
+		out  string
+	}{
+		{name: "NoCommand", args: nil, out: "usage: simplebank partitions"},
+		{name: "UnknownCommand", args: []string{"shuffle"}, out: `unknown partitions command "shuffle"`},
+		{name: "InvalidCount", args: []string{"create", "many"}, out: `invalid argument "many"`},
+		{name: "ArchiveWithoutMonth", args: []string{"archive"}, out: "archive <yyyy-mm> [dir]"},
+		{name: "InvalidMonth", args: []string{"archive", "2024-13"}, out: `invalid month "2024-13"`},
+		{name: "ListWithArgument", args: []string{"list", "1"}, out: "usage: simplebank partitions"},
+		{name: "ArchivedWithArgument", args: []string{"archived", "1"}, out: "usage: simplebank partitions"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := Run(context.Background(), nil, &out, tc.args)
+			require.ErrorIs(t, err, ErrUsage)
+			require.Contains(t, out.String(), tc.out)
+		})
+	}
+}
+
+func TestArchive(t *testing.T) {
+	pool, err := pgxpool.New(context.Background(), dbtest.Source(t))
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	ctx := context.Background()
+
+	// a month that is over, with an entry booked during it.
+	month := startOfMonth(time.Now()).AddDate(0, -2, 0)
+	_, err = Create(ctx, pool, month, 0)
+	require.NoError(t, err)
+
+	queries := db.New(pool)
+	user, err := queries.CreateUser(ctx, db.CreateUserParams{
+		Username:       utils.RandomOwner(),
+		HashedPassword: utils.RandomString(32),
+		FullName:       utils.RandomOwner(),
+		Email:          utils.RandomEmailAddress(),
+	})
+	require.NoError(t, err)
+	account, err := queries.CreateAccount(ctx, db.CreateAccountParams{
+		Owner:       &user.Username,
+		Balance:     100,
+		Currency:    utils.USD,
+		AccountType: utils.Checking,
+	})
+	require.NoError(t, err)
+	var entryID int64
+	err = pool.QueryRow(ctx, "INSERT INTO entries (account_id, amount, created_at) VALUES ($1, 100, $2) RETURNING id",
+		account.ID, month.Add(time.Hour)).Scan(&entryID)
+	require.NoError(t, err)
+
+	_, err = ArchiveBefore(ctx, pool, startOfMonth(time.Now()).AddDate(0, 1, 0), t.TempDir())
+	require.ErrorIs(t, err, ErrOpenMonth)
+
+	dir := t.TempDir()
+	archived, err := ArchiveBefore(ctx, pool, startOfMonth(time.Now()), dir)
+	require.NoError(t, err)
+	require.Len(t, archived, 2)
+	require.Equal(t, partitionName("entries", month), archived[0].Name)
+	require.Equal(t, int64(1), archived[0].Rows)
+	require.Equal(t, partitionName("transfers", month), archived[1].Name)
+	require.Zero(t, archived[1].Rows)
+
+	listed, err := ListArchived(ctx, pool)
+	require.NoError(t, err)
+	require.Subset(t, listed, archived)
+
+	// the file holds the header and the entry.
+	file, err := os.Open(filepath.Join(dir, archived[0].Name+".csv.gz"))
+	require.NoError(t, err)
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	records, err := csv.NewReader(zr).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, "id", records[0][0])
+
+	partitions, err := List(ctx, pool)
+	require.NoError(t, err)
+	for _, partition := range partitions {
+		require.False(t, partition.Month.Before(startOfMonth(time.Now())))
+	}
+
+	_, err = queries.GetEntry(ctx, entryID)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	// the archived entry still counts for the balance.
+	mismatches, err := queries.ListAccountBalanceMismatches(ctx)
+	require.NoError(t, err)
+	require.Empty(t, mismatches)
+}
+
+func TestCreateMovesDefaultRows(t *testing.T) {
+	pool, err := pgxpool.New(context.Background(), dbtest.Source(t))
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	ctx := context.Background()
+
+	queries := db.New(pool)
+	user, err := queries.CreateUser(ctx, db.CreateUserParams{
+		Username:       utils.RandomOwner(),
+		HashedPassword: utils.RandomString(32),
+		FullName:       utils.RandomOwner(),
+		Email:          utils.RandomEmailAddress(),
+	})
+	require.NoError(t, err)
+	account, err := queries.CreateAccount(ctx, db.CreateAccountParams{
+		Owner:       &user.Username,
+		Balance:     1,
+		Currency:    utils.USD,
+		AccountType: utils.Checking,
+	})
+	require.NoError(t, err)
+
+	// a month past the created partitions, its entry goes to the default one.
+	month := startOfMonth(time.Now()).AddDate(1, 0, 0)
+	var entryID int64
+	err = pool.QueryRow(ctx, "INSERT INTO entries (account_id, amount, created_at) VALUES ($1, 1, $2) RETURNING id",
+		account.ID, month.Add(time.Hour)).Scan(&entryID)
+	require.NoError(t, err)
+
+	names, err := Create(ctx, pool, month, 0)
+	require.NoError(t, err)
+	require.Contains(t, names, partitionName("entries", month))
+
+	var partition string
+	err = pool.QueryRow(ctx, "SELECT tableoid::regclass::text FROM entries WHERE id = $1", entryID).Scan(&partition)
+	require.NoError(t, err)
+	require.Equal(t, partitionName("entries", month), partition)
+
+	// creating it again is a no-op.
+	_, err = Create(ctx, pool, month, 0)
+	require.NoError(t, err)
+}
+
+func TestIDsAcrossPartitions(t *testing.T) {
+	pool, err := pgxpool.New(context.Background(), dbtest.Source(t))
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	ctx := context.Background()
+
+	// the keys hold created_at, the ids are kept unique by the sequences
+	// giving them to every partition.
+	for _, table := range Tables {
+		var sequence string
+		err := pool.QueryRow(ctx, "SELECT pg_get_serial_sequence($1, 'id')", table).Scan(&sequence)
+		require.NoError(t, err)
+		require.Equal(t, "public."+table+"_id_seq", sequence)
+	}
+
+	queries := db.New(pool)
+	user, err := queries.CreateUser(ctx, db.CreateUserParams{
+		Username:       utils.RandomOwner(),
+		HashedPassword: utils.RandomString(32),
+		FullName:       utils.RandomOwner(),
+		Email:          utils.RandomEmailAddress(),
+	})
+	require.NoError(t, err)
+	account, err := queries.CreateAccount(ctx, db.CreateAccountParams{
+		Owner:       &user.Username,
+		Balance:     2,
+		Currency:    utils.USD,
+		AccountType: utils.Checking,
+	})
+	require.NoError(t, err)
+
+	// entries of this month and of the next one land in different partitions.
+	var ids []int64
+	for _, createdAt := range []time.Time{time.Now(), startOfMonth(time.Now()).AddDate(0, 1, 0)} {
+		var id int64
+		err := pool.QueryRow(ctx, "INSERT INTO entries (account_id, amount, created_at) VALUES ($1, 1, $2) RETURNING id",
+			account.ID, createdAt).Scan(&id)
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	require.Greater(t, ids[1], ids[0])
+
+	for _, id := range ids {
+		entry, err := queries.GetEntry(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, id, entry.ID)
+	}
+}
@@ -0,0 +1,82 @@
+// Package retention purges the data the bank doesn't need to keep.
+package retention
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+)
+
+// How long the data is kept before it is purged, 0 keeps it forever.
+type Policy struct {
+	// Sessions are kept for this long after they expire.
+	Sessions time.Duration
+	// Delivered and dead webhook deliveries are kept for this long after
+	// they were queued. Pending deliveries are never purged.
+	WebhookDeliveries time.Duration
+}
+
+// Number of rows purged by a run of the job.
+type Result struct {
+	Sessions          int64
+	WebhookDeliveries int64
+}
+
+// Purges the data older than its retention period.
+type Job struct {
+	store    db.Store
+	policy   Policy
+	interval time.Duration
+}
+
+// Creates a new retention Job running every interval.
+func NewJob(store db.Store, policy Policy, interval time.Duration) *Job {
+	return &Job{
+		store:    store,
+		policy:   policy,
+		interval: interval,
+	}
+}
+
+// Purges the data that is past its retention period at now.
+func (job *Job) Purge(ctx context.Context, now time.Time) (Result, error) {
+	var result Result
+	var err error
+
+	if job.policy.Sessions > 0 {
+		result.Sessions, err = job.store.DeleteExpiredSessions(ctx, now.Add(-job.policy.Sessions))
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if job.policy.WebhookDeliveries > 0 {
+		result.WebhookDeliveries, err = job.store.DeleteFinishedWebhookDeliveries(ctx, now.Add(-job.policy.WebhookDeliveries))
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// Runs the job until the context is canceled, starting right away.
+func (job *Job) Run(ctx context.Context) error {
+	for {
+		result, err := job.Purge(ctx, time.Now())
+		if err != nil {
+			log.Printf("cannot purge expired data: %v", err)
+		} else {
+			log.Printf("purged %d sessions and %d webhook deliveries", result.Sessions, result.WebhookDeliveries)
+		}
+
+		timer := time.NewTimer(job.interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
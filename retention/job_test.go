@@ -0,0 +1,84 @@
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/kvgtl/simplebank/db/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPurge(t *testing.T) {
+	now := time.Date(2024, time.August, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		policy        Policy
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, result Result, err error)
+	}{
+		{
+			name:   "OK",
+			policy: Policy{Sessions: 24 * time.Hour, WebhookDeliveries: 30 * 24 * time.Hour},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteExpiredSessions(gomock.Any(), gomock.Eq(now.AddDate(0, 0, -1))).
+					Times(1).
+					Return(int64(3), nil)
+				store.EXPECT().
+					DeleteFinishedWebhookDeliveries(gomock.Any(), gomock.Eq(now.AddDate(0, 0, -30))).
+					Times(1).
+					Return(int64(5), nil)
+			},
+			checkResponse: func(t *testing.T, result Result, err error) {
+				require.NoError(t, err)
+				require.Equal(t, Result{Sessions: 3, WebhookDeliveries: 5}, result)
+			},
+		},
+		{
+			name:   "KeepForever",
+			policy: Policy{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DeleteExpiredSessions(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().DeleteFinishedWebhookDeliveries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, result Result, err error) {
+				require.NoError(t, err)
+				require.Zero(t, result)
+			},
+		},
+		{
+			name:   "Error",
+			policy: Policy{Sessions: time.Hour, WebhookDeliveries: time.Hour},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteExpiredSessions(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), sql.ErrConnDone)
+				store.EXPECT().DeleteFinishedWebhookDeliveries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, result Result, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			testCase.buildStubs(store)
+
+			job := NewJob(store, testCase.policy, time.Hour)
+			result, err := job.Purge(context.Background(), now)
+			testCase.checkResponse(t, result, err)
+		})
+	}
+}
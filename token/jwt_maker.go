@@ -23,19 +23,23 @@ func NewJWTMaker(secretKey string) (Maker, error) {
 	return &JWTMaker{secretKey}, nil
 }
 
-// Creates a new token for a specific username and duration.
-func (m *JWTMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewPayload(username, duration)
+// Creates a new token for a specific username, role, duration and type.
+func (m *JWTMaker) CreateToken(username string, role string, duration time.Duration, tokenType TokenType) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration, tokenType)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
 
-	return jwtToken.SignedString([]byte(m.secretKey))
+	token, err := jwtToken.SignedString([]byte(m.secretKey))
+	if err != nil {
+		return "", nil, err
+	}
+	return token, payload, nil
 }
 
-// Checks if the token is valid or not.
-func (m *JWTMaker) VerifyToken(token string) (*Payload, error) {
+// Checks if the token is valid and of the type or not.
+func (m *JWTMaker) VerifyToken(token string, tokenType TokenType) (*Payload, error) {
 	keyFunc := func(token *jwt.Token) (interface{}, error) {
 		_, ok := token.Method.(*jwt.SigningMethodHMAC)
 		if !ok {
@@ -53,7 +57,7 @@ func (m *JWTMaker) VerifyToken(token string) (*Payload, error) {
 	}
 
 	payload, ok := jwtToken.Claims.(*Payload)
-	if !ok {
+	if !ok || payload.Type != tokenType {
 		return nil, ErrInvalidToken
 	}
 	return payload, nil
@@ -14,21 +14,25 @@ func TestJWTMaker(t *testing.T) {
 	require.NoError(t, err)
 
 	username := utils.RandomOwner()
+	role := utils.DepositorRole
 	duration := time.Minute
 
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, err := maker.CreateToken(username, duration)
+	token, payload, err := maker.CreateToken(username, role, duration, TokenTypeAccessToken)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
 
-	payload, err := maker.VerifyToken(token)
+	payload, err = maker.VerifyToken(token, TokenTypeAccessToken)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 
 	require.NotZero(t, payload.ID)
 	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
+	require.Equal(t, TokenTypeAccessToken, payload.Type)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
 }
@@ -38,19 +42,20 @@ func TestExpiredJWTToken(t *testing.T) {
 	require.NoError(t, err)
 
 	username := utils.RandomOwner()
+	role := utils.DepositorRole
 	duration := time.Minute
 
-	token, err := maker.CreateToken(username, -duration)
+	token, _, err := maker.CreateToken(username, role, -duration, TokenTypeAccessToken)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 
-	payload, err := maker.VerifyToken(token)
+	payload, err := maker.VerifyToken(token, TokenTypeAccessToken)
 	require.EqualError(t, err, ErrExpiredToken.Error())
 	require.Nil(t, payload)
 }
 
 func TestInvalidJWTTokenAlgNone(t *testing.T) {
-	payload, err := NewPayload(utils.RandomOwner(), time.Minute)
+	payload, err := NewPayload(utils.RandomOwner(), utils.DepositorRole, time.Minute, TokenTypeAccessToken)
 	require.NoError(t, err)
 
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodNone, payload)
@@ -60,7 +65,20 @@ func TestInvalidJWTTokenAlgNone(t *testing.T) {
 	maker, err := NewJWTMaker(utils.RandomString(32))
 	require.NoError(t, err)
 
-	payload, err = maker.VerifyToken(token)
+	payload, err = maker.VerifyToken(token, TokenTypeAccessToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestJWTTokenWrongType(t *testing.T) {
+	maker, err := NewJWTMaker(utils.RandomString(32))
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(utils.RandomOwner(), utils.DepositorRole, time.Minute, TokenTypeRefreshToken)
+	require.NoError(t, err)
+
+	// a refresh token isn't an access token.
+	payload, err := maker.VerifyToken(token, TokenTypeAccessToken)
 	require.EqualError(t, err, ErrInvalidToken.Error())
 	require.Nil(t, payload)
 }
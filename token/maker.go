@@ -4,9 +4,10 @@ import "time"
 
 // Maker is an interface for managing tokens.
 type Maker interface {
-	// Creates a new token for a specific username and duration.
-	CreateToken(username string, duration time.Duration) (string, error)
+	// Creates a new token for a specific username, role, duration and type,
+	// along with its payload.
+	CreateToken(username string, role string, duration time.Duration, tokenType TokenType) (string, *Payload, error)
 
-	// Checks if the token is valid or not.
-	VerifyToken(token string) (*Payload, error)
+	// Checks if the token is valid and of the type or not.
+	VerifyToken(token string, tokenType TokenType) (*Payload, error)
 }
@@ -27,17 +27,22 @@ func NewPasetoMaker(symmetricKey string) (Maker, error) {
 	return maker, nil
 }
 
-// Creates a new token for a specific username and duration.
-func (m *PasetoMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewPayload(username, duration)
+// Creates a new token for a specific username, role, duration and type.
+func (m *PasetoMaker) CreateToken(username string, role string, duration time.Duration, tokenType TokenType) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration, tokenType)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	return m.paseto.Encrypt(m.symmetricKey, payload, nil)
+
+	token, err := m.paseto.Encrypt(m.symmetricKey, payload, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, payload, nil
 }
 
-// Checks if the token is valid or not.
-func (m *PasetoMaker) VerifyToken(token string) (*Payload, error) {
+// Checks if the token is valid and of the type or not.
+func (m *PasetoMaker) VerifyToken(token string, tokenType TokenType) (*Payload, error) {
 	payload := &Payload{}
 
 	err := m.paseto.Decrypt(token, m.symmetricKey, payload, nil)
@@ -48,6 +53,9 @@ func (m *PasetoMaker) VerifyToken(token string) (*Payload, error) {
 	if err != nil {
 		return nil, err
 	}
+	if payload.Type != tokenType {
+		return nil, ErrInvalidToken
+	}
 
 	return payload, nil
 }
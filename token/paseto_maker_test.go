@@ -13,21 +13,25 @@ func TestPasetoMaker(t *testing.T) {
 	require.NoError(t, err)
 
 	username := utils.RandomOwner()
+	role := utils.DepositorRole
 	duration := time.Minute
 
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, err := maker.CreateToken(username, duration)
+	token, payload, err := maker.CreateToken(username, role, duration, TokenTypeAccessToken)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
 
-	payload, err := maker.VerifyToken(token)
+	payload, err = maker.VerifyToken(token, TokenTypeAccessToken)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 
 	require.NotZero(t, payload.ID)
 	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
+	require.Equal(t, TokenTypeAccessToken, payload.Type)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
 }
@@ -37,13 +41,14 @@ func TestExpiredPasetoToken(t *testing.T) {
 	require.NoError(t, err)
 
 	username := utils.RandomOwner()
+	role := utils.DepositorRole
 	duration := time.Minute
 
-	token, err := maker.CreateToken(username, -duration)
+	token, _, err := maker.CreateToken(username, role, -duration, TokenTypeAccessToken)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 
-	payload, err := maker.VerifyToken(token)
+	payload, err := maker.VerifyToken(token, TokenTypeAccessToken)
 	require.EqualError(t, err, ErrExpiredToken.Error())
 	require.Nil(t, payload)
 }
@@ -53,15 +58,29 @@ func TestInvalidPasetoToken(t *testing.T) {
 	require.NoError(t, err)
 
 	username := utils.RandomOwner()
+	role := utils.DepositorRole
 	duration := time.Minute
 
-	token, err := maker.CreateToken(username, duration)
+	token, _, err := maker.CreateToken(username, role, duration, TokenTypeAccessToken)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 
 	fakeToken := utils.RandomString(11)
 
-	payload, err := maker.VerifyToken(fakeToken)
+	payload, err := maker.VerifyToken(fakeToken, TokenTypeAccessToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestPasetoTokenWrongType(t *testing.T) {
+	maker, err := NewPasetoMaker(utils.RandomString(32))
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(utils.RandomOwner(), utils.DepositorRole, time.Minute, TokenTypeRefreshToken)
+	require.NoError(t, err)
+
+	// a refresh token isn't an access token.
+	payload, err := maker.VerifyToken(token, TokenTypeAccessToken)
 	require.EqualError(t, err, ErrInvalidToken.Error())
 	require.Nil(t, payload)
 }
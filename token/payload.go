@@ -13,16 +13,28 @@ var (
 	ErrExpiredToken = errors.New("token has expired")
 )
 
+// Type of a token, so that a refresh token can't be used as an access token
+// and the other way around.
+type TokenType byte
+
+const (
+	TokenTypeAccessToken  TokenType = 1
+	TokenTypeRefreshToken TokenType = 2
+)
+
 // Contains the payload data of the token.
 type Payload struct {
 	ID        uuid.UUID `json:"id"`
+	Type      TokenType `json:"token_type"`
 	Username  string    `json:"username"`
+	Role      string    `json:"role"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
 }
 
-// Creates a new token payload with a specific username and duration.
-func NewPayload(username string, duration time.Duration) (*Payload, error) {
+// Creates a new token payload with a specific username, role, duration and
+// type.
+func NewPayload(username string, role string, duration time.Duration, tokenType TokenType) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
@@ -30,7 +42,9 @@ func NewPayload(username string, duration time.Duration) (*Payload, error) {
 
 	payload := &Payload{
 		ID:        tokenID,
+		Type:      tokenType,
 		Username:  username,
+		Role:      role,
 		IssuedAt:  time.Now(),
 		ExpiredAt: time.Now().Add(duration),
 	}
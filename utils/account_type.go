@@ -0,0 +1,15 @@
+package utils
+
+// All supported account types.
+const (
+	Checking = "checking"
+	Savings  = "savings"
+)
+
+func IsSupportedAccountType(accountType string) bool {
+	switch accountType {
+	case Checking, Savings:
+		return true
+	}
+	return false
+}
@@ -1,13 +1,94 @@
 package utils
 
-import "github.com/spf13/viper"
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 // Stores all configuration of the app.
 // The values are read by viper from config file or environment variables.
 type Config struct {
+	// postgres, or memory to keep the data in memory without a database.
 	DBDriver      string `mapstructure:"DB_DRIVER"`
 	DBSource      string `mapstructure:"DB_SOURCE"`
 	ServerAddress string `mapstructure:"SERVER_ADDRESS"`
+	// The gRPC server only runs when it has an address.
+	GRPCServerAddress string `mapstructure:"GRPC_SERVER_ADDRESS"`
+
+	// Connection pool of the store. Idle connections above the minimum are
+	// closed after DBMaxConnIdleTime, and statements running longer than
+	// DBStatementTimeout are cancelled by the server; 0 disables the timeout.
+	DBMaxConns         int32         `mapstructure:"DB_MAX_CONNS"`
+	DBMinConns         int32         `mapstructure:"DB_MIN_CONNS"`
+	DBMaxConnIdleTime  time.Duration `mapstructure:"DB_MAX_CONN_IDLE_TIME"`
+	DBStatementTimeout time.Duration `mapstructure:"DB_STATEMENT_TIMEOUT"`
+	// How many times a transaction failing on a serialization failure or a
+	// deadlock is retried.
+	DBTxMaxRetries int `mapstructure:"DB_TX_MAX_RETRIES"`
+	// Whether the list and report queries read from a replica of the
+	// database. They fall back to the primary while it is unavailable.
+	DBReplicaEnabled bool   `mapstructure:"DB_REPLICA_ENABLED"`
+	DBReplicaSource  string `mapstructure:"DB_REPLICA_SOURCE"`
+	// Whether the server applies the pending migrations when it starts.
+	// Off by default, the migrations run with `simplebank migrate up`.
+	DBMigrateOnStart bool `mapstructure:"DB_MIGRATE_ON_START"`
+
+	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	// Refresh tokens renew access tokens for the lifetime of a login session.
+	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+
+	// Default outgoing transfer limits of every account, in minor units.
+	// Accounts can override them in the account_limits table, 0 means unlimited.
+	TransferLimitPerTransaction int64 `mapstructure:"TRANSFER_LIMIT_PER_TRANSACTION"`
+	TransferLimitDaily          int64 `mapstructure:"TRANSFER_LIMIT_DAILY"`
+	TransferLimitMonthly        int64 `mapstructure:"TRANSFER_LIMIT_MONTHLY"`
+
+	// Fees charged on transfers, see ParseFeeSchedule.
+	TransferFees string      `mapstructure:"TRANSFER_FEES"`
+	FeeSchedule  FeeSchedule `mapstructure:"-"`
+
+	// Annual interest rates, see ParseInterestRates.
+	InterestRateList   string        `mapstructure:"INTEREST_RATES"`
+	InterestRates      InterestRates `mapstructure:"-"`
+	InterestJobEnabled bool          `mapstructure:"INTEREST_JOB_ENABLED"`
+
+	// Publishing of the domain events written to the outbox, an event is dead
+	// after OutboxMaxAttempts failures.
+	OutboxDispatcherEnabled bool          `mapstructure:"OUTBOX_DISPATCHER_ENABLED"`
+	OutboxMaxAttempts       int32         `mapstructure:"OUTBOX_MAX_ATTEMPTS"`
+	OutboxPollInterval      time.Duration `mapstructure:"OUTBOX_POLL_INTERVAL"`
+
+	// Delivery of the outbox events to the users' webhooks, a delivery is
+	// dead after WebhookMaxAttempts failures.
+	WebhookDeliveryEnabled bool          `mapstructure:"WEBHOOK_DELIVERY_ENABLED"`
+	WebhookMaxAttempts     int32         `mapstructure:"WEBHOOK_MAX_ATTEMPTS"`
+	WebhookTimeout         time.Duration `mapstructure:"WEBHOOK_TIMEOUT"`
+	WebhookPollInterval    time.Duration `mapstructure:"WEBHOOK_POLL_INTERVAL"`
+
+	// Purging of the data past its retention period, 0 keeps it forever.
+	// Sessions are kept for SessionRetention after they expire, finished
+	// webhook deliveries for WebhookDeliveryRetention after they were queued.
+	RetentionJobEnabled      bool          `mapstructure:"RETENTION_JOB_ENABLED"`
+	RetentionInterval        time.Duration `mapstructure:"RETENTION_INTERVAL"`
+	SessionRetention         time.Duration `mapstructure:"SESSION_RETENTION"`
+	WebhookDeliveryRetention time.Duration `mapstructure:"WEBHOOK_DELIVERY_RETENTION"`
+
+	// Creation of the monthly partitions of the entries and transfers for
+	// the next PartitionMonthsAhead months, on start and then every
+	// PartitionInterval. Without it the rows past the last partition go to
+	// the default ones, they can also be created with
+	// `simplebank partitions create`.
+	PartitionJobEnabled  bool          `mapstructure:"PARTITION_JOB_ENABLED"`
+	PartitionInterval    time.Duration `mapstructure:"PARTITION_INTERVAL"`
+	PartitionMonthsAhead int           `mapstructure:"PARTITION_MONTHS_AHEAD"`
+
+	// Encryption of the personal data of the users, see ParsePIIKeyring. It
+	// is disabled without keys.
+	PIIEncryptionKeys string      `mapstructure:"PII_ENCRYPTION_KEYS"`
+	PIIIndexKey       string      `mapstructure:"PII_INDEX_KEY"`
+	PIIKeyring        *PIIKeyring `mapstructure:"-"`
 }
 
 // Reads configuration from file or environment variables.
@@ -25,5 +106,20 @@ func LoadConfig(path string) (config Config, err error) {
 	}
 
 	err = viper.Unmarshal(&config)
+	if err != nil {
+		return
+	}
+
+	config.FeeSchedule, err = ParseFeeSchedule(config.TransferFees)
+	if err != nil {
+		return
+	}
+
+	config.InterestRates, err = ParseInterestRates(config.InterestRateList)
+	if err != nil {
+		return
+	}
+
+	config.PIIKeyring, err = ParsePIIKeyring(config.PIIEncryptionKeys, config.PIIIndexKey)
 	return
 }
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Fee charged on a transfer: a flat amount plus a percentage of the
+// transferred amount, expressed in basis points (1/100 of a percent).
+type Fee struct {
+	Flat        int64
+	BasisPoints int64
+}
+
+// Computes the fee of a transfer, rounding the percentage half up to
+// the nearest minor unit.
+func (f Fee) Compute(amount int64) int64 {
+	return f.Flat + (amount*f.BasisPoints+5000)/10000
+}
+
+// Fees charged per currency, transfers in other currencies are free.
+type FeeSchedule map[string]Fee
+
+// Parses a fee schedule written as comma separated "CURRENCY:flat:basis_points"
+// rules, e.g. "USD:25:50,EUR:20:50" charges 0.25 USD plus 0.5% on USD transfers.
+func ParseFeeSchedule(s string) (FeeSchedule, error) {
+	schedule := FeeSchedule{}
+
+	for _, rule := range splitList(s) {
+		parts := strings.Split(rule, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid fee rule %q: must be CURRENCY:flat:basis_points", rule)
+		}
+
+		currency := parts[0]
+		if !IsSupportedCurrency(currency) {
+			return nil, fmt.Errorf("invalid fee rule %q: unsupported currency", rule)
+		}
+
+		flat, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || flat < 0 {
+			return nil, fmt.Errorf("invalid fee rule %q: flat fee must be a non negative integer", rule)
+		}
+
+		basisPoints, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil || basisPoints < 0 || basisPoints > 10000 {
+			return nil, fmt.Errorf("invalid fee rule %q: basis points must be between 0 and 10000", rule)
+		}
+
+		schedule[currency] = Fee{Flat: flat, BasisPoints: basisPoints}
+	}
+	return schedule, nil
+}
+
+// Splits a comma separated list, ignoring blank items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
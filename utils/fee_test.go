@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFeeSchedule(t *testing.T) {
+	schedule, err := ParseFeeSchedule("USD:25:50, EUR:0:125")
+	require.NoError(t, err)
+	require.Equal(t, FeeSchedule{
+		USD: {Flat: 25, BasisPoints: 50},
+		EUR: {Flat: 0, BasisPoints: 125},
+	}, schedule)
+
+	schedule, err = ParseFeeSchedule("")
+	require.NoError(t, err)
+	require.Empty(t, schedule)
+
+	for _, invalid := range []string{"USD:25", "XYZ:1:1", "USD:-1:50", "USD:1:10001", "USD:a:b"} {
+		_, err = ParseFeeSchedule(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestFeeCompute(t *testing.T) {
+	fee := Fee{Flat: 25, BasisPoints: 50}
+
+	require.Equal(t, int64(25), fee.Compute(0))
+	require.Equal(t, int64(30), fee.Compute(1000))
+	// 0.5% of 99 is 0.495, rounded half up to 0.
+	require.Equal(t, int64(25), fee.Compute(99))
+	// 0.5% of 100 is 0.5, rounded half up to 1.
+	require.Equal(t, int64(26), fee.Compute(100))
+}
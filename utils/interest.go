@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Number of millionths of a minor unit in a minor unit. Interest is accrued
+// daily in micros so that small daily amounts aren't lost to rounding.
+const MicrosPerMinorUnit = 1_000_000
+
+// Annual interest rates in basis points, per account type and currency.
+type InterestRates map[string]map[string]int64
+
+// Returns the annual rate of an account type and currency, 0 if there is none.
+func (r InterestRates) Rate(accountType, currency string) int64 {
+	return r[accountType][currency]
+}
+
+// Parses interest rates written as comma separated "type:CURRENCY:basis_points"
+// rules, e.g. "savings:USD:150" pays 1.5% a year on USD savings accounts.
+func ParseInterestRates(s string) (InterestRates, error) {
+	rates := InterestRates{}
+
+	for _, rule := range splitList(s) {
+		parts := strings.Split(rule, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid interest rate %q: must be type:CURRENCY:basis_points", rule)
+		}
+
+		accountType, currency := parts[0], parts[1]
+		if !IsSupportedAccountType(accountType) {
+			return nil, fmt.Errorf("invalid interest rate %q: unsupported account type", rule)
+		}
+		if !IsSupportedCurrency(currency) {
+			return nil, fmt.Errorf("invalid interest rate %q: unsupported currency", rule)
+		}
+
+		basisPoints, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil || basisPoints < 0 {
+			return nil, fmt.Errorf("invalid interest rate %q: basis points must be a non negative integer", rule)
+		}
+
+		if rates[accountType] == nil {
+			rates[accountType] = map[string]int64{}
+		}
+		rates[accountType][currency] = basisPoints
+	}
+	return rates, nil
+}
+
+// Computes one day of interest on a balance, in micros, using an actual/365
+// day count. Negative balances don't earn interest.
+func DailyInterestMicros(balance int64, basisPoints int64) int64 {
+	if balance <= 0 || basisPoints <= 0 {
+		return 0
+	}
+
+	// balance * basisPoints / 10000 / 365 minor units.
+	numerator := new(big.Int).Mul(big.NewInt(balance), big.NewInt(basisPoints))
+	numerator.Mul(numerator, big.NewInt(MicrosPerMinorUnit))
+	denominator := big.NewInt(10000 * 365)
+
+	return roundHalfEven(numerator, denominator)
+}
+
+// Rounds an amount in micros to minor units, half to even, so rounding
+// doesn't systematically favour the bank or the customer.
+func MicrosToMinorUnits(micros int64) int64 {
+	return roundHalfEven(big.NewInt(micros), big.NewInt(MicrosPerMinorUnit))
+}
+
+// Divides n by a positive d, rounding half to even.
+func roundHalfEven(n *big.Int, d *big.Int) int64 {
+	quotient, remainder := new(big.Int).QuoRem(n, d, new(big.Int))
+
+	// compare twice the remainder with the divisor to find which way to round.
+	twice := new(big.Int).Abs(remainder)
+	twice.Lsh(twice, 1)
+
+	switch twice.Cmp(d) {
+	case 1:
+		quotient.Add(quotient, big.NewInt(int64(remainder.Sign())))
+	case 0:
+		if quotient.Bit(0) == 1 {
+			quotient.Add(quotient, big.NewInt(int64(remainder.Sign())))
+		}
+	}
+	return quotient.Int64()
+}
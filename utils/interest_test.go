@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInterestRates(t *testing.T) {
+	rates, err := ParseInterestRates("savings:USD:150,savings:EUR:100")
+	require.NoError(t, err)
+	require.Equal(t, int64(150), rates.Rate(Savings, USD))
+	require.Equal(t, int64(100), rates.Rate(Savings, EUR))
+	require.Zero(t, rates.Rate(Savings, CAD))
+	require.Zero(t, rates.Rate(Checking, USD))
+
+	for _, invalid := range []string{"savings:USD", "loan:USD:100", "savings:XYZ:100", "savings:USD:-1"} {
+		_, err = ParseInterestRates(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestDailyInterestMicros(t *testing.T) {
+	// 100.00 at 3.65% earns exactly 0.01 a day.
+	require.Equal(t, int64(MicrosPerMinorUnit), DailyInterestMicros(10000, 365))
+
+	// 1.00 at 1.5% earns 0.0041095890... minor units, rounded to micros.
+	require.Equal(t, int64(4110), DailyInterestMicros(100, 150))
+
+	require.Zero(t, DailyInterestMicros(-10000, 365))
+	require.Zero(t, DailyInterestMicros(10000, 0))
+
+	// large balances don't overflow.
+	require.Equal(t, int64(2_739_726_027_397_260), DailyInterestMicros(1_000_000_000_000, 10000))
+}
+
+func TestMicrosToMinorUnits(t *testing.T) {
+	require.Equal(t, int64(0), MicrosToMinorUnits(499_999))
+	require.Equal(t, int64(0), MicrosToMinorUnits(500_000))
+	require.Equal(t, int64(1), MicrosToMinorUnits(500_001))
+	require.Equal(t, int64(2), MicrosToMinorUnits(1_500_000))
+	require.Equal(t, int64(2), MicrosToMinorUnits(2_500_000))
+	require.Equal(t, int64(-2), MicrosToMinorUnits(-2_500_000))
+	require.Equal(t, int64(-3), MicrosToMinorUnits(-2_600_000))
+}
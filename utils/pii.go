@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Prefix of the values encrypted by a PIIKeyring, the values without it are
+// plaintext written before encryption was enabled.
+const piiPrefix = "enc:v1:"
+
+// Returned by PIIKeyring.Decrypt when a value can't be decrypted, e.g. its key
+// was removed from the keyring or it was moved to another row.
+var ErrPIIDecrypt = errors.New("cannot decrypt personal data")
+
+// Encrypts personal data with envelope encryption: every value is encrypted
+// with its own random data key, which is stored with the value, encrypted by
+// a key encryption key of the keyring. The values are written with the
+// current key, and can be read with any key of the keyring, so that the keys
+// can be rotated.
+//
+// It also computes blind indexes, keyed hashes of the values that can be
+// looked up and constrained unique without being decrypted.
+type PIIKeyring struct {
+	current  string
+	keys     map[string]cipher.AEAD
+	indexKey []byte
+}
+
+// Parses the keys of a PIIKeyring written as comma separated "id:base64_key"
+// pairs, the first key encrypts the new values. The keys and the index key
+// are 32 bytes, base64 encoded. Empty keys return a nil keyring, which leaves
+// the personal data in plaintext.
+func ParsePIIKeyring(keys string, indexKey string) (*PIIKeyring, error) {
+	pairs := splitList(keys)
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	keyring := &PIIKeyring{keys: map[string]cipher.AEAD{}}
+	for i, pair := range pairs {
+		// the errors don't quote the pair, it holds the key.
+		id, encoded, ok := strings.Cut(pair, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid encryption key #%d: must be id:base64_key", i+1)
+		}
+		if _, ok := keyring.keys[id]; ok {
+			return nil, fmt.Errorf("invalid encryption key %q: duplicate id", id)
+		}
+
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %w", id, err)
+		}
+		keyring.keys[id], err = newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		if keyring.current == "" {
+			keyring.current = id
+		}
+	}
+
+	var err error
+	keyring.indexKey, err = decodeKey(indexKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid index key: %w", err)
+	}
+	return keyring, nil
+}
+
+// Decodes a base64 encoded 32 bytes key.
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("must be 32 bytes, base64 encoded")
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Seals plaintext with a random nonce, which is prepended to the result.
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrPIIDecrypt
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+// Encrypts a value with the current key. The context, e.g. the column and the
+// row of the value, must be given again to decrypt it, so that encrypted
+// values can't be swapped between rows. Empty values are kept empty.
+func (k *PIIKeyring) Encrypt(plaintext string, context string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := seal(aead, []byte(plaintext), []byte(context))
+	if err != nil {
+		return "", err
+	}
+	wrappedKey, err := seal(k.keys[k.current], dataKey, []byte(k.current))
+	if err != nil {
+		return "", err
+	}
+
+	return piiPrefix + k.current + ":" +
+		base64.RawStdEncoding.EncodeToString(wrappedKey) + ":" +
+		base64.RawStdEncoding.EncodeToString(value), nil
+}
+
+// Decrypts a value encrypted with any key of the keyring in the same context.
+// Plaintext values are returned as they are.
+func (k *PIIKeyring) Decrypt(value string, context string) (string, error) {
+	rest, ok := strings.CutPrefix(value, piiPrefix)
+	if !ok {
+		return value, nil
+	}
+
+	parts := strings.Split(rest, ":")
+	if len(parts) != 3 {
+		return "", ErrPIIDecrypt
+	}
+	keyID := parts[0]
+	kek, ok := k.keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown key %q", ErrPIIDecrypt, keyID)
+	}
+	wrappedKey, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", ErrPIIDecrypt
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrPIIDecrypt
+	}
+
+	dataKey, err := open(kek, wrappedKey, []byte(keyID))
+	if err != nil {
+		return "", ErrPIIDecrypt
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", ErrPIIDecrypt
+	}
+	plaintext, err := open(aead, sealed, []byte(context))
+	if err != nil {
+		return "", ErrPIIDecrypt
+	}
+	return string(plaintext), nil
+}
+
+// Reports whether a value is encrypted with the current key.
+func (k *PIIKeyring) IsCurrent(value string) bool {
+	return strings.HasPrefix(value, piiPrefix+k.current+":")
+}
+
+// Returns the blind index of a value, its HMAC-SHA256 with the index key.
+// Equal values have equal indexes, whatever key encrypts them.
+func (k *PIIKeyring) BlindIndex(value string) []byte {
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePIIKeyring(t *testing.T) {
+	keyring, err := ParsePIIKeyring("", "")
+	require.NoError(t, err)
+	require.Nil(t, keyring)
+
+	key, indexKey := RandomPIIKey(), RandomPIIKey()
+	for _, invalid := range [][2]string{
+		{key, indexKey},
+		{":" + key, indexKey},
+		{"k1:" + key, ""},
+		{"k1:c2hvcnQ=", indexKey},
+		{"k1:" + key + ",k1:" + RandomPIIKey(), indexKey},
+	} {
+		_, err = ParsePIIKeyring(invalid[0], invalid[1])
+		require.Error(t, err)
+		require.NotContains(t, err.Error(), key)
+	}
+}
+
+func TestPIIKeyring(t *testing.T) {
+	oldKey, indexKey := RandomPIIKey(), RandomPIIKey()
+	old, err := ParsePIIKeyring("k1:"+oldKey, indexKey)
+	require.NoError(t, err)
+
+	email := RandomEmailAddress()
+	encrypted, err := old.Encrypt(email, "users.email/alice")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(encrypted, "enc:v1:k1:"))
+	require.NotContains(t, encrypted, email)
+	require.True(t, old.IsCurrent(encrypted))
+
+	again, err := old.Encrypt(email, "users.email/alice")
+	require.NoError(t, err)
+	require.NotEqual(t, encrypted, again)
+
+	decrypted, err := old.Decrypt(encrypted, "users.email/alice")
+	require.NoError(t, err)
+	require.Equal(t, email, decrypted)
+
+	// the value can't be moved to another row.
+	_, err = old.Decrypt(encrypted, "users.email/bob")
+	require.ErrorIs(t, err, ErrPIIDecrypt)
+
+	// plaintext written before encryption was enabled is read as it is.
+	decrypted, err = old.Decrypt(email, "users.email/alice")
+	require.NoError(t, err)
+	require.Equal(t, email, decrypted)
+	require.False(t, old.IsCurrent(email))
+
+	empty, err := old.Encrypt("", "users.full_name/alice")
+	require.NoError(t, err)
+	require.Empty(t, empty)
+
+	// a new key encrypts the new values, the old one still decrypts.
+	rotated, err := ParsePIIKeyring("k2:"+RandomPIIKey()+",k1:"+oldKey, indexKey)
+	require.NoError(t, err)
+	require.False(t, rotated.IsCurrent(encrypted))
+
+	decrypted, err = rotated.Decrypt(encrypted, "users.email/alice")
+	require.NoError(t, err)
+	require.Equal(t, email, decrypted)
+
+	reencrypted, err := rotated.Encrypt(email, "users.email/alice")
+	require.NoError(t, err)
+	require.True(t, rotated.IsCurrent(reencrypted))
+
+	// once the old key is removed, its values can't be read anymore.
+	_, err = old.Decrypt(reencrypted, "users.email/alice")
+	require.ErrorIs(t, err, ErrPIIDecrypt)
+
+	_, err = old.Decrypt("enc:v1:k1:garbage", "users.email/alice")
+	require.ErrorIs(t, err, ErrPIIDecrypt)
+
+	// the blind index only depends on the value and the index key.
+	require.Equal(t, old.BlindIndex(email), rotated.BlindIndex(email))
+	require.NotEqual(t, old.BlindIndex(email), old.BlindIndex(RandomEmailAddress()))
+	require.Len(t, old.BlindIndex(email), 32)
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	crand "crypto/rand"
+	"encoding/base64"
 	"fmt"
 	"math/rand"
 	"strings"
@@ -56,3 +58,12 @@ func RandomCurrency() string {
 func RandomEmailAddress() string {
 	return fmt.Sprintf("%s@example.com", RandomString(6))
 }
+
+// Generates a random key of a PIIKeyring, 32 bytes base64 encoded.
+func RandomPIIKey() string {
+	key := make([]byte, 32)
+	if _, err := crand.Read(key); err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(key)
+}
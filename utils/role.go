@@ -0,0 +1,15 @@
+package utils
+
+// All user roles.
+const (
+	DepositorRole = "depositor"
+	BankerRole    = "banker"
+)
+
+func IsSupportedRole(role string) bool {
+	switch role {
+	case DepositorRole, BankerRole:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Returned when a webhook endpoint isn't an https URL of a public host.
+var ErrForbiddenEndpoint = errors.New("webhook endpoint must be an https URL of a public host")
+
+// Ranges of addresses that aren't reachable from the internet, on top of the
+// loopback, private, link-local and multicast ones, which the netip package
+// knows about.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// Reports whether an address is reachable from the internet, so that the
+// deliveries can't be used to reach the services of the bank's network.
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// Checks that a webhook endpoint is an https URL whose host isn't a local
+// name or a non public address. The names are resolved when the deliveries
+// are sent, see NewWorker.
+func CheckEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return ErrForbiddenEndpoint
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !IsPublicAddr(addr) {
+			return ErrForbiddenEndpoint
+		}
+		return nil
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || !strings.Contains(host, ".") {
+		return ErrForbiddenEndpoint
+	}
+	return nil
+}
+
+// Refuses the connections to non public addresses. It runs once the host of
+// the endpoint is resolved, so names resolving to internal addresses are
+// refused as well.
+func checkDialAddress(network, address string, conn syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrForbiddenEndpoint, err)
+	}
+	if !IsPublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s is not a public address", ErrForbiddenEndpoint, addrPort.Addr())
+	}
+	return nil
+}
+
+// Returns the client of the deliveries. It only connects to public
+// addresses, without any proxy, and doesn't follow redirects, whose
+// responses fail the delivery.
+func newClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: checkDialAddress,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(request *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every delivery.
+const (
+	SignatureHeader = "X-Simplebank-Signature"
+	EventIDHeader   = "X-Simplebank-Event-Id"
+	EventTypeHeader = "X-Simplebank-Event-Type"
+)
+
+// Returned by Verify when the signature header doesn't match the body.
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Signs a delivery body with the webhook's secret. The signature is an
+// HMAC-SHA256 of the timestamp and the body, so receivers can reject replays
+// of old deliveries. It returns the value of the SignatureHeader,
+// formatted as "t=<unix timestamp>,v1=<hex signature>".
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", t, computeSignature(secret, t, body))
+}
+
+// Checks the SignatureHeader of a delivery, as a receiver would.
+// Signatures older than tolerance are rejected, 0 disables the check.
+func Verify(secret string, header string, body []byte, tolerance time.Duration) error {
+	var t, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			signature = value
+		}
+	}
+
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || signature == "" {
+		return ErrInvalidSignature
+	}
+
+	if tolerance > 0 && time.Since(time.Unix(unix, 0)) > tolerance {
+		return fmt.Errorf("%w: timestamp is too old", ErrInvalidSignature)
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(computeSignature(secret, t, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func computeSignature(secret string, t string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,39 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	secret := utils.RandomString(32)
+	body := []byte(`{"id":1}`)
+
+	header := Sign(secret, time.Now(), body)
+	require.Regexp(t, `^t=\d+,v1=[0-9a-f]{64}$`, header)
+	require.NoError(t, Verify(secret, header, body, time.Minute))
+
+	require.ErrorIs(t, Verify(utils.RandomString(32), header, body, time.Minute), ErrInvalidSignature)
+	require.ErrorIs(t, Verify(secret, header, []byte(`{"id":2}`), time.Minute), ErrInvalidSignature)
+	require.ErrorIs(t, Verify(secret, "v1=abc", body, time.Minute), ErrInvalidSignature)
+	require.ErrorIs(t, Verify(secret, "", body, 0), ErrInvalidSignature)
+}
+
+func TestVerifyTolerance(t *testing.T) {
+	secret := utils.RandomString(32)
+	body := []byte(`{"id":1}`)
+
+	header := Sign(secret, time.Now().Add(-time.Hour), body)
+	require.ErrorIs(t, Verify(secret, header, body, time.Minute), ErrInvalidSignature)
+	require.NoError(t, Verify(secret, header, body, 0))
+}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, baseBackoff, backoff(1))
+	require.Equal(t, 2*baseBackoff, backoff(2))
+	require.Equal(t, 8*baseBackoff, backoff(4))
+	require.Equal(t, maxBackoff, backoff(20))
+}
@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/outbox"
+)
+
+// An outbox sink queuing a delivery of each event for every webhook of the
+// users concerned by it that subscribed to its type.
+// Queuing is idempotent, an event published twice is delivered once.
+type Sink struct {
+	store db.Store
+}
+
+// Creates a new webhook Sink.
+func NewSink(store db.Store) *Sink {
+	return &Sink{
+		store: store,
+	}
+}
+
+// Queues the deliveries of the event.
+func (sink *Sink) Publish(ctx context.Context, event outbox.Event) error {
+	owners, err := sink.eventOwners(ctx, event)
+	if err != nil || len(owners) == 0 {
+		return err
+	}
+
+	webhooks, err := sink.store.ListWebhooksForEvent(ctx, db.ListWebhooksForEventParams{
+		Owners:    owners,
+		EventType: event.Type,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		_, err := sink.store.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+			WebhookID: webhook.ID,
+			EventID:   event.ID,
+			EventType: event.Type,
+			Payload:   event.Payload,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the users concerned by an event: the owners of both accounts of a
+// transfer, the owner of a new account or the new user.
+func (sink *Sink) eventOwners(ctx context.Context, event outbox.Event) ([]string, error) {
+	switch event.Type {
+	case db.EventTransferCompleted:
+		var payload db.TransferCompletedEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return nil, err
+		}
+
+		var owners []string
+		for _, accountID := range []int64{payload.FromAccountID, payload.ToAccountID} {
+			account, err := sink.store.GetAccount(ctx, accountID)
+			if err != nil {
+				return nil, err
+			}
+			// system accounts have no owner.
+			if account.Owner != nil {
+				owners = append(owners, *account.Owner)
+			}
+		}
+		return owners, nil
+
+	case db.EventAccountCreated:
+		var payload db.AccountCreatedEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return nil, err
+		}
+		return []string{payload.Owner}, nil
+
+	case db.EventUserRegistered:
+		var payload db.UserRegisteredEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return nil, err
+		}
+		return []string{payload.Username}, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	mockdb "github.com/kvgtl/simplebank/db/mock"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/outbox"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSinkPublishTransfer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sender := utils.RandomOwner()
+	receiver := utils.RandomOwner()
+	fees := db.SystemFees
+
+	payload, err := json.Marshal(db.TransferCompletedEvent{
+		TransferID:    1,
+		FromAccountID: 10,
+		ToAccountID:   20,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+
+	event := outbox.Event{
+		ID:      7,
+		Type:    db.EventTransferCompleted,
+		Payload: payload,
+	}
+	webhook := db.Webhook{ID: 3, Owner: receiver}
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(int64(10))).Times(1).Return(db.Account{ID: 10, Owner: &sender}, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(int64(20))).Times(1).Return(db.Account{ID: 20, Owner: &receiver}, nil)
+	store.EXPECT().
+		ListWebhooksForEvent(gomock.Any(), gomock.Eq(db.ListWebhooksForEventParams{
+			Owners:    []string{sender, receiver},
+			EventType: db.EventTransferCompleted,
+		})).
+		Times(1).
+		Return([]db.Webhook{webhook}, nil)
+	store.EXPECT().
+		CreateWebhookDelivery(gomock.Any(), gomock.Eq(db.CreateWebhookDeliveryParams{
+			WebhookID: webhook.ID,
+			EventID:   event.ID,
+			EventType: event.Type,
+			Payload:   event.Payload,
+		})).
+		Times(1).
+		Return(int64(1), nil)
+
+	err = NewSink(store).Publish(context.Background(), event)
+	require.NoError(t, err)
+
+	// transfers from system accounts only concern the receiver.
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(int64(10))).Times(1).Return(db.Account{ID: 10, SystemKind: &fees}, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(int64(20))).Times(1).Return(db.Account{ID: 20, Owner: &receiver}, nil)
+	store.EXPECT().
+		ListWebhooksForEvent(gomock.Any(), gomock.Eq(db.ListWebhooksForEventParams{
+			Owners:    []string{receiver},
+			EventType: db.EventTransferCompleted,
+		})).
+		Times(1).
+		Return(nil, nil)
+
+	err = NewSink(store).Publish(context.Background(), event)
+	require.NoError(t, err)
+}
+
+func TestSinkPublishUnknownEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListWebhooksForEvent(gomock.Any(), gomock.Any()).Times(0)
+
+	err := NewSink(store).Publish(context.Background(), outbox.Event{ID: 1, Type: "Unknown"})
+	require.NoError(t, err)
+}
@@ -0,0 +1,184 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	db "github.com/kvgtl/simplebank/db/sqlc"
+)
+
+// Number of deliveries attempted at once.
+const batchSize = 50
+
+// Delay before the first retry of a failed delivery, doubled on every
+// following failure up to maxBackoff.
+const (
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+)
+
+// Body of a delivery.
+type deliveryBody struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Delivers the queued webhook deliveries to their endpoints.
+// Failed deliveries are retried with exponential backoff, and are marked dead
+// once they failed maxAttempts times.
+type Worker struct {
+	store       db.Store
+	client      *http.Client
+	maxAttempts int32
+	interval    time.Duration
+	// How long the claimed deliveries are kept from the other workers, long
+	// enough to attempt a whole batch.
+	lease time.Duration
+	now   func() time.Time
+}
+
+// Creates a new webhook Worker polling for due deliveries every interval.
+// The deliveries are only sent to https endpoints on public addresses.
+func NewWorker(store db.Store, maxAttempts int32, timeout time.Duration, interval time.Duration) *Worker {
+	return &Worker{
+		store:       store,
+		client:      newClient(timeout),
+		maxAttempts: maxAttempts,
+		interval:    interval,
+		lease:       batchSize*timeout + time.Minute,
+		now:         time.Now,
+	}
+}
+
+// Claims and attempts one batch of due deliveries. Workers running at once
+// claim different deliveries; those of a worker stopping mid batch are
+// attempted again once their lease ends.
+// It returns the number of deliveries attempted.
+func (worker *Worker) DeliverOnce(ctx context.Context) (int, error) {
+	deliveries, err := worker.store.ClaimDueWebhookDeliveries(ctx, db.ClaimDueWebhookDeliveriesParams{
+		LeasedUntil: worker.now().Add(worker.lease),
+		LimitCount:  batchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for i, delivery := range deliveries {
+		statusCode, err := worker.send(ctx, delivery)
+		if err == nil {
+			err = worker.store.MarkWebhookDeliveryDelivered(ctx, db.MarkWebhookDeliveryDeliveredParams{
+				ID:             delivery.ID,
+				ResponseStatus: int32(statusCode),
+			})
+			if err != nil {
+				return i, err
+			}
+			continue
+		}
+
+		attempts := delivery.Attempts + 1
+		status := db.WebhookDeliveryPending
+		if attempts >= worker.maxAttempts {
+			status = db.WebhookDeliveryDead
+			log.Printf("webhook delivery %d is dead after %d attempts: %v", delivery.ID, attempts, err)
+		}
+
+		err = worker.store.RecordWebhookDeliveryFailure(ctx, db.RecordWebhookDeliveryFailureParams{
+			ID:             delivery.ID,
+			Status:         status,
+			ResponseStatus: int32(statusCode),
+			LastError:      err.Error(),
+			NextAttemptAt:  worker.now().Add(backoff(attempts)),
+		})
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(deliveries), nil
+}
+
+// Posts a signed delivery to its endpoint. Any response other than 2xx is an
+// error, the response status is 0 if there was none.
+func (worker *Worker) send(ctx context.Context, delivery db.ClaimDueWebhookDeliveriesRow) (int, error) {
+	// the endpoints registered before they had to be https are refused, the
+	// client refuses the non public addresses.
+	if u, err := url.Parse(delivery.Url); err != nil || u.Scheme != "https" {
+		return 0, ErrForbiddenEndpoint
+	}
+
+	body, err := json.Marshal(deliveryBody{
+		ID:        delivery.EventID,
+		Type:      delivery.EventType,
+		CreatedAt: delivery.CreatedAt,
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventIDHeader, strconv.FormatInt(delivery.EventID, 10))
+	request.Header.Set(EventTypeHeader, delivery.EventType)
+	request.Header.Set(SignatureHeader, Sign(delivery.Secret, worker.now(), body))
+
+	response, err := worker.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("unexpected response status %d", response.StatusCode)
+	}
+	return response.StatusCode, nil
+}
+
+// Returns the delay before retrying a delivery that failed attempts times.
+func backoff(attempts int32) time.Duration {
+	delay := baseBackoff
+	for i := int32(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
+
+// Runs the worker until the context is canceled. Full batches are
+// attempted right away, otherwise it waits for the interval.
+func (worker *Worker) Run(ctx context.Context) error {
+	for {
+		attempted, err := worker.DeliverOnce(ctx)
+		if err != nil {
+			log.Printf("cannot deliver webhooks: %v", err)
+		}
+
+		if err == nil && attempted == batchSize {
+			continue
+		}
+
+		timer := time.NewTimer(worker.interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	mockdb "github.com/kvgtl/simplebank/db/mock"
+	db "github.com/kvgtl/simplebank/db/sqlc"
+	"github.com/kvgtl/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func randomDelivery(url string) db.ClaimDueWebhookDeliveriesRow {
+	return db.ClaimDueWebhookDeliveriesRow{
+		ID:        utils.RandomInt(1, 1000),
+		WebhookID: utils.RandomInt(1, 1000),
+		EventID:   utils.RandomInt(1, 1000),
+		EventType: db.EventTransferCompleted,
+		Payload:   json.RawMessage(`{"transfer_id":1}`),
+		Status:    db.WebhookDeliveryPending,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		Url:       url,
+		Secret:    utils.RandomString(32),
+	}
+}
+
+// Lets the worker reach a local test receiver, trusting its certificate. The
+// redirects are still refused.
+func trustReceiver(worker *Worker, receiver *httptest.Server) {
+	transport := worker.client.Transport.(*http.Transport)
+	transport.DialContext = (&net.Dialer{}).DialContext
+	transport.TLSClientConfig = receiver.Client().Transport.(*http.Transport).TLSClientConfig
+}
+
+func TestDeliverOnce(t *testing.T) {
+	var delivery db.ClaimDueWebhookDeliveriesRow
+
+	receiver := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		require.NoError(t, Verify(delivery.Secret, r.Header.Get(SignatureHeader), body, time.Minute))
+		require.Equal(t, strconv.FormatInt(delivery.EventID, 10), r.Header.Get(EventIDHeader))
+		require.Equal(t, delivery.EventType, r.Header.Get(EventTypeHeader))
+
+		var got deliveryBody
+		require.NoError(t, json.Unmarshal(body, &got))
+		require.Equal(t, delivery.EventID, got.ID)
+		require.Equal(t, delivery.EventType, got.Type)
+		require.JSONEq(t, string(delivery.Payload), string(got.Data))
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	delivery = randomDelivery(receiver.URL)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ClaimDueWebhookDeliveries(gomock.Any(), gomock.Eq(db.ClaimDueWebhookDeliveriesParams{
+			LeasedUntil: now.Add(batchSize*time.Second + time.Minute),
+			LimitCount:  batchSize,
+		})).
+		Times(1).
+		Return([]db.ClaimDueWebhookDeliveriesRow{delivery}, nil)
+	store.EXPECT().
+		MarkWebhookDeliveryDelivered(gomock.Any(), gomock.Eq(db.MarkWebhookDeliveryDeliveredParams{
+			ID:             delivery.ID,
+			ResponseStatus: http.StatusNoContent,
+		})).
+		Times(1).
+		Return(nil)
+
+	worker := NewWorker(store, 5, time.Second, time.Second)
+	worker.now = func() time.Time { return now }
+	trustReceiver(worker, receiver)
+
+	attempted, err := worker.DeliverOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, attempted)
+}
+
+func TestDeliverOnceFailures(t *testing.T) {
+	receiver := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "https://169.254.169.254/latest/meta-data", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	now := time.Now()
+
+	testCases := []struct {
+		name           string
+		attempts       int32
+		url            string
+		expectedStatus string
+		expectedCode   int32
+		// whether the worker may reach the local receiver.
+		trusted bool
+	}{
+		{
+			name:           "Retry",
+			attempts:       0,
+			url:            receiver.URL,
+			expectedStatus: db.WebhookDeliveryPending,
+			expectedCode:   http.StatusInternalServerError,
+			trusted:        true,
+		},
+		{
+			name:           "Unreachable",
+			attempts:       1,
+			url:            "https://127.0.0.1:1",
+			expectedStatus: db.WebhookDeliveryPending,
+			expectedCode:   0,
+			trusted:        true,
+		},
+		{
+			name:           "DeadLetter",
+			attempts:       4,
+			url:            receiver.URL,
+			expectedStatus: db.WebhookDeliveryDead,
+			expectedCode:   http.StatusInternalServerError,
+			trusted:        true,
+		},
+		{
+			name:           "Redirect",
+			attempts:       0,
+			url:            receiver.URL + "/redirect",
+			expectedStatus: db.WebhookDeliveryPending,
+			expectedCode:   http.StatusFound,
+			trusted:        true,
+		},
+		{
+			name:           "InternalAddress",
+			attempts:       0,
+			url:            receiver.URL,
+			expectedStatus: db.WebhookDeliveryPending,
+			expectedCode:   0,
+		},
+		{
+			name:           "PlainHTTP",
+			attempts:       0,
+			url:            "http://example.com/hooks",
+			expectedStatus: db.WebhookDeliveryPending,
+			expectedCode:   0,
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			delivery := randomDelivery(testCase.url)
+			delivery.Attempts = testCase.attempts
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				ClaimDueWebhookDeliveries(gomock.Any(), gomock.Any()).
+				Times(1).
+				Return([]db.ClaimDueWebhookDeliveriesRow{delivery}, nil)
+			store.EXPECT().
+				MarkWebhookDeliveryDelivered(gomock.Any(), gomock.Any()).
+				Times(0)
+			store.EXPECT().
+				RecordWebhookDeliveryFailure(gomock.Any(), gomock.Any()).
+				Times(1).
+				DoAndReturn(func(ctx context.Context, args db.RecordWebhookDeliveryFailureParams) error {
+					require.Equal(t, delivery.ID, args.ID)
+					require.Equal(t, testCase.expectedStatus, args.Status)
+					require.Equal(t, testCase.expectedCode, args.ResponseStatus)
+					require.NotEmpty(t, args.LastError)
+					if !testCase.trusted {
+						require.Contains(t, args.LastError, ErrForbiddenEndpoint.Error())
+					}
+					require.Equal(t, now.Add(backoff(testCase.attempts+1)), args.NextAttemptAt)
+					return nil
+				})
+
+			worker := NewWorker(store, 5, time.Second, time.Second)
+			worker.now = func() time.Time { return now }
+			if testCase.trusted {
+				trustReceiver(worker, receiver)
+			}
+
+			attempted, err := worker.DeliverOnce(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, 1, attempted)
+		})
+	}
+}